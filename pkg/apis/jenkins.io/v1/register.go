@@ -40,6 +40,8 @@ func init() {
 // Adds the list of known types to Scheme.
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
+		&BuildCounter{},
+		&BuildCounterList{},
 		&BuildPack{},
 		&BuildPackList{},
 		&App{},
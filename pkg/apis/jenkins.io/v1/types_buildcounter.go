@@ -0,0 +1,41 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+
+// BuildCounter records the last build number issued for a pipeline. It is updated using optimistic concurrency so
+// that build numbers are unique across replicas of the build number service, and keep increasing even when the
+// PipelineActivity resources for old builds are garbage collected.
+type BuildCounter struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object's metadata.
+	// More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	Spec BuildCounterSpec `json:"spec,omitempty" protobuf:"bytes,2,opt,name=spec"`
+}
+
+// BuildCounterSpec is the pipeline and the last build number issued for it
+type BuildCounterSpec struct {
+	// Pipeline is the ID of the pipeline, such as owner/repository/branch
+	Pipeline string `json:"pipeline,omitempty" protobuf:"bytes,1,opt,name=pipeline"`
+	// LastBuildNumber is the last build number issued for the pipeline
+	LastBuildNumber int `json:"lastBuildNumber,omitempty" protobuf:"varint,2,opt,name=lastBuildNumber"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// BuildCounterList is a list of BuildCounter resources
+type BuildCounterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []BuildCounter `json:"items"`
+}
@@ -50,6 +50,36 @@ type EnvironmentSpec struct {
 
 	// RemoteCluster flag indicates if the Environment is deployed in a separate cluster to the Development Environment
 	RemoteCluster bool `json:"remoteCluster,omitempty" protobuf:"bytes,12,opt,name=remoteCluster"`
+
+	// DeploymentWindows restricts when automatic promotions can be deployed to the Environment
+	DeploymentWindows *DeploymentWindows `json:"deploymentWindows,omitempty" protobuf:"bytes,13,opt,name=deploymentWindows"`
+}
+
+// DeploymentWindows are the schedules which allow or deny automatic promotions to an Environment. If there are any
+// Allow windows then promotions only happen while one of them is open, and no promotions happen while any Deny
+// window is open, such as during a change freeze. Promotions which are not allowed are queued until they are.
+type DeploymentWindows struct {
+	// TimeZone is the IANA time zone, such as 'Europe/London', the schedules are evaluated in. Defaults to UTC
+	TimeZone string `json:"timeZone,omitempty" protobuf:"bytes,1,opt,name=timeZone"`
+	// Allow the windows when promotions are allowed
+	Allow []DeploymentWindow `json:"allow,omitempty" protobuf:"bytes,2,rep,name=allow"`
+	// Deny the windows when promotions are frozen
+	Deny []DeploymentWindow `json:"deny,omitempty" protobuf:"bytes,3,rep,name=deny"`
+}
+
+// DeploymentWindow is a recurring schedule and/or a fixed period when a deployment window is open
+type DeploymentWindow struct {
+	// Name describes the window in messages, such as 'business-hours' or 'christmas-freeze'
+	Name string `json:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
+	// Schedule is a cron-like expression 'minute hour day-of-month month day-of-week' which matches the minutes the
+	// window is open, such as '* 9-16 * * 1-5' for 9am to 5pm on weekdays
+	Schedule string `json:"schedule,omitempty" protobuf:"bytes,2,opt,name=schedule"`
+	// Start the time the window opens, if it has a fixed period
+	Start *metav1.Time `json:"start,omitempty" protobuf:"bytes,3,opt,name=start"`
+	// End the time the window closes, if it has a fixed period
+	End *metav1.Time `json:"end,omitempty" protobuf:"bytes,4,opt,name=end"`
+	// TimeZone overrides the time zone of the DeploymentWindows for this window
+	TimeZone string `json:"timeZone,omitempty" protobuf:"bytes,5,opt,name=timeZone"`
 }
 
 // EnvironmentStatus is the status for an Environment resource
@@ -221,6 +251,31 @@ type TeamSettings struct {
 
 	// ProwConfig is the way we manage prow configurations
 	ProwConfig ProwConfigType `json:"prowConfig,omitempty" protobuf:"bytes,29,opt,name=prowConfig"`
+
+	// ChatNotifications configures posting the outcome of pipelines and promotions to a chat channel
+	ChatNotifications *ChatNotifications `json:"chatNotifications,omitempty" protobuf:"bytes,30,opt,name=chatNotifications"`
+}
+
+// ChatNotifications configures which pipeline and promotion outcomes are posted to a chat service
+type ChatNotifications struct {
+	// URL is the URL of the chat server or incoming webhook, whose kind and credentials are in the chat auth config
+	URL string `json:"url,omitempty" protobuf:"bytes,1,opt,name=url"`
+	// Kind is the kind of chat service, such as slack, mattermost or rocketchat, if it isn't in the chat auth config
+	Kind string `json:"kind,omitempty" protobuf:"bytes,2,opt,name=kind"`
+	// Channel is the channel that pipeline outcomes are posted to
+	Channel string `json:"channel,omitempty" protobuf:"bytes,3,opt,name=channel"`
+	// PromotionChannel is the channel that promotions are posted to, which defaults to Channel
+	PromotionChannel string `json:"promotionChannel,omitempty" protobuf:"bytes,4,opt,name=promotionChannel"`
+	// FailuresOnly only posts the pipelines which fail, rather than every completed pipeline
+	FailuresOnly bool `json:"failuresOnly,omitempty" protobuf:"bytes,5,opt,name=failuresOnly"`
+}
+
+// PromotionChannelOrDefault returns the channel that promotions are posted to
+func (c *ChatNotifications) PromotionChannelOrDefault() string {
+	if c.PromotionChannel != "" {
+		return c.PromotionChannel
+	}
+	return c.Channel
 }
 
 // StorageLocation
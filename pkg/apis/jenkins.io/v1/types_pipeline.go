@@ -77,10 +77,11 @@ type PullRequestInfo struct {
 
 // PipelineActivityStep represents a step in a pipeline activity
 type PipelineActivityStep struct {
-	Kind    ActivityStepKindType `json:"kind,omitempty" protobuf:"bytes,1,opt,name=kind"`
-	Stage   *StageActivityStep   `json:"stage,omitempty" protobuf:"bytes,2,opt,name=stage"`
-	Promote *PromoteActivityStep `json:"promote,omitempty" protobuf:"bytes,3,opt,name=promote"`
-	Preview *PreviewActivityStep `json:"preview,omitempty" protobuf:"bytes,4,opt,name=preview"`
+	Kind     ActivityStepKindType  `json:"kind,omitempty" protobuf:"bytes,1,opt,name=kind"`
+	Stage    *StageActivityStep    `json:"stage,omitempty" protobuf:"bytes,2,opt,name=stage"`
+	Promote  *PromoteActivityStep  `json:"promote,omitempty" protobuf:"bytes,3,opt,name=promote"`
+	Preview  *PreviewActivityStep  `json:"preview,omitempty" protobuf:"bytes,4,opt,name=preview"`
+	Approval *ApprovalActivityStep `json:"approval,omitempty" protobuf:"bytes,5,opt,name=approval"`
 }
 
 // CoreActivityStep is a base step included in Stages of a pipeline or other kinds of step
@@ -108,6 +109,15 @@ type PreviewActivityStep struct {
 	ApplicationURL string `json:"applicationURL,omitempty" protobuf:"bytes,3,opt,name=applicationURL"`
 }
 
+// ApprovalActivityStep is the step of waiting for a manual approval of a workflow. Its name is the name of the
+// workflow step
+type ApprovalActivityStep struct {
+	CoreActivityStep `json:",inline"`
+
+	// Approver is the user who approved or rejected the step
+	Approver string `json:"approver,omitempty" protobuf:"bytes,1,opt,name=approver"`
+}
+
 // PromoteActivityStep is the step of promoting a version of an application to an environment
 type PromoteActivityStep struct {
 	CoreActivityStep `json:",inline"`
@@ -116,6 +126,12 @@ type PromoteActivityStep struct {
 	PullRequest    *PromotePullRequestStep `json:"pullRequest,omitempty" protobuf:"bytes,2,opt,name=pullRequest"`
 	Update         *PromoteUpdateStep      `json:"update,omitempty" protobuf:"bytes,3,opt,name=update"`
 	ApplicationURL string                  `json:"applicationURL,omitempty" protobuf:"bytes,4,opt,name=environment"`
+	// BlockedReason is why the promotion is blocked by the gates of its workflow step, or empty if it is not blocked
+	BlockedReason string `json:"blockedReason,omitempty" protobuf:"bytes,5,opt,name=blockedReason"`
+	// Canary is the Flagger canary analysis of the promoted version, if the application has a Canary in the environment
+	Canary *PromoteCanaryStep `json:"canary,omitempty" protobuf:"bytes,6,opt,name=canary"`
+	// Phase is how far the promotion has progressed so that it can be resumed after a restart
+	Phase PromotePhase `json:"phase,omitempty" protobuf:"bytes,7,opt,name=phase"`
 }
 
 // GitStatus the status of a git commit in terms of CI/CD
@@ -140,6 +156,24 @@ type PromoteUpdateStep struct {
 	Statuses []GitStatus `json:"statuses,omitempty" protobuf:"bytes,1,opt,name=statuses"`
 }
 
+// PromoteCanaryStep is the step for waiting for the Flagger canary analysis of the promoted version to complete
+type PromoteCanaryStep struct {
+	CoreActivityStep `json:",inline"`
+
+	// Canary is the name of the Flagger Canary resource
+	Canary string `json:"canary,omitempty" protobuf:"bytes,1,opt,name=canary"`
+	// Phase is the last phase of the canary analysis such as Progressing, Succeeded or Failed
+	Phase string `json:"phase,omitempty" protobuf:"bytes,2,opt,name=phase"`
+	// Weight is the percentage of traffic routed to the promoted version
+	Weight int `json:"weight,omitempty" protobuf:"varint,3,opt,name=weight"`
+	// FailedChecks is the number of failed metric checks of the canary analysis
+	FailedChecks int `json:"failedChecks,omitempty" protobuf:"varint,4,opt,name=failedChecks"`
+	// Iterations is the number of iterations of the canary analysis
+	Iterations int `json:"iterations,omitempty" protobuf:"varint,5,opt,name=iterations"`
+	// Message describes the outcome of the canary analysis
+	Message string `json:"message,omitempty" protobuf:"bytes,6,opt,name=message"`
+}
+
 // PipelineActivityStatus is the status for an Environment resource
 type PipelineActivityStatus struct {
 	Version string `json:"version,omitempty"  protobuf:"bytes,1,opt,name=version"`
@@ -167,6 +201,8 @@ const (
 	ActivityStepKindTypePreview ActivityStepKindType = "Preview"
 	// ActivityStepKindTypePromote a promote activity
 	ActivityStepKindTypePromote ActivityStepKindType = "Promote"
+	// ActivityStepKindTypeApproval a manual approval of a workflow
+	ActivityStepKindTypeApproval ActivityStepKindType = "Approval"
 )
 
 // ActivityStatusType is the status of an activity; usually succeeded or failed/error on completion
@@ -191,8 +227,33 @@ const (
 	ActivityStatusTypeAborted ActivityStatusType = "Aborted"
 	// ActivityStatusTypeNotExecuted if the workflow was not executed
 	ActivityStatusTypeNotExecuted ActivityStatusType = "NotExecuted"
+	// ActivityStatusTypeSkipped if a stage was not run as its when conditions were not met
+	ActivityStatusTypeSkipped ActivityStatusType = "Skipped"
 )
 
+// PromotePhase is how far a promotion via a Pull Request on the environment repository has progressed
+type PromotePhase string
+
+const (
+	// PromotePhaseNone the promotion has not started yet
+	PromotePhaseNone PromotePhase = ""
+	// PromotePhasePullRequest the Pull Request is open and waiting for its checks to pass before it is merged
+	PromotePhasePullRequest PromotePhase = "PullRequest"
+	// PromotePhaseMerged the Pull Request was merged, or the chart installed directly, and the environment is updating
+	PromotePhaseMerged PromotePhase = "Merged"
+	// PromotePhaseCanary the environment was updated and the canary analysis of the new version is in progress
+	PromotePhaseCanary PromotePhase = "Canary"
+	// PromotePhaseSucceeded the promotion succeeded
+	PromotePhaseSucceeded PromotePhase = "Succeeded"
+	// PromotePhaseFailed the promotion failed
+	PromotePhaseFailed PromotePhase = "Failed"
+)
+
+// IsTerminated returns true if the promotion has stopped
+func (p PromotePhase) IsTerminated() bool {
+	return p == PromotePhaseSucceeded || p == PromotePhaseFailed
+}
+
 type Attachment struct {
 	Name string   `json:"name,omitempty"  protobuf:"bytes,1,opt,name=name"`
 	URLs []string `json:"urls,omitempty"  protobuf:"bytes,2,opt,name=urls"`
@@ -200,7 +261,7 @@ type Attachment struct {
 
 // IsTerminated returns true if this activity has stopped executing
 func (s ActivityStatusType) IsTerminated() bool {
-	return s == ActivityStatusTypeSucceeded || s == ActivityStatusTypeFailed || s == ActivityStatusTypeError || s == ActivityStatusTypeAborted
+	return s == ActivityStatusTypeSucceeded || s == ActivityStatusTypeFailed || s == ActivityStatusTypeError || s == ActivityStatusTypeAborted || s == ActivityStatusTypeSkipped
 }
 
 func (s ActivityStatusType) String() string {
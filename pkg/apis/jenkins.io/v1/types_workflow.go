@@ -34,6 +34,9 @@ type WorkflowStep struct {
 	Description   string                `json:"description,omitempty" protobuf:"bytes,2,opt,name=description"`
 	Preconditions WorkflowPreconditions `json:"trigger,omitempty" protobuf:"bytes,3,opt,name=trigger"`
 	Promote       *PromoteWorkflowStep  `json:"promote,omitempty" protobuf:"bytes,4,opt,name=promote"`
+	Approval      *ApprovalWorkflowStep `json:"approval,omitempty" protobuf:"bytes,5,opt,name=approval"`
+	// Timeout is how long the step can run for once it has started, such as '30m' or '24h', before it fails
+	Timeout string `json:"timeout,omitempty" protobuf:"bytes,6,opt,name=timeout"`
 }
 
 // PromoteWorkflowStep is the step of promoting a version of an application to an environment
@@ -41,10 +44,23 @@ type PromoteWorkflowStep struct {
 	Environment string `json:"environment,omitempty" protobuf:"bytes,1,opt,name=environment"`
 }
 
+// ApprovalWorkflowStep is a step which waits for a user to manually approve the workflow before the steps which depend
+// on it can be triggered
+type ApprovalWorkflowStep struct {
+	// Message is displayed to the approvers
+	Message string `json:"message,omitempty" protobuf:"bytes,2,opt,name=message"`
+}
+
 // WorkflowPreconditions is the trigger to start a step
 type WorkflowPreconditions struct {
 	// the names of the environments which need to have promoted before this step can be triggered
 	Environments []string `json:"environments,omitempty" protobuf:"bytes,1,opt,name=environments"`
+	// Gates are expressions over the Facts of the PipelineActivity which must all pass before this step can be
+	// triggered, such as 'jx.coverage/Covered >= 80', 'jx.cve/Critical == 0' or 'manual-approval == true'
+	Gates []string `json:"gates,omitempty" protobuf:"bytes,2,opt,name=gates"`
+	// the names of the steps which need to have succeeded before this step can be triggered. Promote steps without a
+	// name are named after their environment
+	Steps []string `json:"steps,omitempty" protobuf:"bytes,3,opt,name=steps"`
 }
 
 // WorkflowStatus is the status for an Environment resource
@@ -70,6 +86,8 @@ const (
 	WorkflowStepKindTypeNone WorkflowStepKindType = ""
 	// WorkflowStepKindTypePromote a promote activity
 	WorkflowStepKindTypePromote WorkflowStepKindType = "Promote"
+	// WorkflowStepKindTypeApproval a manual approval
+	WorkflowStepKindTypeApproval WorkflowStepKindType = "Approval"
 )
 
 // WorkflowStatusType is the status of an activity; usually succeeded or failed/error on completion
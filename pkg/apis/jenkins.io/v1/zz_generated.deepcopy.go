@@ -132,6 +132,39 @@ func (in *AppSpec) DeepCopy() *AppSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalActivityStep) DeepCopyInto(out *ApprovalActivityStep) {
+	*out = *in
+	in.CoreActivityStep.DeepCopyInto(&out.CoreActivityStep)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalActivityStep.
+func (in *ApprovalActivityStep) DeepCopy() *ApprovalActivityStep {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalActivityStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalWorkflowStep) DeepCopyInto(out *ApprovalWorkflowStep) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalWorkflowStep.
+func (in *ApprovalWorkflowStep) DeepCopy() *ApprovalWorkflowStep {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalWorkflowStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Approve) DeepCopyInto(out *Approve) {
 	*out = *in
@@ -310,6 +343,82 @@ func (in *Brancher) DeepCopy() *Brancher {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildCounter) DeepCopyInto(out *BuildCounter) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildCounter.
+func (in *BuildCounter) DeepCopy() *BuildCounter {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildCounter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BuildCounter) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildCounterList) DeepCopyInto(out *BuildCounterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BuildCounter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildCounterList.
+func (in *BuildCounterList) DeepCopy() *BuildCounterList {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildCounterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BuildCounterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildCounterSpec) DeepCopyInto(out *BuildCounterSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildCounterSpec.
+func (in *BuildCounterSpec) DeepCopy() *BuildCounterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildCounterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BuildPack) DeepCopyInto(out *BuildPack) {
 	*out = *in
@@ -409,6 +518,22 @@ func (in *ChartRef) DeepCopy() *ChartRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChatNotifications) DeepCopyInto(out *ChatNotifications) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChatNotifications.
+func (in *ChatNotifications) DeepCopy() *ChatNotifications {
+	if in == nil {
+		return nil
+	}
+	out := new(ChatNotifications)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommitStatus) DeepCopyInto(out *CommitStatus) {
 	*out = *in
@@ -795,6 +920,68 @@ func (in DependencyUpdatePath) DeepCopy() DependencyUpdatePath {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentWindow) DeepCopyInto(out *DeploymentWindow) {
+	*out = *in
+	if in.Start != nil {
+		in, out := &in.Start, &out.Start
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = (*in).DeepCopy()
+		}
+	}
+	if in.End != nil {
+		in, out := &in.End, &out.End
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = (*in).DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentWindow.
+func (in *DeploymentWindow) DeepCopy() *DeploymentWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentWindows) DeepCopyInto(out *DeploymentWindows) {
+	*out = *in
+	if in.Allow != nil {
+		in, out := &in.Allow, &out.Allow
+		*out = make([]DeploymentWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Deny != nil {
+		in, out := &in.Deny, &out.Deny
+		*out = make([]DeploymentWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentWindows.
+func (in *DeploymentWindows) DeepCopy() *DeploymentWindows {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentWindows)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Environment) DeepCopyInto(out *Environment) {
 	*out = *in
@@ -1010,6 +1197,15 @@ func (in *EnvironmentSpec) DeepCopyInto(out *EnvironmentSpec) {
 	out.Source = in.Source
 	in.TeamSettings.DeepCopyInto(&out.TeamSettings)
 	out.PreviewGitSpec = in.PreviewGitSpec
+	if in.DeploymentWindows != nil {
+		in, out := &in.DeploymentWindows, &out.DeploymentWindows
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(DeploymentWindows)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -2266,6 +2462,15 @@ func (in *PipelineActivityStep) DeepCopyInto(out *PipelineActivityStep) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.Approval != nil {
+		in, out := &in.Approval, &out.Approval
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ApprovalActivityStep)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -2876,6 +3081,15 @@ func (in *PromoteActivityStep) DeepCopyInto(out *PromoteActivityStep) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(PromoteCanaryStep)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -2889,6 +3103,23 @@ func (in *PromoteActivityStep) DeepCopy() *PromoteActivityStep {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromoteCanaryStep) DeepCopyInto(out *PromoteCanaryStep) {
+	*out = *in
+	in.CoreActivityStep.DeepCopyInto(&out.CoreActivityStep)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromoteCanaryStep.
+func (in *PromoteCanaryStep) DeepCopy() *PromoteCanaryStep {
+	if in == nil {
+		return nil
+	}
+	out := new(PromoteCanaryStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PromotePullRequestStep) DeepCopyInto(out *PromotePullRequestStep) {
 	*out = *in
@@ -4080,6 +4311,11 @@ func (in *TeamSettings) DeepCopyInto(out *TeamSettings) {
 		copy(*out, *in)
 	}
 	out.DefaultScheduler = in.DefaultScheduler
+	if in.ChatNotifications != nil {
+		in, out := &in.ChatNotifications, &out.ChatNotifications
+		*out = new(ChatNotifications)
+		**out = **in
+	}
 	return
 }
 
@@ -4382,6 +4618,16 @@ func (in *WorkflowPreconditions) DeepCopyInto(out *WorkflowPreconditions) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Gates != nil {
+		in, out := &in.Gates, &out.Gates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -4447,6 +4693,15 @@ func (in *WorkflowStep) DeepCopyInto(out *WorkflowStep) {
 			**out = **in
 		}
 	}
+	if in.Approval != nil {
+		in, out := &in.Approval, &out.Approval
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ApprovalWorkflowStep)
+			**out = **in
+		}
+	}
 	return
 }
 
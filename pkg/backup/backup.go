@@ -0,0 +1,298 @@
+package backup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// TeamSettingsResource is the name of the backups of the team settings of the development environment
+	TeamSettingsResource = "teamsetting"
+)
+
+// Resource describes how a kind of resource is backed up into and restored from a backup repository
+type Resource struct {
+	// Name is the singular lower case name of the resource which is used for its directory in the backup repository
+	Name string
+	// NewObject creates an empty resource to load a backup into
+	NewObject func() runtime.Object
+	// List lists the resources in a namespace
+	List func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (runtime.Object, error)
+	// Watch watches the resources in a namespace
+	Watch func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (watch.Interface, error)
+	// Get returns the resource of the given name
+	Get func(jxClient versioned.Interface, ns string, name string) (runtime.Object, error)
+	// Create creates the resource
+	Create func(jxClient versioned.Interface, ns string, obj runtime.Object) error
+	// Update updates the resource
+	Update func(jxClient versioned.Interface, ns string, obj runtime.Object) error
+}
+
+// ListWatch returns the ListWatch to use with an informer of the resources in the namespace
+func (r *Resource) ListWatch(jxClient versioned.Interface, ns string) *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
+			return r.List(jxClient, ns, lo)
+		},
+		WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
+			return r.Watch(jxClient, ns, lo)
+		},
+	}
+}
+
+// Resources returns the resources which are backed up in the order in which they are restored
+func Resources() []*Resource {
+	return []*Resource{
+		{
+			Name:      "team",
+			NewObject: func() runtime.Object { return &v1.Team{} },
+			List: func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (runtime.Object, error) {
+				return jxClient.JenkinsV1().Teams(ns).List(lo)
+			},
+			Watch: func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (watch.Interface, error) {
+				return jxClient.JenkinsV1().Teams(ns).Watch(lo)
+			},
+			Get: func(jxClient versioned.Interface, ns string, name string) (runtime.Object, error) {
+				return jxClient.JenkinsV1().Teams(ns).Get(name, metav1.GetOptions{})
+			},
+			Create: func(jxClient versioned.Interface, ns string, obj runtime.Object) error {
+				_, err := jxClient.JenkinsV1().Teams(ns).Create(obj.(*v1.Team))
+				return err
+			},
+			Update: func(jxClient versioned.Interface, ns string, obj runtime.Object) error {
+				_, err := jxClient.JenkinsV1().Teams(ns).Update(obj.(*v1.Team))
+				return err
+			},
+		},
+		{
+			Name:      "user",
+			NewObject: func() runtime.Object { return &v1.User{} },
+			List: func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (runtime.Object, error) {
+				return jxClient.JenkinsV1().Users(ns).List(lo)
+			},
+			Watch: func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (watch.Interface, error) {
+				return jxClient.JenkinsV1().Users(ns).Watch(lo)
+			},
+			Get: func(jxClient versioned.Interface, ns string, name string) (runtime.Object, error) {
+				return jxClient.JenkinsV1().Users(ns).Get(name, metav1.GetOptions{})
+			},
+			Create: func(jxClient versioned.Interface, ns string, obj runtime.Object) error {
+				_, err := jxClient.JenkinsV1().Users(ns).Create(obj.(*v1.User))
+				return err
+			},
+			Update: func(jxClient versioned.Interface, ns string, obj runtime.Object) error {
+				_, err := jxClient.JenkinsV1().Users(ns).Update(obj.(*v1.User))
+				return err
+			},
+		},
+		{
+			Name:      "environment",
+			NewObject: func() runtime.Object { return &v1.Environment{} },
+			List: func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (runtime.Object, error) {
+				return jxClient.JenkinsV1().Environments(ns).List(lo)
+			},
+			Watch: func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (watch.Interface, error) {
+				return jxClient.JenkinsV1().Environments(ns).Watch(lo)
+			},
+			Get: func(jxClient versioned.Interface, ns string, name string) (runtime.Object, error) {
+				return jxClient.JenkinsV1().Environments(ns).Get(name, metav1.GetOptions{})
+			},
+			Create: func(jxClient versioned.Interface, ns string, obj runtime.Object) error {
+				_, err := jxClient.JenkinsV1().Environments(ns).Create(obj.(*v1.Environment))
+				return err
+			},
+			Update: func(jxClient versioned.Interface, ns string, obj runtime.Object) error {
+				_, err := jxClient.JenkinsV1().Environments(ns).Update(obj.(*v1.Environment))
+				return err
+			},
+		},
+		{
+			Name:      "environmentrolebinding",
+			NewObject: func() runtime.Object { return &v1.EnvironmentRoleBinding{} },
+			List: func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (runtime.Object, error) {
+				return jxClient.JenkinsV1().EnvironmentRoleBindings(ns).List(lo)
+			},
+			Watch: func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (watch.Interface, error) {
+				return jxClient.JenkinsV1().EnvironmentRoleBindings(ns).Watch(lo)
+			},
+			Get: func(jxClient versioned.Interface, ns string, name string) (runtime.Object, error) {
+				return jxClient.JenkinsV1().EnvironmentRoleBindings(ns).Get(name, metav1.GetOptions{})
+			},
+			Create: func(jxClient versioned.Interface, ns string, obj runtime.Object) error {
+				_, err := jxClient.JenkinsV1().EnvironmentRoleBindings(ns).Create(obj.(*v1.EnvironmentRoleBinding))
+				return err
+			},
+			Update: func(jxClient versioned.Interface, ns string, obj runtime.Object) error {
+				_, err := jxClient.JenkinsV1().EnvironmentRoleBindings(ns).Update(obj.(*v1.EnvironmentRoleBinding))
+				return err
+			},
+		},
+		{
+			Name:      "scheduler",
+			NewObject: func() runtime.Object { return &v1.Scheduler{} },
+			List: func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (runtime.Object, error) {
+				return jxClient.JenkinsV1().Schedulers(ns).List(lo)
+			},
+			Watch: func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (watch.Interface, error) {
+				return jxClient.JenkinsV1().Schedulers(ns).Watch(lo)
+			},
+			Get: func(jxClient versioned.Interface, ns string, name string) (runtime.Object, error) {
+				return jxClient.JenkinsV1().Schedulers(ns).Get(name, metav1.GetOptions{})
+			},
+			Create: func(jxClient versioned.Interface, ns string, obj runtime.Object) error {
+				_, err := jxClient.JenkinsV1().Schedulers(ns).Create(obj.(*v1.Scheduler))
+				return err
+			},
+			Update: func(jxClient versioned.Interface, ns string, obj runtime.Object) error {
+				_, err := jxClient.JenkinsV1().Schedulers(ns).Update(obj.(*v1.Scheduler))
+				return err
+			},
+		},
+		{
+			Name:      "sourcerepository",
+			NewObject: func() runtime.Object { return &v1.SourceRepository{} },
+			List: func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (runtime.Object, error) {
+				return jxClient.JenkinsV1().SourceRepositories(ns).List(lo)
+			},
+			Watch: func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (watch.Interface, error) {
+				return jxClient.JenkinsV1().SourceRepositories(ns).Watch(lo)
+			},
+			Get: func(jxClient versioned.Interface, ns string, name string) (runtime.Object, error) {
+				return jxClient.JenkinsV1().SourceRepositories(ns).Get(name, metav1.GetOptions{})
+			},
+			Create: func(jxClient versioned.Interface, ns string, obj runtime.Object) error {
+				_, err := jxClient.JenkinsV1().SourceRepositories(ns).Create(obj.(*v1.SourceRepository))
+				return err
+			},
+			Update: func(jxClient versioned.Interface, ns string, obj runtime.Object) error {
+				_, err := jxClient.JenkinsV1().SourceRepositories(ns).Update(obj.(*v1.SourceRepository))
+				return err
+			},
+		},
+		{
+			Name:      "app",
+			NewObject: func() runtime.Object { return &v1.App{} },
+			List: func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (runtime.Object, error) {
+				return jxClient.JenkinsV1().Apps(ns).List(lo)
+			},
+			Watch: func(jxClient versioned.Interface, ns string, lo metav1.ListOptions) (watch.Interface, error) {
+				return jxClient.JenkinsV1().Apps(ns).Watch(lo)
+			},
+			Get: func(jxClient versioned.Interface, ns string, name string) (runtime.Object, error) {
+				return jxClient.JenkinsV1().Apps(ns).Get(name, metav1.GetOptions{})
+			},
+			Create: func(jxClient versioned.Interface, ns string, obj runtime.Object) error {
+				_, err := jxClient.JenkinsV1().Apps(ns).Create(obj.(*v1.App))
+				return err
+			},
+			Update: func(jxClient versioned.Interface, ns string, obj runtime.Object) error {
+				_, err := jxClient.JenkinsV1().Apps(ns).Update(obj.(*v1.App))
+				return err
+			},
+		},
+	}
+}
+
+// ResourceFile returns the file in the backup repository of the resource of the given name in the namespace
+func ResourceFile(dir string, resource string, ns string, name string) string {
+	plural := resource + "s"
+	if strings.HasSuffix(resource, "y") {
+		plural = strings.TrimSuffix(resource, "y") + "ies"
+	}
+	return filepath.Join(dir, plural, ns, fmt.Sprintf("%s.yaml", name))
+}
+
+// CleanObject returns a copy of the resource without the type and the metadata which are populated by the cluster so
+// that it can be created in another cluster, along with the name of the resource
+func CleanObject(obj runtime.Object) (runtime.Object, string, error) {
+	answer := obj.DeepCopyObject()
+	answer.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{})
+	m, err := meta.Accessor(answer)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to access the metadata of the resource")
+	}
+	m.SetResourceVersion("")
+	m.SetUID("")
+	m.SetSelfLink("")
+	m.SetGeneration(0)
+	m.SetCreationTimestamp(metav1.Time{})
+	return answer, m.GetName(), nil
+}
+
+// WriteFile writes the backup of the resource of the given name in the namespace to the backup repository
+func WriteFile(dir string, resource string, ns string, name string, obj interface{}) error {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s %s", resource, name)
+	}
+	fileName := ResourceFile(dir, resource, ns, name)
+	err = os.MkdirAll(filepath.Dir(fileName), os.FileMode(0755))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the directory for %s", fileName)
+	}
+	err = ioutil.WriteFile(fileName, data, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %s", fileName)
+	}
+	return nil
+}
+
+// DeleteFile removes the backup of the resource of the given name in the namespace from the backup repository so
+// that the deletion is recorded in its history
+func DeleteFile(dir string, resource string, ns string, name string) error {
+	fileName := ResourceFile(dir, resource, ns, name)
+	err := os.Remove(fileName)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove %s", fileName)
+	}
+	return nil
+}
+
+// WriteResource writes the backup of the resource and returns its name. The team settings of the development
+// environment are also written on their own so that they can be restored into an existing development environment
+func WriteResource(dir string, resource *Resource, ns string, obj runtime.Object) (string, error) {
+	cleaned, name, err := CleanObject(obj)
+	if err != nil {
+		return "", err
+	}
+	err = WriteFile(dir, resource.Name, ns, name, cleaned)
+	if err != nil {
+		return name, err
+	}
+	env, ok := obj.(*v1.Environment)
+	if ok && env.Spec.Kind == v1.EnvironmentKindTypeDevelopment {
+		err = WriteFile(dir, TeamSettingsResource, ns, name, &env.Spec.TeamSettings)
+	}
+	return name, err
+}
+
+// DeleteResource removes the backup of the resource and returns its name
+func DeleteResource(dir string, resource *Resource, ns string, obj runtime.Object) (string, error) {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to access the metadata of the resource")
+	}
+	name := m.GetName()
+	err = DeleteFile(dir, resource.Name, ns, name)
+	if err != nil {
+		return name, err
+	}
+	if resource.Name == "environment" {
+		err = DeleteFile(dir, TeamSettingsResource, ns, name)
+	}
+	return name, err
+}
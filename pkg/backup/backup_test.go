@@ -0,0 +1,135 @@
+package backup_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/backup"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testNamespace = "jx"
+
+func findResource(t *testing.T, name string) *backup.Resource {
+	for _, resource := range backup.Resources() {
+		if resource.Name == name {
+			return resource
+		}
+	}
+	require.Fail(t, "no resource called "+name)
+	return nil
+}
+
+func createDevEnvironment(promotionEngine v1.PromotionEngineType) *v1.Environment {
+	env := kube.NewPermanentEnvironment(kube.LabelValueDevEnvironment)
+	env.Namespace = testNamespace
+	env.ResourceVersion = "3"
+	env.Spec.Kind = v1.EnvironmentKindTypeDevelopment
+	env.Spec.TeamSettings.PromotionEngine = promotionEngine
+	return env
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-backup-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	envResource := findResource(t, "environment")
+	repoResource := findResource(t, "sourcerepository")
+
+	devEnv := createDevEnvironment(v1.PromotionEngineProw)
+	_, err = backup.WriteResource(dir, envResource, testNamespace, devEnv)
+	require.NoError(t, err)
+	assert.Equal(t, "3", devEnv.ResourceVersion, "the backed up resource is not modified")
+
+	repo := &v1.SourceRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "myorg-myapp",
+			Namespace:       testNamespace,
+			ResourceVersion: "7",
+		},
+		Spec: v1.SourceRepositorySpec{
+			Org:  "myorg",
+			Repo: "myapp",
+		},
+	}
+	name, err := backup.WriteResource(dir, repoResource, testNamespace, repo)
+	require.NoError(t, err)
+	assert.Equal(t, "myorg-myapp", name)
+
+	for _, fileName := range []string{
+		filepath.Join(dir, "environments", testNamespace, "dev.yaml"),
+		filepath.Join(dir, "teamsettings", testNamespace, "dev.yaml"),
+		filepath.Join(dir, "sourcerepositories", testNamespace, "myorg-myapp.yaml"),
+	} {
+		exists, err := util.FileExists(fileName)
+		require.NoError(t, err)
+		assert.True(t, exists, "file %s should exist", fileName)
+	}
+
+	oldRepo := repo.DeepCopy()
+	oldRepo.Name = "myorg-oldapp"
+	_, err = backup.WriteResource(dir, repoResource, testNamespace, oldRepo)
+	require.NoError(t, err)
+	_, err = backup.DeleteResource(dir, repoResource, testNamespace, oldRepo)
+	require.NoError(t, err)
+	exists, err := util.FileExists(backup.ResourceFile(dir, repoResource.Name, testNamespace, oldRepo.Name))
+	require.NoError(t, err)
+	assert.False(t, exists, "the deleted resource is removed from the backup")
+
+	// restore into a cluster whose development environment was created with other team settings
+	jxClient := fake.NewSimpleClientset(createDevEnvironment(v1.PromotionEngineJenkins))
+	out := &bytes.Buffer{}
+	restorer := &backup.Restorer{
+		JXClient:        jxClient,
+		Dir:             dir,
+		SourceNamespace: testNamespace,
+		Namespace:       testNamespace,
+		DryRun:          true,
+		Out:             out,
+	}
+	results, err := restorer.Restore()
+	require.NoError(t, err)
+	assert.Equal(t, 1, results.Created)
+	assert.Equal(t, 2, results.Updated, "the environment and its team settings")
+	assert.Contains(t, out.String(), "would create sourcerepository")
+	assert.Contains(t, out.String(), "+ promotionEngine: Prow")
+	assert.NotContains(t, out.String(), "myorg-oldapp")
+
+	_, err = jxClient.JenkinsV1().SourceRepositories(testNamespace).Get("myorg-myapp", metav1.GetOptions{})
+	assert.Error(t, err, "a dry run does not change the cluster")
+
+	restorer.DryRun = false
+	restorer.Out = &bytes.Buffer{}
+	_, err = restorer.Restore()
+	require.NoError(t, err)
+
+	restored, err := jxClient.JenkinsV1().SourceRepositories(testNamespace).Get("myorg-myapp", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "myapp", restored.Spec.Repo)
+	env, err := jxClient.JenkinsV1().Environments(testNamespace).Get("dev", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.PromotionEngineProw, env.Spec.TeamSettings.PromotionEngine)
+
+	results, err = restorer.Restore()
+	require.NoError(t, err)
+	assert.Equal(t, backup.RestoreResults{Unchanged: 3}, *results)
+}
+
+func TestDiffLines(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", backup.DiffLines("a\nb\n", "a\nb\n"))
+	assert.Equal(t, "- b\n+ c\n+ d\n", backup.DiffLines("a\nb\ne\n", "a\nc\nd\ne\n"))
+}
@@ -0,0 +1,261 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Restorer replays the resources in a backup repository into a cluster
+type Restorer struct {
+	JXClient versioned.Interface
+	// Dir is the directory of the backup repository
+	Dir string
+	// SourceNamespace is the namespace in the backup repository to restore
+	SourceNamespace string
+	// Namespace is the namespace the resources are restored into
+	Namespace string
+	// DryRun only reports the differences between the backup and the cluster
+	DryRun bool
+	Out    io.Writer
+}
+
+// RestoreResults counts the resources which were restored
+type RestoreResults struct {
+	Created   int
+	Updated   int
+	Unchanged int
+}
+
+// Restore creates or updates the resources in the backup repository. Resources which are not in the backup are left
+// alone. In dry run mode the differences are written to the output without changing the cluster
+func (r *Restorer) Restore() (*RestoreResults, error) {
+	results := &RestoreResults{}
+	for _, resource := range Resources() {
+		names, err := r.backupNames(resource.Name)
+		if err != nil {
+			return results, err
+		}
+		for _, name := range names {
+			err = r.restoreResource(resource, name, results)
+			if err != nil {
+				return results, errors.Wrapf(err, "failed to restore %s %s", resource.Name, name)
+			}
+		}
+	}
+	names, err := r.backupNames(TeamSettingsResource)
+	if err != nil {
+		return results, err
+	}
+	for _, name := range names {
+		err = r.restoreTeamSettings(name, results)
+		if err != nil {
+			return results, errors.Wrapf(err, "failed to restore the team settings of environment %s", name)
+		}
+	}
+	return results, nil
+}
+
+// backupNames returns the sorted names of the backups of the resource in the source namespace
+func (r *Restorer) backupNames(resource string) ([]string, error) {
+	dir := filepath.Dir(ResourceFile(r.Dir, resource, r.SourceNamespace, "dummy"))
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read directory %s", dir)
+	}
+	names := []string{}
+	for _, f := range files {
+		name := f.Name()
+		if !f.IsDir() && strings.HasSuffix(name, ".yaml") {
+			names = append(names, strings.TrimSuffix(name, ".yaml"))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// loadFile loads the backup of the resource of the given name into the object
+func (r *Restorer) loadFile(resource string, name string, obj interface{}) error {
+	fileName := ResourceFile(r.Dir, resource, r.SourceNamespace, name)
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", fileName)
+	}
+	err = yaml.Unmarshal(data, obj)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unmarshal %s", fileName)
+	}
+	return nil
+}
+
+func (r *Restorer) restoreResource(resource *Resource, name string, results *RestoreResults) error {
+	loaded := resource.NewObject()
+	err := r.loadFile(resource.Name, name, loaded)
+	if err != nil {
+		return err
+	}
+	obj, _, err := CleanObject(loaded)
+	if err != nil {
+		return err
+	}
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return errors.Wrap(err, "failed to access the metadata of the resource")
+	}
+	m.SetNamespace(r.Namespace)
+
+	existing, err := resource.Get(r.JXClient, r.Namespace, name)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		r.report("create", resource.Name, name, "")
+		results.Created++
+		if r.DryRun {
+			return nil
+		}
+		return resource.Create(r.JXClient, r.Namespace, obj)
+	}
+
+	cleaned, _, err := CleanObject(existing)
+	if err != nil {
+		return err
+	}
+	diff, err := diffYaml(cleaned, obj)
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		results.Unchanged++
+		return nil
+	}
+	r.report("update", resource.Name, name, diff)
+	results.Updated++
+	if r.DryRun {
+		return nil
+	}
+	existingMeta, err := meta.Accessor(existing)
+	if err != nil {
+		return errors.Wrap(err, "failed to access the metadata of the resource")
+	}
+	m.SetResourceVersion(existingMeta.GetResourceVersion())
+	return resource.Update(r.JXClient, r.Namespace, obj)
+}
+
+// restoreTeamSettings restores the team settings of the development environment so that they are restored even if the
+// development environment was recreated by the installation of the new cluster
+func (r *Restorer) restoreTeamSettings(name string, results *RestoreResults) error {
+	settings := &v1.TeamSettings{}
+	err := r.loadFile(TeamSettingsResource, name, settings)
+	if err != nil {
+		return err
+	}
+	envs := r.JXClient.JenkinsV1().Environments(r.Namespace)
+	env, err := envs.Get(name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) && r.DryRun {
+			// the environment would have been created from the backup
+			return nil
+		}
+		return err
+	}
+	diff, err := diffYaml(&env.Spec.TeamSettings, settings)
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		results.Unchanged++
+		return nil
+	}
+	r.report("update", TeamSettingsResource, name, diff)
+	results.Updated++
+	if r.DryRun {
+		return nil
+	}
+	env.Spec.TeamSettings = *settings
+	_, err = envs.Update(env)
+	return err
+}
+
+func (r *Restorer) report(action string, resource string, name string, diff string) {
+	if r.DryRun {
+		action = "would " + action
+	}
+	fmt.Fprintf(r.Out, "%s %s %s\n", action, resource, util.ColorInfo(name))
+	if diff != "" {
+		fmt.Fprint(r.Out, diff)
+	}
+}
+
+// diffYaml returns the differences between the YAML of the resources or an empty string if they are the same
+func diffYaml(from interface{}, to interface{}) (string, error) {
+	fromData, err := yaml.Marshal(from)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the resource")
+	}
+	toData, err := yaml.Marshal(to)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the resource")
+	}
+	return DiffLines(string(fromData), string(toData)), nil
+}
+
+// DiffLines returns the lines removed from the text prefixed with '-' and the lines added to it prefixed with '+' or
+// an empty string if there are no differences
+func DiffLines(from string, to string) string {
+	if from == to {
+		return ""
+	}
+	a := strings.Split(strings.TrimSuffix(from, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(to, "\n"), "\n")
+
+	// lengths of the longest common subsequences of the suffixes of the lines
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var buffer strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			buffer.WriteString("+ " + b[j] + "\n")
+			j++
+		default:
+			buffer.WriteString("- " + a[i] + "\n")
+			i++
+		}
+	}
+	return buffer.String()
+}
@@ -0,0 +1,252 @@
+package buildnum
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	v1 "github.com/jenkins-x/jx/pkg/client/clientset/versioned/typed/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// counterBackoff is how often to retry when another replica updated a counter at the same time
+var counterBackoff = wait.Backoff{
+	Steps:    10,
+	Duration: 10 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
+// BuildCounterBuildNumGen generates build numbers backed by a BuildCounter K8S CRD for each pipeline. The counters
+// are updated using optimistic concurrency, so any number of replicas can generate build numbers at once, and build
+// numbers never go backwards when PipelineActivities are garbage collected.
+type BuildCounterBuildNumGen struct {
+	countersGetter   v1.BuildCounterInterface
+	activitiesGetter v1.PipelineActivityInterface
+	seeded           int32
+}
+
+// NewBuildCounterBuildNumGen initialises a new BuildCounterBuildNumGen that will store the counters in the supplied
+// namespace. Build numbers can be generated straight away as the counter of a pipeline catches up with its
+// PipelineActivities whenever it is behind them, Seed brings all the counters up to date up front.
+func NewBuildCounterBuildNumGen(jxClient versioned.Interface, ns string) *BuildCounterBuildNumGen {
+	return &BuildCounterBuildNumGen{
+		countersGetter:   jxClient.JenkinsV1().BuildCounters(ns),
+		activitiesGetter: jxClient.JenkinsV1().PipelineActivities(ns),
+	}
+}
+
+// Ready returns true once the counters have been seeded from the existing PipelineActivities.
+func (g *BuildCounterBuildNumGen) Ready() bool {
+	return atomic.LoadInt32(&g.seeded) == 1
+}
+
+// NextBuildNumber increments the counter for the specified pipeline ID and creates a PipelineActivity for the new
+// build number. If the PipelineActivity already exists the counter is behind, for example as it hasn't been seeded
+// yet, so it jumps to the highest build number of the pipeline's PipelineActivities and tries again. Returns the build
+// number, or an error if there is a problem with K8S resources.
+func (g *BuildCounterBuildNumGen) NextBuildNumber(pipeline kube.PipelineID) (string, error) {
+	var answer string
+	err := wait.ExponentialBackoff(counterBackoff, func() (bool, error) {
+		build, err := g.incrementCounter(pipeline)
+		if err != nil {
+			return false, ignoreConcurrentUpdate(err)
+		}
+
+		nextBuild := strconv.Itoa(build)
+		a := &jenkinsv1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: pipeline.GetActivityName(nextBuild),
+			},
+			Spec: jenkinsv1.PipelineActivitySpec{
+				Build:    nextBuild,
+				Pipeline: pipeline.ID,
+			},
+		}
+		created, err := g.activitiesGetter.Create(a)
+		if apierrors.IsAlreadyExists(err) {
+			// the build number has been used, so catch up with the existing activities and try again
+			err = g.catchUp(pipeline)
+			return false, err
+		}
+		if err != nil {
+			return false, err
+		}
+		answer = created.Spec.Build
+		return true, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return "", errors.Errorf("gave up generating a build number for pipeline %s after too many concurrent updates", pipeline.ID)
+	}
+	return answer, err
+}
+
+// incrementCounter increments the counter for the pipeline, creating it after the pipeline's last PipelineActivity if
+// it doesn't exist, and returns the new value.
+func (g *BuildCounterBuildNumGen) incrementCounter(pipeline kube.PipelineID) (int, error) {
+	counter, err := g.countersGetter.Get(pipeline.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lastBuild, err := g.lastActivityBuild(pipeline)
+		if err != nil {
+			return 0, err
+		}
+		counter = &jenkinsv1.BuildCounter{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: pipeline.Name,
+			},
+			Spec: jenkinsv1.BuildCounterSpec{
+				Pipeline:        pipeline.ID,
+				LastBuildNumber: lastBuild + 1,
+			},
+		}
+		_, err = g.countersGetter.Create(counter)
+		return counter.Spec.LastBuildNumber, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	counter.Spec.LastBuildNumber++
+	// the update fails with a conflict if another replica has updated the counter since we read it
+	updated, err := g.countersGetter.Update(counter)
+	if err != nil {
+		return 0, err
+	}
+	return updated.Spec.LastBuildNumber, nil
+}
+
+// Seed migrates from build numbers generated by scanning PipelineActivities by making sure the counter for each
+// pipeline is at least the highest build number of its PipelineActivities, and then marks the generator as ready.
+func (g *BuildCounterBuildNumGen) Seed() error {
+	activities, err := g.activitiesGetter.List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list PipelineActivities")
+	}
+
+	lastBuilds := map[string]int{}
+	pipelines := map[string]kube.PipelineID{}
+	for _, a := range activities.Items {
+		if a.Spec.Pipeline == "" || a.Spec.Build == "" {
+			continue
+		}
+		build, err := strconv.Atoi(a.Spec.Build)
+		if err != nil {
+			continue
+		}
+		pipeline := kube.NewPipelineIDFromString(a.Spec.Pipeline)
+		if _, ok := pipelines[pipeline.Name]; !ok {
+			pipelines[pipeline.Name] = pipeline
+		}
+		if build > lastBuilds[pipeline.Name] {
+			lastBuilds[pipeline.Name] = build
+		}
+	}
+
+	for name, lastBuild := range lastBuilds {
+		err = g.seedCounter(pipelines[name], lastBuild)
+		if err != nil {
+			return errors.Wrapf(err, "failed to seed the build counter for pipeline %s", pipelines[name].ID)
+		}
+	}
+	log.Logger().Infof("Seeded the build counters for %d pipelines", len(lastBuilds))
+
+	atomic.StoreInt32(&g.seeded, 1)
+	return nil
+}
+
+// catchUp makes sure the counter for the pipeline is at least the highest build number of its PipelineActivities.
+func (g *BuildCounterBuildNumGen) catchUp(pipeline kube.PipelineID) error {
+	lastBuild, err := g.lastActivityBuild(pipeline)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("Moving the build counter for pipeline %s on to build %d", pipeline.ID, lastBuild)
+	return g.seedCounter(pipeline, lastBuild)
+}
+
+// lastActivityBuild returns the highest build number of the PipelineActivities of the pipeline, or 0 if it has none.
+func (g *BuildCounterBuildNumGen) lastActivityBuild(pipeline kube.PipelineID) (int, error) {
+	activities, err := g.activitiesGetter.List(metav1.ListOptions{
+		LabelSelector: pipelineActivitySelector(pipeline),
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to list the PipelineActivities of pipeline %s", pipeline.ID)
+	}
+	lastBuild := 0
+	for _, a := range activities.Items {
+		if a.Spec.Pipeline != pipeline.ID {
+			continue
+		}
+		build, err := strconv.Atoi(a.Spec.Build)
+		if err == nil && build > lastBuild {
+			lastBuild = build
+		}
+	}
+	return lastBuild, nil
+}
+
+// pipelineActivitySelector returns the label selector of the PipelineActivities of the pipeline from its owner,
+// repository and branch, or an empty selector if they are not valid label values.
+func pipelineActivitySelector(pipeline kube.PipelineID) string {
+	paths := strings.SplitN(pipeline.ID, "/", 3)
+	if len(paths) != 3 {
+		return ""
+	}
+	for _, path := range paths {
+		if len(validation.IsValidLabelValue(path)) > 0 {
+			return ""
+		}
+	}
+	return labels.Set{
+		jenkinsv1.LabelOwner:      paths[0],
+		jenkinsv1.LabelRepository: paths[1],
+		jenkinsv1.LabelBranch:     paths[2],
+	}.String()
+}
+
+// seedCounter makes sure the counter for the pipeline is at least lastBuild, never reducing it.
+func (g *BuildCounterBuildNumGen) seedCounter(pipeline kube.PipelineID, lastBuild int) error {
+	return wait.ExponentialBackoff(counterBackoff, func() (bool, error) {
+		counter, err := g.countersGetter.Get(pipeline.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			counter = &jenkinsv1.BuildCounter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: pipeline.Name,
+				},
+				Spec: jenkinsv1.BuildCounterSpec{
+					Pipeline:        pipeline.ID,
+					LastBuildNumber: lastBuild,
+				},
+			}
+			_, err = g.countersGetter.Create(counter)
+			return err == nil, ignoreConcurrentUpdate(err)
+		}
+		if err != nil {
+			return false, err
+		}
+		if counter.Spec.LastBuildNumber >= lastBuild {
+			return true, nil
+		}
+		counter.Spec.LastBuildNumber = lastBuild
+		_, err = g.countersGetter.Update(counter)
+		return err == nil, ignoreConcurrentUpdate(err)
+	})
+}
+
+// ignoreConcurrentUpdate returns nil if the error was caused by another replica creating or updating the same
+// resource, so that the operation is retried, otherwise the error.
+func ignoreConcurrentUpdate(err error) error {
+	if err == nil || apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+package buildnum
+
+import (
+	"strings"
+	"testing"
+
+	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+const testNamespace = "jx"
+
+func TestBuildCounterNextBuildNumber(t *testing.T) {
+	jxClient := fake.NewSimpleClientset()
+	gen := NewBuildCounterBuildNumGen(jxClient, testNamespace)
+	pID := kube.NewPipelineIDFromString("owner1/repo1/branch1")
+
+	for _, expected := range []string{"1", "2", "3"} {
+		build, err := gen.NextBuildNumber(pID)
+		require.NoError(t, err)
+		assert.Equal(t, expected, build)
+	}
+
+	counter, err := jxClient.JenkinsV1().BuildCounters(testNamespace).Get(pID.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "owner1/repo1/branch1", counter.Spec.Pipeline)
+	assert.Equal(t, 3, counter.Spec.LastBuildNumber)
+
+	activity, err := jxClient.JenkinsV1().PipelineActivities(testNamespace).Get(pID.GetActivityName("3"), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "3", activity.Spec.Build)
+
+	// deleting old activities doesn't reuse their build numbers
+	err = jxClient.JenkinsV1().PipelineActivities(testNamespace).DeleteCollection(&metav1.DeleteOptions{}, metav1.ListOptions{})
+	require.NoError(t, err)
+	build, err := gen.NextBuildNumber(pID)
+	require.NoError(t, err)
+	assert.Equal(t, "4", build)
+}
+
+func TestBuildCounterRetriesOnConflict(t *testing.T) {
+	jxClient := fake.NewSimpleClientset(&jenkinsv1.BuildCounter{
+		ObjectMeta: metav1.ObjectMeta{Name: "owner1-repo1-branch1", Namespace: testNamespace},
+		Spec:       jenkinsv1.BuildCounterSpec{Pipeline: "owner1/repo1/branch1", LastBuildNumber: 7},
+	})
+	conflicts := 2
+	jxClient.PrependReactor("update", "buildcounters", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		if conflicts > 0 {
+			conflicts--
+			return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "buildcounters"}, "owner1-repo1-branch1", nil)
+		}
+		return false, nil, nil
+	})
+	gen := NewBuildCounterBuildNumGen(jxClient, testNamespace)
+
+	build, err := gen.NextBuildNumber(kube.NewPipelineIDFromString("owner1/repo1/branch1"))
+	require.NoError(t, err)
+	assert.Equal(t, "8", build)
+	assert.Equal(t, 0, conflicts)
+}
+
+func testActivity(pipeline string, build string) *jenkinsv1.PipelineActivity {
+	pID := kube.NewPipelineIDFromString(pipeline)
+	paths := strings.Split(pipeline, "/")
+	return &jenkinsv1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pID.GetActivityName(build),
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				jenkinsv1.LabelOwner:      paths[0],
+				jenkinsv1.LabelRepository: paths[1],
+				jenkinsv1.LabelBranch:     paths[2],
+			},
+		},
+		Spec: jenkinsv1.PipelineActivitySpec{Pipeline: pipeline, Build: build},
+	}
+}
+
+func TestBuildCounterCatchesUpWithoutSeeding(t *testing.T) {
+	jxClient := fake.NewSimpleClientset(
+		testActivity("owner1/repo1/master", "4"),
+		testActivity("owner1/repo2/master", "2"),
+		testActivity("owner1/repo2/master", "3"),
+		&jenkinsv1.BuildCounter{
+			ObjectMeta: metav1.ObjectMeta{Name: "owner1-repo2-master", Namespace: testNamespace},
+			Spec:       jenkinsv1.BuildCounterSpec{Pipeline: "owner1/repo2/master", LastBuildNumber: 1},
+		},
+	)
+	gen := NewBuildCounterBuildNumGen(jxClient, testNamespace)
+	require.False(t, gen.Ready())
+
+	// a new counter starts after the existing activities
+	build, err := gen.NextBuildNumber(kube.NewPipelineIDFromString("owner1/repo1/master"))
+	require.NoError(t, err)
+	assert.Equal(t, "5", build)
+
+	// a counter behind the activities jumps past them rather than failing
+	build, err = gen.NextBuildNumber(kube.NewPipelineIDFromString("owner1/repo2/master"))
+	require.NoError(t, err)
+	assert.Equal(t, "4", build)
+
+	counter, err := jxClient.JenkinsV1().BuildCounters(testNamespace).Get("owner1-repo2-master", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 4, counter.Spec.LastBuildNumber)
+}
+
+func TestPipelineActivitySelector(t *testing.T) {
+	assert.Equal(t, "branch=master,owner=owner1,repository=repo1", pipelineActivitySelector(kube.NewPipelineIDFromString("owner1/repo1/master")))
+	assert.Equal(t, "", pipelineActivitySelector(kube.NewPipelineIDFromString("owner1/repo1/feature/foo")), "the branch is not a valid label value")
+}
+
+func TestBuildCounterSeed(t *testing.T) {
+	jxClient := fake.NewSimpleClientset(
+		testActivity("owner1/repo1/master", "3"),
+		testActivity("owner1/repo1/master", "12"),
+		testActivity("owner1/repo1/master", "9"),
+		testActivity("owner1/repo2/master", "2"),
+		&jenkinsv1.BuildCounter{
+			ObjectMeta: metav1.ObjectMeta{Name: "owner1-repo2-master", Namespace: testNamespace},
+			Spec:       jenkinsv1.BuildCounterSpec{Pipeline: "owner1/repo2/master", LastBuildNumber: 5},
+		},
+	)
+	gen := NewBuildCounterBuildNumGen(jxClient, testNamespace)
+	assert.False(t, gen.Ready())
+
+	err := gen.Seed()
+	require.NoError(t, err)
+	assert.True(t, gen.Ready())
+
+	build, err := gen.NextBuildNumber(kube.NewPipelineIDFromString("owner1/repo1/master"))
+	require.NoError(t, err)
+	assert.Equal(t, "13", build)
+
+	// seeding never reduces a counter
+	build, err = gen.NextBuildNumber(kube.NewPipelineIDFromString("owner1/repo2/master"))
+	require.NoError(t, err)
+	assert.Equal(t, "6", build)
+}
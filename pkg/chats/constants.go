@@ -3,8 +3,12 @@ package chats
 const (
 	Slack = "slack"
 	Irc   = "irc"
+	// Mattermost posts messages to a Mattermost incoming webhook
+	Mattermost = "mattermost"
+	// RocketChat posts messages to a Rocket.Chat incoming webhook
+	RocketChat = "rocketchat"
 )
 
 var (
-	ChatKinds = []string{Slack, Irc}
+	ChatKinds = []string{Slack, Irc, Mattermost, RocketChat}
 )
@@ -0,0 +1,159 @@
+package chats
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/gits"
+)
+
+// MessageStatus is the outcome reported by a message, which is shown as the color of the message
+type MessageStatus string
+
+const (
+	// MessageStatusInfo the message does not report an outcome
+	MessageStatusInfo MessageStatus = ""
+	// MessageStatusRunning the message reports something which is still running
+	MessageStatusRunning MessageStatus = "running"
+	// MessageStatusSucceeded the message reports something which succeeded
+	MessageStatusSucceeded MessageStatus = "succeeded"
+	// MessageStatusFailed the message reports something which failed
+	MessageStatusFailed MessageStatus = "failed"
+)
+
+// Message is a rich chat message
+type Message struct {
+	Title string
+	// TitleURL is the link for the title, such as the build logs
+	TitleURL string
+	Text     string
+	Status   MessageStatus
+	Fields   []MessageField
+	Links    []MessageLink
+}
+
+// MessageField is a short name and value shown in a message, such as the version
+type MessageField struct {
+	Title string
+	Value string
+}
+
+// MessageLink is a link shown at the end of a message, such as to the pull request
+type MessageLink struct {
+	Name string
+	URL  string
+}
+
+// Color returns the color chat services use to show the status
+func (s MessageStatus) Color() string {
+	switch s {
+	case MessageStatusRunning:
+		return "#daa038"
+	case MessageStatusSucceeded:
+		return "#36a64f"
+	case MessageStatusFailed:
+		return "#d00000"
+	default:
+		return "#439fe0"
+	}
+}
+
+// AddField adds a field to the message if the value is not empty
+func (m *Message) AddField(title string, value string) {
+	if value != "" {
+		m.Fields = append(m.Fields, MessageField{Title: title, Value: value})
+	}
+}
+
+// AddLink adds a link to the message if the URL is not empty
+func (m *Message) AddLink(name string, url string) {
+	if url != "" {
+		m.Links = append(m.Links, MessageLink{Name: name, URL: url})
+	}
+}
+
+// LinksMarkdown returns the links as a line of markdown
+func (m *Message) LinksMarkdown() string {
+	var links []string
+	for _, l := range m.Links {
+		links = append(links, fmt.Sprintf("[%s](%s)", l.Name, l.URL))
+	}
+	return strings.Join(links, " | ")
+}
+
+// ActivityStatus returns the message status for the status of a pipeline or step
+func ActivityStatus(status v1.ActivityStatusType) MessageStatus {
+	switch status {
+	case v1.ActivityStatusTypeSucceeded:
+		return MessageStatusSucceeded
+	case v1.ActivityStatusTypeFailed, v1.ActivityStatusTypeError, v1.ActivityStatusTypeAborted:
+		return MessageStatusFailed
+	case v1.ActivityStatusTypePending, v1.ActivityStatusTypeRunning, v1.ActivityStatusTypeWaitingForApproval:
+		return MessageStatusRunning
+	default:
+		return MessageStatusInfo
+	}
+}
+
+// NewPipelineMessage creates a message with the outcome of a pipeline, linking to its logs, commit and pull request
+func NewPipelineMessage(activity *v1.PipelineActivity) *Message {
+	spec := &activity.Spec
+	status := spec.Status
+	if status == v1.ActivityStatusTypeNone {
+		status = v1.ActivityStatusTypePending
+	}
+	message := &Message{
+		Title:    fmt.Sprintf("Pipeline %s #%s %s", spec.Pipeline, spec.Build, strings.ToLower(string(status))),
+		TitleURL: spec.BuildLogsURL,
+		Text:     spec.PullTitle,
+		Status:   ActivityStatus(spec.Status),
+	}
+	if message.TitleURL == "" {
+		message.TitleURL = spec.BuildURL
+	}
+	if message.Text == "" {
+		message.Text = spec.LastCommitMessage
+	}
+	message.AddField("Version", spec.Version)
+	message.AddField("Author", spec.Author)
+
+	message.AddLink("Logs", spec.BuildLogsURL)
+	if spec.GitURL != "" {
+		branch := activity.BranchName()
+		if strings.HasPrefix(strings.ToUpper(branch), "PR-") {
+			gitInfo, err := gits.ParseGitURL(spec.GitURL)
+			if err == nil {
+				message.AddLink("Pull Request", gitInfo.PullRequestURL(branch[3:]))
+			}
+		}
+	}
+	message.AddLink("Commit", spec.LastCommitURL)
+	message.AddLink("Release Notes", spec.ReleaseNotesURL)
+	return message
+}
+
+// NewPromotionMessage creates a message with the outcome of promoting a version of an application to an environment
+func NewPromotionMessage(app string, version string, environment string, step *v1.PromoteActivityStep) *Message {
+	message := &Message{
+		Title: fmt.Sprintf("Promoted %s %s to %s", app, version, environment),
+	}
+	message.AddField("Application", app)
+	message.AddField("Version", version)
+	message.AddField("Environment", environment)
+	if step != nil {
+		message.Status = ActivityStatus(step.Status)
+		switch message.Status {
+		case MessageStatusFailed:
+			message.Title = fmt.Sprintf("Failed to promote %s %s to %s", app, version, environment)
+		case MessageStatusRunning:
+			message.Title = fmt.Sprintf("Promoting %s %s to %s", app, version, environment)
+		}
+		message.TitleURL = step.ApplicationURL
+		message.AddLink("Application", step.ApplicationURL)
+		if step.PullRequest != nil {
+			message.AddLink("Pull Request", step.PullRequest.PullRequestURL)
+		}
+	}
+	return message
+}
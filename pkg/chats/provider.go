@@ -10,6 +10,9 @@ import (
 // ChatProvider represents an integration interface to chat
 type ChatProvider interface {
 	GetChannelMetrics(name string) (*ChannelMetrics, error)
+
+	// PostMessage posts the message to the channel
+	PostMessage(channel string, message *Message) error
 }
 
 // ChannelMetrics metrics for a channel
@@ -30,6 +33,8 @@ func CreateChatProvider(kind string, server *auth.AuthServer, userAuth *auth.Use
 	switch kind {
 	case Slack:
 		return CreateSlackChatProvider(server, userAuth, batchMode)
+	case Mattermost, RocketChat:
+		return CreateWebhookChatProvider(server, userAuth, batchMode)
 	default:
 		return nil, fmt.Errorf("Unsupported chat provider kind: %s", kind)
 	}
@@ -8,6 +8,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
 )
 
 type SlackChatProvider struct {
@@ -62,3 +63,35 @@ func (c *SlackChatProvider) GetChannelMetrics(name string) (*ChannelMetrics, err
 	metrics.URL = util.UrlJoin(c.Server.URL, "messages", info.ID)
 	return metrics, nil
 }
+
+// PostMessage posts the message to the channel as an attachment, so that it is shown with the color of its status
+func (c *SlackChatProvider) PostMessage(channel string, message *Message) error {
+	attachment := slack.Attachment{
+		Color:     message.Status.Color(),
+		Fallback:  message.Title,
+		Title:     message.Title,
+		TitleLink: message.TitleURL,
+		Text:      message.Text,
+	}
+	for _, f := range message.Fields {
+		attachment.Fields = append(attachment.Fields, slack.AttachmentField{
+			Title: f.Title,
+			Value: f.Value,
+			Short: true,
+		})
+	}
+	var links []string
+	for _, l := range message.Links {
+		links = append(links, fmt.Sprintf("<%s|%s>", l.URL, l.Name))
+	}
+	attachment.Footer = strings.Join(links, " | ")
+
+	params := slack.PostMessageParameters{
+		Attachments: []slack.Attachment{attachment},
+	}
+	_, _, err := c.SlackClient.PostMessage(channel, "", params)
+	if err != nil {
+		return errors.Wrapf(err, "failed to post message to Slack channel %s", channel)
+	}
+	return nil
+}
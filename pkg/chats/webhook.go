@@ -0,0 +1,116 @@
+package chats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// WebhookChatProvider posts messages to an incoming webhook which accepts Slack compatible payloads, such as the
+// incoming webhooks of Mattermost and Rocket.Chat
+type WebhookChatProvider struct {
+	Client   *http.Client
+	Server   *auth.AuthServer
+	UserAuth *auth.UserAuth
+	// URL is the URL of the webhook
+	URL string
+}
+
+type webhookPayload struct {
+	Channel     string              `json:"channel,omitempty"`
+	Username    string              `json:"username,omitempty"`
+	Text        string              `json:"text,omitempty"`
+	Attachments []webhookAttachment `json:"attachments,omitempty"`
+}
+
+type webhookAttachment struct {
+	Fallback  string         `json:"fallback,omitempty"`
+	Color     string         `json:"color,omitempty"`
+	Title     string         `json:"title,omitempty"`
+	TitleLink string         `json:"title_link,omitempty"`
+	Text      string         `json:"text,omitempty"`
+	Fields    []webhookField `json:"fields,omitempty"`
+}
+
+type webhookField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// CreateWebhookChatProvider creates a provider which posts to the server's incoming webhook. If the user has an API
+// token it is appended to the server URL, so that the secret part of the webhook URL can be kept in the auth config.
+func CreateWebhookChatProvider(server *auth.AuthServer, userAuth *auth.UserAuth, batchMode bool) (ChatProvider, error) {
+	u := server.URL
+	if u == "" {
+		return nil, fmt.Errorf("No webhook URL for server!")
+	}
+	if userAuth != nil && userAuth.ApiToken != "" {
+		u = util.UrlJoin(u, userAuth.ApiToken)
+	}
+	return &WebhookChatProvider{
+		Client:   util.GetClient(),
+		Server:   server,
+		UserAuth: userAuth,
+		URL:      u,
+	}, nil
+}
+
+// GetChannelMetrics is not supported as incoming webhooks can only post messages
+func (c *WebhookChatProvider) GetChannelMetrics(name string) (*ChannelMetrics, error) {
+	return nil, fmt.Errorf("channel metrics are not supported for %s incoming webhooks", c.Server.Kind)
+}
+
+// PostMessage posts the message to the channel, or the default channel of the webhook if the channel is empty
+func (c *WebhookChatProvider) PostMessage(channel string, message *Message) error {
+	attachment := webhookAttachment{
+		Fallback:  message.Title,
+		Color:     message.Status.Color(),
+		Title:     message.Title,
+		TitleLink: message.TitleURL,
+		Text:      message.Text,
+	}
+	if len(message.Links) > 0 {
+		if attachment.Text != "" {
+			attachment.Text += "\n\n"
+		}
+		attachment.Text += message.LinksMarkdown()
+	}
+	for _, f := range message.Fields {
+		attachment.Fields = append(attachment.Fields, webhookField{
+			Title: f.Title,
+			Value: f.Value,
+			Short: true,
+		})
+	}
+	payload := &webhookPayload{
+		Channel:     channel,
+		Attachments: []webhookAttachment{attachment},
+	}
+	if c.UserAuth != nil {
+		payload.Username = c.UserAuth.Username
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the message")
+	}
+	resp, err := c.Client.Post(c.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrapf(err, "failed to post message to %s", c.Server.URL)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("error response posting message to %s: %s", c.Server.URL, resp.Status)
+	}
+	return nil
+}
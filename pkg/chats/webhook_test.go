@@ -0,0 +1,100 @@
+package chats
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookChatProviderPostMessage(t *testing.T) {
+	t.Parallel()
+
+	var path string
+	payload := map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &payload))
+	}))
+	defer server.Close()
+
+	provider, err := CreateChatProvider(Mattermost, &auth.AuthServer{URL: server.URL + "/hooks", Kind: Mattermost},
+		&auth.UserAuth{Username: "jenkins-x", ApiToken: "abc123"}, true)
+	require.NoError(t, err)
+
+	activity := &v1.PipelineActivity{
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:     "myorg/myrepo/PR-12",
+			Build:        "3",
+			Status:       v1.ActivityStatusTypeFailed,
+			BuildLogsURL: "https://logs.example.com/myorg/myrepo/PR-12/3",
+			GitURL:       "https://github.com/myorg/myrepo.git",
+			PullTitle:    "fix: the thing",
+			Author:       "someone",
+		},
+	}
+	err = provider.PostMessage("builds", NewPipelineMessage(activity))
+	require.NoError(t, err)
+
+	assert.Equal(t, "/hooks/abc123", path)
+	assert.Equal(t, "builds", payload["channel"])
+	assert.Equal(t, "jenkins-x", payload["username"])
+	attachments := payload["attachments"].([]interface{})
+	require.Len(t, attachments, 1)
+	attachment := attachments[0].(map[string]interface{})
+	assert.Equal(t, "Pipeline myorg/myrepo/PR-12 #3 failed", attachment["title"])
+	assert.Equal(t, "https://logs.example.com/myorg/myrepo/PR-12/3", attachment["title_link"])
+	assert.Equal(t, MessageStatusFailed.Color(), attachment["color"])
+	assert.Equal(t, "fix: the thing\n\n[Logs](https://logs.example.com/myorg/myrepo/PR-12/3) | [Pull Request](https://github.com/myorg/myrepo/pull/12)", attachment["text"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"title": "Author", "value": "someone", "short": true}}, attachment["fields"])
+}
+
+func TestWebhookChatProviderErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	provider, err := CreateChatProvider(RocketChat, &auth.AuthServer{URL: server.URL, Kind: RocketChat}, nil, true)
+	require.NoError(t, err)
+
+	err = provider.PostMessage("", &Message{Title: "hello"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
+
+func TestNewPromotionMessage(t *testing.T) {
+	t.Parallel()
+
+	step := &v1.PromoteActivityStep{
+		CoreActivityStep: v1.CoreActivityStep{Status: v1.ActivityStatusTypeSucceeded},
+		Environment:      "production",
+		ApplicationURL:   "https://myapp.example.com",
+		PullRequest: &v1.PromotePullRequestStep{
+			PullRequestURL: "https://github.com/myorg/environment-production/pull/7",
+		},
+	}
+	message := NewPromotionMessage("myapp", "1.2.3", "production", step)
+
+	assert.Equal(t, "Promoted myapp 1.2.3 to production", message.Title)
+	assert.Equal(t, MessageStatusSucceeded, message.Status)
+	assert.Equal(t, []MessageLink{
+		{Name: "Application", URL: "https://myapp.example.com"},
+		{Name: "Pull Request", URL: "https://github.com/myorg/environment-production/pull/7"},
+	}, message.Links)
+
+	step.Status = v1.ActivityStatusTypeFailed
+	message = NewPromotionMessage("myapp", "1.2.3", "production", step)
+	assert.Equal(t, "Failed to promote myapp 1.2.3 to production", message.Title)
+	assert.Equal(t, MessageStatusFailed, message.Status)
+}
@@ -0,0 +1,112 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	jenkins_io_v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeBuildCounters implements BuildCounterInterface
+type FakeBuildCounters struct {
+	Fake *FakeJenkinsV1
+	ns   string
+}
+
+var buildCountersResource = schema.GroupVersionResource{Group: "jenkins.io", Version: "v1", Resource: "buildcounters"}
+
+var buildCountersKind = schema.GroupVersionKind{Group: "jenkins.io", Version: "v1", Kind: "BuildCounter"}
+
+// Get takes name of the buildCounter, and returns the corresponding buildCounter object, and an error if there is any.
+func (c *FakeBuildCounters) Get(name string, options v1.GetOptions) (result *jenkins_io_v1.BuildCounter, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(buildCountersResource, c.ns, name), &jenkins_io_v1.BuildCounter{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*jenkins_io_v1.BuildCounter), err
+}
+
+// List takes label and field selectors, and returns the list of BuildCounters that match those selectors.
+func (c *FakeBuildCounters) List(opts v1.ListOptions) (result *jenkins_io_v1.BuildCounterList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(buildCountersResource, buildCountersKind, c.ns, opts), &jenkins_io_v1.BuildCounterList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &jenkins_io_v1.BuildCounterList{ListMeta: obj.(*jenkins_io_v1.BuildCounterList).ListMeta}
+	for _, item := range obj.(*jenkins_io_v1.BuildCounterList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested buildCounters.
+func (c *FakeBuildCounters) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(buildCountersResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a buildCounter and creates it.  Returns the server's representation of the buildCounter, and an error, if there is any.
+func (c *FakeBuildCounters) Create(buildCounter *jenkins_io_v1.BuildCounter) (result *jenkins_io_v1.BuildCounter, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(buildCountersResource, c.ns, buildCounter), &jenkins_io_v1.BuildCounter{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*jenkins_io_v1.BuildCounter), err
+}
+
+// Update takes the representation of a buildCounter and updates it. Returns the server's representation of the buildCounter, and an error, if there is any.
+func (c *FakeBuildCounters) Update(buildCounter *jenkins_io_v1.BuildCounter) (result *jenkins_io_v1.BuildCounter, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(buildCountersResource, c.ns, buildCounter), &jenkins_io_v1.BuildCounter{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*jenkins_io_v1.BuildCounter), err
+}
+
+// Delete takes name of the buildCounter and deletes it. Returns an error if one occurs.
+func (c *FakeBuildCounters) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(buildCountersResource, c.ns, name), &jenkins_io_v1.BuildCounter{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeBuildCounters) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(buildCountersResource, c.ns, listOptions)
+
+	_, err := c.Fake.Invokes(action, &jenkins_io_v1.BuildCounterList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched buildCounter.
+func (c *FakeBuildCounters) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *jenkins_io_v1.BuildCounter, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(buildCountersResource, c.ns, name, data, subresources...), &jenkins_io_v1.BuildCounter{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*jenkins_io_v1.BuildCounter), err
+}
@@ -16,6 +16,10 @@ func (c *FakeJenkinsV1) Apps(namespace string) v1.AppInterface {
 	return &FakeApps{c, namespace}
 }
 
+func (c *FakeJenkinsV1) BuildCounters(namespace string) v1.BuildCounterInterface {
+	return &FakeBuildCounters{c, namespace}
+}
+
 func (c *FakeJenkinsV1) BuildPacks(namespace string) v1.BuildPackInterface {
 	return &FakeBuildPacks{c, namespace}
 }
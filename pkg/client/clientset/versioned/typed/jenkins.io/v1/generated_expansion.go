@@ -2,6 +2,8 @@
 
 package v1
 
+type BuildCounterExpansion interface{}
+
 type SchedulerExpansion interface{}
 
 type SourceRepositoryGroupExpansion interface{}
@@ -39,6 +39,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=jenkins.io, Version=v1
 	case v1.SchemeGroupVersion.WithResource("apps"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Jenkins().V1().Apps().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("buildcounters"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Jenkins().V1().BuildCounters().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("buildpacks"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Jenkins().V1().BuildPacks().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("commitstatuses"):
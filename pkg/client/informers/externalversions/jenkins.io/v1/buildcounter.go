@@ -0,0 +1,73 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	time "time"
+
+	jenkins_io_v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	versioned "github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/jenkins-x/jx/pkg/client/informers/externalversions/internalinterfaces"
+	v1 "github.com/jenkins-x/jx/pkg/client/listers/jenkins.io/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// BuildCounterInformer provides access to a shared informer and lister for
+// BuildCounters.
+type BuildCounterInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1.BuildCounterLister
+}
+
+type buildCounterInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewBuildCounterInformer constructs a new informer for BuildCounter type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewBuildCounterInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredBuildCounterInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredBuildCounterInformer constructs a new informer for BuildCounter type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredBuildCounterInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.JenkinsV1().BuildCounters(namespace).List(options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.JenkinsV1().BuildCounters(namespace).Watch(options)
+			},
+		},
+		&jenkins_io_v1.BuildCounter{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *buildCounterInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredBuildCounterInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *buildCounterInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&jenkins_io_v1.BuildCounter{}, f.defaultInformer)
+}
+
+func (f *buildCounterInformer) Lister() v1.BuildCounterLister {
+	return v1.NewBuildCounterLister(f.Informer().GetIndexer())
+}
@@ -10,6 +10,14 @@ type AppListerExpansion interface{}
 // AppNamespaceLister.
 type AppNamespaceListerExpansion interface{}
 
+// BuildCounterListerExpansion allows custom methods to be added to
+// BuildCounterLister.
+type BuildCounterListerExpansion interface{}
+
+// BuildCounterNamespaceListerExpansion allows custom methods to be added to
+// BuildCounterNamespaceLister.
+type BuildCounterNamespaceListerExpansion interface{}
+
 // BuildPackListerExpansion allows custom methods to be added to
 // BuildPackLister.
 type BuildPackListerExpansion interface{}
@@ -17,16 +17,22 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.App":                                 schema_pkg_apis_jenkinsio_v1_App(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.AppList":                             schema_pkg_apis_jenkinsio_v1_AppList(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.AppSpec":                             schema_pkg_apis_jenkinsio_v1_AppSpec(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ApprovalActivityStep":                schema_pkg_apis_jenkinsio_v1_ApprovalActivityStep(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ApprovalWorkflowStep":                schema_pkg_apis_jenkinsio_v1_ApprovalWorkflowStep(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Approve":                             schema_pkg_apis_jenkinsio_v1_Approve(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Attachment":                          schema_pkg_apis_jenkinsio_v1_Attachment(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BatchPipelineActivity":               schema_pkg_apis_jenkinsio_v1_BatchPipelineActivity(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Binary":                              schema_pkg_apis_jenkinsio_v1_Binary(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BranchProtectionContextPolicy":       schema_pkg_apis_jenkinsio_v1_BranchProtectionContextPolicy(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Brancher":                            schema_pkg_apis_jenkinsio_v1_Brancher(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BuildCounter":                        schema_pkg_apis_jenkinsio_v1_BuildCounter(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BuildCounterList":                    schema_pkg_apis_jenkinsio_v1_BuildCounterList(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BuildCounterSpec":                    schema_pkg_apis_jenkinsio_v1_BuildCounterSpec(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BuildPack":                           schema_pkg_apis_jenkinsio_v1_BuildPack(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BuildPackList":                       schema_pkg_apis_jenkinsio_v1_BuildPackList(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BuildPackSpec":                       schema_pkg_apis_jenkinsio_v1_BuildPackSpec(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ChartRef":                            schema_pkg_apis_jenkinsio_v1_ChartRef(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ChatNotifications":                   schema_pkg_apis_jenkinsio_v1_ChatNotifications(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.CommitStatus":                        schema_pkg_apis_jenkinsio_v1_CommitStatus(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.CommitStatusCommitReference":         schema_pkg_apis_jenkinsio_v1_CommitStatusCommitReference(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.CommitStatusDetails":                 schema_pkg_apis_jenkinsio_v1_CommitStatusDetails(ref),
@@ -40,6 +46,8 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.CoreActivityStep":                    schema_pkg_apis_jenkinsio_v1_CoreActivityStep(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.DependencyUpdate":                    schema_pkg_apis_jenkinsio_v1_DependencyUpdate(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.DependencyUpdateDetails":             schema_pkg_apis_jenkinsio_v1_DependencyUpdateDetails(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.DeploymentWindow":                    schema_pkg_apis_jenkinsio_v1_DeploymentWindow(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.DeploymentWindows":                   schema_pkg_apis_jenkinsio_v1_DeploymentWindows(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Environment":                         schema_pkg_apis_jenkinsio_v1_Environment(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.EnvironmentFilter":                   schema_pkg_apis_jenkinsio_v1_EnvironmentFilter(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.EnvironmentList":                     schema_pkg_apis_jenkinsio_v1_EnvironmentList(ref),
@@ -104,6 +112,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PreviewActivityStep":                 schema_pkg_apis_jenkinsio_v1_PreviewActivityStep(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PreviewGitSpec":                      schema_pkg_apis_jenkinsio_v1_PreviewGitSpec(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteActivityStep":                 schema_pkg_apis_jenkinsio_v1_PromoteActivityStep(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteCanaryStep":                   schema_pkg_apis_jenkinsio_v1_PromoteCanaryStep(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromotePullRequestStep":              schema_pkg_apis_jenkinsio_v1_PromotePullRequestStep(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteUpdateStep":                   schema_pkg_apis_jenkinsio_v1_PromoteUpdateStep(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteWorkflowStep":                 schema_pkg_apis_jenkinsio_v1_PromoteWorkflowStep(ref),
@@ -301,6 +310,77 @@ func schema_pkg_apis_jenkinsio_v1_AppSpec(ref common.ReferenceCallback) common.O
 	}
 }
 
+func schema_pkg_apis_jenkinsio_v1_ApprovalActivityStep(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ApprovalActivityStep is the step of waiting for a manual approval of a workflow. Its name is the name of the workflow step",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"description": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"startedTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"completedTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"approver": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Approver is the user who approved or rejected the step",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_jenkinsio_v1_ApprovalWorkflowStep(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ApprovalWorkflowStep is a step which waits for a user to manually approve the workflow before the steps which depend on it can be triggered",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message is displayed to the approvers",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
 func schema_pkg_apis_jenkinsio_v1_Approve(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -501,6 +581,120 @@ func schema_pkg_apis_jenkinsio_v1_Brancher(ref common.ReferenceCallback) common.
 	}
 }
 
+func schema_pkg_apis_jenkinsio_v1_BuildCounter(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "BuildCounter records the last build number issued for a pipeline. It is updated using optimistic concurrency so that build numbers are unique across replicas of the build number service, and keep increasing even when the PipelineActivity resources for old builds are garbage collected.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Standard object's metadata. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#metadata",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BuildCounterSpec"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BuildCounterSpec", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+	}
+}
+
+func schema_pkg_apis_jenkinsio_v1_BuildCounterList(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "BuildCounterList is a list of BuildCounter resources",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"),
+						},
+					},
+					"items": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BuildCounter"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"metadata", "items"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BuildCounter", "k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"},
+	}
+}
+
+func schema_pkg_apis_jenkinsio_v1_BuildCounterSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "BuildCounterSpec is the pipeline and the last build number issued for it",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"pipeline": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Pipeline is the ID of the pipeline, such as owner/repository/branch",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"lastBuildNumber": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastBuildNumber is the last build number issued for the pipeline",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_jenkinsio_v1_BuildPack(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -664,6 +858,55 @@ func schema_pkg_apis_jenkinsio_v1_ChartRef(ref common.ReferenceCallback) common.
 	}
 }
 
+func schema_pkg_apis_jenkinsio_v1_ChatNotifications(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ChatNotifications configures which pipeline and promotion outcomes are posted to a chat service",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"url": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL is the URL of the chat server or incoming webhook, whose kind and credentials are in the chat auth config",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is the kind of chat service, such as slack, mattermost or rocketchat, if it isn't in the chat auth config",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"channel": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Channel is the channel that pipeline outcomes are posted to",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"promotionChannel": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PromotionChannel is the channel that promotions are posted to, which defaults to Channel",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"failuresOnly": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailuresOnly only posts the pipelines which fail, rather than every completed pipeline",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
 func schema_pkg_apis_jenkinsio_v1_CommitStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -1316,6 +1559,102 @@ func schema_pkg_apis_jenkinsio_v1_DependencyUpdateDetails(ref common.ReferenceCa
 	}
 }
 
+func schema_pkg_apis_jenkinsio_v1_DeploymentWindow(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DeploymentWindow is a recurring schedule and/or a fixed period when a deployment window is open",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name describes the window in messages, such as 'business-hours' or 'christmas-freeze'",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"schedule": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Schedule is a cron-like expression 'minute hour day-of-month month day-of-week' which matches the minutes the window is open, such as '* 9-16 * * 1-5' for 9am to 5pm on weekdays",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"start": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Start the time the window opens, if it has a fixed period",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"end": {
+						SchemaProps: spec.SchemaProps{
+							Description: "End the time the window closes, if it has a fixed period",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"timeZone": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TimeZone overrides the time zone of the DeploymentWindows for this window",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_jenkinsio_v1_DeploymentWindows(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DeploymentWindows are the schedules which allow or deny automatic promotions to an Environment. If there are any Allow windows then promotions only happen while one of them is open, and no promotions happen while any Deny window is open, such as during a change freeze. Promotions which are not allowed are queued until they are.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"timeZone": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TimeZone is the IANA time zone, such as 'Europe/London', the schedules are evaluated in. Defaults to UTC",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"allow": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Allow the windows when promotions are allowed",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.DeploymentWindow"),
+									},
+								},
+							},
+						},
+					},
+					"deny": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Deny the windows when promotions are frozen",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.DeploymentWindow"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.DeploymentWindow"},
+	}
+}
+
 func schema_pkg_apis_jenkinsio_v1_Environment(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -1723,11 +2062,17 @@ func schema_pkg_apis_jenkinsio_v1_EnvironmentSpec(ref common.ReferenceCallback)
 							Format:      "",
 						},
 					},
+					"deploymentWindows": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DeploymentWindows restricts when automatic promotions can be deployed to the Environment",
+							Ref:         ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.DeploymentWindows"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.EnvironmentRepository", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PreviewGitSpec", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.TeamSettings"},
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.DeploymentWindows", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.EnvironmentRepository", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PreviewGitSpec", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.TeamSettings"},
 	}
 }
 
@@ -3589,11 +3934,16 @@ func schema_pkg_apis_jenkinsio_v1_PipelineActivityStep(ref common.ReferenceCallb
 							Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PreviewActivityStep"),
 						},
 					},
+					"approval": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ApprovalActivityStep"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PreviewActivityStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteActivityStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.StageActivityStep"},
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ApprovalActivityStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PreviewActivityStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteActivityStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.StageActivityStep"},
 	}
 }
 
@@ -4387,11 +4737,116 @@ func schema_pkg_apis_jenkinsio_v1_PromoteActivityStep(ref common.ReferenceCallba
 							Format: "",
 						},
 					},
+					"blockedReason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BlockedReason is why the promotion is blocked by the gates of its workflow step, or empty if it is not blocked",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"canary": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Canary is the Flagger canary analysis of the promoted version, if the application has a Canary in the environment",
+							Ref:         ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteCanaryStep"),
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase is how far the promotion has progressed so that it can be resumed after a restart",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromotePullRequestStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteUpdateStep", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteCanaryStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromotePullRequestStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteUpdateStep", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_jenkinsio_v1_PromoteCanaryStep(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PromoteCanaryStep is the step for waiting for the Flagger canary analysis of the promoted version to complete",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"description": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"startedTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"completedTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"canary": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Canary is the name of the Flagger Canary resource",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase is the last phase of the canary analysis such as Progressing, Succeeded or Failed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"weight": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Weight is the percentage of traffic routed to the promoted version",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"failedChecks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailedChecks is the number of failed metric checks of the canary analysis",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"iterations": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Iterations is the number of iterations of the canary analysis",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message describes the outcome of the canary analysis",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -6186,11 +6641,17 @@ func schema_pkg_apis_jenkinsio_v1_TeamSettings(ref common.ReferenceCallback) com
 							Format:      "",
 						},
 					},
+					"chatNotifications": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ChatNotifications configures posting the outcome of pipelines and promotions to a chat channel",
+							Ref:         ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ChatNotifications"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.QuickStartLocation", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ResourceReference", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.StorageLocation", "k8s.io/api/batch/v1.Job"},
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ChatNotifications", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.QuickStartLocation", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ResourceReference", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.StorageLocation", "k8s.io/api/batch/v1.Job"},
 	}
 }
 
@@ -6639,6 +7100,34 @@ func schema_pkg_apis_jenkinsio_v1_WorkflowPreconditions(ref common.ReferenceCall
 							},
 						},
 					},
+					"gates": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Gates are expressions over the Facts of the PipelineActivity which must all pass before this step can be triggered, such as 'jx.coverage/Covered >= 80', 'jx.cve/Critical == 0' or 'manual-approval == true'",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"steps": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the names of the steps which need to have succeeded before this step can be triggered. Promote steps without a name are named after their environment",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
@@ -6734,10 +7223,22 @@ func schema_pkg_apis_jenkinsio_v1_WorkflowStep(ref common.ReferenceCallback) com
 							Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteWorkflowStep"),
 						},
 					},
+					"approval": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ApprovalWorkflowStep"),
+						},
+					},
+					"timeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Timeout is how long the step can run for once it has started, such as '30m' or '24h', before it fails",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteWorkflowStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.WorkflowPreconditions"},
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ApprovalWorkflowStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteWorkflowStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.WorkflowPreconditions"},
 	}
 }
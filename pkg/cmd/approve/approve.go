@@ -0,0 +1,134 @@
+package approve
+
+import (
+	"fmt"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/jenkins-x/jx/pkg/workflow"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApproveOptions contains the command line options
+type ApproveOptions struct {
+	*opts.CommonOptions
+
+	Activity string
+	Step     string
+	Reject   bool
+}
+
+var (
+	approveLong = templates.LongDesc(`
+		Approves or rejects a manual approval step of the Workflow of a PipelineActivity.
+
+		The workflow controller waits for the approval before triggering the steps which depend on it. Rejecting the
+		step fails the workflow.
+
+		The approval is recorded on the PipelineActivity with the permissions of the current user, so use RBAC on
+		PipelineActivities to restrict who can approve steps.
+
+`)
+
+	approveExample = templates.Examples(`
+		# Approves the step the PipelineActivity is waiting for
+		jx approve myorg-myapp-master-3
+
+		# Approves a specific step
+		jx approve myorg-myapp-master-3 --step approve-production
+
+		# Rejects a step
+		jx approve myorg-myapp-master-3 --step approve-production --reject
+	`)
+)
+
+// NewCmdApprove creates the command
+func NewCmdApprove(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &ApproveOptions{
+		CommonOptions: commonOpts,
+	}
+	cmd := &cobra.Command{
+		Use:     "approve [activity]",
+		Short:   "Approves or rejects a manual approval step of the Workflow of a PipelineActivity",
+		Long:    approveLong,
+		Example: approveExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Activity, "activity", "a", "", "The name of the PipelineActivity")
+	cmd.Flags().StringVarP(&options.Step, "step", "s", "", "The name of the approval step. Defaults to the step the PipelineActivity is waiting for")
+	cmd.Flags().BoolVarP(&options.Reject, "reject", "", false, "Rejects the step rather than approving it")
+	return cmd
+}
+
+// Run implements this command
+func (o *ApproveOptions) Run() error {
+	name := o.Activity
+	if name == "" && len(o.Args) > 0 {
+		name = o.Args[0]
+	}
+	if name == "" {
+		return util.MissingOption("activity")
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	activity, err := jxClient.JenkinsV1().PipelineActivities(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get PipelineActivity %s", name)
+	}
+	flow, err := workflow.GetWorkflow(activity.Spec.Workflow, jxClient, ns)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the Workflow of PipelineActivity %s", name)
+	}
+	stepName := o.Step
+	if stepName == "" {
+		stepName, err = WaitingApprovalStep(activity)
+		if err != nil {
+			return err
+		}
+	}
+	user, err := o.GetClusterUserName()
+	if err != nil {
+		return errors.Wrap(err, "failed to find the current user")
+	}
+	err = workflow.ApproveStep(jxClient, ns, activity, flow, stepName, user, !o.Reject)
+	if err != nil {
+		return err
+	}
+	if o.Reject {
+		log.Logger().Infof("Rejected step %s of PipelineActivity %s", util.ColorInfo(stepName), util.ColorInfo(name))
+	} else {
+		log.Logger().Infof("Approved step %s of PipelineActivity %s", util.ColorInfo(stepName), util.ColorInfo(name))
+	}
+	return nil
+}
+
+// WaitingApprovalStep returns the name of the only approval step the PipelineActivity is waiting for
+func WaitingApprovalStep(activity *v1.PipelineActivity) (string, error) {
+	names := []string{}
+	for _, step := range activity.Spec.Steps {
+		approval := step.Approval
+		if approval != nil && approval.Status == v1.ActivityStatusTypeWaitingForApproval {
+			names = append(names, approval.Name)
+		}
+	}
+	switch len(names) {
+	case 0:
+		return "", fmt.Errorf("PipelineActivity %s is not waiting for any approvals", activity.Name)
+	case 1:
+		return names[0], nil
+	}
+	return "", fmt.Errorf("PipelineActivity %s is waiting for the approval of steps %v so please specify the --step", activity.Name, names)
+}
@@ -0,0 +1,42 @@
+package approve
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createApprovalStep(name string, status v1.ActivityStatusType) v1.PipelineActivityStep {
+	return v1.PipelineActivityStep{
+		Kind: v1.ActivityStepKindTypeApproval,
+		Approval: &v1.ApprovalActivityStep{
+			CoreActivityStep: v1.CoreActivityStep{
+				Name:   name,
+				Status: status,
+			},
+		},
+	}
+}
+
+func TestWaitingApprovalStep(t *testing.T) {
+	t.Parallel()
+
+	activity := &v1.PipelineActivity{}
+	activity.Name = "myorg-myapp-master-1"
+	_, err := WaitingApprovalStep(activity)
+	assert.Error(t, err)
+
+	activity.Spec.Steps = []v1.PipelineActivityStep{
+		createApprovalStep("approve-staging", v1.ActivityStatusTypeSucceeded),
+		createApprovalStep("approve-production", v1.ActivityStatusTypeWaitingForApproval),
+	}
+	name, err := WaitingApprovalStep(activity)
+	require.NoError(t, err)
+	assert.Equal(t, "approve-production", name)
+
+	activity.Spec.Steps = append(activity.Spec.Steps, createApprovalStep("approve-security", v1.ActivityStatusTypeWaitingForApproval))
+	_, err = WaitingApprovalStep(activity)
+	assert.Error(t, err, "more than one step is waiting")
+}
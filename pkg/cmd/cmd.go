@@ -19,6 +19,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/jenkins-x/jx/pkg/cmd/approve"
 	"github.com/jenkins-x/jx/pkg/cmd/boot"
 	"github.com/jenkins-x/jx/pkg/cmd/cloudbees"
 	"github.com/jenkins-x/jx/pkg/cmd/compliance"
@@ -31,6 +32,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/cmd/importcmd"
 	"github.com/jenkins-x/jx/pkg/cmd/initcmd"
 	"github.com/jenkins-x/jx/pkg/cmd/preview"
+	"github.com/jenkins-x/jx/pkg/cmd/restore"
 	"github.com/jenkins-x/jx/pkg/cmd/rsh"
 	"github.com/jenkins-x/jx/pkg/cmd/start"
 	"github.com/jenkins-x/jx/pkg/cmd/stop"
@@ -50,6 +52,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/cmd/add"
 	"github.com/jenkins-x/jx/pkg/cmd/namespace"
 	"github.com/jenkins-x/jx/pkg/cmd/promote"
+	"github.com/jenkins-x/jx/pkg/cmd/rollback"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -96,6 +99,7 @@ func NewJXCommand(f clients.Factory, in terminal.FileReader, out terminal.FileWr
 		create.NewCmdInstall(commonOpts),
 		uninstall.NewCmdUninstall(commonOpts),
 		upgrade.NewCmdUpgrade(commonOpts),
+		restore.NewCmdRestore(commonOpts),
 	}
 	installCommands = append(installCommands, findCommands("cluster", createCommands, deleteCommands)...)
 	installCommands = append(installCommands, findCommands("cluster", updateCommands)...)
@@ -123,6 +127,8 @@ func NewJXCommand(f clients.Factory, in terminal.FileReader, out terminal.FileWr
 	environmentsCommands := []*cobra.Command{
 		preview.NewCmdPreview(commonOpts),
 		promote.NewCmdPromote(commonOpts),
+		rollback.NewCmdRollback(commonOpts),
+		approve.NewCmdApprove(commonOpts),
 	}
 	environmentsCommands = append(environmentsCommands, findCommands("environment", createCommands, deleteCommands, editCommands, getCommands)...)
 
@@ -2,12 +2,17 @@ package controller
 
 import (
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/log"
+	pipeline_events "github.com/jenkins-x/jx/pkg/pipeline_events"
 	"github.com/spf13/cobra"
 
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
 )
 
+// pipelineEventsQueueSize is the number of pipeline events which can wait to be sent before new events are dropped
+const pipelineEventsQueueSize = 1000
+
 // ControllerOptions contains the CLI options
 type ControllerOptions struct {
 	*opts.CommonOptions
@@ -58,3 +63,17 @@ func NewCmdController(commonOpts *opts.CommonOptions) *cobra.Command {
 func (o *ControllerOptions) Run() error {
 	return o.Cmd.Help()
 }
+
+// initPipelineEventsProvider creates the configured pipeline events provider once for the lifetime of the controller,
+// rather than opening a new one for every event sent. The events are sent from a queue so that the informers are not
+// held up by the events server
+func (o *ControllerOptions) initPipelineEventsProvider() {
+	provider, err := o.CreatePipelineEventsProvider()
+	if err != nil {
+		log.Logger().Warnf("Failed to create the pipeline events provider: %s", err)
+		return
+	}
+	if provider != nil {
+		o.SetPipelineEventsProvider(pipeline_events.NewQueuedProvider(provider, pipelineEventsQueueSize))
+	}
+}
@@ -2,23 +2,22 @@ package controller
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
 
-	"github.com/ghodss/yaml"
-	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/backup"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/spf13/cobra"
-	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -30,6 +29,12 @@ type ControllerBackupOptions struct {
 
 	Namespace    string
 	Organisation string
+	CommitDelay  time.Duration
+
+	// lock serializes writing the backups with committing and pushing them
+	lock sync.Mutex
+	// pendingChanges describes the changes made since the last commit, which is scheduled if there are any
+	pendingChanges []string
 }
 
 // NewCmdControllerBackup creates a command object for the generic "get" action, which
@@ -55,24 +60,19 @@ func NewCmdControllerBackup(commonOpts *opts.CommonOptions) *cobra.Command {
 
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace to watch or defaults to the current namespace")
 	cmd.Flags().StringVarP(&options.Organisation, "organisation", "o", "", "The organisation to backup")
+	cmd.Flags().DurationVarP(&options.CommitDelay, "commit-delay", "", 10*time.Second, "How long to wait for more changes before committing and pushing them together")
 
 	return cmd
 }
 
 // Run implements this command
 func (o *ControllerBackupOptions) Run() error {
-	// ensure Environment / Team / User CRDs are registered before we start
-	err := o.RegisterEnvironmentCRD()
+	// ensure the CRDs of the resources we back up are registered before we start
+	apisClient, err := o.ApiExtensionsClient()
 	if err != nil {
 		return err
 	}
-
-	err = o.RegisterTeamCRD()
-	if err != nil {
-		return err
-	}
-
-	err = o.RegisterUserCRD()
+	err = kube.RegisterAllCRDs(apisClient)
 	if err != nil {
 		return err
 	}
@@ -88,144 +88,107 @@ func (o *ControllerBackupOptions) Run() error {
 	}
 
 	dir, err := o.getOrCreateBackupRepository()
+	if err != nil {
+		return err
+	}
 
-	log.Logger().Infof("Watching for users/teams/environments in namespace %s", util.ColorInfo(ns))
-
-	_, environmentController := cache.NewInformer(
-		&cache.ListWatch{
-			ListFunc: func(lo meta_v1.ListOptions) (runtime.Object, error) {
-				return jxClient.JenkinsV1().Environments(ns).List(lo)
-			},
-			WatchFunc: func(lo meta_v1.ListOptions) (watch.Interface, error) {
-				return jxClient.JenkinsV1().Environments(ns).Watch(lo)
-			},
-		},
-		&v1.Environment{},
-		time.Minute*10,
-		cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				o.onEnvironmentChange(obj, ns, dir)
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				o.onEnvironmentChange(newObj, ns, dir)
-			},
-			DeleteFunc: func(obj interface{}) {
-				//o.onEnvironmentChange(obj, jxClient, ns)
-			},
-		},
-	)
+	resources := backup.Resources()
+	names := []string{}
+	for _, resource := range resources {
+		names = append(names, resource.Name)
+	}
+	log.Logger().Infof("Watching for %s in namespace %s", strings.Join(names, "/"), util.ColorInfo(ns))
 
 	stop := make(chan struct{})
-	go environmentController.Run(stop)
-
-	_, teamController := cache.NewInformer(
-		&cache.ListWatch{
-			ListFunc: func(lo meta_v1.ListOptions) (runtime.Object, error) {
-				return jxClient.JenkinsV1().Teams(ns).List(lo)
-			},
-			WatchFunc: func(lo meta_v1.ListOptions) (watch.Interface, error) {
-				return jxClient.JenkinsV1().Teams(ns).Watch(lo)
-			},
-		},
-		&v1.Team{},
-		time.Minute*10,
-		cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				o.onTeamChange(obj, ns, dir)
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				o.onTeamChange(newObj, ns, dir)
-			},
-			DeleteFunc: func(obj interface{}) {
-				//o.onEnvironmentChange(obj, jxClient, ns)
-			},
-		},
-	)
-
-	go teamController.Run(stop)
-
-	_, userController := cache.NewInformer(
-		&cache.ListWatch{
-			ListFunc: func(lo meta_v1.ListOptions) (runtime.Object, error) {
-				return jxClient.JenkinsV1().Users(ns).List(lo)
-			},
-			WatchFunc: func(lo meta_v1.ListOptions) (watch.Interface, error) {
-				return jxClient.JenkinsV1().Users(ns).Watch(lo)
-			},
-		},
-		&v1.User{},
-		time.Minute*10,
-		cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				o.onUserChange(obj, ns, dir)
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				o.onUserChange(newObj, ns, dir)
+	for _, r := range resources {
+		resource := r
+		_, controller := cache.NewInformer(
+			resource.ListWatch(jxClient, ns),
+			resource.NewObject(),
+			time.Minute*10,
+			cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					o.onResourceChange(resource, obj, ns, dir)
+				},
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					o.onResourceChange(resource, newObj, ns, dir)
+				},
+				DeleteFunc: func(obj interface{}) {
+					o.onResourceDelete(resource, obj, ns, dir)
+				},
 			},
-			DeleteFunc: func(obj interface{}) {
-				//o.onEnvironmentChange(obj, jxClient, ns)
-			},
-		},
-	)
-
-	go userController.Run(stop)
+		)
+		go controller.Run(stop)
+	}
 
 	// Wait forever
 	select {}
 }
 
-func (o *ControllerBackupOptions) onEnvironmentChange(obj interface{}, ns string, dir string) {
-	env, ok := obj.(*v1.Environment)
+func (o *ControllerBackupOptions) onResourceChange(resource *backup.Resource, obj interface{}, ns string, dir string) {
+	runtimeObj, ok := obj.(runtime.Object)
 	if !ok {
-		log.Logger().Infof("Object is not a Environment %#v", obj)
+		log.Logger().Infof("Object is not a %s %#v", resource.Name, obj)
 		return
 	}
-	o.writeResourceToBackupFile(env, "environment", env.ObjectMeta.Name, ns, dir)
-}
-
-func (o *ControllerBackupOptions) onTeamChange(obj interface{}, ns string, dir string) {
-	env, ok := obj.(*v1.Team)
-	if !ok {
-		log.Logger().Infof("Object is not a Team %#v", obj)
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	name, err := backup.WriteResource(dir, resource, ns, runtimeObj)
+	if err != nil {
+		log.Logger().Errorf("Unable to backup %s %s: %s", resource.Name, name, err)
 		return
 	}
-	o.writeResourceToBackupFile(env, "team", env.ObjectMeta.Name, ns, dir)
+	o.queueCommit(dir, fmt.Sprintf("Updating %s %s", resource.Name, name))
 }
 
-func (o *ControllerBackupOptions) onUserChange(obj interface{}, ns string, dir string) {
-	env, ok := obj.(*v1.User)
+func (o *ControllerBackupOptions) onResourceDelete(resource *backup.Resource, obj interface{}, ns string, dir string) {
+	// the informer may have missed the deletion so only knows the last state of the resource
+	deleted, ok := obj.(cache.DeletedFinalStateUnknown)
+	if ok {
+		obj = deleted.Obj
+	}
+	runtimeObj, ok := obj.(runtime.Object)
 	if !ok {
-		log.Logger().Infof("Object is not a User %#v", obj)
+		log.Logger().Infof("Object is not a %s %#v", resource.Name, obj)
 		return
 	}
-	o.writeResourceToBackupFile(env, "user", env.ObjectMeta.Name, ns, dir)
-}
-
-func (o *ControllerBackupOptions) writeResourceToBackupFile(obj interface{}, resource string, key string, ns string, dir string) {
-	out, err := yaml.Marshal(obj)
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	name, err := backup.DeleteResource(dir, resource, ns, runtimeObj)
 	if err != nil {
-		log.Logger().Errorf("Unable to marshall %s %s", resource, err)
+		log.Logger().Errorf("Unable to remove the backup of %s %s: %s", resource.Name, name, err)
 		return
 	}
+	o.queueCommit(dir, fmt.Sprintf("Deleting %s %s", resource.Name, name))
+}
 
-	log.Logger().Debugf("Dumping %s with key %s...", util.ColorInfo(resource), util.ColorInfo(key))
-	log.Logger().Debugf("%s", string(out))
-
-	nsDir := path.Join(dir, fmt.Sprintf("%ss", resource), ns)
-	err = os.MkdirAll(nsDir, os.FileMode(0755))
-	if err != nil {
-		log.Logger().Errorf("Unable to create directory %s", err)
-		return
+// queueCommit records the change and schedules a commit after the commit delay if one isn't already scheduled, so
+// that changes made together, such as when the informers first list the resources, are pushed in a single commit.
+// The lock must be held
+func (o *ControllerBackupOptions) queueCommit(dir string, message string) {
+	o.pendingChanges = append(o.pendingChanges, message)
+	if len(o.pendingChanges) == 1 {
+		time.AfterFunc(o.CommitDelay, func() {
+			o.commitPendingChanges(dir)
+		})
 	}
+}
 
-	envFile := path.Join(nsDir, fmt.Sprintf("%s.yaml", key))
-	err = ioutil.WriteFile(envFile, out, 0644)
-	if err != nil {
-		log.Logger().Errorf("Unable to write file %s", err)
+// commitPendingChanges commits and pushes the changes made since the last commit
+func (o *ControllerBackupOptions) commitPendingChanges(dir string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	changes := o.pendingChanges
+	o.pendingChanges = nil
+	switch len(changes) {
+	case 0:
 		return
+	case 1:
+		o.commitDirIfChanges(dir, changes[0])
+	default:
+		message := fmt.Sprintf("Backing up %d changes\n\n%s", len(changes), strings.Join(changes, "\n"))
+		o.commitDirIfChanges(dir, message)
 	}
-
-	o.commitDirIfChanges(dir, fmt.Sprintf("Updating %s %s", resource, key))
 }
 
 func (o *ControllerBackupOptions) commitDirIfChanges(dir string, message string) {
@@ -254,7 +217,8 @@ func (o *ControllerBackupOptions) commitDirIfChanges(dir string, message string)
 			return
 		}
 
-		fmt.Fprintf(o.Out, "Pushed update '%s' Git repository %s\n", util.ColorInfo(message), util.ColorInfo(dir))
+		summary := strings.SplitN(message, "\n", 2)[0]
+		fmt.Fprintf(o.Out, "Pushed update '%s' Git repository %s\n", util.ColorInfo(summary), util.ColorInfo(dir))
 	}
 }
 
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupBatchesCommits(t *testing.T) {
+	t.Parallel()
+
+	git := &gits.GitFake{Changes: true}
+	commonOpts := &opts.CommonOptions{Out: os.Stdout}
+	commonOpts.SetGit(git)
+	o := &ControllerBackupOptions{
+		ControllerOptions: ControllerOptions{
+			CommonOptions: commonOpts,
+		},
+		CommitDelay: time.Hour,
+	}
+
+	o.lock.Lock()
+	o.queueCommit("backup", "Updating Environment staging")
+	o.queueCommit("backup", "Deleting Environment preview")
+	o.lock.Unlock()
+	assert.Empty(t, git.Commits, "the commit waits for more changes")
+
+	o.commitPendingChanges("backup")
+	require.Len(t, git.Commits, 1)
+	assert.Equal(t, "Backing up 2 changes\n\nUpdating Environment staging\nDeleting Environment preview", git.Commits[0].Message)
+
+	o.commitPendingChanges("backup")
+	assert.Len(t, git.Commits, 1, "there are no more changes to commit")
+}
@@ -24,7 +24,9 @@ import (
 	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/builds"
 	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	jenkinsv1client "github.com/jenkins-x/jx/pkg/client/clientset/versioned/typed/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/log"
+	pipeline_events "github.com/jenkins-x/jx/pkg/pipeline_events"
 	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/spf13/cobra"
 	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
@@ -113,6 +115,7 @@ func (o *ControllerBuildOptions) Run() error {
 	}
 
 	o.EnvironmentCache = kube.CreateEnvironmentCache(jxClient, ns)
+	o.initPipelineEventsProvider()
 
 	if o.InitGitCredentials {
 		err = o.InitGitConfigAndUser()
@@ -222,10 +225,8 @@ func (o *ControllerBuildOptions) handleStandalonePod(pod *corev1.Pod, kubeClient
 						return err
 					}
 					if o.updatePipelineActivity(kubeClient, ns, a, buildName, pod) {
-						log.Logger().Debugf("updating PipelineActivity %s", a.Name)
-						_, err := activities.PatchUpdate(a)
+						err := o.patchPipelineActivity(activities, a)
 						if err != nil {
-							log.Logger().Warnf("Failed to update PipelineActivity %s due to: %s", a.Name, err.Error())
 							name = a.Name
 							return err
 						}
@@ -292,10 +293,8 @@ func (o *ControllerBuildOptions) onPipelinePod(obj interface{}, kubeClient kuber
 								return err
 							}
 							if o.updatePipelineActivityForRun(kubeClient, ns, a, pri, pod) {
-								log.Logger().Debugf("updating PipelineActivity %s", a.Name)
-								_, err := activities.PatchUpdate(a)
+								err := o.patchPipelineActivity(activities, a)
 								if err != nil {
-									log.Logger().Warnf("Failed to update PipelineActivity %s due to: %s", a.Name, err.Error())
 									name = a.Name
 									return err
 								}
@@ -314,6 +313,20 @@ func (o *ControllerBuildOptions) onPipelinePod(obj interface{}, kubeClient kuber
 	}
 }
 
+// patchPipelineActivity saves the changes to the activity and sends a pipeline event for the updated activity
+func (o *ControllerBuildOptions) patchPipelineActivity(activities jenkinsv1client.PipelineActivityInterface, a *v1.PipelineActivity) error {
+	log.Logger().Debugf("updating PipelineActivity %s", a.Name)
+	updated, err := activities.PatchUpdate(a)
+	if err != nil {
+		log.Logger().Warnf("Failed to update PipelineActivity %s due to: %s", a.Name, err.Error())
+		return err
+	}
+	o.SendPipelineEvent(func(provider pipeline_events.PipelineEventsProvider) error {
+		return provider.SendActivity(updated)
+	})
+	return nil
+}
+
 // createPromoteStepActivityKey deduces the pipeline metadata from the Knative build pod
 func (o *ControllerBuildOptions) createPromoteStepActivityKey(buildName string, pod *corev1.Pod) *kube.PromoteStepActivityKey {
 
@@ -513,7 +526,7 @@ func (o *ControllerBuildOptions) updatePipelineActivity(kubeClient kubernetes.In
 			}
 			if stageFinished {
 				switch stage.Status {
-				case v1.ActivityStatusTypeSucceeded, v1.ActivityStatusTypeNotExecuted:
+				case v1.ActivityStatusTypeSucceeded, v1.ActivityStatusTypeNotExecuted, v1.ActivityStatusTypeSkipped:
 					// stage did not fail
 				default:
 					failed = true
@@ -628,7 +641,7 @@ func (o *ControllerBuildOptions) updatePipelineActivityForRun(kubeClient kuberne
 			}
 			if stageFinished {
 				switch stage.Status {
-				case v1.ActivityStatusTypeSucceeded, v1.ActivityStatusTypeNotExecuted:
+				case v1.ActivityStatusTypeSucceeded, v1.ActivityStatusTypeNotExecuted, v1.ActivityStatusTypeSkipped:
 					// stage did not fail
 				default:
 					failed = true
@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/jenkins-x/jx/pkg/cmd/testhelpers"
 	"path"
@@ -9,14 +11,17 @@ import (
 	"time"
 
 	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/kube"
+	pipeline_events "github.com/jenkins-x/jx/pkg/pipeline_events"
 	"github.com/jenkins-x/jx/pkg/tekton"
 	"github.com/jenkins-x/jx/pkg/tekton/tekton_helpers_test"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -86,6 +91,41 @@ func TestCompleteBuildSourceInfo(t *testing.T) {
 	assert.Equal(t, act.Spec.PullTitle, "This is the PR title")
 }
 
+func TestPatchPipelineActivitySendsEvent(t *testing.T) {
+	act := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-org-my-repo-master-1",
+			Namespace: "jx",
+		},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline: "my-org/my-repo/master",
+			Build:    "1",
+		},
+	}
+	jxClient := fake.NewSimpleClientset(act)
+
+	var out bytes.Buffer
+	o := &ControllerBuildOptions{
+		ControllerOptions: ControllerOptions{
+			CommonOptions: &opts.CommonOptions{},
+		},
+	}
+	o.SetPipelineEventsProvider(pipeline_events.NewWriterProvider(&out))
+
+	act = act.DeepCopy()
+	act.Spec.Status = v1.ActivityStatusTypeSucceeded
+	err := o.patchPipelineActivity(jxClient.JenkinsV1().PipelineActivities("jx"), act)
+	require.NoError(t, err)
+
+	event := pipeline_events.CloudEvent{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &event))
+	assert.Equal(t, pipeline_events.EventTypeActivity, event.Type)
+	assert.Equal(t, "my-org-my-repo-master-1", event.Subject)
+	data, ok := event.Data.(map[string]interface{})
+	require.True(t, ok, "event data is an object")
+	assert.Equal(t, string(v1.ActivityStatusTypeSucceeded), data["spec"].(map[string]interface{})["status"])
+}
+
 func TestUpdateForStage(t *testing.T) {
 	pod := tekton_helpers_test.AssertLoadSinglePod(t, path.Join("test_data", "controller_build", "update_stage_info"))
 	si := &tekton.StageInfo{
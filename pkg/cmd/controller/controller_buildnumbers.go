@@ -1,8 +1,12 @@
 package controller
 
 import (
+	"time"
+
 	"github.com/jenkins-x/jx/pkg/buildnum"
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
 
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
@@ -13,18 +17,26 @@ const (
 	command    = "buildnumbers"
 	optionPort = "port"
 	optionBind = "bind"
+
+	optionUseActivities = "use-activities"
+
+	seedRetryInterval = 10 * time.Second
 )
 
 // ControllerBuildNumbersOptions holds the options for the build number service.
 type ControllerBuildNumbersOptions struct {
 	*opts.CommonOptions
-	BindAddress string
-	Port        int
+	BindAddress   string
+	Port          int
+	UseActivities bool
 }
 
 var (
 	serveBuildNumbersLong = templates.LongDesc(`Runs the build number controller that serves sequential build 
-		numbers over an HTTP interface.`)
+		numbers over an HTTP interface.
+
+		The last build number for each pipeline is stored in a BuildCounter resource, so more than one replica of the 
+		controller can be run. On startup the counters are seeded from the existing PipelineActivities.`)
 
 	serveBuildNumbersExample = templates.Examples("jx " + command)
 )
@@ -49,6 +61,8 @@ func NewCmdControllerBuildNumbers(commonOpts *opts.CommonOptions) *cobra.Command
 	cmd.Flags().IntVarP(&options.Port, optionPort, "", 8080, "The TCP port to listen on.")
 	cmd.Flags().StringVarP(&options.BindAddress, optionBind, "", "",
 		"The interface address to bind to (by default, will listen on all interfaces/addresses).")
+	cmd.Flags().BoolVarP(&options.UseActivities, optionUseActivities, "", false,
+		"Generate build numbers by scanning the PipelineActivities rather than using BuildCounters. Only one replica can be run.")
 	return cmd
 }
 
@@ -58,8 +72,37 @@ func (o *ControllerBuildNumbersOptions) Run() error {
 	if err != nil {
 		return err
 	}
-	buildNumGen := buildnum.NewCRDBuildNumGen(jxClient, ns)
+	var buildNumGen buildnum.BuildNumberIssuer
+	if o.UseActivities {
+		buildNumGen = buildnum.NewCRDBuildNumGen(jxClient, ns)
+	} else {
+		apisClient, err := o.ApiExtensionsClient()
+		if err != nil {
+			return err
+		}
+		err = kube.RegisterBuildCounterCRD(apisClient)
+		if err != nil {
+			return err
+		}
+		counterGen := buildnum.NewBuildCounterBuildNumGen(jxClient, ns)
+		go seedBuildCounters(counterGen)
+		buildNumGen = counterGen
+	}
 
 	httpBuildNumServer := buildnum.NewHTTPBuildNumberServer(o.BindAddress, o.Port, buildNumGen)
 	return httpBuildNumServer.Start()
 }
+
+// seedBuildCounters seeds the build counters from the existing PipelineActivities, retrying until it succeeds. The
+// service reports that it isn't ready until then. Build numbers generated in the meantime are still unique as a
+// counter which is behind its PipelineActivities catches up with them.
+func seedBuildCounters(gen *buildnum.BuildCounterBuildNumGen) {
+	for {
+		err := gen.Seed()
+		if err == nil {
+			return
+		}
+		log.Logger().Warnf("Failed to seed the build counters, will retry in %s: %s", seedRetryInterval, err)
+		time.Sleep(seedRetryInterval)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
@@ -34,17 +35,30 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/jenkins-x/jx/pkg/log"
+	pipeline_events "github.com/jenkins-x/jx/pkg/pipeline_events"
 
 	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 
 	"github.com/jenkins-x/jx/pkg/kube"
 
 	"github.com/spf13/cobra"
+	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
 )
 
 // ControllerCommitStatusOptions the options for the controller
 type ControllerCommitStatusOptions struct {
 	ControllerOptions
+
+	ContextTemplate   string
+	TargetURLTemplate string
+
+	// reportedStageStatuses caches the last state reported for each commit and context of the Tekton stages of each
+	// PipelineRun, the entries of a PipelineRun are evicted once it completes or is deleted
+	reportedStageStatuses     map[string]map[string]string
+	reportedStageStatusesLock sync.Mutex
+	// startTime is when the controller started watching PipelineRuns, the stage statuses of the PipelineRuns which
+	// completed before then have already been reported
+	startTime time.Time
 }
 
 // NewCmdControllerCommitStatus creates a command object for the "create" command
@@ -58,6 +72,14 @@ func NewCmdControllerCommitStatus(commonOpts *opts.CommonOptions) *cobra.Command
 	cmd := &cobra.Command{
 		Use:   "commitstatus",
 		Short: "Updates commit status",
+		Long: `Updates the commit statuses of Pull Requests.
+
+When Tekton is enabled a commit status is reported for each stage of the pipeline. The context and target URL of
+these statuses are go templates which can use the fields Namespace, Owner, Repo, Branch, Build, Context, Stage,
+PipelineRun and PipelineActivity. If no target URL template is given the URL of the build logs is used when available.
+
+When Tekton is enabled the build pods are not watched, so the CommitStatus resources for the contexts required by
+branch protection are no longer created or updated from the build pods.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			options.Cmd = cmd
 			options.Args = args
@@ -65,6 +87,8 @@ func NewCmdControllerCommitStatus(commonOpts *opts.CommonOptions) *cobra.Command
 			helper.CheckErr(err)
 		},
 	}
+	cmd.Flags().StringVarP(&options.ContextTemplate, "context-template", "", defaultStageContextTemplate, "The go template for the context of the commit status of each Tekton pipeline stage")
+	cmd.Flags().StringVarP(&options.TargetURLTemplate, "target-url-template", "", "", "The go template for the target URL of the commit status of each Tekton pipeline stage, such as the page showing the build logs in your UI")
 	return cmd
 }
 
@@ -93,6 +117,7 @@ func (o *ControllerCommitStatusOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	o.initPipelineEventsProvider()
 
 	commitstatusListWatch := cache.NewListWatchFromClient(jxClient.JenkinsV1().RESTClient(), "commitstatuses", ns, fields.Everything())
 	kube.SortListWatchByName(commitstatusListWatch)
@@ -115,6 +140,51 @@ func (o *ControllerCommitStatusOptions) Run() error {
 	stop := make(chan struct{})
 	go commitstatusController.Run(stop)
 
+	tektonEnabled, err := kube.IsTektonEnabled(kubeClient, ns)
+	if err != nil {
+		return err
+	}
+	if tektonEnabled {
+		tektonClient, _, err := o.TektonClient()
+		if err != nil {
+			return err
+		}
+		if o.ContextTemplate == "" {
+			o.ContextTemplate = defaultStageContextTemplate
+		}
+		// the PipelineRuns are watched instead of the build pods so the branch protection CommitStatuses which are
+		// updated from the build pods are not maintained
+		log.Logger().Infof("Watching for PipelineRuns in namespace %s", ns)
+		o.startTime = time.Now()
+		pipelineRunListWatch := cache.NewListWatchFromClient(tektonClient.TektonV1alpha1().RESTClient(), "pipelineruns", ns, fields.Everything())
+		kube.SortListWatchByName(pipelineRunListWatch)
+		_, pipelineRunController := cache.NewInformer(
+			pipelineRunListWatch,
+			&pipelineapi.PipelineRun{},
+			time.Minute*10,
+			cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					o.onPipelineRunObj(obj, jxClient, ns)
+				},
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					// ignore the periodic resyncs so the statuses of completed PipelineRuns are not reported again
+					oldPr, ok := oldObj.(*pipelineapi.PipelineRun)
+					newPr, ok2 := newObj.(*pipelineapi.PipelineRun)
+					if ok && ok2 && oldPr.ResourceVersion == newPr.ResourceVersion {
+						return
+					}
+					o.onPipelineRunObj(newObj, jxClient, ns)
+				},
+				DeleteFunc: func(obj interface{}) {
+					o.onPipelineRunDelete(obj)
+				},
+			},
+		)
+		stop = make(chan struct{})
+		pipelineRunController.Run(stop)
+		return nil
+	}
+
 	podListWatch := cache.NewListWatchFromClient(kubeClient.CoreV1().RESTClient(), "pods", ns, fields.Everything())
 	kube.SortListWatchByName(podListWatch)
 	_, podWatch := cache.NewInformer(
@@ -190,6 +260,9 @@ func (o *ControllerCommitStatusOptions) onCommitStatus(check *jenkinsv1.CommitSt
 			return err
 		}
 	}
+	o.SendPipelineEvent(func(provider pipeline_events.PipelineEventsProvider) error {
+		return provider.SendCommitStatus(check)
+	})
 	return nil
 }
 
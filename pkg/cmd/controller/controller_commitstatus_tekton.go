@@ -0,0 +1,304 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	jenkinsv1client "github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/extensions"
+	"github.com/jenkins-x/jx/pkg/kube/naming"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/tekton"
+	knativeapis "github.com/knative/pkg/apis"
+	"github.com/pkg/errors"
+	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// defaultStageContextTemplate is the default template for the context of the commit status of each pipeline stage
+	defaultStageContextTemplate = "{{ .Context }}/{{ .Stage }}"
+
+	// maxCommitStatusDescriptionLength is the longest commit status description git providers accept
+	maxCommitStatusDescriptionLength = 140
+)
+
+// StageCommitStatusData is the data available to the templates used for the context and target URL of the commit
+// status of a pipeline stage
+type StageCommitStatusData struct {
+	Namespace        string
+	Owner            string
+	Repo             string
+	Branch           string
+	Build            string
+	Context          string
+	Stage            string
+	PipelineRun      string
+	PipelineActivity string
+}
+
+// StageCommitStatus is the commit status of a single stage of a Tekton pipeline
+type StageCommitStatus struct {
+	Stage       string
+	State       string
+	Description string
+}
+
+func (o *ControllerCommitStatusOptions) onPipelineRunObj(obj interface{}, jxClient jenkinsv1client.Interface, ns string) {
+	pr, ok := obj.(*pipelineapi.PipelineRun)
+	if !ok {
+		log.Logger().Warnf("pipeline run watcher: unexpected type %v", obj)
+		return
+	}
+	err := o.onPipelineRun(pr, jxClient, ns)
+	if err != nil {
+		log.Logger().Warnf("pipeline run watcher: failed to update the commit statuses of %s: %s", pr.Name, err)
+	}
+}
+
+// onPipelineRun reports a commit status for each stage of the PipelineRun
+func (o *ControllerCommitStatusOptions) onPipelineRun(pr *pipelineapi.PipelineRun, jxClient jenkinsv1client.Interface, ns string) error {
+	if strings.HasPrefix(pr.Name, "metapipeline-") || o.completedBeforeStart(pr) {
+		return nil
+	}
+	owner := pr.Labels[tekton.LabelOwner]
+	repo := pr.Labels[tekton.LabelRepo]
+	branch := pr.Labels[tekton.LabelBranch]
+	build := pr.Labels[tekton.LabelBuild]
+	if owner == "" || repo == "" || branch == "" || build == "" {
+		return nil
+	}
+
+	activityName := naming.ToValidName(fmt.Sprintf("%s-%s-%s-%s", owner, repo, branch, build))
+	activity, err := jxClient.JenkinsV1().PipelineActivities(ns).Get(activityName, metav1.GetOptions{})
+	if err != nil {
+		// the build controller has not created the activity yet, we'll get called again when the PipelineRun changes
+		log.Logger().Debugf("pipeline run watcher: unable to find PipelineActivity %s", activityName)
+		return nil
+	}
+	if activity.Spec.GitURL == "" || activity.Spec.LastCommitSHA == "" {
+		log.Logger().Debugf("pipeline run watcher: no git URL or commit SHA on PipelineActivity %s yet", activityName)
+		return nil
+	}
+	structure, err := jxClient.JenkinsV1().PipelineStructures(ns).Get(pr.Name, metav1.GetOptions{})
+	if err != nil {
+		log.Logger().Debugf("pipeline run watcher: unable to find PipelineStructure %s", pr.Name)
+		return nil
+	}
+	statuses, err := pipelineStageStatuses(pr, structure)
+	if err != nil {
+		return err
+	}
+
+	commit := jenkinsv1.CommitStatusCommitReference{
+		GitURL: activity.Spec.GitURL,
+		SHA:    activity.Spec.LastCommitSHA,
+	}
+	if strings.HasPrefix(strings.ToUpper(branch), "PR-") {
+		commit.PullRequest = strings.ToUpper(branch)
+	}
+	gitProvider, gitRepoInfo, err := o.getGitProvider(commit.GitURL)
+	if err != nil {
+		return err
+	}
+	data := StageCommitStatusData{
+		Namespace:        ns,
+		Owner:            owner,
+		Repo:             repo,
+		Branch:           branch,
+		Build:            build,
+		Context:          pr.Labels[tekton.LabelContext],
+		PipelineRun:      pr.Name,
+		PipelineActivity: activity.Name,
+	}
+	if data.Context == "" {
+		data.Context = webhookStatusContext
+	}
+	for _, status := range statuses {
+		data.Stage = status.Stage
+		context, err := renderStageTemplate(o.ContextTemplate, data)
+		if err != nil {
+			return errors.Wrap(err, "rendering the commit status context")
+		}
+		targetURL, err := renderStageTemplate(o.TargetURLTemplate, data)
+		if err != nil {
+			return errors.Wrap(err, "rendering the commit status target URL")
+		}
+		if targetURL == "" && isHTTPURL(activity.Spec.BuildLogsURL) {
+			targetURL = activity.Spec.BuildLogsURL
+		}
+
+		key := commit.SHA + "/" + context
+		reported := status.State + "/" + status.Description
+		if o.isStageStatusReported(pr.Name, key, reported) {
+			continue
+		}
+		_, err = extensions.NotifyCommitStatus(commit, status.State, targetURL, status.Description, "", context, gitProvider, gitRepoInfo)
+		if err != nil {
+			return errors.Wrapf(err, "reporting commit status %s of %s", context, commit.SHA)
+		}
+		o.recordStageStatus(pr.Name, key, reported)
+	}
+	switch pipelineRunStatus(pr).Status {
+	case pipelineRunStatusSucceeded, pipelineRunStatusFailed, pipelineRunStatusCancelled:
+		// the statuses of a completed PipelineRun no longer change
+		o.forgetStageStatuses(pr.Name)
+	}
+	return nil
+}
+
+// completedBeforeStart returns true if the PipelineRun completed before the controller started, so that the stage
+// statuses of every PipelineRun are not reported again when the informer lists the existing PipelineRuns
+func (o *ControllerCommitStatusOptions) completedBeforeStart(pr *pipelineapi.PipelineRun) bool {
+	completed := pr.Status.CompletionTime
+	return completed != nil && completed.Time.Before(o.startTime)
+}
+
+func (o *ControllerCommitStatusOptions) onPipelineRunDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pr, ok := obj.(*pipelineapi.PipelineRun)
+	if !ok {
+		log.Logger().Warnf("pipeline run watcher: unexpected type %v", obj)
+		return
+	}
+	o.forgetStageStatuses(pr.Name)
+}
+
+// isStageStatusReported returns true if the state was the last one reported by the PipelineRun for the commit and
+// context in the key
+func (o *ControllerCommitStatusOptions) isStageStatusReported(name string, key string, reported string) bool {
+	o.reportedStageStatusesLock.Lock()
+	defer o.reportedStageStatusesLock.Unlock()
+	return o.reportedStageStatuses[name][key] == reported
+}
+
+// recordStageStatus records the state reported by the PipelineRun for the commit and context in the key
+func (o *ControllerCommitStatusOptions) recordStageStatus(name string, key string, reported string) {
+	o.reportedStageStatusesLock.Lock()
+	defer o.reportedStageStatusesLock.Unlock()
+	if o.reportedStageStatuses == nil {
+		o.reportedStageStatuses = map[string]map[string]string{}
+	}
+	if o.reportedStageStatuses[name] == nil {
+		o.reportedStageStatuses[name] = map[string]string{}
+	}
+	o.reportedStageStatuses[name][key] = reported
+}
+
+// forgetStageStatuses evicts the states reported by the PipelineRun
+func (o *ControllerCommitStatusOptions) forgetStageStatuses(name string) {
+	o.reportedStageStatusesLock.Lock()
+	defer o.reportedStageStatusesLock.Unlock()
+	delete(o.reportedStageStatuses, name)
+}
+
+// pipelineStageStatuses computes the commit status of each stage with steps in the PipelineStructure from the
+// conditions of its TaskRun in the PipelineRun
+func pipelineStageStatuses(pr *pipelineapi.PipelineRun, structure *jenkinsv1.PipelineStructure) ([]StageCommitStatus, error) {
+	pri := &tekton.PipelineRunInfo{
+		PipelineRun: pr.Name,
+	}
+	err := pri.SetPodsForPipelineRun(&corev1.PodList{}, structure)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the stages of PipelineRun %s", pr.Name)
+	}
+	pipelineStatus := pipelineRunStatus(pr).Status
+
+	var statuses []StageCommitStatus
+	for _, si := range pri.GetOrderedTaskStages() {
+		status := StageCommitStatus{
+			Stage: si.GetStageNameIncludingParents(),
+		}
+		taskRun := stageTaskRunStatus(pr, structure.GetStage(si.Name), si.Name)
+		var condition *knativeapis.Condition
+		if taskRun != nil && taskRun.Status != nil {
+			condition = taskRun.Status.GetCondition(knativeapis.ConditionSucceeded)
+		}
+		stageStatus := conditionStatus(condition, pr.Spec.Status == pipelineapi.PipelineRunSpecStatusCancelled)
+		skipped := false
+		if stageStatus == pipelineRunStatusPending && pipelineStatus != pipelineRunStatusPending && pipelineStatus != pipelineRunStatusRunning {
+			// the pipeline has finished without ever running this stage
+			stageStatus = pipelineStatus
+			if stageStatus == pipelineRunStatusFailed {
+				stageStatus = pipelineRunStatusCancelled
+			}
+			skipped = true
+		}
+		switch stageStatus {
+		case pipelineRunStatusPending:
+			status.State = "pending"
+			status.Description = "Waiting to start"
+		case pipelineRunStatusRunning:
+			status.State = "pending"
+			status.Description = "Running"
+		case pipelineRunStatusCancelling:
+			status.State = "pending"
+			status.Description = "Cancelling"
+		case pipelineRunStatusSucceeded:
+			status.State = "success"
+			status.Description = "Succeeded"
+		case pipelineRunStatusFailed:
+			status.State = "failure"
+			status.Description = "Failed"
+			if condition != nil && condition.Message != "" {
+				status.Description = condition.Message
+			}
+		default:
+			status.State = "error"
+			status.Description = "Cancelled"
+		}
+		if skipped {
+			status.Description = "Skipped"
+		}
+		if len(status.Description) > maxCommitStatusDescriptionLength {
+			status.Description = status.Description[:maxCommitStatusDescriptionLength-3] + "..."
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// stageTaskRunStatus finds the status of the TaskRun of a stage in the PipelineRun
+func stageTaskRunStatus(pr *pipelineapi.PipelineRun, stage *jenkinsv1.PipelineStructureStage, name string) *pipelineapi.PipelineRunTaskRunStatus {
+	if stage != nil && stage.TaskRunRef != nil {
+		if taskRun, ok := pr.Status.TaskRuns[*stage.TaskRunRef]; ok {
+			return taskRun
+		}
+	}
+	// pipeline tasks are named after their stage
+	taskName := strings.ToLower(strings.NewReplacer(" ", "-").Replace(name))
+	for _, taskRun := range pr.Status.TaskRuns {
+		if taskRun != nil && taskRun.PipelineTaskName == taskName {
+			return taskRun
+		}
+	}
+	return nil
+}
+
+// renderStageTemplate renders the template of a commit status context or target URL for a stage
+func renderStageTemplate(text string, data StageCommitStatusData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := template.New("commitstatus").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing template %s", text)
+	}
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, data)
+	if err != nil {
+		return "", errors.Wrapf(err, "executing template %s", text)
+	}
+	return strings.TrimSpace(buffer.String()), nil
+}
+
+func isHTTPURL(u string) bool {
+	return strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://")
+}
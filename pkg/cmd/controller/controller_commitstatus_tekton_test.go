@@ -0,0 +1,151 @@
+// +build !integration
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	knativeapis "github.com/knative/pkg/apis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func createTestTaskRunStatus(pipelineTask string, status corev1.ConditionStatus, message string) *pipelineapi.PipelineRunTaskRunStatus {
+	taskStatus := &pipelineapi.TaskRunStatus{}
+	taskStatus.SetCondition(&knativeapis.Condition{
+		Type:    knativeapis.ConditionSucceeded,
+		Status:  status,
+		Message: message,
+	})
+	return &pipelineapi.PipelineRunTaskRunStatus{
+		PipelineTaskName: pipelineTask,
+		Status:           taskStatus,
+	}
+}
+
+func createTestPipelineStructure(name string) *jenkinsv1.PipelineStructure {
+	task := func(name string) *string {
+		return &name
+	}
+	parent := "Tests"
+	return &jenkinsv1.PipelineStructure{
+		Stages: []jenkinsv1.PipelineStructureStage{
+			{Name: "Build", TaskRef: task(name + "-build")},
+			{Name: "Tests", Parallel: []string{"Unit", "Integration"}},
+			{Name: "Unit", TaskRef: task(name + "-unit"), Depth: 1, Parent: &parent},
+			{Name: "Integration", TaskRef: task(name + "-integration"), Depth: 1, Parent: &parent},
+			{Name: "Deploy Preview", TaskRef: task(name + "-deploy-preview")},
+		},
+	}
+}
+
+func TestPipelineStageStatuses(t *testing.T) {
+	t.Parallel()
+
+	pr := createTestPipelineRun("myorg-myapp-pr-3-1", corev1.ConditionUnknown)
+	pr.Status.TaskRuns = map[string]*pipelineapi.PipelineRunTaskRunStatus{
+		"myorg-myapp-pr-3-1-build-x8k2p":       createTestTaskRunStatus("build", corev1.ConditionTrue, ""),
+		"myorg-myapp-pr-3-1-unit-b5q7z":        createTestTaskRunStatus("unit", corev1.ConditionFalse, "step unit-tests exited with code 1"),
+		"myorg-myapp-pr-3-1-integration-r2m4t": createTestTaskRunStatus("integration", corev1.ConditionUnknown, ""),
+	}
+	structure := createTestPipelineStructure("myorg-myapp-pr-3")
+
+	statuses, err := pipelineStageStatuses(pr, structure)
+	require.NoError(t, err)
+	assert.Equal(t, []StageCommitStatus{
+		{Stage: "Build", State: "success", Description: "Succeeded"},
+		{Stage: "Tests / Unit", State: "failure", Description: "step unit-tests exited with code 1"},
+		{Stage: "Tests / Integration", State: "pending", Description: "Running"},
+		{Stage: "Deploy Preview", State: "pending", Description: "Waiting to start"},
+	}, statuses)
+
+	pr.Status.SetCondition(&knativeapis.Condition{
+		Type:   knativeapis.ConditionSucceeded,
+		Status: corev1.ConditionFalse,
+	})
+	statuses, err = pipelineStageStatuses(pr, structure)
+	require.NoError(t, err)
+	require.Len(t, statuses, 4)
+	assert.Equal(t, StageCommitStatus{Stage: "Deploy Preview", State: "error", Description: "Skipped"}, statuses[3],
+		"stages which never ran in a failed pipeline are reported as errors")
+}
+
+func TestStageTaskRunStatusUsesTaskRunRef(t *testing.T) {
+	t.Parallel()
+
+	pr := createTestPipelineRun("myorg-myapp-master-1", corev1.ConditionUnknown)
+	pr.Status.TaskRuns = map[string]*pipelineapi.PipelineRunTaskRunStatus{
+		"myorg-myapp-master-1-release-x8k2p": createTestTaskRunStatus("renamed", corev1.ConditionTrue, ""),
+	}
+	taskRun := "myorg-myapp-master-1-release-x8k2p"
+	stage := &jenkinsv1.PipelineStructureStage{Name: "Release", TaskRunRef: &taskRun}
+
+	assert.Equal(t, "renamed", stageTaskRunStatus(pr, stage, "Release").PipelineTaskName)
+	assert.Nil(t, stageTaskRunStatus(pr, nil, "Release"))
+}
+
+func TestRenderStageTemplate(t *testing.T) {
+	t.Parallel()
+
+	data := StageCommitStatusData{
+		Namespace: "jx",
+		Owner:     "myorg",
+		Repo:      "myapp",
+		Branch:    "PR-3",
+		Build:     "1",
+		Context:   "pr-build",
+		Stage:     "Tests / Unit",
+	}
+	context, err := renderStageTemplate(defaultStageContextTemplate, data)
+	require.NoError(t, err)
+	assert.Equal(t, "pr-build/Tests / Unit", context)
+
+	url, err := renderStageTemplate("https://jx.example.com/teams/{{ .Namespace }}/projects/{{ .Owner }}/{{ .Repo }}/{{ .Branch }}/{{ .Build }}", data)
+	require.NoError(t, err)
+	assert.Equal(t, "https://jx.example.com/teams/jx/projects/myorg/myapp/PR-3/1", url)
+
+	url, err = renderStageTemplate("", data)
+	require.NoError(t, err)
+	assert.Empty(t, url)
+
+	_, err = renderStageTemplate("{{ .Unknown }}", data)
+	assert.Error(t, err)
+}
+
+func TestReportedStageStatusesEvicted(t *testing.T) {
+	t.Parallel()
+
+	o := &ControllerCommitStatusOptions{}
+	o.recordStageStatus("myorg-myapp-pr-3-1", "abc123/pr-build/Build", "success/Succeeded")
+	o.recordStageStatus("myorg-myapp-pr-4-1", "def456/pr-build/Build", "pending/Running")
+	assert.True(t, o.isStageStatusReported("myorg-myapp-pr-3-1", "abc123/pr-build/Build", "success/Succeeded"))
+	assert.False(t, o.isStageStatusReported("myorg-myapp-pr-3-1", "abc123/pr-build/Build", "failure/Failed"))
+
+	o.forgetStageStatuses("myorg-myapp-pr-3-1")
+	assert.False(t, o.isStageStatusReported("myorg-myapp-pr-3-1", "abc123/pr-build/Build", "success/Succeeded"))
+
+	pr := createTestPipelineRun("myorg-myapp-pr-4-1", corev1.ConditionUnknown)
+	o.onPipelineRunDelete(cache.DeletedFinalStateUnknown{Key: "jx/myorg-myapp-pr-4-1", Obj: pr})
+	assert.Empty(t, o.reportedStageStatuses)
+}
+
+func TestCompletedBeforeStart(t *testing.T) {
+	t.Parallel()
+
+	o := &ControllerCommitStatusOptions{startTime: time.Now()}
+	pr := createTestPipelineRun("myorg-myapp-pr-3-1", corev1.ConditionTrue)
+	pr.Status.CompletionTime = &metav1.Time{Time: o.startTime.Add(-time.Minute)}
+	assert.True(t, o.completedBeforeStart(pr))
+
+	pr.Status.CompletionTime = &metav1.Time{Time: o.startTime.Add(time.Minute)}
+	assert.False(t, o.completedBeforeStart(pr))
+
+	pr.Status.CompletionTime = nil
+	assert.False(t, o.completedBeforeStart(pr), "the PipelineRun is still running")
+}
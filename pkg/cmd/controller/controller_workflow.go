@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
@@ -49,8 +50,15 @@ type ControllerWorkflowOptions struct {
 
 	// calculated fields
 	PullRequestPollDuration *time.Duration
-	workflowMap             map[string]*v1.Workflow
-	pipelineMap             map[string]*v1.PipelineActivity
+
+	// lock guards the maps below which are shared by the informers and the poll of the git statuses
+	lock        sync.Mutex
+	workflowMap map[string]*v1.Workflow
+	pipelineMap map[string]*v1.PipelineActivity
+	// environmentKeys indexes the git repository key of the permanent environments by name
+	environmentKeys map[string]string
+	// environmentsByGitKey indexes the permanent environments by the key of their git repository
+	environmentsByGitKey map[string]*v1.Environment
 
 	// Allow Git to be configured
 	ConfigureGitFn gits.ConfigureGitFn
@@ -124,6 +132,8 @@ func (o *ControllerWorkflowOptions) Run() error {
 
 	o.workflowMap = map[string]*v1.Workflow{}
 	o.pipelineMap = map[string]*v1.PipelineActivity{}
+	o.environmentKeys = map[string]string{}
+	o.environmentsByGitKey = map[string]*v1.Environment{}
 
 	if o.NoWatch {
 		return o.updatePipelinesWithoutWatching(jxClient, ns)
@@ -153,6 +163,28 @@ func (o *ControllerWorkflowOptions) Run() error {
 	stop := make(chan struct{})
 	go workflowController.Run(stop)
 
+	// index the environments by their git repository so that the PipelineActivity resources of environments can be
+	// found without listing the environments on every event
+	environment := &v1.Environment{}
+	environmentListWatch := cache.NewListWatchFromClient(jxClient.JenkinsV1().RESTClient(), "environments", ns, fields.Everything())
+	_, environmentController := cache.NewInformer(
+		environmentListWatch,
+		environment,
+		time.Minute*10,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				o.onEnvironmentObj(obj)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				o.onEnvironmentObj(newObj)
+			},
+			DeleteFunc: func(obj interface{}) {
+				o.onEnvironmentDelete(obj)
+			},
+		},
+	)
+	go environmentController.Run(stop)
+
 	pipelineListWatch := cache.NewListWatchFromClient(jxClient.JenkinsV1().RESTClient(), "pipelineactivities", ns, fields.Everything())
 	kube.SortListWatchByName(pipelineListWatch)
 	_, pipelineController := cache.NewInformer(
@@ -186,8 +218,44 @@ func (o *ControllerWorkflowOptions) Run() error {
 	select {}
 }
 
+// PipelineMap returns a copy of the PipelineActivity resources being processed indexed by name
 func (o *ControllerWorkflowOptions) PipelineMap() map[string]*v1.PipelineActivity {
-	return o.pipelineMap
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	answer := map[string]*v1.PipelineActivity{}
+	for name, pipeline := range o.pipelineMap {
+		answer[name] = pipeline
+	}
+	return answer
+}
+
+// pipelines returns the PipelineActivity resources being processed
+func (o *ControllerWorkflowOptions) pipelines() []*v1.PipelineActivity {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	answer := make([]*v1.PipelineActivity, 0, len(o.pipelineMap))
+	for _, pipeline := range o.pipelineMap {
+		answer = append(answer, pipeline)
+	}
+	return answer
+}
+
+func (o *ControllerWorkflowOptions) addPipeline(pipeline *v1.PipelineActivity) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.pipelineMap[pipeline.Name] = pipeline
+}
+
+func (o *ControllerWorkflowOptions) removePipeline(name string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	delete(o.pipelineMap, name)
+}
+
+func (o *ControllerWorkflowOptions) getWorkflow(name string) *v1.Workflow {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.workflowMap[name]
 }
 
 func (o *ControllerWorkflowOptions) updatePipelinesWithoutWatching(jxClient versioned.Interface, ns string) error {
@@ -203,9 +271,10 @@ func (o *ControllerWorkflowOptions) updatePipelinesWithoutWatching(jxClient vers
 	if err != nil {
 		return err
 	}
-	for _, pipeline := range pipelines.Items {
-		o.pipelineMap[pipeline.Name] = &pipeline
-		o.onActivity(&pipeline, jxClient, ns)
+	for i := range pipelines.Items {
+		pipeline := &pipelines.Items[i]
+		o.addPipeline(pipeline)
+		o.onActivity(pipeline, jxClient, ns)
 	}
 	return nil
 }
@@ -233,13 +302,88 @@ func (o *ControllerWorkflowOptions) deleteWorkflowObjb(obj interface{}, jxClient
 }
 
 func (o *ControllerWorkflowOptions) onWorkflow(workflow *v1.Workflow, jxClient versioned.Interface, ns string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
 	o.workflowMap[workflow.Name] = workflow
 }
 
 func (o *ControllerWorkflowOptions) onWorkflowDelete(workflow *v1.Workflow, jxClient versioned.Interface, ns string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
 	delete(o.workflowMap, workflow.Name)
 }
 
+func (o *ControllerWorkflowOptions) onEnvironmentObj(obj interface{}) {
+	env, ok := obj.(*v1.Environment)
+	if !ok {
+		log.Logger().Warnf("Object is not an Environment %#v", obj)
+		return
+	}
+	key := ""
+	if env.Spec.Kind == v1.EnvironmentKindTypePermanent && env.Spec.Source.URL != "" {
+		var err error
+		key, err = environmentGitKey(env.Spec.Source.URL)
+		if err != nil {
+			log.Logger().Debugf("Failed to parse the git URL %s of Environment %s: %s", env.Spec.Source.URL, env.Name, err)
+		}
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.removeEnvironmentLocked(env.Name)
+	if key != "" {
+		o.environmentKeys[env.Name] = key
+		o.environmentsByGitKey[key] = env
+	}
+}
+
+func (o *ControllerWorkflowOptions) onEnvironmentDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	env, ok := obj.(*v1.Environment)
+	if !ok {
+		log.Logger().Warnf("Object is not an Environment %#v", obj)
+		return
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.removeEnvironmentLocked(env.Name)
+}
+
+// removeEnvironmentLocked removes the environment from the index, the lock must be held by the caller
+func (o *ControllerWorkflowOptions) removeEnvironmentLocked(name string) {
+	key, ok := o.environmentKeys[name]
+	if !ok {
+		return
+	}
+	delete(o.environmentKeys, name)
+	if env := o.environmentsByGitKey[key]; env != nil && env.Name == name {
+		delete(o.environmentsByGitKey, key)
+	}
+}
+
+// environmentForGitURL returns the permanent environment whose source repository is the given git URL or nil
+func (o *ControllerWorkflowOptions) environmentForGitURL(gitURL string) *v1.Environment {
+	key, err := environmentGitKey(gitURL)
+	if err != nil {
+		log.Logger().Debugf("Failed to parse git URL %s: %s", gitURL, err)
+		return nil
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.environmentsByGitKey[key]
+}
+
+// environmentGitKey returns the key used to index the environments by their git repository which ignores the case
+// and the form of the git URL
+func environmentGitKey(gitURL string) (string, error) {
+	gitInfo, err := gits.ParseGitURL(gitURL)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(gitInfo.Host + "/" + gitInfo.Organisation + "/" + gitInfo.Name), nil
+}
+
 func (o *ControllerWorkflowOptions) onActivityObj(obj interface{}, jxClient versioned.Interface, ns string) {
 	pipeline, ok := obj.(*v1.PipelineActivity)
 	if !ok {
@@ -255,6 +399,7 @@ func (o *ControllerWorkflowOptions) onActivityObj(obj interface{}, jxClient vers
 			}
 		}
 		o.onActivity(pipeline, jxClient, ns)
+		o.onEnvironmentActivity(pipeline, jxClient, ns)
 	}
 }
 
@@ -282,7 +427,7 @@ func (o *ControllerWorkflowOptions) onActivity(pipeline *v1.PipelineActivity, jx
 	}
 
 	if !pipeline.Spec.WorkflowStatus.IsTerminated() {
-		flow := o.workflowMap[workflowName]
+		flow := o.getWorkflow(workflowName)
 		if flow == nil && workflowName == "default" {
 			var err error
 			flow, err = workflow.CreateDefaultWorkflow(jxClient, ns)
@@ -309,13 +454,64 @@ func (o *ControllerWorkflowOptions) onActivity(pipeline *v1.PipelineActivity, jx
 		}
 
 		// ensure the pipeline is in our map
-		o.pipelineMap[pipeline.Name] = pipeline
+		o.addPipeline(pipeline)
 
 		// lets walk the Workflow spec and see if we need to trigger any PRs or move the PipelineActivity forward
+		err := workflow.ValidateWorkflow(flow)
+		if err != nil {
+			log.Logger().Warnf("Cannot process PipelineActivity %s as the Workflow is invalid: %s", pipeline.Name, err)
+			return
+		}
 		promoteStatusMap := createPromoteStatus(pipeline)
 
 		allStepsComplete := true
-		for _, step := range flow.Spec.Steps {
+		failedStep := ""
+		for i := range flow.Spec.Steps {
+			step := &flow.Spec.Steps[i]
+			stepName := workflow.StepName(step)
+			stepStatus := workflow.GetStepStatus(pipeline, step)
+			if stepStatus != nil && stepStatus.Status.IsTerminated() && stepStatus.Status != v1.ActivityStatusTypeSucceeded {
+				failedStep = stepName
+				continue
+			}
+			timedOut, err := workflow.StepTimedOut(step, stepStatus, time.Now())
+			if err != nil {
+				log.Logger().Warnf("Cannot check the timeout of step %s of PipelineActivity %s: %s", stepName, pipeline.Name, err)
+			}
+			if timedOut {
+				reason := fmt.Sprintf("timed out after %s", step.Timeout)
+				log.Logger().Warnf("Step %s of PipelineActivity %s %s", stepName, pipeline.Name, reason)
+				err = modifyLatestPipeline(activities, pipeline.Name, func(activity *v1.PipelineActivity) bool {
+					status := workflow.GetStepStatus(activity, step)
+					if status == nil || status.Status.IsTerminated() {
+						return false
+					}
+					workflow.FailStep(status, reason)
+					return true
+				})
+				if err != nil {
+					log.Logger().Warnf("Failed to time out step %s of PipelineActivity %s: %s", stepName, pipeline.Name, err)
+				}
+				failedStep = stepName
+				continue
+			}
+
+			if step.Approval != nil {
+				if stepStatus == nil && canStartApproval(flow, pipeline, step, jxClient, ns) {
+					log.Logger().Infof("Waiting for the approval of step %s of PipelineActivity %s", stepName, pipeline.Name)
+					err = modifyLatestPipeline(activities, pipeline.Name, func(activity *v1.PipelineActivity) bool {
+						return workflow.StartApproval(activity, step)
+					})
+					if err != nil {
+						log.Logger().Warnf("Failed to start the approval of step %s of PipelineActivity %s: %s", stepName, pipeline.Name, err)
+					}
+				}
+				if stepStatus == nil || stepStatus.Status != v1.ActivityStatusTypeSucceeded {
+					allStepsComplete = false
+				}
+				continue
+			}
+
 			promote := step.Promote
 			if promote != nil {
 				envName := promote.Environment
@@ -324,7 +520,11 @@ func (o *ControllerWorkflowOptions) onActivity(pipeline *v1.PipelineActivity, jx
 					if status == nil || status.PullRequest == nil || status.PullRequest.PullRequestURL == "" {
 						allStepsComplete = false
 						// can we generate a PR now?
-						if canExecuteStep(flow, pipeline, &step, promoteStatusMap, envName) {
+						canExecute, blockedReason := canExecuteStep(flow, pipeline, step, envName, jxClient, ns)
+						if blockedReason != nil {
+							recordPromoteBlockedReason(activities, pipeline, envName, *blockedReason)
+						}
+						if canExecute {
 							log.Logger().Infof("Creating PR for environment %s from PipelineActivity %s as current status is %#v", envName, pipeline.Name, status)
 							po := o.createPromoteOptions(repoName, envName, pipelineName, build, version)
 
@@ -340,10 +540,28 @@ func (o *ControllerWorkflowOptions) onActivity(pipeline *v1.PipelineActivity, jx
 				}
 			}
 		}
+		if failedStep != "" {
+			// the steps which depend on the failed step can never run
+			err = modifyLatestPipeline(activities, pipeline.Name, func(activity *v1.PipelineActivity) bool {
+				if activity.Spec.WorkflowStatus == v1.ActivityStatusTypeFailed {
+					return false
+				}
+				activity.Spec.Status = v1.ActivityStatusTypeFailed
+				activity.Spec.WorkflowStatus = v1.ActivityStatusTypeFailed
+				activity.Spec.WorkflowMessage = fmt.Sprintf("step %s failed", failedStep)
+				return true
+			})
+			if err != nil {
+				log.Logger().Warnf("Failed to update PipelineActivity %s due to step %s failing: %s", pipeline.Name, failedStep, err)
+			}
+			return
+		}
 		if allStepsComplete && (pipeline.Spec.Status != v1.ActivityStatusTypeSucceeded || pipeline.Spec.WorkflowStatus != v1.ActivityStatusTypeSucceeded) {
-			pipeline.Spec.Status = v1.ActivityStatusTypeSucceeded
-			pipeline.Spec.WorkflowStatus = v1.ActivityStatusTypeSucceeded
-			_, err := jxClient.JenkinsV1().PipelineActivities(ns).PatchUpdate(pipeline)
+			err = modifyLatestPipeline(activities, pipeline.Name, func(activity *v1.PipelineActivity) bool {
+				activity.Spec.Status = v1.ActivityStatusTypeSucceeded
+				activity.Spec.WorkflowStatus = v1.ActivityStatusTypeSucceeded
+				return true
+			})
 			if err != nil {
 				log.Logger().Warnf("Failed to update PipelineActivity %s due to being complete: %s", pipeline.Name, err)
 			}
@@ -421,7 +639,7 @@ func (o *ControllerWorkflowOptions) pollGitPipelineStatuses(jxClient versioned.I
 	environments := jxClient.JenkinsV1().Environments(ns)
 	activities := jxClient.JenkinsV1().PipelineActivities(ns)
 
-	for _, activity := range o.pipelineMap {
+	for _, activity := range o.pipelines() {
 		o.pollGitStatusforPipeline(activity, activities, environments, ns)
 	}
 }
@@ -463,7 +681,7 @@ func (o *ControllerWorkflowOptions) pollGitStatusforPipeline(activity *v1.Pipeli
 		if promoteStep == nil {
 			continue
 		}
-		if promoteStep.Status.IsTerminated() {
+		if promoteStep.Status.IsTerminated() || promoteStep.Phase.IsTerminated() {
 			log.Logger().Debugf("Pipeline %s promote Environment %s ignored as status %s", activity.Name, promoteStep.Environment, string(promoteStep.Status))
 			continue
 		}
@@ -478,180 +696,94 @@ func (o *ControllerWorkflowOptions) pollGitStatusforPipeline(activity *v1.Pipeli
 			log.Logger().Infof("Pipeline %s promote Environment %s status %s ignored for PR %s", activity.Name, promoteStep.Environment, string(promoteStep.Status), prURL)
 			continue
 		}
-		gitProvider, gitInfo, err := o.createGitProviderForPR(prURL)
-		if err != nil {
-			log.Logger().Warnf("Failed to create git Provider: %s", err)
-			return
-		}
-		if gitProvider == nil || gitInfo == nil {
-			return
-		}
-		prNumber, err := PullRequestURLToNumber(prURL)
-		if err != nil {
-			log.Logger().Warnf("Failed to get PR number: %s", err)
-			return
-		}
-		pr, err := gitProvider.GetPullRequest(gitInfo.Organisation, gitInfo, prNumber)
-		if err != nil {
-			log.Logger().Warnf("Failed to query the Pull Request status on pipeline %s for repo %s PR %d for PR %s: %s", activity.Name, gitInfo.HttpsURL(), prNumber, prURL, err)
-		} else {
-			log.Logger().Debugf("Pipeline %s promote Environment %s has PR %s", activity.Name, envName, prURL)
-			po := o.createPromoteOptionsFromActivity(activity, envName)
-			po.GitInfo = gitInfo
-
-			if pr.Merged != nil && *pr.Merged {
-				if pr.MergeCommitSHA == nil {
-					log.Logger().Warnf("Pipeline %s promote Environment %s has PR %s which is merged but there is no merge SHA", activity.Name, envName, prURL)
-				} else {
-					mergeSha := *pr.MergeCommitSHA
-					mergedPR := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromotePullRequestStep) error {
-						kube.CompletePromotionPullRequest(a, s, ps, p)
-						p.MergeCommitSHA = mergeSha
-						return nil
-					}
-					env, err := environments.Get(envName, metav1.GetOptions{})
-					if err != nil {
-						log.Logger().Warnf("Failed to find environment %s: %s", envName, err)
-						return
-					} else {
-						promoteKey := po.CreatePromoteKey(env)
-
-						jxClient, _, err := o.JXClient()
-						if err != nil {
-							log.Logger().Warnf("Failed to get the jx client: %s", err)
-							return
-						}
-						promoteKey.OnPromotePullRequest(jxClient, o.Namespace, mergedPR)
-						promoteKey.OnPromoteUpdate(jxClient, o.Namespace, kube.StartPromotionUpdate)
+		o.resumePromotion(activity, promoteStep, environments, ns)
+	}
+}
 
-						if o.NoWaitForUpdatePipeline {
-							log.Logger().Infof("Pull Request %d merged but we are not waiting for the update pipeline to complete!",
-								prNumber)
-							err = po.CommentOnIssues(ns, env, promoteKey)
-							if err != nil {
-								log.Logger().Warnf("Failed to comment on issues: %s", err)
-							}
-							err = promoteKey.OnPromoteUpdate(jxClient, o.Namespace, kube.CompletePromotionUpdate)
-							if err != nil {
-								log.Logger().Warnf("PipelineActivity update failed while completing promotion step. activity=%s",
-									activity.Name)
-							}
-							return
-						}
+// resumePromotion reconciles the GitOps promotion recorded on the PipelineActivity once, so that the promotion moves
+// on even if the process which created its Pull Request has died
+func (o *ControllerWorkflowOptions) resumePromotion(activity *v1.PipelineActivity, promoteStep *v1.PromoteActivityStep, environments typev1.EnvironmentInterface, ns string) {
+	envName := promoteStep.Environment
+	prURL := promoteStep.PullRequest.PullRequestURL
+	gitProvider, gitInfo, err := o.createGitProviderForPR(prURL)
+	if err != nil {
+		log.Logger().Warnf("Failed to create git Provider: %s", err)
+		return
+	}
+	if gitProvider == nil || gitInfo == nil {
+		return
+	}
+	prNumber, err := PullRequestURLToNumber(prURL)
+	if err != nil {
+		log.Logger().Warnf("Failed to get PR number: %s", err)
+		return
+	}
+	pr, err := gitProvider.GetPullRequest(gitInfo.Organisation, gitInfo, prNumber)
+	if err != nil {
+		log.Logger().Warnf("Failed to query the Pull Request status on pipeline %s for repo %s PR %d for PR %s: %s", activity.Name, gitInfo.HttpsURL(), prNumber, prURL, err)
+		return
+	}
+	log.Logger().Debugf("Pipeline %s promote Environment %s has PR %s in phase %s", activity.Name, envName, prURL, string(promoteStep.Phase))
+	env, err := environments.Get(envName, metav1.GetOptions{})
+	if err != nil {
+		log.Logger().Warnf("Failed to find environment %s: %s", envName, err)
+		return
+	}
+	releaseInfo := o.createReleaseInfo(activity, env)
+	if releaseInfo == nil {
+		return
+	}
+	releaseInfo.PullRequestInfo = &gits.PullRequestInfo{
+		GitProvider: gitProvider,
+		PullRequest: pr,
+	}
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		log.Logger().Warnf("Failed to get the jx client: %s", err)
+		return
+	}
 
-						statuses, err := gitProvider.ListCommitStatus(pr.Owner, pr.Repo, mergeSha)
-						if err == nil {
-							urlStatusMap := map[string]string{}
-							urlStatusTargetURLMap := map[string]string{}
-							if len(statuses) > 0 {
-								for _, status := range statuses {
-									if status.IsFailed() {
-										log.Logger().Warnf("merge status: %s URL: %s description: %s",
-											status.State, status.TargetURL, status.Description)
-										return
-									}
-									url := status.URL
-									state := status.State
-									if urlStatusMap[url] == "" || urlStatusMap[url] != promote.GitStatusSuccess {
-										if urlStatusMap[url] != state {
-											urlStatusMap[url] = state
-											urlStatusTargetURLMap[url] = status.TargetURL
-										}
-									}
-								}
-								prStatuses := []v1.GitStatus{}
-								keys := util.SortedMapKeys(urlStatusMap)
-								for _, url := range keys {
-									state := urlStatusMap[url]
-									targetURL := urlStatusTargetURLMap[url]
-									if targetURL == "" {
-										targetURL = url
-									}
-									prStatuses = append(prStatuses, v1.GitStatus{
-										URL:    targetURL,
-										Status: state,
-									})
-								}
-								updateStatuses := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteUpdateStep) error {
-									p.Statuses = prStatuses
-									return nil
-								}
-								promoteKey.OnPromoteUpdate(jxClient, o.Namespace, updateStatuses)
-
-								succeeded := true
-								for _, v := range urlStatusMap {
-									if v != promote.GitStatusSuccess {
-										succeeded = false
-									}
-								}
-								if succeeded {
-									gitURL := activity.Spec.GitURL
-									if gitURL == "" {
-										log.Logger().Warnf("No git URL for PipelineActivity %s so cannot comment on issues", activity.Name)
-										return
-									}
-									gitInfo, err := gits.ParseGitURL(gitURL)
-									if err != nil {
-										log.Logger().Warnf("Failed to parse Git URL %s for PipelineActivity %s so cannot comment on issues: %s", gitURL, activity.Name, err)
-										return
-									}
-									po.GitInfo = gitInfo
-									err = po.CommentOnIssues(ns, env, promoteKey)
-									if err != nil {
-										log.Logger().Warnf("Failed to comment on issues: %s", err)
-										return
-									}
-									err = promoteKey.OnPromoteUpdate(jxClient, o.Namespace, kube.CompletePromotionUpdate)
-									if err != nil {
-										log.Logger().Warnf("Failed to update PipelineActivity on promotion completion: %s", err)
-									}
-									return
-								}
-							}
-						}
-					}
-				}
-			} else {
-				if pr.IsClosed() {
-					log.Logger().Warnf("Pull Request %s is closed", util.ColorInfo(pr.URL))
-					// TODO should we mark the PipelineActivity as complete?
-					return
-				}
+	po := o.createPromoteOptionsFromActivity(activity, envName)
+	po.NoMergePullRequest = o.NoMergePullRequest
+	po.NoWaitForUpdatePipeline = o.NoWaitForUpdatePipeline
+	// the issues fixed by the release are in the repository of the application
+	if activity.Spec.GitURL != "" {
+		po.GitInfo, err = gits.ParseGitURL(activity.Spec.GitURL)
+		if err != nil {
+			log.Logger().Warnf("Failed to parse Git URL %s for PipelineActivity %s so cannot comment on issues: %s", activity.Spec.GitURL, activity.Name, err)
+		}
+	}
+	promoteKey := po.CreatePromoteKey(env)
+	promotion := po.ResumeGitOpsPromotion(ns, env, releaseInfo, promoteKey, promoteStep)
+	done, err := promotion.Reconcile(jxClient)
+	if err != nil {
+		log.Logger().Warnf("Promotion of PipelineActivity %s to Environment %s failed: %s", activity.Name, envName, err)
+	} else if done {
+		log.Logger().Infof("Promotion of PipelineActivity %s to Environment %s is complete", activity.Name, envName)
+	}
+}
 
-				// lets try merge if the status is good
-				status, err := gitProvider.PullRequestLastCommitStatus(pr)
-				if err != nil {
-					log.Logger().Warnf("Failed to query the Pull Request last commit status for %s ref %s %s", pr.URL, pr.LastCommitSha, err)
-					//return fmt.Errorf("Failed to query the Pull Request last commit status for %s ref %s %s", pr.URL, pr.LastCommitSha, err)
-				} else if status == "in-progress" {
-					log.Logger().Info("The build for the Pull Request last commit is currently in progress.")
-				} else {
-					log.Logger().Infof("Pipeline %s promote Environment %s has PR %s with status %s", activity.Name, envName, prURL, status)
-
-					if status == "success" {
-						if !o.NoMergePullRequest {
-							err = gitProvider.MergePullRequest(pr, "jx promote automatically merged promotion PR")
-							if err != nil {
-								log.Logger().Warnf("Failed to merge the Pull Request %s due to %s maybe I don't have karma?", pr.URL, err)
-							}
-						}
-					} else if status == "error" || status == "failure" {
-						log.Logger().Warnf("Pull request %s last commit has status %s for ref %s", pr.URL, status, pr.LastCommitSha)
-						return
-					}
-				}
-			}
-			if pr.Mergeable != nil && !*pr.Mergeable {
-				log.Logger().Info("Rebasing PullRequest due to conflict")
-				env, err := environments.Get(envName, metav1.GetOptions{})
-				if err != nil {
-					log.Logger().Warnf("Failed to find environment %s: %s", envName, err)
-				} else {
-					releaseInfo := o.createReleaseInfo(activity, env)
-					if releaseInfo != nil {
-						err = po.PromoteViaPullRequest(env, releaseInfo)
-					}
-				}
+// onEnvironmentActivity resumes the promotions to the environment whose repository the PipelineActivity builds, so
+// that promotions react to the pipelines triggered by the webhooks of the environment repository rather than waiting
+// for the next poll
+func (o *ControllerWorkflowOptions) onEnvironmentActivity(activity *v1.PipelineActivity, jxClient versioned.Interface, ns string) {
+	gitURL := activity.Spec.GitURL
+	if gitURL == "" {
+		return
+	}
+	env := o.environmentForGitURL(gitURL)
+	if env == nil {
+		return
+	}
+	activities := jxClient.JenkinsV1().PipelineActivities(ns)
+	environments := jxClient.JenkinsV1().Environments(ns)
+	for _, pipeline := range o.pipelines() {
+		for _, step := range pipeline.Spec.Steps {
+			promoteStep := step.Promote
+			if promoteStep != nil && promoteStep.Environment == env.Name && !promoteStep.Status.IsTerminated() {
+				log.Logger().Debugf("Resuming the promotion of PipelineActivity %s as PipelineActivity %s of Environment %s changed", pipeline.Name, activity.Name, env.Name)
+				o.pollGitStatusforPipeline(pipeline, activities, environments, ns)
+				break
 			}
 		}
 	}
@@ -679,18 +811,57 @@ func (o *ControllerWorkflowOptions) createReleaseInfo(activity *v1.PipelineActiv
 	}
 }
 
-func canExecuteStep(workflow *v1.Workflow, activity *v1.PipelineActivity, step *v1.WorkflowStep, statusMap map[string]*v1.PromoteActivityStep, promoteToEnv string) bool {
-	for _, envName := range step.Preconditions.Environments {
-		status := statusMap[envName]
-		if status == nil {
-			log.Logger().Warnf("Cannot promote to Environment: %s as precondition Environment: %s as no status", promoteToEnv, envName)
-			return false
-		}
-		if status.Status != v1.ActivityStatusTypeSucceeded {
-			log.Logger().Warnf("Cannot promote to Environment: %s as precondition Environment: %s has status %s", promoteToEnv, envName, string(status.Status))
+// canExecuteStep returns true if the promote step can run. Once the steps it depends on have succeeded the reason the
+// gates or deployment windows block the promotion is returned, or an empty string if they do not, so that the caller
+// can record it on the PipelineActivity
+func canExecuteStep(flow *v1.Workflow, activity *v1.PipelineActivity, step *v1.WorkflowStep, promoteToEnv string, jxClient versioned.Interface, ns string) (bool, *string) {
+	reason := workflow.DependenciesSucceeded(flow, activity, step)
+	if reason != "" {
+		log.Logger().Warnf("Cannot promote to Environment: %s as %s", promoteToEnv, reason)
+		return false, nil
+	}
+	reason, err := workflow.CheckPromotion(jxClient, ns, activity, step, promoteToEnv)
+	if err != nil {
+		log.Logger().Warnf("Cannot promote to Environment: %s as failed to check the gates and deployment windows: %s", promoteToEnv, err)
+		return false, nil
+	}
+	if reason != "" {
+		log.Logger().Warnf("Cannot promote to Environment: %s as %s", promoteToEnv, reason)
+		return false, &reason
+	}
+	return true, &reason
+}
+
+// recordPromoteBlockedReason records the reason the promotion to the environment is blocked on the PipelineActivity
+// if it has changed
+func recordPromoteBlockedReason(activities typev1.PipelineActivityInterface, activity *v1.PipelineActivity, envName string, reason string) {
+	if !workflow.SetPromoteBlockedReason(activity.DeepCopy(), envName, reason) {
+		return
+	}
+	err := modifyLatestPipeline(activities, activity.Name, func(activity *v1.PipelineActivity) bool {
+		return workflow.SetPromoteBlockedReason(activity, envName, reason)
+	})
+	if err != nil {
+		log.Logger().Warnf("Failed to record the blocked reason of the promotion to %s on PipelineActivity %s: %s", envName, activity.Name, err)
+	}
+}
+
+// canStartApproval returns true if the steps the approval step depends on have succeeded and its gates pass
+func canStartApproval(flow *v1.Workflow, activity *v1.PipelineActivity, step *v1.WorkflowStep, jxClient versioned.Interface, ns string) bool {
+	stepName := workflow.StepName(step)
+	reason := workflow.DependenciesSucceeded(flow, activity, step)
+	if reason == "" {
+		var err error
+		reason, err = workflow.EvaluatePromotionGates(jxClient, ns, activity, step)
+		if err != nil {
+			log.Logger().Warnf("Cannot start the approval of step %s as failed to check the gates: %s", stepName, err)
 			return false
 		}
 	}
+	if reason != "" {
+		log.Logger().Debugf("Cannot start the approval of step %s as %s", stepName, reason)
+		return false
+	}
 	return true
 }
 
@@ -815,7 +986,7 @@ func (o *ControllerWorkflowOptions) removePipelineActivityIfNoManual(activity *v
 
 func (o *ControllerWorkflowOptions) modifyAndRemovePipelineActivity(activity *v1.PipelineActivity, activities typev1.PipelineActivityInterface, callback func(activity *v1.PipelineActivity) bool) error {
 	err := modifyPipeline(activities, activity, callback)
-	delete(o.pipelineMap, activity.Name)
+	o.removePipeline(activity.Name)
 	return err
 }
 
@@ -833,11 +1004,21 @@ func modifyPipeline(activities typev1.PipelineActivityInterface, activity *v1.Pi
 	return nil
 }
 
+// modifyLatestPipeline applies the callback to the latest version of the PipelineActivity so that the changes made
+// by promotions since it was loaded are not reverted
+func modifyLatestPipeline(activities typev1.PipelineActivityInterface, name string, callback func(activity *v1.PipelineActivity) bool) error {
+	activity, err := activities.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get PipelineActivity %s", name)
+	}
+	return modifyPipeline(activities, activity, callback)
+}
+
 // isNewestPipeline returns true if this pipeline is the newest pipeline version for a repo
 func (o *ControllerWorkflowOptions) isNewestPipeline(activity *v1.PipelineActivity, activities typev1.PipelineActivityInterface) bool {
 	newest := true
 	deleteNames := []*v1.PipelineActivity{}
-	for _, act2 := range o.pipelineMap {
+	for _, act2 := range o.pipelines() {
 		if activity.Spec.Pipeline == act2.Spec.Pipeline {
 			b1 := activity.Spec.Build
 			b2 := act2.Spec.Build
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestEnvironmentForGitURL(t *testing.T) {
+	t.Parallel()
+
+	o := &ControllerWorkflowOptions{
+		environmentKeys:      map[string]string{},
+		environmentsByGitKey: map[string]*v1.Environment{},
+	}
+	production := kube.NewPermanentEnvironmentWithGit("production", "https://github.com/MyOrg/environment-mycluster-production.git")
+	o.onEnvironmentObj(production)
+	o.onEnvironmentObj(kube.NewPreviewEnvironment("preview"))
+
+	env := o.environmentForGitURL("git@github.com:myorg/environment-mycluster-production.git")
+	require.NotNil(t, env)
+	assert.Equal(t, "production", env.Name)
+	assert.Nil(t, o.environmentForGitURL("https://github.com/myorg/myapp.git"))
+
+	// the index follows the changes of the git URL of the environment
+	moved := production.DeepCopy()
+	moved.Spec.Source.URL = "https://github.com/myorg/environment-production.git"
+	o.onEnvironmentObj(moved)
+	assert.Nil(t, o.environmentForGitURL("https://github.com/myorg/environment-mycluster-production.git"))
+	require.NotNil(t, o.environmentForGitURL("https://github.com/myorg/environment-production"))
+
+	o.onEnvironmentDelete(cache.DeletedFinalStateUnknown{Key: "jx/production", Obj: moved})
+	assert.Nil(t, o.environmentForGitURL("https://github.com/myorg/environment-production"))
+}
@@ -60,6 +60,21 @@ type PipelineRunnerOptions struct {
 	UseMetaPipeline      bool
 	MetaPipelineImage    string
 	SemanticRelease      bool
+	HMACToken            string
+	BearerToken          string
+
+	// webhookSecretLock guards the webhook secret and when it was loaded
+	webhookSecretLock   sync.Mutex
+	webhookSecret       []byte
+	webhookSecretLoaded time.Time
+	// webhookCtx is cancelled when the server shuts down to stop reporting the results of pipelines started by webhooks
+	webhookCtx context.Context
+	// webhookReporters limits how many results of pipelines started by webhooks are reported at once
+	webhookReporters chan struct{}
+
+	// signaturesLock guards seenSignatures, the HMAC signatures already accepted, to reject replayed requests
+	signaturesLock sync.Mutex
+	seenSignatures map[string]time.Time
 }
 
 // PipelineRunRequest the request to trigger a pipeline run
@@ -85,11 +100,30 @@ type ObjectReference struct {
 }
 
 var (
-	controllerPipelineRunnersLong = templates.LongDesc(`Runs the service to generate Tekton resources from source code webhooks such as from Prow`)
+	controllerPipelineRunnersLong = templates.LongDesc(`Runs the service to generate Tekton resources from source code webhooks such as from Prow
+
+		As well as starting pipelines the service can cancel a running PipelineRun via POST /cancel, return the status
+		of a PipelineRun and its TaskRuns via GET /status?name=<name> and re-run a previous PipelineRun via POST /rerun.
+
+		If an HMAC token or a bearer token is configured, requests must either be signed with the HMAC token or include
+		the bearer token in the Authorization header. Signed requests put the current Unix time in seconds in the
+		X-Pipeline-Runner-Timestamp header and the HMAC-SHA1 of the timestamp, a '.' and the body in the X-Hub-Signature
+		header. Signatures older than 5 minutes, or which have already been used, are rejected.
+
+		As /cancel and /rerun change PipelineRuns they are refused unless a token is configured.
+
+		Teams without Prow can point the push and pull request webhooks of GitHub, GitLab, Bitbucket Server or Gitea
+		repositories, or the service hooks jx creates for Azure DevOps repositories, at /hook. Webhooks are validated with the token in the hmac-token Secret and the result of the
+		pipeline is reported back as a commit status. Pull Requests are only built if their author owns the repository,
+		is a collaborator or a member of the organisation, or once someone adds the ok-to-test label.
+`)
 
 	controllerPipelineRunnersExample = templates.Examples(`
 			# run the pipeline runner controller
 			jx controller pipelinerunner
+
+			# run the pipeline runner controller requiring a bearer token
+			PIPELINE_RUNNER_BEARER_TOKEN=mytoken jx controller pipelinerunner
 		`)
 )
 
@@ -117,6 +151,8 @@ func NewCmdControllerPipelineRunner(commonOpts *opts.CommonOptions) *cobra.Comma
 	cmd.Flags().StringVarP(&options.ServiceAccount, "service-account", "", "tekton-bot", "The Kubernetes ServiceAccount to use to run the pipeline.")
 	cmd.Flags().BoolVarP(&options.NoGitCredentialsInit, "no-git-init", "", false, "Disables checking we have setup git credentials on startup.")
 	cmd.Flags().BoolVarP(&options.SemanticRelease, "semantic-release", "", false, "Enable semantic releases")
+	cmd.Flags().StringVarP(&options.HMACToken, "hmac-token", "", "", "The HMAC token requests must be signed with. If not specified defaults to $"+hmacTokenEnvVar)
+	cmd.Flags().StringVarP(&options.BearerToken, "bearer-token", "", "", "The bearer token requests must include. If not specified defaults to $"+bearerTokenEnvVar)
 
 	// TODO - temporary flags until meta pipeline is the default
 	cmd.Flags().BoolVarP(&options.UseMetaPipeline, "use-meta-pipeline", "", false, "Uses the meta pipeline to create the pipeline.")
@@ -132,6 +168,15 @@ func (o *PipelineRunnerOptions) Run() error {
 			return err
 		}
 	}
+	if o.HMACToken == "" {
+		o.HMACToken = os.Getenv(hmacTokenEnvVar)
+	}
+	if o.BearerToken == "" {
+		o.BearerToken = os.Getenv(bearerTokenEnvVar)
+	}
+	if o.HMACToken == "" && o.BearerToken == "" {
+		logger.Warn("no HMAC or bearer token is configured so requests are not authenticated and the cancel and rerun endpoints are disabled")
+	}
 
 	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(context.Background())
@@ -143,11 +188,17 @@ func (o *PipelineRunnerOptions) Run() error {
 }
 
 func (o *PipelineRunnerOptions) startWorkers(ctx context.Context, wg *sync.WaitGroup, cancel context.CancelFunc) {
+	o.webhookCtx = ctx
+	o.webhookReporters = make(chan struct{}, maxWebhookReporters)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		mux := http.NewServeMux()
-		mux.Handle(o.Path, http.HandlerFunc(o.pipeline))
+		mux.Handle(o.Path, o.authenticated(o.pipeline, false))
+		mux.Handle(cancelPath, o.authenticated(o.cancel, true))
+		mux.Handle(statusPath, o.authenticated(o.status, false))
+		mux.Handle(rerunPath, o.authenticated(o.rerun, true))
+		mux.Handle(webhookPath, http.HandlerFunc(o.webhook))
 		mux.Handle(healthPath, http.HandlerFunc(o.health))
 		mux.Handle(readyPath, http.HandlerFunc(o.ready))
 		srv := &http.Server{
@@ -280,7 +331,10 @@ func (o *PipelineRunnerOptions) startPipeline(pipelineRun PipelineRunRequest) (P
 		return response, errors.Wrap(err, "failed to get env vars from prowjob")
 	}
 
-	sourceURL := fmt.Sprintf("https://github.com/%s/%s.git", prowJobSpec.Refs.Org, prowJobSpec.Refs.Repo)
+	sourceURL := prowJobSpec.Refs.CloneURI
+	if sourceURL == "" {
+		sourceURL = fmt.Sprintf("https://github.com/%s/%s.git", prowJobSpec.Refs.Org, prowJobSpec.Refs.Repo)
+	}
 	if sourceURL == "" {
 		return response, errors.Wrap(err, "missing sourceURL property")
 	}
@@ -316,6 +370,7 @@ func (o *PipelineRunnerOptions) startPipeline(pipelineRun PipelineRunRequest) (P
 		}
 		results.Resources = pipelineCreateOption.Results.ObjectReferences()
 	}
+	o.recordPipelineRunRequest(pipelineRun, results)
 
 	return results, nil
 }
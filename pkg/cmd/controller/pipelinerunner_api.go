@@ -0,0 +1,414 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/tekton"
+	knativeapis "github.com/knative/pkg/apis"
+	"github.com/pkg/errors"
+	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// cancelPath is the URL path for the HTTP endpoint that cancels a running PipelineRun
+	cancelPath = "/cancel"
+	// statusPath is the URL path for the HTTP endpoint that returns the status of a PipelineRun
+	statusPath = "/status"
+	// rerunPath is the URL path for the HTTP endpoint that re-runs a previous PipelineRun
+	rerunPath = "/rerun"
+
+	// pipelineRunRequestAnnotation is the annotation on a PipelineRun storing the request which started it so that it
+	// can be re-run
+	pipelineRunRequestAnnotation = "jenkins.io/pipeline-run-request"
+
+	// hmacTokenEnvVar is the environment variable for the HMAC token used to sign requests
+	hmacTokenEnvVar = "PIPELINE_RUNNER_HMAC_TOKEN"
+	// bearerTokenEnvVar is the environment variable for the bearer token of requests
+	bearerTokenEnvVar = "PIPELINE_RUNNER_BEARER_TOKEN"
+
+	// timestampHeader is the header with the Unix time in seconds when a request was signed, which is included in
+	// the signature so that requests cannot be replayed later
+	timestampHeader = "X-Pipeline-Runner-Timestamp"
+	// signatureMaxAge is how old the timestamp of a signed request can be
+	signatureMaxAge = 5 * time.Minute
+
+	pipelineRunStatusPending    = "Pending"
+	pipelineRunStatusRunning    = "Running"
+	pipelineRunStatusCancelling = "Cancelling"
+	pipelineRunStatusCancelled  = "Cancelled"
+	pipelineRunStatusSucceeded  = "Succeeded"
+	pipelineRunStatusFailed     = "Failed"
+)
+
+// PipelineRunReference the request to cancel, query or re-run a PipelineRun
+type PipelineRunReference struct {
+	Name string `json:"name"`
+}
+
+// PipelineStatusResponse the status of a PipelineRun and the TaskRuns it created
+type PipelineStatusResponse struct {
+	Name     string              `json:"name"`
+	Status   string              `json:"status"`
+	Reason   string              `json:"reason,omitempty"`
+	Message  string              `json:"message,omitempty"`
+	Labels   map[string]string   `json:"labels,omitempty"`
+	TaskRuns []TaskStatusSummary `json:"taskRuns,omitempty"`
+}
+
+// TaskStatusSummary the status of a TaskRun created by a PipelineRun
+type TaskStatusSummary struct {
+	Name     string `json:"name"`
+	TaskName string `json:"taskName,omitempty"`
+	Status   string `json:"status"`
+	Message  string `json:"message,omitempty"`
+}
+
+// authenticated only calls the handler if the request has a valid HMAC signature or bearer token. If neither token
+// is configured requests are allowed unless requireToken is set, which is used for the endpoints that change
+// PipelineRuns
+func (o *PipelineRunnerOptions) authenticated(handler http.HandlerFunc, requireToken bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if o.HMACToken == "" && o.BearerToken == "" {
+			if requireToken {
+				logger.Warnf("rejecting %s request for %s as no HMAC or bearer token is configured", r.Method, r.URL.Path)
+				responseHTTPError(w, http.StatusForbidden, "403 Forbidden: No HMAC or bearer token is configured")
+				return
+			}
+			handler(w, r)
+			return
+		}
+		payload, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			responseHTTPError(w, http.StatusInternalServerError, "500 Internal Server Error: Failed to read request body")
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(payload))
+		if !o.validRequest(r, payload) {
+			logger.Warnf("rejecting unauthenticated %s request for %s", r.Method, r.URL.Path)
+			responseHTTPError(w, http.StatusUnauthorized, "401 Unauthorized: Missing, invalid or expired X-Hub-Signature or Authorization header")
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// validRequest returns true if the request is signed with the HMAC token or has the bearer token
+func (o *PipelineRunnerOptions) validRequest(r *http.Request, payload []byte) bool {
+	if o.BearerToken != "" {
+		auth := r.Header.Get("Authorization")
+		if strings.HasPrefix(auth, "Bearer ") {
+			token := strings.TrimPrefix(auth, "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(o.BearerToken)) == 1 {
+				return true
+			}
+		}
+	}
+	if o.HMACToken != "" {
+		sig := r.Header.Get("X-Hub-Signature")
+		timestamp := r.Header.Get(timestampHeader)
+		if sig != "" && validTimestamp(timestamp, time.Now()) &&
+			ValidatePayload(signedMaterial(timestamp, payload), sig, []byte(o.HMACToken)) {
+			return o.firstUseOfSignature(sig, time.Now())
+		}
+	}
+	return false
+}
+
+// RequestSignature returns the X-Hub-Signature header of a request to the pipeline runner signed at the timestamp
+func RequestSignature(timestamp string, payload []byte, key []byte) string {
+	return PayloadSignature(signedMaterial(timestamp, payload), key)
+}
+
+// signedMaterial is what the HMAC signature of a request covers
+func signedMaterial(timestamp string, payload []byte) []byte {
+	return append([]byte(timestamp+"."), payload...)
+}
+
+// validTimestamp returns true if the Unix timestamp is within signatureMaxAge of now
+func validTimestamp(timestamp string, now time.Time) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := now.Sub(time.Unix(seconds, 0))
+	return age <= signatureMaxAge && age >= -signatureMaxAge
+}
+
+// firstUseOfSignature records the signature, returning false if it has already been used. Signatures are forgotten
+// once their timestamps can no longer be valid
+func (o *PipelineRunnerOptions) firstUseOfSignature(sig string, now time.Time) bool {
+	o.signaturesLock.Lock()
+	defer o.signaturesLock.Unlock()
+	if o.seenSignatures == nil {
+		o.seenSignatures = map[string]time.Time{}
+	}
+	for s, seen := range o.seenSignatures {
+		if now.Sub(seen) > 2*signatureMaxAge {
+			delete(o.seenSignatures, s)
+		}
+	}
+	if _, ok := o.seenSignatures[sig]; ok {
+		return false
+	}
+	o.seenSignatures[sig] = now
+	return true
+}
+
+// cancel handles requests to cancel a running PipelineRun
+func (o *PipelineRunnerOptions) cancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	ref, err := o.parsePipelineRunReference(r)
+	if err != nil {
+		o.returnStatusBadRequest(err, "could not read the JSON request body: "+err.Error(), w)
+		return
+	}
+	tektonClient, ns, err := o.TektonClient()
+	if err != nil {
+		o.returnStatusBadRequest(err, "could not create the tekton client: "+err.Error(), w)
+		return
+	}
+	response, err := cancelPipelineRun(tektonClient, ns, ref.Name)
+	if err != nil {
+		o.returnStatusBadRequest(err, "could not cancel pipeline: "+err.Error(), w)
+		return
+	}
+	o.writeResponse(response, w)
+}
+
+// status handles requests for the status of a PipelineRun
+func (o *PipelineRunnerOptions) status(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		o.returnStatusBadRequest(errors.New("missing name"), "missing name query parameter", w)
+		return
+	}
+	tektonClient, ns, err := o.TektonClient()
+	if err != nil {
+		o.returnStatusBadRequest(err, "could not create the tekton client: "+err.Error(), w)
+		return
+	}
+	pr, err := tektonClient.TektonV1alpha1().PipelineRuns(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		o.returnStatusBadRequest(err, "could not find pipeline: "+err.Error(), w)
+		return
+	}
+	o.writeResponse(pipelineRunStatus(pr), w)
+}
+
+// rerun handles requests to start a PipelineRun again from the request which started it
+func (o *PipelineRunnerOptions) rerun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	ref, err := o.parsePipelineRunReference(r)
+	if err != nil {
+		o.returnStatusBadRequest(err, "could not read the JSON request body: "+err.Error(), w)
+		return
+	}
+	tektonClient, ns, err := o.TektonClient()
+	if err != nil {
+		o.returnStatusBadRequest(err, "could not create the tekton client: "+err.Error(), w)
+		return
+	}
+	request, err := pipelineRunRequest(tektonClient, ns, ref.Name)
+	if err != nil {
+		o.returnStatusBadRequest(err, "could not re-run pipeline: "+err.Error(), w)
+		return
+	}
+	response, err := o.startPipeline(request)
+	if err != nil {
+		o.returnStatusBadRequest(err, "could not start pipeline: "+err.Error(), w)
+		return
+	}
+	o.writeResponse(response, w)
+}
+
+func (o *PipelineRunnerOptions) parsePipelineRunReference(r *http.Request) (PipelineRunReference, error) {
+	ref := PipelineRunReference{}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return ref, errors.Wrap(err, "could not read the JSON request body")
+	}
+	err = json.Unmarshal(data, &ref)
+	if err != nil {
+		return ref, errors.Wrap(err, "failed to unmarshal the JSON request body")
+	}
+	if ref.Name == "" {
+		return ref, errors.New("missing name")
+	}
+	return ref, nil
+}
+
+func (o *PipelineRunnerOptions) writeResponse(response interface{}, w http.ResponseWriter) {
+	data, err := o.marshalPayload(response)
+	if err != nil {
+		o.returnStatusBadRequest(err, "failed to marshal payload", w)
+		return
+	}
+	_, err = w.Write(data)
+	if err != nil {
+		logger.Errorf("error writing response: %s", err.Error())
+	}
+}
+
+// recordPipelineRunRequest annotates the PipelineRuns started by the request with it so that they can be re-run
+func (o *PipelineRunnerOptions) recordPipelineRunRequest(request PipelineRunRequest, response PipelineRunResponse) {
+	tektonClient, ns, err := o.TektonClient()
+	if err != nil {
+		logger.Warnf("unable to record the request of the pipeline so it cannot be re-run: %s", err)
+		return
+	}
+	for _, resource := range response.Resources {
+		if resource.Kind != "PipelineRun" {
+			continue
+		}
+		err = annotatePipelineRun(tektonClient, ns, resource.Name, request)
+		if err != nil {
+			logger.Warnf("unable to record the request of PipelineRun %s so it cannot be re-run: %s", resource.Name, err)
+		}
+	}
+}
+
+// annotatePipelineRun stores the request which started the PipelineRun on it
+func annotatePipelineRun(tektonClient tektonclient.Interface, ns string, name string, request PipelineRunRequest) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the request")
+	}
+	pipelineRuns := tektonClient.TektonV1alpha1().PipelineRuns(ns)
+	pr, err := pipelineRuns.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if pr.Annotations == nil {
+		pr.Annotations = map[string]string{}
+	}
+	pr.Annotations[pipelineRunRequestAnnotation] = string(data)
+	_, err = pipelineRuns.Update(pr)
+	return err
+}
+
+// pipelineRunRequest returns the request which started the PipelineRun
+func pipelineRunRequest(tektonClient tektonclient.Interface, ns string, name string) (PipelineRunRequest, error) {
+	request := PipelineRunRequest{}
+	pr, err := tektonClient.TektonV1alpha1().PipelineRuns(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return request, err
+	}
+	data := pr.Annotations[pipelineRunRequestAnnotation]
+	if data == "" {
+		return request, fmt.Errorf("PipelineRun %s was not started by the pipeline runner", name)
+	}
+	err = json.Unmarshal([]byte(data), &request)
+	if err != nil {
+		return request, errors.Wrapf(err, "failed to unmarshal the request of PipelineRun %s", name)
+	}
+	return request, nil
+}
+
+// cancelPipelineRun cancels the PipelineRun if it is still running
+func cancelPipelineRun(tektonClient tektonclient.Interface, ns string, name string) (*PipelineStatusResponse, error) {
+	pipelineRuns := tektonClient.TektonV1alpha1().PipelineRuns(ns)
+	pr, err := pipelineRuns.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	status := pipelineRunStatus(pr)
+	switch status.Status {
+	case pipelineRunStatusSucceeded, pipelineRunStatusFailed, pipelineRunStatusCancelled:
+		return status, fmt.Errorf("PipelineRun %s has already completed with status %s", name, status.Status)
+	case pipelineRunStatusCancelling:
+		return status, nil
+	}
+	pr.Spec.Status = pipelineapi.PipelineRunSpecStatusCancelled
+	pr, err = pipelineRuns.Update(pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to cancel PipelineRun %s", name)
+	}
+	logger.Infof("cancelled PipelineRun %s", name)
+	return pipelineRunStatus(pr), nil
+}
+
+// pipelineRunStatus summarises the status of the PipelineRun and its TaskRuns
+func pipelineRunStatus(pr *pipelineapi.PipelineRun) *PipelineStatusResponse {
+	answer := &PipelineStatusResponse{
+		Name:   pr.Name,
+		Labels: map[string]string{},
+	}
+	for _, label := range []string{tekton.LabelOwner, tekton.LabelRepo, tekton.LabelBranch, tekton.LabelBuild, tekton.LabelContext} {
+		if value := pr.Labels[label]; value != "" {
+			answer.Labels[label] = value
+		}
+	}
+	condition := pr.Status.GetCondition(knativeapis.ConditionSucceeded)
+	answer.Status = conditionStatus(condition, pr.Spec.Status == pipelineapi.PipelineRunSpecStatusCancelled)
+	if condition != nil {
+		answer.Reason = condition.Reason
+		answer.Message = condition.Message
+	}
+	for name, taskRun := range pr.Status.TaskRuns {
+		if taskRun == nil {
+			continue
+		}
+		summary := TaskStatusSummary{
+			Name:     name,
+			TaskName: taskRun.PipelineTaskName,
+			Status:   pipelineRunStatusPending,
+		}
+		if taskRun.Status != nil {
+			taskCondition := taskRun.Status.GetCondition(knativeapis.ConditionSucceeded)
+			summary.Status = conditionStatus(taskCondition, false)
+			if taskCondition != nil {
+				summary.Message = taskCondition.Message
+			}
+		}
+		answer.TaskRuns = append(answer.TaskRuns, summary)
+	}
+	sort.Slice(answer.TaskRuns, func(i, j int) bool {
+		return answer.TaskRuns[i].Name < answer.TaskRuns[j].Name
+	})
+	return answer
+}
+
+// conditionStatus converts the Succeeded condition of a PipelineRun or TaskRun into a status
+func conditionStatus(condition *knativeapis.Condition, cancelRequested bool) string {
+	if condition == nil {
+		if cancelRequested {
+			return pipelineRunStatusCancelling
+		}
+		return pipelineRunStatusPending
+	}
+	switch condition.Status {
+	case corev1.ConditionTrue:
+		return pipelineRunStatusSucceeded
+	case corev1.ConditionFalse:
+		if condition.Reason == string(pipelineapi.PipelineRunSpecStatusCancelled) {
+			return pipelineRunStatusCancelled
+		}
+		return pipelineRunStatusFailed
+	}
+	if cancelRequested {
+		return pipelineRunStatusCancelling
+	}
+	return pipelineRunStatusRunning
+}
@@ -0,0 +1,169 @@
+// +build !integration
+
+package controller
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	knativeapis "github.com/knative/pkg/apis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testPipelineRunNamespace = "jx"
+
+func createTestPipelineRun(name string, status corev1.ConditionStatus) *pipelineapi.PipelineRun {
+	pr := &pipelineapi.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testPipelineRunNamespace,
+			Labels: map[string]string{
+				"owner":  "myorg",
+				"repo":   "myapp",
+				"branch": "master",
+				"build":  "1",
+			},
+		},
+	}
+	pr.Status.SetCondition(&knativeapis.Condition{
+		Type:   knativeapis.ConditionSucceeded,
+		Status: status,
+	})
+	return pr
+}
+
+func TestPipelineRunnerAuthentication(t *testing.T) {
+	t.Parallel()
+
+	o := &PipelineRunnerOptions{
+		HMACToken:   "my-hmac-token",
+		BearerToken: "my-bearer-token",
+	}
+	payload := []byte(`{"name":"myorg-myapp-master-1"}`)
+	var body []byte
+	handler := o.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}, true)
+	send := func(headers map[string]string) int {
+		r := httptest.NewRequest(http.MethodPost, cancelPath, bytes.NewReader(payload))
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Code
+	}
+	signed := func(timestamp time.Time, token string) map[string]string {
+		ts := strconv.FormatInt(timestamp.Unix(), 10)
+		return map[string]string{
+			timestampHeader:   ts,
+			"X-Hub-Signature": RequestSignature(ts, payload, []byte(token)),
+		}
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send(nil))
+	assert.Equal(t, http.StatusUnauthorized, send(map[string]string{"Authorization": "Bearer wrong-token"}))
+	assert.Equal(t, http.StatusUnauthorized, send(signed(time.Now(), "wrong-token")))
+	assert.Equal(t, http.StatusUnauthorized, send(map[string]string{"X-Hub-Signature": PayloadSignature(payload, []byte("my-hmac-token"))}),
+		"the signature must include a timestamp")
+	assert.Equal(t, http.StatusUnauthorized, send(signed(time.Now().Add(-time.Hour), "my-hmac-token")), "the signature has expired")
+
+	assert.Equal(t, http.StatusNoContent, send(map[string]string{"Authorization": "Bearer my-bearer-token"}))
+	body = nil
+	headers := signed(time.Now(), "my-hmac-token")
+	assert.Equal(t, http.StatusNoContent, send(headers))
+	assert.Equal(t, payload, body, "the handler can still read the request body")
+	assert.Equal(t, http.StatusUnauthorized, send(headers), "the signed request cannot be replayed")
+
+	o = &PipelineRunnerOptions{}
+	handler = o.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}, false)
+	assert.Equal(t, http.StatusNoContent, send(nil), "requests are not authenticated without tokens")
+
+	handler = o.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}, true)
+	assert.Equal(t, http.StatusForbidden, send(nil), "cancel and rerun are refused without tokens")
+}
+
+func TestCancelPipelineRun(t *testing.T) {
+	t.Parallel()
+
+	tektonClient := tektonfake.NewSimpleClientset(
+		createTestPipelineRun("running", corev1.ConditionUnknown),
+		createTestPipelineRun("succeeded", corev1.ConditionTrue),
+	)
+
+	status, err := cancelPipelineRun(tektonClient, testPipelineRunNamespace, "running")
+	require.NoError(t, err)
+	assert.Equal(t, pipelineRunStatusCancelling, status.Status)
+	assert.Equal(t, "myapp", status.Labels["repo"])
+
+	pr, err := tektonClient.TektonV1alpha1().PipelineRuns(testPipelineRunNamespace).Get("running", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, pipelineapi.PipelineRunSpecStatusCancelled, pr.Spec.Status)
+
+	_, err = cancelPipelineRun(tektonClient, testPipelineRunNamespace, "succeeded")
+	assert.Error(t, err, "the PipelineRun has already completed")
+}
+
+func TestPipelineRunStatus(t *testing.T) {
+	t.Parallel()
+
+	pr := createTestPipelineRun("failed", corev1.ConditionFalse)
+	taskStatus := &pipelineapi.TaskRunStatus{}
+	taskStatus.SetCondition(&knativeapis.Condition{
+		Type:    knativeapis.ConditionSucceeded,
+		Status:  corev1.ConditionFalse,
+		Message: "build step failed",
+	})
+	pr.Status.TaskRuns = map[string]*pipelineapi.PipelineRunTaskRunStatus{
+		"failed-build": {
+			PipelineTaskName: "build",
+			Status:           taskStatus,
+		},
+		"failed-approve": {
+			PipelineTaskName: "approve",
+		},
+	}
+
+	status := pipelineRunStatus(pr)
+	assert.Equal(t, pipelineRunStatusFailed, status.Status)
+	assert.Equal(t, []TaskStatusSummary{
+		{Name: "failed-approve", TaskName: "approve", Status: pipelineRunStatusPending},
+		{Name: "failed-build", TaskName: "build", Status: pipelineRunStatusFailed, Message: "build step failed"},
+	}, status.TaskRuns)
+}
+
+func TestPipelineRunRequestIsRecordedForRerun(t *testing.T) {
+	t.Parallel()
+
+	tektonClient := tektonfake.NewSimpleClientset(createTestPipelineRun("myorg-myapp-master-1", corev1.ConditionTrue))
+
+	_, err := pipelineRunRequest(tektonClient, testPipelineRunNamespace, "myorg-myapp-master-1")
+	assert.Error(t, err, "the PipelineRun was not started by the pipeline runner")
+
+	request := PipelineRunRequest{
+		Labels: map[string]string{jobLabel: "cdf89f04"},
+	}
+	request.ProwJobSpec.Context = "serverless-jenkins"
+	err = annotatePipelineRun(tektonClient, testPipelineRunNamespace, "myorg-myapp-master-1", request)
+	require.NoError(t, err)
+
+	rerun, err := pipelineRunRequest(tektonClient, testPipelineRunNamespace, "myorg-myapp-master-1")
+	require.NoError(t, err)
+	assert.Equal(t, "cdf89f04", rerun.Labels[jobLabel])
+	assert.Equal(t, "serverless-jenkins", rerun.ProwJobSpec.Context)
+}
@@ -0,0 +1,323 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/pkg/errors"
+	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+)
+
+const (
+	// webhookPath is the URL path for the HTTP endpoint that starts pipelines from git provider webhooks
+	webhookPath = "/hook"
+
+	// webhookSecretName is the Secret storing the hmac token git providers sign their webhooks with
+	webhookSecretName = "hmac-token"
+	// webhookSecretKey is the key of the hmac token in the Secret
+	webhookSecretKey = "hmac"
+
+	// webhookJob is the name of the job of pipelines started by webhooks
+	webhookJob = "jenkins-x"
+	// webhookLabel is the label on the pipelines started by webhooks whose value is the kind of git provider
+	webhookLabel = "created-by-webhook"
+	// webhookStatusContext is the context of the commit statuses reported for pipelines started by webhooks
+	webhookStatusContext = "jenkins-x"
+
+	webhookStatusPollPeriod = 30 * time.Second
+	webhookStatusTimeout    = 4 * time.Hour
+	// maxWebhookReporters is how many pipelines started by webhooks can have their results reported at once
+	maxWebhookReporters = 100
+	// webhookSecretRefreshPeriod is how often the webhook secret is reloaded so that it can be rotated
+	webhookSecretRefreshPeriod = 5 * time.Minute
+)
+
+// webhook handles push and Pull Request webhooks sent directly by git providers
+func (o *PipelineRunnerOptions) webhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responseHTTPError(w, http.StatusInternalServerError, "500 Internal Server Error: Failed to read request body")
+		return
+	}
+	secret, err := o.getWebhookSecret()
+	if err != nil {
+		logger.Errorf("unable to load the webhook secret: %s", err)
+		responseHTTPError(w, http.StatusInternalServerError, "500 Internal Server Error: Failed to load the webhook secret")
+		return
+	}
+	event, err := gits.ParseWebHook(r.Header, payload, secret)
+	if err == gits.ErrInvalidWebHookSignature {
+		responseHTTPError(w, http.StatusUnauthorized, "401 Unauthorized: Invalid webhook signature")
+		return
+	}
+	if err != nil {
+		o.returnStatusBadRequest(err, "could not parse the webhook: "+err.Error(), w)
+		return
+	}
+	if event == nil || !event.ShouldBuild() {
+		_, err = fmt.Fprintf(w, "ignoring webhook\n")
+		if err != nil {
+			logger.Errorf("unable to write response to webhook: %s", err.Error())
+		}
+		return
+	}
+
+	provider, err := o.webhookGitProvider(event)
+	if err != nil {
+		logger.Warnf("unable to create the git provider for %s: %s", event.CloneURL, err)
+		responseHTTPError(w, http.StatusInternalServerError, "500 Internal Server Error: Failed to create the git provider")
+		return
+	}
+	if event.IsPullRequest() && !trustedPullRequest(provider, event) {
+		logger.Infof("not building Pull Request %d of %s/%s from untrusted user %s", event.PullRequestNumber, event.Owner, event.Repo, event.Author)
+		reportCommitStatus(provider, event, "pending", fmt.Sprintf("Waiting for the %s label to be added", gits.OkToTestLabel))
+		_, err = fmt.Fprintf(w, "ignoring webhook from untrusted user %s\n", event.Author)
+		if err != nil {
+			logger.Errorf("unable to write response to webhook: %s", err.Error())
+		}
+		return
+	}
+
+	request := webhookPipelineRunRequest(event, uuid.New().String())
+	reportCommitStatus(provider, event, "pending", "Pipeline starting")
+	response, err := o.startPipeline(request)
+	if err != nil {
+		reportCommitStatus(provider, event, "error", "Failed to start the pipeline")
+		o.returnStatusBadRequest(err, "could not start pipeline: "+err.Error(), w)
+		return
+	}
+	o.startWebhookReporter(provider, event, request.Labels[jobLabel])
+	o.writeResponse(response, w)
+}
+
+// getWebhookSecret loads the hmac token git providers sign their webhooks with, reloading it periodically so that
+// the Secret can be rotated without restarting
+func (o *PipelineRunnerOptions) getWebhookSecret() ([]byte, error) {
+	o.webhookSecretLock.Lock()
+	defer o.webhookSecretLock.Unlock()
+	if len(o.webhookSecret) > 0 && time.Since(o.webhookSecretLoaded) < webhookSecretRefreshPeriod {
+		return o.webhookSecret, nil
+	}
+	token, err := o.loadWebhookSecret()
+	if err != nil {
+		if len(o.webhookSecret) > 0 {
+			logger.Warnf("unable to reload the webhook secret so using the previous one: %s", err)
+			return o.webhookSecret, nil
+		}
+		return nil, err
+	}
+	o.webhookSecret = token
+	o.webhookSecretLoaded = time.Now()
+	return token, nil
+}
+
+// loadWebhookSecret reads the hmac token from its Secret
+func (o *PipelineRunnerOptions) loadWebhookSecret() ([]byte, error) {
+	kubeClient, ns, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get(webhookSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get Secret %s in namespace %s", webhookSecretName, ns)
+	}
+	token := secret.Data[webhookSecretKey]
+	if len(token) == 0 {
+		return nil, fmt.Errorf("no %s key in Secret %s in namespace %s", webhookSecretKey, webhookSecretName, ns)
+	}
+	return token, nil
+}
+
+// webhookGitProvider creates the git provider of the repository of the webhook event
+func (o *PipelineRunnerOptions) webhookGitProvider(event *gits.WebHookEvent) (gits.GitProvider, error) {
+	gitInfo, err := gits.ParseGitURL(event.CloneURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse git URL %s", event.CloneURL)
+	}
+	return o.GitProviderForGitServerURL(gitInfo.HostURL(), event.Kind)
+}
+
+// trustedPullRequest returns true if the Pull Request was opened by the owner of the repository, a collaborator or a
+// member of the organisation, or has been approved for building with the ok-to-test label. This stops anyone who
+// can open a Pull Request from running pipelines with the secrets of the cluster
+func trustedPullRequest(provider gits.GitProvider, event *gits.WebHookEvent) bool {
+	if event.Action == gits.WebHookActionLabeled && event.Label == gits.OkToTestLabel {
+		return true
+	}
+	author := event.Author
+	if author != "" {
+		if strings.EqualFold(author, event.Owner) {
+			return true
+		}
+		if checker, ok := provider.(gits.CollaboratorChecker); ok {
+			collaborator, err := checker.IsCollaborator(event.Owner, event.Repo, author)
+			if err != nil {
+				logger.Debugf("unable to check if %s is a collaborator of %s/%s: %s", author, event.Owner, event.Repo, err)
+			}
+			if collaborator {
+				return true
+			}
+		}
+		if checker, ok := provider.(gits.OrganisationChecker); ok {
+			member, err := checker.IsUserInOrganisation(author, event.Owner)
+			if err != nil {
+				logger.Debugf("unable to check if %s is a member of %s: %s", author, event.Owner, err)
+			}
+			if member {
+				return true
+			}
+		}
+	}
+	pr, err := provider.GetPullRequest(event.Owner, &gits.GitRepository{Name: event.Repo}, event.PullRequestNumber)
+	if err != nil {
+		logger.Warnf("unable to get Pull Request %d of %s/%s to check for the %s label: %s", event.PullRequestNumber, event.Owner, event.Repo, gits.OkToTestLabel, err)
+		return false
+	}
+	for _, label := range pr.Labels {
+		if label != nil && label.Name != nil && *label.Name == gits.OkToTestLabel {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookPipelineRunRequest creates the request to start the pipeline of the webhook event as if it came from Prow
+func webhookPipelineRunRequest(event *gits.WebHookEvent, job string) PipelineRunRequest {
+	refs := &prowapi.Refs{
+		Org:      event.Owner,
+		Repo:     event.Repo,
+		BaseRef:  event.Branch,
+		BaseSHA:  event.BaseSHA,
+		CloneURI: event.CloneURL,
+	}
+	spec := prowapi.ProwJobSpec{
+		Job:  webhookJob,
+		Refs: refs,
+	}
+	if event.IsPullRequest() {
+		spec.Type = prowapi.PresubmitJob
+		refs.Pulls = []prowapi.Pull{
+			{
+				Number: event.PullRequestNumber,
+				SHA:    event.SHA,
+			},
+		}
+	} else {
+		spec.Type = prowapi.PostsubmitJob
+		refs.BaseSHA = event.SHA
+	}
+	return PipelineRunRequest{
+		Labels: map[string]string{
+			jobLabel:     job,
+			webhookLabel: event.Kind,
+		},
+		ProwJobSpec: spec,
+	}
+}
+
+// reportCommitStatus reports the commit status of the pipeline of the webhook event back to the git provider
+func reportCommitStatus(provider gits.GitProvider, event *gits.WebHookEvent, state string, description string) {
+	status := &gits.GitRepoStatus{
+		Context:     webhookStatusContext,
+		State:       state,
+		Description: description,
+	}
+	_, err := provider.UpdateCommitStatus(event.Owner, event.Repo, event.SHA, status)
+	if err != nil {
+		logger.Warnf("unable to report the commit status of %s/%s at %s: %s", event.Owner, event.Repo, event.SHA, err)
+	}
+}
+
+// startWebhookReporter reports the result of the job in the background unless too many results are already being
+// reported. Reporting stops when the server shuts down
+func (o *PipelineRunnerOptions) startWebhookReporter(provider gits.GitProvider, event *gits.WebHookEvent, job string) {
+	select {
+	case o.webhookReporters <- struct{}{}:
+	default:
+		logger.Warnf("not reporting the result of job %s as the results of %d pipelines are already being reported", job, cap(o.webhookReporters))
+		return
+	}
+	go func() {
+		defer func() { <-o.webhookReporters }()
+		o.reportWebhookResult(o.webhookCtx, provider, event, job)
+	}()
+}
+
+// reportWebhookResult waits for the PipelineRuns of the job to complete and reports their result back to the git
+// provider as there is no Prow to do so
+func (o *PipelineRunnerOptions) reportWebhookResult(ctx context.Context, provider gits.GitProvider, event *gits.WebHookEvent, job string) {
+	tektonClient, ns, err := o.TektonClient()
+	if err != nil {
+		logger.Warnf("unable to create the tekton client to report the result of job %s: %s", job, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookStatusTimeout)
+	defer cancel()
+	selector := fmt.Sprintf("%s=%s", jobLabel, job)
+	reported := "pending"
+	for {
+		prList, err := tektonClient.TektonV1alpha1().PipelineRuns(ns).List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			logger.Warnf("unable to list the PipelineRuns of job %s: %s", job, err)
+		} else {
+			state, description, done := webhookPipelineState(prList.Items)
+			if state != reported {
+				reportCommitStatus(provider, event, state, description)
+				reported = state
+			}
+			if done {
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				reportCommitStatus(provider, event, "error", "Timed out waiting for the pipeline")
+			}
+			return
+		case <-time.After(webhookStatusPollPeriod):
+		}
+	}
+}
+
+// webhookPipelineState returns the commit status of the PipelineRuns of a job and whether they have all completed.
+// The meta pipeline which creates the build pipeline is only used if it fails
+func webhookPipelineState(prs []pipelineapi.PipelineRun) (string, string, bool) {
+	builds := 0
+	completed := 0
+	for i := range prs {
+		pr := &prs[i]
+		status := pipelineRunStatus(pr).Status
+		finished := status == pipelineRunStatusSucceeded || status == pipelineRunStatusFailed || status == pipelineRunStatusCancelled
+		if strings.HasPrefix(pr.Name, "metapipeline-") {
+			if finished && status != pipelineRunStatusSucceeded {
+				return "failure", "Failed to create the pipeline", true
+			}
+			continue
+		}
+		builds++
+		if !finished {
+			continue
+		}
+		if status != pipelineRunStatusSucceeded {
+			return "failure", fmt.Sprintf("Pipeline %s", strings.ToLower(status)), true
+		}
+		completed++
+	}
+	if builds > 0 && completed == builds {
+		return "success", "Pipeline succeeded", true
+	}
+	return "pending", "Pipeline running", false
+}
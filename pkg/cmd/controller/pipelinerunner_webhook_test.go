@@ -0,0 +1,142 @@
+// +build !integration
+
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+)
+
+func TestWebhookPipelineRunRequest(t *testing.T) {
+	t.Parallel()
+
+	event := &gits.WebHookEvent{
+		Kind:              gits.KindGitea,
+		Event:             gits.WebHookEventPullRequest,
+		Action:            gits.WebHookActionOpened,
+		Owner:             "myorg",
+		Repo:              "myapp",
+		CloneURL:          "https://gitea.example.com/myorg/myapp.git",
+		Branch:            "master",
+		SHA:               "06b5fa6804aa0bd1f4f533010d1b335918a433e2",
+		BaseSHA:           "3f00363d651280ab2a8ee67f395de1689156d762",
+		PullRequestNumber: 3,
+	}
+	request := webhookPipelineRunRequest(event, "cdf89f04")
+	assert.Equal(t, "cdf89f04", request.Labels[jobLabel])
+	assert.Equal(t, gits.KindGitea, request.Labels[webhookLabel])
+	assert.Equal(t, prowapi.PresubmitJob, request.ProwJobSpec.Type)
+	refs := request.ProwJobSpec.Refs
+	require.NotNil(t, refs)
+	assert.Equal(t, "https://gitea.example.com/myorg/myapp.git", refs.CloneURI)
+	assert.Equal(t, "3f00363d651280ab2a8ee67f395de1689156d762", refs.BaseSHA)
+	assert.Equal(t, []prowapi.Pull{{Number: 3, SHA: "06b5fa6804aa0bd1f4f533010d1b335918a433e2"}}, refs.Pulls)
+	assert.Equal(t, "PR-3", (&PipelineRunnerOptions{}).getBranch(request.ProwJobSpec))
+
+	event.Event = gits.WebHookEventPush
+	request = webhookPipelineRunRequest(event, "cdf89f04")
+	assert.Equal(t, prowapi.PostsubmitJob, request.ProwJobSpec.Type)
+	assert.Empty(t, request.ProwJobSpec.Refs.Pulls)
+	assert.Equal(t, "06b5fa6804aa0bd1f4f533010d1b335918a433e2", request.ProwJobSpec.Refs.BaseSHA)
+	assert.Equal(t, "master", (&PipelineRunnerOptions{}).getBranch(request.ProwJobSpec))
+}
+
+func TestWebhookPipelineState(t *testing.T) {
+	t.Parallel()
+
+	meta := *createTestPipelineRun("metapipeline-myorg-myapp-pr-3-1", corev1.ConditionTrue)
+	state, _, done := webhookPipelineState([]pipelineapi.PipelineRun{meta})
+	assert.Equal(t, "pending", state, "the build pipeline has not been created yet")
+	assert.False(t, done)
+
+	build := *createTestPipelineRun("myorg-myapp-pr-3-1", corev1.ConditionUnknown)
+	state, _, done = webhookPipelineState([]pipelineapi.PipelineRun{meta, build})
+	assert.Equal(t, "pending", state)
+	assert.False(t, done)
+
+	build = *createTestPipelineRun("myorg-myapp-pr-3-1", corev1.ConditionTrue)
+	state, _, done = webhookPipelineState([]pipelineapi.PipelineRun{meta, build})
+	assert.Equal(t, "success", state)
+	assert.True(t, done)
+
+	build = *createTestPipelineRun("myorg-myapp-pr-3-1", corev1.ConditionFalse)
+	state, description, done := webhookPipelineState([]pipelineapi.PipelineRun{meta, build})
+	assert.Equal(t, "failure", state)
+	assert.Equal(t, "Pipeline failed", description)
+	assert.True(t, done)
+
+	meta = *createTestPipelineRun("metapipeline-myorg-myapp-pr-3-1", corev1.ConditionFalse)
+	state, _, done = webhookPipelineState([]pipelineapi.PipelineRun{meta})
+	assert.Equal(t, "failure", state)
+	assert.True(t, done)
+}
+
+func TestTrustedPullRequest(t *testing.T) {
+	t.Parallel()
+
+	repo := gits.NewFakeRepository("myorg", "myapp")
+	okToTest := gits.OkToTestLabel
+	repo.PullRequests = map[int]*gits.FakePullRequest{
+		1: {PullRequest: &gits.GitPullRequest{}},
+		2: {PullRequest: &gits.GitPullRequest{Labels: []*gits.Label{{Name: &okToTest}}}},
+	}
+	provider := gits.NewFakeProvider(repo)
+	event := func(number int, author string) *gits.WebHookEvent {
+		return &gits.WebHookEvent{
+			Kind:              gits.KindGitHub,
+			Event:             gits.WebHookEventPullRequest,
+			Action:            gits.WebHookActionOpened,
+			Owner:             "myorg",
+			Repo:              "myapp",
+			PullRequestNumber: number,
+			Author:            author,
+		}
+	}
+
+	assert.True(t, trustedPullRequest(provider, event(1, "myorg")), "the owner of the repository is trusted")
+	assert.False(t, trustedPullRequest(provider, event(1, "stranger")))
+	assert.True(t, trustedPullRequest(provider, event(2, "stranger")), "the Pull Request has the ok-to-test label")
+	assert.False(t, trustedPullRequest(provider, event(3, "stranger")), "the Pull Request cannot be found")
+
+	labeled := event(1, "stranger")
+	labeled.Action = gits.WebHookActionLabeled
+	labeled.Label = gits.OkToTestLabel
+	assert.True(t, trustedPullRequest(provider, labeled), "the ok-to-test label was just added")
+}
+
+func TestWebhookValidatesSignature(t *testing.T) {
+	t.Parallel()
+
+	o := &PipelineRunnerOptions{
+		webhookSecret:       []byte("my-hmac-token"),
+		webhookSecretLoaded: time.Now(),
+	}
+	send := func(payload []byte, signature string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, webhookPath, bytes.NewReader(payload))
+		r.Header.Set("X-GitHub-Event", "ping")
+		if signature != "" {
+			r.Header.Set("X-Hub-Signature", signature)
+		}
+		w := httptest.NewRecorder()
+		o.webhook(w, r)
+		return w
+	}
+
+	payload := []byte(`{"zen": "Keep it logically awesome."}`)
+	assert.Equal(t, http.StatusUnauthorized, send(payload, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, send(payload, PayloadSignature(payload, []byte("wrong-token"))).Code)
+
+	w := send(payload, PayloadSignature(payload, []byte("my-hmac-token")))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "ignoring webhook")
+}
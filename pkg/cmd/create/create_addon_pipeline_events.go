@@ -20,6 +20,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/log"
+	pipeline_events "github.com/jenkins-x/jx/pkg/pipeline_events"
 	"github.com/jenkins-x/jx/pkg/util"
 )
 
@@ -44,6 +45,12 @@ var (
 
 		# Create the pipeline-events addon in a custom namespace
 		jx create addon pipeline-events -n mynamespace
+
+		# Post the pipeline events as CloudEvents to a webhook
+		jx create addon pipeline-events --provider webhook --url https://events.example.com/jx --token mytoken
+
+		# Write the pipeline events as CloudEvents to the standard output, which is useful for testing
+		jx create addon pipeline-events --provider file
 	`)
 )
 
@@ -51,6 +58,10 @@ var (
 type CreateAddonPipelineEventsOptions struct {
 	CreateAddonOptions
 	Password string
+	Provider string
+	URL      string
+	Token    string
+	File     string
 }
 
 // NewCmdCreateAddonPipelineEvents creates a command object for the "create" command
@@ -80,11 +91,31 @@ func NewCmdCreateAddonPipelineEvents(commonOpts *opts.CommonOptions) *cobra.Comm
 	options.addFlags(cmd, defaultPENamespace, defaultPEReleaseName, defaultPEVersion)
 
 	cmd.Flags().StringVarP(&options.Password, "password", "p", "", "Password to access pipeline-events services such as Kibana and Elasticsearch.  Defaults to default Jenkins X admin password.")
+	cmd.Flags().StringVarP(&options.Provider, "provider", "", pipeline_events.ProviderKindElasticsearch, "The provider to send the pipeline events to. Valid values: "+strings.Join(pipeline_events.ProviderKinds, ", "))
+	cmd.Flags().StringVarP(&options.URL, "url", "", "", "The URL of the webhook to post the events to when using the '"+pipeline_events.ProviderKindWebhook+"' provider")
+	cmd.Flags().StringVarP(&options.Token, "token", "", "", "The bearer token to send to the webhook when using the '"+pipeline_events.ProviderKindWebhook+"' provider")
+	cmd.Flags().StringVarP(&options.File, "file", "", "", "The file to write the events to when using the '"+pipeline_events.ProviderKindFile+"' provider. Defaults to the standard output")
 	return cmd
 }
 
 // Run implements the command
 func (o *CreateAddonPipelineEventsOptions) Run() error {
+	switch o.Provider {
+	case pipeline_events.ProviderKindWebhook:
+		if o.URL == "" {
+			return util.MissingOption("url")
+		}
+		return o.saveEventsServer(o.URL, pipeline_events.ProviderKindWebhook)
+	case pipeline_events.ProviderKindFile:
+		file := o.File
+		if file == "" {
+			file = "-"
+		}
+		return o.saveEventsServer(opts.PipelineEventsFilePrefix+file, pipeline_events.ProviderKindFile)
+	case pipeline_events.ProviderKindElasticsearch, "":
+	default:
+		return util.InvalidOption("provider", o.Provider, pipeline_events.ProviderKinds)
+	}
 
 	if o.ReleaseName == "" {
 		return util.MissingOption(optionRelease)
@@ -202,6 +233,30 @@ func (o *CreateAddonPipelineEventsOptions) Run() error {
 	log.Logger().Infof("kibana is available and running %s", kIng)
 	return nil
 }
+
+// saveEventsServer saves the server the pipeline events are sent to in the addon auth configuration and makes it the
+// current server, so that it is used instead of any other pipeline events server
+func (o *CreateAddonPipelineEventsOptions) saveEventsServer(url string, name string) error {
+	authConfigSvc, err := o.CreateAddonAuthConfigService()
+	if err != nil {
+		return err
+	}
+	config := authConfigSvc.Config()
+	server := config.GetOrCreateServerName(url, name, kube.ValueKindPipelineEvent)
+	server.Name = name
+	if o.Token != "" {
+		userAuth := config.GetOrCreateUserAuth(url, "jx")
+		userAuth.ApiToken = o.Token
+	}
+	config.CurrentServer = url
+	err = authConfigSvc.SaveConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to save the pipeline events configuration")
+	}
+	log.Logger().Infof("pipeline events will be sent to %s using the %s provider", util.ColorInfo(url), util.ColorInfo(name))
+	return nil
+}
+
 func (o *CreateAddonPipelineEventsOptions) addExposecontrollerAnnotations(serviceName string) error {
 	client, err := o.KubeClient()
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/pkg/errors"
@@ -22,6 +23,9 @@ var (
 		# Add a new Git server with a name
 		jx create git server -k bitbucketcloud -u http://bitbucket.org -n MyBitBucket 
 
+		# Add Azure DevOps Services
+		jx create git server azuredevops
+
 		For more documentation see: [https://jenkins-x.io/developing/git/](https://jenkins-x.io/developing/git/)
 
 	`)
@@ -29,6 +33,10 @@ var (
 	gitKindToServiceName = map[string]string{
 		"gitea": "gitea-gitea",
 	}
+
+	gitKindToDefaultURL = map[string]string{
+		gits.KindAzureDevOps: gits.AzureDevOpsURL,
+	}
 )
 
 // CreateGitServerOptions the options for the create spring command
@@ -95,6 +103,8 @@ func (o *CreateGitServerOptions) Run() error {
 					return errors.Wrapf(err, "Failed to find %s Git service %s", kind, serviceName)
 				}
 				gitUrl = url
+			} else {
+				gitUrl = gitKindToDefaultURL[kind]
 			}
 		}
 	}
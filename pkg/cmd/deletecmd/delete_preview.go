@@ -11,6 +11,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/log"
+	pipeline_events "github.com/jenkins-x/jx/pkg/pipeline_events"
 	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/spf13/cobra"
 )
@@ -135,5 +136,16 @@ func (o *DeletePreviewOptions) DeletePreview(name string) error {
 		DeleteNamespace: true,
 	}
 	deleteOptions.Args = []string{name}
-	return deleteOptions.Run()
+	err = deleteOptions.Run()
+	if err != nil {
+		return err
+	}
+
+	o.SendPipelineEvent(func(provider pipeline_events.PipelineEventsProvider) error {
+		return provider.SendPreview(&pipeline_events.PreviewEvent{
+			Action:      pipeline_events.PreviewDeleted,
+			Environment: environment,
+		})
+	})
+	return nil
 }
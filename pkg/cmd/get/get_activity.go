@@ -1,6 +1,7 @@
 package get
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -198,12 +199,15 @@ func (o *GetActivityOptions) addStepRow(table *tbl.Table, parent *v1.PipelineAct
 	stage := parent.Stage
 	preview := parent.Preview
 	promote := parent.Promote
+	approval := parent.Approval
 	if stage != nil {
 		addStageRow(table, stage, indent)
 	} else if preview != nil {
 		addPreviewRow(table, preview, indent)
 	} else if promote != nil {
 		addPromoteRow(table, promote, indent)
+	} else if approval != nil {
+		addApprovalRow(table, approval, indent)
 	} else {
 		log.Logger().Warnf("Unknown step kind %#v", parent)
 	}
@@ -240,6 +244,10 @@ func addPromoteRow(table *tbl.Table, parent *v1.PromoteActivityStep, indent stri
 	addStepRowItem(table, &parent.CoreActivityStep, indent, "Promote: "+parent.Environment, "")
 	indent += indentation
 
+	if parent.BlockedReason != "" {
+		addStepRowItem(table, &parent.CoreActivityStep, indent, "Blocked", util.ColorWarning(parent.BlockedReason))
+	}
+
 	pullRequest := parent.PullRequest
 	update := parent.Update
 	if pullRequest != nil {
@@ -248,12 +256,24 @@ func addPromoteRow(table *tbl.Table, parent *v1.PromoteActivityStep, indent stri
 	if update != nil {
 		addStepRowItem(table, &update.CoreActivityStep, indent, "Update", describePromoteUpdate(update))
 	}
+	canary := parent.Canary
+	if canary != nil {
+		addStepRowItem(table, &canary.CoreActivityStep, indent, "Canary", describePromoteCanary(canary))
+	}
 	appURL := parent.ApplicationURL
 	if appURL != "" {
 		addStepRowItem(table, &update.CoreActivityStep, indent, "Promoted", " Application is at: "+util.ColorInfo(appURL))
 	}
 }
 
+func addApprovalRow(table *tbl.Table, parent *v1.ApprovalActivityStep, indent string) {
+	description := ""
+	if parent.Approver != "" {
+		description = "by " + util.ColorInfo(parent.Approver)
+	}
+	addStepRowItem(table, &parent.CoreActivityStep, indent, "Approval", description)
+}
+
 func addStepRowItem(table *tbl.Table, step *v1.CoreActivityStep, indent string, name string, description string) {
 	text := step.Description
 	if description != "" {
@@ -314,6 +334,32 @@ func describePromoteUpdate(promote *v1.PromoteUpdateStep) string {
 	return description
 }
 
+func describePromoteCanary(canary *v1.PromoteCanaryStep) string {
+	description := ""
+	if canary.Phase != "" {
+		description += " Phase: " + canaryPhaseString(canary.Phase)
+	}
+	description += fmt.Sprintf(" Weight: %s%%", util.ColorInfo(canary.Weight))
+	if canary.FailedChecks > 0 {
+		description += fmt.Sprintf(" Failed checks: %s", util.ColorWarning(canary.FailedChecks))
+	}
+	if canary.Message != "" {
+		description += " " + canary.Message
+	}
+	return description
+}
+
+func canaryPhaseString(phase string) string {
+	switch phase {
+	case "Succeeded", "Initialized":
+		return util.ColorInfo(phase)
+	case "Failed":
+		return util.ColorError(phase)
+	default:
+		return util.ColorStatus(phase)
+	}
+}
+
 func pullRequestStatusString(text string) string {
 	title := strings.Title(text)
 	switch text {
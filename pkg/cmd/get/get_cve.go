@@ -1,7 +1,6 @@
 package get
 
 import (
-	"github.com/jenkins-x/jx/pkg/cmd/create"
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
 	"github.com/spf13/cobra"
 
@@ -11,7 +10,6 @@ import (
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/pkg/cve"
 	"github.com/jenkins-x/jx/pkg/kube"
-	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
 )
 
@@ -23,6 +21,7 @@ type GetCVEOptions struct {
 	Version           string
 	Env               string
 	VulnerabilityType string
+	CVEProvider       opts.CVEProviderOptions
 }
 
 var (
@@ -39,6 +38,9 @@ var (
 		jx get cve --app foo --version 1.0.0
 		jx get cve --app foo --environment staging
 		jx get cve --environment staging
+
+		# List the CVEs found in an image by Trivy or Grype
+		jx get cve --provider report --report trivy.json --image-name foo
 	`)
 )
 
@@ -74,6 +76,7 @@ func (o *GetCVEOptions) addGetCVEFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.ImageID, "image-id", "", "", "Image ID in CVE engine if already known")
 	cmd.Flags().StringVarP(&o.Version, "version", "", "", "Version or tag e.g. 0.0.1")
 	cmd.Flags().StringVarP(&o.Env, "environment", "e", "", "The Environment to find running applications")
+	o.CVEProvider.AddCVEProviderFlags(cmd)
 }
 
 // Run implements this command
@@ -89,25 +92,14 @@ func (o *GetCVEOptions) Run() error {
 		return fmt.Errorf("cannot create jx client: %v", err)
 	}
 
-	externalURL, err := o.EnsureAddonServiceAvailable(kube.AddonServices[create.DefaultAnchoreName])
-	if err != nil {
-		log.Logger().Warnf("no CVE provider service found, are you in your teams dev environment?  Type `jx env` to switch.")
-		return fmt.Errorf("if no CVE provider running, try running `jx create addon anchore` in your teams dev environment: %v", err)
-	}
-
 	// if no flags are set try and guess the image name from the current directory
 	if o.ImageID == "" && o.ImageName == "" && o.Env == "" {
 		return fmt.Errorf("no --image-name, --image-id or --environment flags set\n")
 	}
 
-	server, auth, err := o.GetAddonAuthByKind(kube.ValueKindCVE, externalURL)
-	if err != nil {
-		return fmt.Errorf("error getting anchore engine auth details, %v", err)
-	}
-
-	p, err := cve.NewAnchoreProvider(server, auth)
+	p, err := o.CreateCVEProvider(&o.CVEProvider)
 	if err != nil {
-		return fmt.Errorf("error creating anchore provider, %v", err)
+		return err
 	}
 	table := o.CreateTable()
 	table.AddRow("Image", util.ColorInfo("Severity"), "Vulnerability", "URL", "Package", "Fix")
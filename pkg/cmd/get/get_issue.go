@@ -137,8 +137,7 @@ func (o *GetIssueOptions) findRelease(tracker issues.IssueProvider, issue *gits.
 			if pr.URL == issue.URL {
 				return &rel
 			} else {
-				issueKind := issues.GetIssueProvider(tracker)
-				issueIDs := o.parseIssueIDs(pr, issueKind)
+				issueIDs := o.parseIssueIDs(pr, issues.IssueKeyRegex(tracker))
 				issueURLs := o.convertIssueIDsToURLs(tracker, issueIDs)
 				for _, issueURL := range issueURLs {
 					if issueURL == issue.URL {
@@ -158,11 +157,7 @@ func (o *GetIssueOptions) findRelease(tracker issues.IssueProvider, issue *gits.
 	return nil
 }
 
-func (o *GetIssueOptions) parseIssueIDs(issue v1.IssueSummary, issueKind string) []string {
-	regex := regexp.MustCompile(`(\#\d+)`)
-	if issueKind == issues.Jira {
-		regex = regexp.MustCompile(`[A-Z][A-Z]+-(\d+)`)
-	}
+func (o *GetIssueOptions) parseIssueIDs(issue v1.IssueSummary, regex *regexp.Regexp) []string {
 	issues := []string{}
 	foundIssues := map[string]bool{}
 	matches := regex.FindAllStringSubmatch(issue.Body, -1)
@@ -5,11 +5,13 @@ import (
 
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
 
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/workflow"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -18,7 +20,8 @@ import (
 type GetWorkflowOptions struct {
 	GetOptions
 
-	Name string
+	Name     string
+	Activity string
 }
 
 var (
@@ -32,6 +35,9 @@ var (
 
 		# Display a specific workflow
 		jx get workflow -n default
+
+		# Display the state of the steps of a workflow for a pipeline activity
+		jx get workflow -n default -a myorg-myapp-master-1
 	`)
 )
 
@@ -56,6 +62,7 @@ func NewCmdGetWorkflow(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVarP(&options.Name, "name", "n", "", "The name of the workflow to display")
+	cmd.Flags().StringVarP(&options.Activity, "activity", "a", "", "The name of the PipelineActivity to display the state of the workflow steps for")
 
 	options.AddGetFlags(cmd)
 	return cmd
@@ -94,42 +101,79 @@ func (o *GetWorkflowOptions) Run() error {
 }
 
 func (o *GetWorkflowOptions) getWorkflow(name string, jxClient versioned.Interface, ns string) error {
-	workflow, err := workflow.GetWorkflow(name, jxClient, ns)
+	flow, err := workflow.GetWorkflow(name, jxClient, ns)
+	if err != nil {
+		return err
+	}
+	stages, err := workflow.WorkflowStages(flow)
 	if err != nil {
 		return err
 	}
 
-	log.Logger().Infof("Workflow: %s", workflow.Name)
+	var activity *v1.PipelineActivity
+	if o.Activity != "" {
+		activity, err = jxClient.JenkinsV1().PipelineActivities(ns).Get(o.Activity, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get PipelineActivity %s", o.Activity)
+		}
+	}
+
+	log.Logger().Infof("Workflow: %s", flow.Name)
 	lines := []*StepSummary{}
-	var lastSummary *StepSummary
-	for _, step := range workflow.Spec.Steps {
-		promote := step.Promote
-		if promote != nil {
-			if len(step.Preconditions.Environments) > 0 {
-				lastSummary = nil
+	for _, stage := range stages {
+		var promoteSummary *StepSummary
+		for _, step := range stage {
+			text := o.describeStep(flow, activity, step)
+			if step.Approval != nil {
+				lines = append(lines, &StepSummary{
+					Action:    "approve",
+					Resources: []string{text},
+				})
+				continue
 			}
-			if lastSummary == nil {
-				lastSummary = &StepSummary{
-					Action: "promote",
-					// Resources: []string{},
+			if promoteSummary == nil {
+				promoteSummary = &StepSummary{
+					Action: "promote to",
 				}
-				lines = append(lines, lastSummary)
-			}
-			lastSummary.Resources = append(lastSummary.Resources, promote.Environment)
-			if len(step.Preconditions.Environments) > 0 {
-				lastSummary = nil
+				lines = append(lines, promoteSummary)
 			}
+			promoteSummary.Resources = append(promoteSummary.Resources, text)
 		}
 	}
 	for i, summary := range lines {
 		if i > 0 {
 			log.Logger().Info("    |")
 		}
-		log.Logger().Infof("%s to %s", summary.Action, strings.Join(summary.Resources, " + "))
+		log.Logger().Infof("%s %s", summary.Action, strings.Join(summary.Resources, " + "))
 	}
 	return nil
 }
 
+// describeStep returns the name of the step with its dependencies, timeout and the status of the step on the activity
+func (o *GetWorkflowOptions) describeStep(flow *v1.Workflow, activity *v1.PipelineActivity, step *v1.WorkflowStep) string {
+	text := workflow.StepName(step)
+	details := []string{}
+	if len(step.Preconditions.Steps) > 0 {
+		details = append(details, "after "+strings.Join(workflow.StepDependencies(flow, step), ", "))
+	}
+	if step.Timeout != "" {
+		details = append(details, "timeout "+step.Timeout)
+	}
+	if len(details) > 0 {
+		text += " (" + strings.Join(details, ", ") + ")"
+	}
+	if activity != nil {
+		status := workflow.GetStepStatus(activity, step)
+		if status == nil {
+			text += " " + statusString(v1.ActivityStatusTypePending)
+		} else {
+			text += " " + statusString(status.Status)
+		}
+	}
+	return text
+}
+
+// StepSummary is a line of the rendered workflow
 type StepSummary struct {
 	Action    string
 	Resources []string
@@ -1,8 +1,11 @@
 package opts
 
 import (
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/auth"
 	"github.com/jenkins-x/jx/pkg/chats"
 	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/log"
 )
 
 // CreateChatProvider creates a new chart provider from the given configuration
@@ -18,9 +21,50 @@ func (o *CommonOptions) CreateChatProvider(chatConfig *config.ChatConfig) (chats
 	config := authConfigSvc.Config()
 
 	server := config.GetOrCreateServer(u)
+	if server.Kind == "" {
+		server.Kind = chatConfig.Kind
+	}
 	userAuth, err := config.PickServerUserAuth(server, "user to access the chat service at "+u, o.BatchMode, "", o.In, o.Out, o.Err)
 	if err != nil {
 		return nil, err
 	}
 	return chats.CreateChatProvider(server.Kind, server, userAuth, o.BatchMode)
 }
+
+// CreateChatNotificationsProvider returns the team's chat notification settings and the provider to post the
+// notifications with, or nil settings if chat notifications are not configured for the team. It never prompts as it
+// is used from pipelines, so the user of the chat service is taken from the chat auth config
+func (o *CommonOptions) CreateChatNotificationsProvider() (*v1.ChatNotifications, chats.ChatProvider, error) {
+	settings, err := o.TeamSettings()
+	if err != nil {
+		return nil, nil, err
+	}
+	notifications := settings.ChatNotifications
+	if notifications == nil || notifications.URL == "" {
+		return nil, nil, nil
+	}
+	u := notifications.URL
+	authConfigSvc, err := o.CreateChatAuthConfigService()
+	if err != nil {
+		return nil, nil, err
+	}
+	config := authConfigSvc.Config()
+
+	server := config.GetOrCreateServer(u)
+	if server.Kind == "" {
+		server.Kind = notifications.Kind
+	}
+	var userAuth *auth.UserAuth
+	userAuths := config.FindUserAuths(u)
+	if len(userAuths) > 0 {
+		userAuth = userAuths[0]
+	} else {
+		// incoming webhooks may hold their token in the URL so carry on without a user
+		log.Logger().Warnf("No user found for the chat service at %s in the chat auth config", u)
+	}
+	provider, err := chats.CreateChatProvider(server.Kind, server, userAuth, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return notifications, provider, nil
+}
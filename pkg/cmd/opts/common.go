@@ -30,6 +30,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/helm"
 	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/log"
+	pipeline_events "github.com/jenkins-x/jx/pkg/pipeline_events"
 	"github.com/jenkins-x/jx/pkg/table"
 	"github.com/jenkins-x/jx/pkg/util"
 	certmngclient "github.com/jetstack/cert-manager/pkg/client/clientset/versioned"
@@ -137,6 +138,7 @@ type CommonOptions struct {
 	modifyDevEnvironmentFn ModifyDevEnvironmentFn
 	modifyEnvironmentFn    ModifyEnvironmentFn
 	NameServers            []string
+	pipelineEventsProvider pipeline_events.PipelineEventsProvider
 	resourcesInstaller     resources.Installer
 	systemVaultClient      vault.Client
 	tektonClient           tektonclient.Interface
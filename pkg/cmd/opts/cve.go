@@ -0,0 +1,50 @@
+package opts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/cve"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// CVEProviderOptions the flags for choosing which CVE provider to query
+type CVEProviderOptions struct {
+	Provider string
+	Reports  []string
+}
+
+// AddCVEProviderFlags adds the flags for choosing the CVE provider
+func (o *CVEProviderOptions) AddCVEProviderFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.Provider, "provider", "", cve.ProviderKindAnchore, "The CVE provider to query. Valid values: "+strings.Join(cve.ProviderKinds, ", "))
+	cmd.Flags().StringArrayVarP(&o.Reports, "report", "", nil, "The Trivy or Grype JSON report files to read when using the '"+cve.ProviderKindReport+"' provider")
+}
+
+// CreateCVEProvider creates the CVE provider chosen by the options
+func (o *CommonOptions) CreateCVEProvider(providerOptions *CVEProviderOptions) (cve.CVEProvider, error) {
+	switch providerOptions.Provider {
+	case cve.ProviderKindReport:
+		return cve.NewReportProvider(providerOptions.Reports)
+	case cve.ProviderKindAnchore, "":
+		externalURL, err := o.EnsureAddonServiceAvailable(kube.AddonServices[cve.ProviderKindAnchore])
+		if err != nil {
+			log.Logger().Warnf("no CVE provider service found, are you in your teams dev environment?  Type `jx env` to switch.")
+			return nil, fmt.Errorf("if no CVE provider running, try running `jx create addon anchore` in your teams dev environment: %v", err)
+		}
+
+		server, auth, err := o.GetAddonAuthByKind(kube.ValueKindCVE, externalURL)
+		if err != nil {
+			return nil, fmt.Errorf("error getting anchore engine auth details, %v", err)
+		}
+
+		p, err := cve.NewAnchoreProvider(server, auth)
+		if err != nil {
+			return nil, fmt.Errorf("error creating anchore provider, %v", err)
+		}
+		return p, nil
+	default:
+		return nil, fmt.Errorf("unknown CVE provider %s. Valid values: %s", providerOptions.Provider, strings.Join(cve.ProviderKinds, ", "))
+	}
+}
@@ -2,6 +2,7 @@ package opts
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/pkg/errors"
 
@@ -36,11 +37,13 @@ func (o *CommonOptions) CreateIssueProvider(dir string) (issues.IssueProvider, e
 	if err != nil {
 		return nil, err
 	}
+	projectDir := dir
 	if pc != nil && pc.IssueTracker == nil {
 		pc, _, err = config.LoadProjectConfig(gitDir)
 		if err != nil {
 			return nil, err
 		}
+		projectDir = gitDir
 	}
 	if pc != nil {
 		it := pc.IssueTracker
@@ -56,7 +59,18 @@ func (o *CommonOptions) CreateIssueProvider(dir string) (issues.IssueProvider, e
 				if err != nil {
 					return nil, err
 				}
-				return issues.CreateIssueProvider(it.Kind, server, userAuth, it.Project, o.BatchMode, o.Git())
+				configFile := it.Config
+				if configFile != "" && !filepath.IsAbs(configFile) {
+					configFile = filepath.Join(projectDir, configFile)
+				}
+				return issues.CreateIssueProviderWithArguments(it.Kind, &issues.ProviderArguments{
+					Server:     server,
+					UserAuth:   userAuth,
+					Project:    it.Project,
+					ConfigFile: configFile,
+					BatchMode:  o.BatchMode,
+					Git:        o.Git(),
+				})
 			}
 		}
 	}
@@ -0,0 +1,81 @@
+package opts
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	pipeline_events "github.com/jenkins-x/jx/pkg/pipeline_events"
+	"github.com/pkg/errors"
+)
+
+// PipelineEventsFilePrefix the prefix of the URL of an addon server which writes pipeline events to a file
+const PipelineEventsFilePrefix = "file://"
+
+// SetPipelineEventsProvider sets the provider used by SendPipelineEvent, which lets long running controllers create
+// the provider once rather than for every event. The caller is responsible for closing it.
+func (o *CommonOptions) SetPipelineEventsProvider(provider pipeline_events.PipelineEventsProvider) {
+	o.pipelineEventsProvider = provider
+}
+
+// CreatePipelineEventsProvider creates the pipeline events provider configured by `jx create addon pipeline-events`,
+// returning nil if there isn't one
+func (o *CommonOptions) CreatePipelineEventsProvider() (pipeline_events.PipelineEventsProvider, error) {
+	authConfigSvc, err := o.CreateAddonAuthConfigService()
+	if err != nil {
+		return nil, err
+	}
+	config := authConfigSvc.Config()
+
+	var server *auth.AuthServer
+	for _, s := range config.Servers {
+		if s.Kind == kube.ValueKindPipelineEvent && (server == nil || s.URL == config.CurrentServer) {
+			server = s
+		}
+	}
+	if server == nil {
+		return nil, nil
+	}
+
+	user := server.CurrentAuth()
+	switch {
+	case strings.HasPrefix(server.URL, PipelineEventsFilePrefix):
+		return pipeline_events.NewFileProvider(strings.TrimPrefix(server.URL, PipelineEventsFilePrefix))
+	case server.Name == pipeline_events.ProviderKindWebhook:
+		return pipeline_events.NewWebhookProvider(server, user)
+	default:
+		if user == nil {
+			return nil, errors.Errorf("no user found for the pipeline events server %s", server.URL)
+		}
+		return pipeline_events.NewElasticsearchProvider(server, user)
+	}
+}
+
+// SendPipelineEvent sends an event using the provider set with SetPipelineEventsProvider, otherwise using the
+// configured pipeline events provider if there is one. Failures are logged rather than returned so that pipelines
+// don't fail when the events can't be sent.
+func (o *CommonOptions) SendPipelineEvent(send func(provider pipeline_events.PipelineEventsProvider) error) {
+	provider := o.pipelineEventsProvider
+	if provider == nil {
+		var err error
+		provider, err = o.CreatePipelineEventsProvider()
+		if err != nil {
+			log.Logger().Warnf("Failed to create the pipeline events provider: %s", err)
+			return
+		}
+		if provider == nil {
+			return
+		}
+		defer func() {
+			err := provider.Close()
+			if err != nil {
+				log.Logger().Warnf("Failed to close the pipeline events provider: %s", err)
+			}
+		}()
+	}
+	err := send(provider)
+	if err != nil {
+		log.Logger().Warnf("Failed to send pipeline event: %s", err)
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/log"
+	pipeline_events "github.com/jenkins-x/jx/pkg/pipeline_events"
 	"github.com/jenkins-x/jx/pkg/util"
 	kserve "github.com/knative/serving/pkg/client/clientset/versioned"
 	"github.com/spf13/cobra"
@@ -598,6 +599,14 @@ func (o *PreviewOptions) Run() error {
 		log.Logger().Infof("Preview application is now available at: %s\n", util.ColorInfo(url))
 	}
 
+	o.SendPipelineEvent(func(provider pipeline_events.PipelineEventsProvider) error {
+		return provider.SendPreview(&pipeline_events.PreviewEvent{
+			Action:      pipeline_events.PreviewCreated,
+			Environment: env,
+			URL:         url,
+		})
+	})
+
 	stepPRCommentOptions := pr.StepPRCommentOptions{
 		Flags: pr.StepPRCommentFlags{
 			Owner:      o.GitInfo.Organisation,
@@ -0,0 +1,344 @@
+package promote
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/flagger"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// GitOpsPromotion moves a promotion via a Pull Request on the environment repository through its phases. Each
+// transition is recorded on the PromoteActivityStep so that the workflow controller can resume the promotion from the
+// PipelineActivity if the process which created the Pull Request dies
+type GitOpsPromotion struct {
+	Options     *PromoteOptions
+	Namespace   string
+	Environment *v1.Environment
+	ReleaseInfo *ReleaseInfo
+	PromoteKey  *kube.PromoteStepActivityKey
+
+	// Merged is when the Pull Request was first seen merged, which is zero until then
+	Merged time.Time
+	// BlockedReason is why the deployment windows of the environment do not allow the Pull Request to merge yet
+	BlockedReason string
+	// CanaryStarted is when the merge statuses passed and the promotion started waiting for the analysis of the new
+	// version by the Canary, which is zero until then
+	CanaryStarted time.Time
+	// Canary is the name of the Flagger Canary which analyses the new version
+	Canary string
+
+	lastCanary            *v1.PromoteCanaryStep
+	logged                map[string]bool
+	urlStatusMap          map[string]string
+	urlStatusTargetURLMap map[string]string
+}
+
+// NewGitOpsPromotion creates the promotion of the release via its Pull Request on the repository of the environment
+func (o *PromoteOptions) NewGitOpsPromotion(ns string, env *v1.Environment, releaseInfo *ReleaseInfo, promoteKey *kube.PromoteStepActivityKey) *GitOpsPromotion {
+	return &GitOpsPromotion{
+		Options:               o,
+		Namespace:             ns,
+		Environment:           env,
+		ReleaseInfo:           releaseInfo,
+		PromoteKey:            promoteKey,
+		logged:                map[string]bool{},
+		urlStatusMap:          map[string]string{},
+		urlStatusTargetURLMap: map[string]string{},
+	}
+}
+
+// ResumeGitOpsPromotion creates the promotion from the state recorded on the PromoteActivityStep, so that a promotion
+// whose Pull Request was created by another process can be moved on
+func (o *PromoteOptions) ResumeGitOpsPromotion(ns string, env *v1.Environment, releaseInfo *ReleaseInfo, promoteKey *kube.PromoteStepActivityKey, step *v1.PromoteActivityStep) *GitOpsPromotion {
+	p := o.NewGitOpsPromotion(ns, env, releaseInfo, promoteKey)
+	p.BlockedReason = step.BlockedReason
+	pullRequest := step.PullRequest
+	if pullRequest != nil && pullRequest.MergeCommitSHA != "" && pullRequest.CompletedTimestamp != nil {
+		p.Merged = pullRequest.CompletedTimestamp.Time
+	}
+	// the merge statuses are not seeded from the step as they are recorded by their target URL rather than the URL
+	// the git provider identifies them by, so they are read from the git provider again instead
+	canary := step.Canary
+	if step.Phase == v1.PromotePhaseCanary && canary != nil && canary.Canary != "" && canary.StartedTimestamp != nil {
+		p.CanaryStarted = canary.StartedTimestamp.Time
+		p.Canary = canary.Canary
+		p.lastCanary = canary.DeepCopy()
+	}
+	return p
+}
+
+// Reconcile checks the Pull Request and the statuses of its merge commit once and moves the promotion on to the next
+// phase if it can. Returns true once the promotion has stopped, along with an error if it failed
+func (p *GitOpsPromotion) Reconcile(jxClient versioned.Interface) (bool, error) {
+	pullRequestInfo := p.ReleaseInfo.PullRequestInfo
+	if pullRequestInfo == nil || pullRequestInfo.PullRequest == nil {
+		return true, nil
+	}
+	pr := pullRequestInfo.PullRequest
+	gitProvider := pullRequestInfo.GitProvider
+	err := gitProvider.UpdatePullRequestStatus(pr)
+	if err != nil {
+		log.Logger().Warnf("Failed to query the Pull Request status for %s %s", pr.URL, err)
+		return false, nil
+	}
+	var done bool
+	if pr.Merged != nil && *pr.Merged {
+		done, err = p.reconcileMerged(jxClient, pr, gitProvider)
+	} else {
+		done, err = p.reconcilePullRequest(jxClient, pr, gitProvider)
+	}
+	if done && err != nil {
+		p.fail(jxClient)
+	}
+	return done, err
+}
+
+// reconcilePullRequest merges the open Pull Request once its last commit has succeeded and the deployment windows of
+// the environment allow it
+func (p *GitOpsPromotion) reconcilePullRequest(jxClient versioned.Interface, pr *gits.GitPullRequest, gitProvider gits.GitProvider) (bool, error) {
+	o := p.Options
+	if pr.IsClosed() {
+		log.Logger().Warnf("Pull Request %s is closed", util.ColorInfo(pr.URL))
+		return true, fmt.Errorf("Promotion failed as Pull Request %s is closed without merging", pr.URL)
+	}
+
+	// lets try merge if the status is good
+	status, err := gitProvider.PullRequestLastCommitStatus(pr)
+	if err != nil {
+		log.Logger().Warnf("Failed to query the Pull Request last commit status for %s ref %s %s", pr.URL, pr.LastCommitSha, err)
+	} else if status == "in-progress" {
+		log.Logger().Info("The build for the Pull Request last commit is currently in progress.")
+	} else if status == "success" {
+		if !o.NoMergePullRequest {
+			reason, err := kube.CheckPullRequestDeploymentWindows(p.Environment, pr, time.Now())
+			if err != nil {
+				return false, err
+			}
+			if reason != p.BlockedReason {
+				p.BlockedReason = reason
+				if reason != "" {
+					log.Logger().Warnf("Not merging Pull Request %s yet as %s", util.ColorInfo(pr.URL), reason)
+				}
+				o.recordBlockedReason(jxClient, p.PromoteKey, reason)
+			}
+		}
+		if !o.NoMergePullRequest && p.BlockedReason == "" {
+			err = gitProvider.MergePullRequest(pr, "jx promote automatically merged promotion PR")
+			if err != nil {
+				if p.firstTime("mergeFailure") {
+					log.Logger().Warnf("Failed to merge the Pull Request %s due to %s maybe I don't have karma?", pr.URL, err)
+				}
+			}
+		}
+	} else if status == "error" || status == "failure" {
+		return true, fmt.Errorf("Pull request %s last commit has status %s for ref %s", pr.URL, status, pr.LastCommitSha)
+	}
+
+	if pr.Mergeable != nil && !*pr.Mergeable {
+		log.Logger().Info("Rebasing PullRequest due to conflict")
+		err = o.PromoteViaPullRequest(p.Environment, p.ReleaseInfo)
+		if err != nil {
+			log.Logger().Warnf("Failed to rebase Pull Request %s: %s", pr.URL, err)
+		}
+	}
+	return false, nil
+}
+
+// reconcileMerged records the merge of the Pull Request and completes the promotion once the pipeline of the merge
+// commit has updated the environment and any canary analysis of the new version has passed. The canary analysis is
+// checked once per reconcile rather than waited for, so that the workflow controller is never blocked by it
+func (p *GitOpsPromotion) reconcileMerged(jxClient versioned.Interface, pr *gits.GitPullRequest, gitProvider gits.GitProvider) (bool, error) {
+	o := p.Options
+	if pr.MergeCommitSHA == nil {
+		if p.firstTime("noMergeSha") {
+			log.Logger().Infof("Pull Request %s is merged but waiting for Merge SHA", util.ColorInfo(pr.URL))
+		}
+		return false, nil
+	}
+	mergeSha := *pr.MergeCommitSHA
+	if p.Merged.IsZero() {
+		p.Merged = time.Now()
+		log.Logger().Infof("Pull Request %s is merged at sha %s", util.ColorInfo(pr.URL), util.ColorInfo(mergeSha))
+
+		mergedPR := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, prStep *v1.PromotePullRequestStep) error {
+			kube.CompletePromotionPullRequest(a, s, ps, prStep)
+			prStep.MergeCommitSHA = mergeSha
+			return nil
+		}
+		p.PromoteKey.OnPromotePullRequest(jxClient, o.Namespace, mergedPR)
+
+		if o.NoWaitAfterMerge {
+			log.Logger().Infof("Pull requests are merged, No wait on promotion to complete")
+			return true, nil
+		}
+	}
+
+	if !p.CanaryStarted.IsZero() {
+		return p.reconcileCanary(jxClient)
+	}
+
+	p.PromoteKey.OnPromoteUpdate(jxClient, o.Namespace, kube.StartPromotionUpdate)
+
+	if o.NoWaitForUpdatePipeline {
+		log.Logger().Info("Pull Request merged but we are not waiting for the update pipeline to complete!")
+		return p.startCanary(jxClient)
+	}
+
+	statuses, err := gitProvider.ListCommitStatus(pr.Owner, pr.Repo, mergeSha)
+	if err != nil {
+		if p.firstTime("mergeStatusError") {
+			log.Logger().Warnf("Failed to query merge status of repo %s/%s with merge sha %s due to: %s", pr.Owner, pr.Repo, mergeSha, err)
+		}
+		return false, nil
+	}
+	if len(statuses) == 0 {
+		if p.firstTime("noMergeStatuses") {
+			log.Logger().Infof("Merge commit has not yet any statuses on repo %s/%s merge sha %s", pr.Owner, pr.Repo, mergeSha)
+		}
+		return false, nil
+	}
+	for _, status := range statuses {
+		if status.IsFailed() {
+			log.Logger().Warnf("merge status: %s URL: %s description: %s",
+				status.State, status.TargetURL, status.Description)
+			return true, fmt.Errorf("Status: %s URL: %s description: %s\n",
+				status.State, status.TargetURL, status.Description)
+		}
+		url := status.URL
+		state := status.State
+		if p.urlStatusMap[url] == "" || p.urlStatusMap[url] != GitStatusSuccess {
+			if p.urlStatusMap[url] != state {
+				p.urlStatusMap[url] = state
+				p.urlStatusTargetURLMap[url] = status.TargetURL
+				log.Logger().Infof("merge status: %s for URL %s with target: %s description: %s",
+					util.ColorInfo(state), util.ColorInfo(status.URL), util.ColorInfo(status.TargetURL), util.ColorInfo(status.Description))
+			}
+		}
+	}
+	prStatuses := []v1.GitStatus{}
+	keys := util.SortedMapKeys(p.urlStatusMap)
+	for _, url := range keys {
+		state := p.urlStatusMap[url]
+		targetURL := p.urlStatusTargetURLMap[url]
+		if targetURL == "" {
+			targetURL = url
+		}
+		prStatuses = append(prStatuses, v1.GitStatus{
+			URL:    targetURL,
+			Status: state,
+		})
+	}
+	updateStatuses := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, update *v1.PromoteUpdateStep) error {
+		update.Statuses = prStatuses
+		return nil
+	}
+	p.PromoteKey.OnPromoteUpdate(jxClient, o.Namespace, updateStatuses)
+
+	for _, v := range p.urlStatusMap {
+		if v != GitStatusSuccess {
+			return false, nil
+		}
+	}
+	log.Logger().Info("Merge status checks all passed so the promotion worked!")
+	return p.startCanary(jxClient)
+}
+
+// startCanary moves the promotion on to the Canary phase if the application has a Canary in the environment,
+// otherwise the promotion is complete
+func (p *GitOpsPromotion) startCanary(jxClient versioned.Interface) (bool, error) {
+	o := p.Options
+	canary, err := o.findCanary(p.Namespace)
+	if err != nil {
+		if p.firstTime("findCanaryError") {
+			log.Logger().Warnf("Failed to find the canary of %s in namespace %s: %s", o.Application, p.Namespace, err)
+		}
+		return false, nil
+	}
+	if canary == nil {
+		return true, p.complete(jxClient)
+	}
+	log.Logger().Infof("Waiting for the analysis of canary %s in namespace %s", util.ColorInfo(canary.Name), util.ColorInfo(p.Namespace))
+	p.CanaryStarted = time.Now()
+	p.Canary = canary.Name
+	startCanary := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, cs *v1.PromoteCanaryStep) error {
+		err := kube.StartPromotionCanary(a, s, ps, cs)
+		cs.Canary = canary.Name
+		return err
+	}
+	err = p.PromoteKey.OnPromoteCanary(jxClient, o.Namespace, startCanary)
+	if err != nil {
+		log.Logger().Warnf("Failed to record the canary analysis on PipelineActivity %s: %s", p.PromoteKey.Name, err)
+	}
+	return o.checkCanary(jxClient, p.PromoteKey, canary, p.Merged, p.CanaryStarted, &p.lastCanary)
+}
+
+// reconcileCanary checks the analysis of the new version by the Canary once and completes the promotion once the
+// analysis has finished
+func (p *GitOpsPromotion) reconcileCanary(jxClient versioned.Interface) (bool, error) {
+	o := p.Options
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		log.Logger().Warnf("Failed to get the kube client: %s", err)
+		return false, nil
+	}
+	canary, err := flagger.GetCanary(kubeClient, p.Namespace, p.Canary)
+	if err != nil {
+		if apierrors.IsNotFound(errors.Cause(err)) {
+			log.Logger().Warnf("Canary %s in namespace %s has been removed so not waiting for its analysis", p.Canary, p.Namespace)
+			return true, p.complete(jxClient)
+		}
+		if p.firstTime("getCanaryError") {
+			log.Logger().Warnf("Failed to get canary %s in namespace %s: %s", p.Canary, p.Namespace, err)
+		}
+		return false, nil
+	}
+	done, err := o.checkCanary(jxClient, p.PromoteKey, canary, p.Merged, p.CanaryStarted, &p.lastCanary)
+	if !done || err != nil {
+		return done, err
+	}
+	return true, p.complete(jxClient)
+}
+
+// complete comments on the issues fixed by the release and marks the promotion as complete
+func (p *GitOpsPromotion) complete(jxClient versioned.Interface) error {
+	o := p.Options
+	err := o.CommentOnIssues(p.Namespace, p.Environment, p.PromoteKey)
+	if err != nil {
+		return err
+	}
+	return o.completePromotion(jxClient, p.PromoteKey)
+}
+
+// fail records that the promotion failed so that it is not resumed
+func (p *GitOpsPromotion) fail(jxClient versioned.Interface) {
+	var err error
+	if p.Merged.IsZero() {
+		failed := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, prStep *v1.PromotePullRequestStep) error {
+			kube.FailedPromote(ps)
+			return kube.FailedPromotionPullRequest(a, s, ps, prStep)
+		}
+		err = p.PromoteKey.OnPromotePullRequest(jxClient, p.Options.Namespace, failed)
+	} else {
+		err = p.PromoteKey.OnPromoteUpdate(jxClient, p.Options.Namespace, kube.FailedPromotionUpdate)
+	}
+	if err != nil {
+		log.Logger().Warnf("Failed to record the failed promotion on PipelineActivity %s: %s", p.PromoteKey.Name, err)
+	}
+}
+
+// firstTime returns true the first time the promotion hits the given condition so that it is only logged once
+func (p *GitOpsPromotion) firstTime(key string) bool {
+	if p.logged[key] {
+		return false
+	}
+	p.logged[key] = true
+	return true
+}
@@ -0,0 +1,319 @@
+package promote
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/flagger"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apifake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const testActivityName = "myorg-myapp-master-1"
+
+// createTestPromotion creates a promotion to production whose Pull Request has a commit with the given status
+func createTestPromotion(t *testing.T, status gits.CommitStatus) (*GitOpsPromotion, *gits.FakeRepository, versioned.Interface) {
+	repo := gits.NewFakeRepository("myorg", "environment-mycluster-production")
+	repo.Commits = []*gits.FakeCommit{
+		{
+			Commit: &gits.GitCommit{SHA: "merged"},
+			Status: gits.CommitSatusSuccess,
+		},
+	}
+	gitProvider := gits.NewFakeProvider(repo)
+	pr, err := gitProvider.CreatePullRequest(&gits.GitPullRequestArguments{
+		Title:         "myapp to 1.2.3",
+		Head:          "promote-myapp-1.2.3",
+		Base:          "master",
+		GitRepository: repo.GitRepo,
+	})
+	require.NoError(t, err)
+	repo.PullRequests[*pr.Number].Commits[0].Status = status
+
+	jxClient := fake.NewSimpleClientset()
+	o := &PromoteOptions{
+		Application: "myapp",
+		Version:     "1.2.3",
+		Namespace:   "jx",
+	}
+	env := kube.NewPermanentEnvironment("production")
+	promoteKey := &kube.PromoteStepActivityKey{
+		PipelineActivityKey: kube.PipelineActivityKey{
+			Name:     testActivityName,
+			Pipeline: "myorg/myapp/master",
+			Build:    "1",
+		},
+		Environment: env.Name,
+	}
+	startPR := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromotePullRequestStep) error {
+		p.PullRequestURL = pr.URL
+		return kube.StartPromotionPullRequest(a, s, ps, p)
+	}
+	err = promoteKey.OnPromotePullRequest(jxClient, o.Namespace, startPR)
+	require.NoError(t, err)
+
+	releaseInfo := &ReleaseInfo{
+		ReleaseName: "jx-production-myapp",
+		Version:     "1.2.3",
+		PullRequestInfo: &gits.PullRequestInfo{
+			GitProvider: gitProvider,
+			PullRequest: pr,
+		},
+	}
+	return o.NewGitOpsPromotion(env.Spec.Namespace, env, releaseInfo, promoteKey), repo, jxClient
+}
+
+func getPromoteStep(t *testing.T, jxClient versioned.Interface) *v1.PromoteActivityStep {
+	activity, err := jxClient.JenkinsV1().PipelineActivities("jx").Get(testActivityName, metav1.GetOptions{})
+	require.NoError(t, err)
+	for _, step := range activity.Spec.Steps {
+		if step.Promote != nil && step.Promote.Environment == "production" {
+			return step.Promote
+		}
+	}
+	require.Fail(t, "no promote step for production")
+	return nil
+}
+
+func TestGitOpsPromotionMergesPullRequest(t *testing.T) {
+	t.Parallel()
+
+	promotion, repo, jxClient := createTestPromotion(t, gits.CommitStatusPending)
+	promotion.Options.NoWaitAfterMerge = true
+	assert.Equal(t, v1.PromotePhasePullRequest, getPromoteStep(t, jxClient).Phase)
+
+	done, err := promotion.Reconcile(jxClient)
+	require.NoError(t, err)
+	assert.False(t, done, "the Pull Request is still building")
+	assert.Len(t, repo.PullRequests, 1)
+
+	repo.PullRequests[1].Commits[0].Status = gits.CommitSatusSuccess
+	done, err = promotion.Reconcile(jxClient)
+	require.NoError(t, err)
+	assert.False(t, done, "the Pull Request was merged but not seen merged yet")
+	assert.Empty(t, repo.PullRequests, "the Pull Request was merged")
+
+	done, err = promotion.Reconcile(jxClient)
+	require.NoError(t, err)
+	assert.True(t, done)
+
+	step := getPromoteStep(t, jxClient)
+	assert.Equal(t, v1.PromotePhaseMerged, step.Phase)
+	require.NotNil(t, step.PullRequest)
+	assert.Equal(t, "promote-myapp-1.2.3", step.PullRequest.MergeCommitSHA)
+	assert.Equal(t, v1.ActivityStatusTypeSucceeded, step.PullRequest.Status)
+
+	// the controller resumes the promotion from the PipelineActivity
+	resumed := promotion.Options.ResumeGitOpsPromotion(promotion.Namespace, promotion.Environment, promotion.ReleaseInfo, promotion.PromoteKey, step)
+	assert.False(t, resumed.Merged.IsZero(), "the promotion was merged")
+}
+
+func TestGitOpsPromotionResumesMergeStatuses(t *testing.T) {
+	t.Parallel()
+
+	// without the Canary CRD the promotion completes once the merge statuses pass
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.SetAPIExtensionsClient(apifake.NewSimpleClientset())
+
+	promotion, repo, jxClient := createTestPromotion(t, gits.CommitSatusSuccess)
+	promotion.Options.CommonOptions = &commonOpts
+	merged := &gits.FakeCommit{
+		Commit: &gits.GitCommit{
+			SHA: "promote-myapp-1.2.3",
+			URL: "https://api.github.com/repos/myorg/environment-mycluster-production/statuses/promote-myapp-1.2.3",
+		},
+		Status: gits.CommitStatusPending,
+	}
+	repo.Commits = append(repo.Commits, merged)
+
+	done, err := promotion.Reconcile(jxClient)
+	require.NoError(t, err)
+	assert.False(t, done, "the Pull Request was merged but not seen merged yet")
+	done, err = promotion.Reconcile(jxClient)
+	require.NoError(t, err)
+	assert.False(t, done, "the merge status is pending")
+
+	// the statuses are recorded by their target URL which differs from the URL the git provider returns
+	step := getPromoteStep(t, jxClient)
+	require.NotNil(t, step.Update)
+	step.Update.Statuses = []v1.GitStatus{
+		{URL: "https://jenkins.example.com/job/environment-mycluster-production/1", Status: string(gits.CommitStatusPending)},
+	}
+
+	resumed := promotion.Options.ResumeGitOpsPromotion(promotion.Namespace, promotion.Environment, promotion.ReleaseInfo, promotion.PromoteKey, step)
+	merged.Status = gits.CommitSatusSuccess
+	done, err = resumed.Reconcile(jxClient)
+	require.NoError(t, err)
+	assert.True(t, done, "the merge status succeeded")
+
+	step = getPromoteStep(t, jxClient)
+	assert.Equal(t, v1.PromotePhaseSucceeded, step.Phase)
+	assert.Equal(t, []v1.GitStatus{{URL: merged.Commit.URL, Status: string(gits.CommitSatusSuccess)}}, step.Update.Statuses)
+}
+
+func TestGitOpsPromotionFailsWhenPullRequestFails(t *testing.T) {
+	t.Parallel()
+
+	promotion, repo, jxClient := createTestPromotion(t, gits.CommitStatusFailure)
+
+	done, err := promotion.Reconcile(jxClient)
+	assert.Error(t, err)
+	assert.True(t, done)
+	assert.Len(t, repo.PullRequests, 1, "the Pull Request was not merged")
+
+	step := getPromoteStep(t, jxClient)
+	assert.Equal(t, v1.PromotePhaseFailed, step.Phase)
+	assert.Equal(t, v1.ActivityStatusTypeFailed, step.Status)
+	require.NotNil(t, step.PullRequest)
+	assert.Equal(t, v1.ActivityStatusTypeFailed, step.PullRequest.Status)
+}
+
+// fakeCanaryServer serves the Flagger Canary of myapp in the production namespace with the phase set on it
+type fakeCanaryServer struct {
+	sync.Mutex
+	phase      flagger.CanaryPhase
+	transition time.Time
+}
+
+func (f *fakeCanaryServer) setPhase(phase flagger.CanaryPhase, transition time.Time) {
+	f.Lock()
+	defer f.Unlock()
+	f.phase = phase
+	f.transition = transition
+}
+
+func (f *fakeCanaryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	canary := fmt.Sprintf(`{
+  "metadata": {"name": "jx-myapp", "namespace": "jx-production"},
+  "spec": {"targetRef": {"apiVersion": "apps/v1", "kind": "Deployment", "name": "jx-myapp"}},
+  "status": {"phase": "%s", "canaryWeight": 10, "lastTransitionTime": "%s"}
+}`, f.phase, f.transition.UTC().Format(time.RFC3339))
+	f.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/apis/flagger.app/v1alpha3/namespaces/jx-production/canaries":
+		w.Write([]byte(`{"kind": "CanaryList", "items": [` + canary + `]}`))
+	case "/apis/flagger.app/v1alpha3/namespaces/jx-production/canaries/jx-myapp":
+		w.Write([]byte(canary))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestGitOpsPromotionWaitsForCanary(t *testing.T) {
+	t.Parallel()
+
+	canaries := &fakeCanaryServer{}
+	canaries.setPhase(flagger.CanaryPhaseSucceeded, time.Now().Add(-time.Hour))
+	server := httptest.NewServer(canaries)
+	defer server.Close()
+
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.SetKubeClient(kubeClient)
+	commonOpts.SetAPIExtensionsClient(apifake.NewSimpleClientset(&v1beta1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: flagger.CanaryCRDName,
+		},
+	}))
+
+	promotion, _, jxClient := createTestPromotion(t, gits.CommitSatusSuccess)
+	promotion.Options.CommonOptions = &commonOpts
+
+	done, err := promotion.Reconcile(jxClient)
+	require.NoError(t, err)
+	assert.False(t, done, "the Pull Request was merged but not seen merged yet")
+
+	done, err = promotion.Reconcile(jxClient)
+	require.NoError(t, err)
+	assert.False(t, done, "the canary has not analysed the new version yet")
+	step := getPromoteStep(t, jxClient)
+	assert.Equal(t, v1.PromotePhaseCanary, step.Phase)
+	require.NotNil(t, step.Canary)
+	assert.Equal(t, "jx-myapp", step.Canary.Canary)
+	assert.Equal(t, v1.ActivityStatusTypeRunning, step.Canary.Status)
+
+	canaries.setPhase(flagger.CanaryPhaseProgressing, time.Now())
+	done, err = promotion.Reconcile(jxClient)
+	require.NoError(t, err)
+	assert.False(t, done, "the canary analysis is in progress")
+	step = getPromoteStep(t, jxClient)
+	assert.Equal(t, v1.PromotePhaseCanary, step.Phase)
+	assert.Equal(t, string(flagger.CanaryPhaseProgressing), step.Canary.Phase)
+	assert.Equal(t, 10, step.Canary.Weight)
+
+	// the controller resumes the promotion in the Canary phase without checking the merge statuses again
+	resumed := promotion.Options.ResumeGitOpsPromotion(promotion.Namespace, promotion.Environment, promotion.ReleaseInfo, promotion.PromoteKey, step)
+	assert.Equal(t, "jx-myapp", resumed.Canary)
+	assert.False(t, resumed.CanaryStarted.IsZero())
+
+	done, err = resumed.Reconcile(jxClient)
+	require.NoError(t, err)
+	assert.False(t, done, "the canary analysis is still in progress")
+
+	canaries.setPhase(flagger.CanaryPhaseSucceeded, time.Now().Add(time.Minute))
+	done, err = resumed.Reconcile(jxClient)
+	require.NoError(t, err)
+	assert.True(t, done)
+
+	step = getPromoteStep(t, jxClient)
+	assert.Equal(t, v1.PromotePhaseSucceeded, step.Phase)
+	assert.Equal(t, v1.ActivityStatusTypeSucceeded, step.Status)
+	assert.Equal(t, v1.ActivityStatusTypeSucceeded, step.Canary.Status)
+}
+
+func TestGitOpsPromotionFailsWhenCanaryFails(t *testing.T) {
+	t.Parallel()
+
+	canaries := &fakeCanaryServer{}
+	canaries.setPhase(flagger.CanaryPhaseProgressing, time.Now())
+	server := httptest.NewServer(canaries)
+	defer server.Close()
+
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.SetKubeClient(kubeClient)
+	commonOpts.SetAPIExtensionsClient(apifake.NewSimpleClientset(&v1beta1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: flagger.CanaryCRDName,
+		},
+	}))
+
+	promotion, _, jxClient := createTestPromotion(t, gits.CommitSatusSuccess)
+	promotion.Options.CommonOptions = &commonOpts
+
+	done, err := promotion.Reconcile(jxClient)
+	require.NoError(t, err)
+	assert.False(t, done)
+	done, err = promotion.Reconcile(jxClient)
+	require.NoError(t, err)
+	assert.False(t, done)
+
+	canaries.setPhase(flagger.CanaryPhaseFailed, time.Now().Add(time.Minute))
+	done, err = promotion.Reconcile(jxClient)
+	assert.Error(t, err)
+	assert.True(t, done)
+
+	step := getPromoteStep(t, jxClient)
+	assert.Equal(t, v1.PromotePhaseFailed, step.Phase)
+	assert.Equal(t, v1.ActivityStatusTypeFailed, step.Canary.Status)
+}
@@ -22,15 +22,21 @@ import (
 	"github.com/jenkins-x/jx/pkg/kube/services"
 
 	"github.com/blang/semver"
+	"github.com/jenkins-x/jx/pkg/chats"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
 	typev1 "github.com/jenkins-x/jx/pkg/client/clientset/versioned/typed/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/flagger"
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/helm"
 	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/log"
+	pipeline_events "github.com/jenkins-x/jx/pkg/pipeline_events"
 	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/jenkins-x/jx/pkg/workflow"
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -42,6 +48,9 @@ const (
 
 var (
 	waitAfterPullRequestCreated = time.Second * 3
+	// canaryStartTimeout is how long to wait for Flagger to start analysing a promoted version before assuming the
+	// Deployment did not change
+	canaryStartTimeout = time.Minute * 2
 )
 
 // PromoteOptions containers the CLI options
@@ -81,6 +90,8 @@ type PromoteOptions struct {
 	releaseResource         *v1.Release
 	ReleaseInfo             *ReleaseInfo
 	prow                    bool
+	// promotionQueued is true if the promotion Pull Request was left open as the deployment windows do not allow it
+	promotionQueued bool
 }
 
 type ReleaseInfo struct {
@@ -312,6 +323,16 @@ func (o *PromoteOptions) PromoteAllAutomatic() error {
 			if ns == "" {
 				return fmt.Errorf("No namespace for environment %s", env.Name)
 			}
+			reason, err := kube.CheckDeploymentWindows(&env, time.Now())
+			if err != nil {
+				return err
+			}
+			if reason != "" {
+				// later environments are not promoted to until this one has been
+				log.Logger().Warnf("Queuing the promotion of %s to %s as %s", o.Application, env.Name, reason)
+				o.queuePromotion(jxClient, &env, reason)
+				return nil
+			}
 			releaseInfo, err := o.Promote(ns, &env, false)
 			if err != nil {
 				return err
@@ -322,6 +343,9 @@ func (o *PromoteOptions) PromoteAllAutomatic() error {
 				if err != nil {
 					return err
 				}
+				if o.promotionQueued {
+					return nil
+				}
 			}
 		}
 	}
@@ -379,6 +403,10 @@ func (o *PromoteOptions) Promote(targetNS string, env *v1.Environment, warnIfAut
 		return releaseInfo, err
 	}
 	promoteKey := o.CreatePromoteKey(env)
+	err = o.checkGates(jxClient, promoteKey)
+	if err != nil {
+		return releaseInfo, err
+	}
 	if env != nil {
 		source := &env.Spec.Source
 		if source.URL != "" && env.Spec.Kind.IsPermanent() {
@@ -437,13 +465,18 @@ func (o *PromoteOptions) Promote(targetNS string, env *v1.Environment, warnIfAut
 		NoForce:     true,
 		Wait:        true,
 	}
+	installed := time.Now()
 	err = o.InstallChartWithOptions(helmOptions)
 	if err == nil {
+		err = o.waitForCanary(jxClient, targetNS, promoteKey, installed)
+		if err != nil {
+			return releaseInfo, err
+		}
 		err = o.CommentOnIssues(targetNS, env, promoteKey)
 		if err != nil {
 			log.Logger().Warnf("Failed to comment on issues for release %s: %s", releaseName, err)
 		}
-		err = promoteKey.OnPromoteUpdate(jxClient, o.Namespace, kube.CompletePromotionUpdate)
+		err = o.completePromotion(jxClient, promoteKey)
 	} else {
 		err = promoteKey.OnPromoteUpdate(jxClient, o.Namespace, kube.FailedPromotionUpdate)
 	}
@@ -559,198 +592,287 @@ func (o *PromoteOptions) WaitForPromotion(ns string, env *v1.Environment, releas
 	duration := *o.TimeoutDuration
 	end := time.Now().Add(duration)
 
-	jxClient, _, err := o.JXClient()
-	if err != nil {
-		return errors.Wrap(err, "Getting jx client")
-	}
 	pullRequestInfo := releaseInfo.PullRequestInfo
 	if pullRequestInfo != nil {
 		promoteKey := o.CreatePromoteKey(env)
 
-		err := o.waitForGitOpsPullRequest(ns, env, releaseInfo, end, duration, promoteKey)
-		if err != nil {
-			// TODO based on if the PR completed or not fail the PR or the Promote?
-			promoteKey.OnPromotePullRequest(jxClient, o.Namespace, kube.FailedPromotionPullRequest)
-			return err
-		}
+		// failures are recorded on the PipelineActivity by the promotion, whereas a promotion which timed out is left
+		// running so that the workflow controller can resume it
+		return o.waitForGitOpsPullRequest(ns, env, releaseInfo, end, duration, promoteKey)
 	}
 	return nil
 }
 
-// TODO This could do with a refactor and some tests...
+// waitForGitOpsPullRequest reconciles the promotion via its Pull Request until it completes or times out. The
+// progress is recorded on the PipelineActivity so that the workflow controller can resume the promotion if this
+// process dies
 func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment, releaseInfo *ReleaseInfo, end time.Time, duration time.Duration, promoteKey *kube.PromoteStepActivityKey) error {
-	pullRequestInfo := releaseInfo.PullRequestInfo
-	logMergeFailure := false
-	logNoMergeCommitSha := false
-	logHasMergeSha := false
-	logMergeStatusError := false
-	logNoMergeStatuses := false
-	urlStatusMap := map[string]string{}
-	urlStatusTargetURLMap := map[string]string{}
-
+	if releaseInfo.PullRequestInfo == nil {
+		return nil
+	}
 	jxClient, _, err := o.JXClient()
 	if err != nil {
 		return errors.Wrap(err, "Getting jx client")
 	}
+	promotion := o.NewGitOpsPromotion(ns, env, releaseInfo, promoteKey)
+	for {
+		done, err := promotion.Reconcile(jxClient)
+		if done || err != nil {
+			return err
+		}
+		if time.Now().After(end) {
+			pr := releaseInfo.PullRequestInfo.PullRequest
+			if promotion.BlockedReason != "" {
+				o.promotionQueued = true
+				// the Pull Request stays open so that the workflow controller merges it once the deployment windows allow
+				log.Logger().Warnf("Leaving Pull Request %s open as %s", util.ColorInfo(pr.URL), promotion.BlockedReason)
+				return nil
+			}
+			return fmt.Errorf("Timed out waiting for pull request %s to merge. Waited %s", pr.URL, duration.String())
+		}
+		time.Sleep(*o.PullRequestPollDuration)
+	}
+}
 
-	if pullRequestInfo != nil {
-		for {
-			pr := pullRequestInfo.PullRequest
-			gitProvider := pullRequestInfo.GitProvider
-			err := gitProvider.UpdatePullRequestStatus(pr)
-			if err != nil {
-				log.Logger().Warnf("Failed to query the Pull Request status for %s %s", pr.URL, err)
-			} else {
-				if pr.Merged != nil && *pr.Merged {
-					if pr.MergeCommitSHA == nil {
-						if !logNoMergeCommitSha {
-							logNoMergeCommitSha = true
-							log.Logger().Infof("Pull Request %s is merged but waiting for Merge SHA", util.ColorInfo(pr.URL))
-						}
-					} else {
-						mergeSha := *pr.MergeCommitSHA
-						if !logHasMergeSha {
-							logHasMergeSha = true
-							log.Logger().Infof("Pull Request %s is merged at sha %s", util.ColorInfo(pr.URL), util.ColorInfo(mergeSha))
-
-							mergedPR := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromotePullRequestStep) error {
-								kube.CompletePromotionPullRequest(a, s, ps, p)
-								p.MergeCommitSHA = mergeSha
-								return nil
-							}
-							promoteKey.OnPromotePullRequest(jxClient, o.Namespace, mergedPR)
+// completePromotion marks the promotion as complete on the PipelineActivity and sends a promotion event
+func (o *PromoteOptions) completePromotion(jxClient versioned.Interface, promoteKey *kube.PromoteStepActivityKey) error {
+	var step *v1.PromoteActivityStep
+	version := o.Version
+	complete := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteUpdateStep) error {
+		err := kube.CompletePromotionUpdate(a, s, ps, p)
+		step = ps.DeepCopy()
+		if version == "" {
+			version = a.Spec.Version
+		}
+		return err
+	}
+	err := promoteKey.OnPromoteUpdate(jxClient, o.Namespace, complete)
+	if err != nil {
+		return err
+	}
+	o.SendPipelineEvent(func(provider pipeline_events.PipelineEventsProvider) error {
+		return provider.SendPromotion(&pipeline_events.PromotionEvent{
+			Application: o.Application,
+			Version:     version,
+			Environment: promoteKey.Environment,
+			Pipeline:    promoteKey.Pipeline,
+			Build:       promoteKey.Build,
+			Step:        step,
+		})
+	})
+	o.notifyPromotion(version, promoteKey.Environment, step)
+	return nil
+}
 
-							if o.NoWaitAfterMerge {
-								log.Logger().Infof("Pull requests are merged, No wait on promotion to complete")
-								return err
-							}
-						}
+// waitForCanary waits for the Flagger canary analysis of the version promoted at the given time to complete if the
+// application has a Canary in the namespace, recording its progress on the PipelineActivity. Returns an error if the
+// analysis failed and Flagger rolled the version back
+func (o *PromoteOptions) waitForCanary(jxClient versioned.Interface, ns string, promoteKey *kube.PromoteStepActivityKey, since time.Time) error {
+	canary, err := o.findCanary(ns)
+	if err != nil || canary == nil {
+		return err
+	}
+	if o.TimeoutDuration == nil || o.PullRequestPollDuration == nil {
+		log.Logger().Infof("Not waiting for the analysis of canary %s as there is no --%s or --%s option", canary.Name, opts.OptionTimeout, optionPullRequestPollTime)
+		return nil
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return errors.Wrap(err, "getting the kube client")
+	}
+	log.Logger().Infof("Waiting for the analysis of canary %s in namespace %s", util.ColorInfo(canary.Name), util.ColorInfo(ns))
 
-						promoteKey.OnPromoteUpdate(jxClient, o.Namespace, kube.StartPromotionUpdate)
+	started := time.Now()
+	var last *v1.PromoteCanaryStep
+	for {
+		done, err := o.checkCanary(jxClient, promoteKey, canary, since, started, &last)
+		if done {
+			return err
+		}
+		time.Sleep(*o.PullRequestPollDuration)
+		canary, err = flagger.GetCanary(kubeClient, ns, canary.Name)
+		if err != nil {
+			return err
+		}
+	}
+}
 
-						if o.NoWaitForUpdatePipeline {
-							log.Logger().Info("Pull Request merged but we are not waiting for the update pipeline to complete!")
-							err = o.CommentOnIssues(ns, env, promoteKey)
-							if err == nil {
-								err = promoteKey.OnPromoteUpdate(jxClient, o.Namespace, kube.CompletePromotionUpdate)
-							}
-							return err
-						}
+// findCanary returns the Flagger Canary which analyses the application in the namespace, or nil if there is none or
+// Flagger is not installed
+func (o *PromoteOptions) findCanary(ns string) (*flagger.Canary, error) {
+	apiClient, err := o.ApiExtensionsClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the api extensions client")
+	}
+	installed, err := flagger.IsCanaryCRDInstalled(apiClient)
+	if err != nil {
+		// the service account running the promotion may not be allowed to read the cluster scoped CRDs
+		log.Logger().Warnf("Not waiting for any canary analysis as failed to check if the Flagger Canary CRD is installed: %s", err)
+		return nil, nil
+	}
+	if !installed {
+		return nil, nil
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the kube client")
+	}
+	canaries, err := flagger.ListCanaries(kubeClient, ns)
+	if err != nil {
+		return nil, err
+	}
+	return flagger.FindCanary(canaries, ns, o.Application), nil
+}
 
-						statuses, err := gitProvider.ListCommitStatus(pr.Owner, pr.Repo, mergeSha)
-						if err != nil {
-							if !logMergeStatusError {
-								logMergeStatusError = true
-								log.Logger().Warnf("Failed to query merge status of repo %s/%s with merge sha %s due to: %s", pr.Owner, pr.Repo, mergeSha, err)
-							}
-						} else {
-							if len(statuses) == 0 {
-								if !logNoMergeStatuses {
-									logNoMergeStatuses = true
-									log.Logger().Infof("Merge commit has not yet any statuses on repo %s/%s merge sha %s", pr.Owner, pr.Repo, mergeSha)
-								}
-							} else {
-								for _, status := range statuses {
-									if status.IsFailed() {
-										log.Logger().Warnf("merge status: %s URL: %s description: %s",
-											status.State, status.TargetURL, status.Description)
-										return fmt.Errorf("Status: %s URL: %s description: %s\n",
-											status.State, status.TargetURL, status.Description)
-									}
-									url := status.URL
-									state := status.State
-									if urlStatusMap[url] == "" || urlStatusMap[url] != GitStatusSuccess {
-										if urlStatusMap[url] != state {
-											urlStatusMap[url] = state
-											urlStatusTargetURLMap[url] = status.TargetURL
-											log.Logger().Infof("merge status: %s for URL %s with target: %s description: %s",
-												util.ColorInfo(state), util.ColorInfo(status.URL), util.ColorInfo(status.TargetURL), util.ColorInfo(status.Description))
-										}
-									}
-								}
-								prStatuses := []v1.GitStatus{}
-								keys := util.SortedMapKeys(urlStatusMap)
-								for _, url := range keys {
-									state := urlStatusMap[url]
-									targetURL := urlStatusTargetURLMap[url]
-									if targetURL == "" {
-										targetURL = url
-									}
-									prStatuses = append(prStatuses, v1.GitStatus{
-										URL:    targetURL,
-										Status: state,
-									})
-								}
-								updateStatuses := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteUpdateStep) error {
-									p.Statuses = prStatuses
-									return nil
-								}
-								promoteKey.OnPromoteUpdate(jxClient, o.Namespace, updateStatuses)
-
-								succeeded := true
-								for _, v := range urlStatusMap {
-									if v != GitStatusSuccess {
-										succeeded = false
-									}
-								}
-								if succeeded {
-									log.Logger().Info("Merge status checks all passed so the promotion worked!")
-									err = o.CommentOnIssues(ns, env, promoteKey)
-									if err == nil {
-										err = promoteKey.OnPromoteUpdate(jxClient, o.Namespace, kube.CompletePromotionUpdate)
-									}
-									return err
-								}
-							}
-						}
-					}
-				} else {
-					if pr.IsClosed() {
-						log.Logger().Warnf("Pull Request %s is closed", util.ColorInfo(pr.URL))
-						return fmt.Errorf("Promotion failed as Pull Request %s is closed without merging", pr.URL)
-					}
+// checkCanary checks the analysis of the version promoted at the given time once without blocking, recording its
+// progress on the PipelineActivity and storing the recorded step in last. The started time is when the promotion
+// started waiting for the analysis. Returns true once the analysis has finished, along with an error if it failed or
+// timed out
+func (o *PromoteOptions) checkCanary(jxClient versioned.Interface, promoteKey *kube.PromoteStepActivityKey, canary *flagger.Canary, since time.Time, started time.Time, last **v1.PromoteCanaryStep) (bool, error) {
+	if canary.AnalysedSince(since) {
+		fn := kube.StartPromotionCanary
+		if canary.IsFailed() {
+			fn = kube.FailedPromotionCanary
+		} else if canary.IsComplete() {
+			fn = kube.CompletePromotionCanary
+		}
+		var step *v1.PromoteCanaryStep
+		err := promoteKey.OnPromoteCanary(jxClient, o.Namespace, updateCanaryStep(canary, fn, &step))
+		if err != nil {
+			log.Logger().Warnf("Failed to update PipelineActivity %s: %s", promoteKey.Name, err)
+		}
+		previous := *last
+		if step != nil && (previous == nil || previous.Phase != step.Phase || previous.Weight != step.Weight) {
+			log.Logger().Infof("Canary %s is %s with weight %d and %d failed checks", canary.Name, util.ColorInfo(step.Phase), step.Weight, step.FailedChecks)
+		}
+		if step != nil {
+			*last = step
+		}
+		if canary.IsFailed() {
+			return true, fmt.Errorf("Promotion failed as the analysis of canary %s rolled back the new version: %s", canary.Name, canary.Message())
+		}
+		if canary.IsComplete() {
+			return true, nil
+		}
+	} else if time.Now().After(started.Add(canaryStartTimeout)) {
+		log.Logger().Infof("Canary %s has not started analysing a new version so assuming the Deployment did not change", canary.Name)
+		return true, nil
+	}
+	if o.TimeoutDuration != nil && time.Now().After(started.Add(*o.TimeoutDuration)) {
+		return true, fmt.Errorf("Timed out waiting for the analysis of canary %s. Waited %s", canary.Name, o.TimeoutDuration.String())
+	}
+	return false, nil
+}
 
-					// lets try merge if the status is good
-					status, err := gitProvider.PullRequestLastCommitStatus(pr)
-					if err != nil {
-						log.Logger().Warnf("Failed to query the Pull Request last commit status for %s ref %s %s", pr.URL, pr.LastCommitSha, err)
-						//return fmt.Errorf("Failed to query the Pull Request last commit status for %s ref %s %s", pr.URL, pr.LastCommitSha, err)
-					} else if status == "in-progress" {
-						log.Logger().Info("The build for the Pull Request last commit is currently in progress.")
-					} else {
-						if status == "success" {
-							if !o.NoMergePullRequest {
-								err = gitProvider.MergePullRequest(pr, "jx promote automatically merged promotion PR")
-								if err != nil {
-									if !logMergeFailure {
-										logMergeFailure = true
-										log.Logger().Warnf("Failed to merge the Pull Request %s due to %s maybe I don't have karma?", pr.URL, err)
-									}
-								}
-							}
-						} else if status == "error" || status == "failure" {
-							return fmt.Errorf("Pull request %s last commit has status %s for ref %s", pr.URL, status, pr.LastCommitSha)
-						}
-					}
-				}
-				if pr.Mergeable != nil && !*pr.Mergeable {
-					log.Logger().Info("Rebasing PullRequest due to conflict")
+// updateCanaryStep returns a function which applies the status of the canary to the PromoteCanaryStep after the
+// given function, storing a copy of the step in answer
+func updateCanaryStep(canary *flagger.Canary, fn kube.PromoteCanaryFn, answer **v1.PromoteCanaryStep) kube.PromoteCanaryFn {
+	return func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteCanaryStep) error {
+		err := fn(a, s, ps, p)
+		if err != nil {
+			return err
+		}
+		status := canary.Status
+		p.Canary = canary.Name
+		p.Phase = string(status.Phase)
+		p.Weight = status.CanaryWeight
+		p.FailedChecks = status.FailedChecks
+		p.Iterations = status.Iterations
+		p.Message = canary.Message()
+		*answer = p.DeepCopy()
+		return nil
+	}
+}
 
-					err = o.PromoteViaPullRequest(env, releaseInfo)
-					if releaseInfo.PullRequestInfo != nil {
-						pullRequestInfo = releaseInfo.PullRequestInfo
-					}
-				}
-			}
-			if time.Now().After(end) {
-				return fmt.Errorf("Timed out waiting for pull request %s to merge. Waited %s", pr.URL, duration.String())
-			}
-			time.Sleep(*o.PullRequestPollDuration)
+// queuePromotion records the reason the promotion to the environment is queued on the PipelineActivity
+func (o *PromoteOptions) queuePromotion(jxClient versioned.Interface, env *v1.Environment, reason string) {
+	promoteKey := o.CreatePromoteKey(env)
+	if !promoteKey.IsValid() {
+		return
+	}
+	activity, _, err := promoteKey.GetOrCreate(jxClient, o.Namespace)
+	if err == nil {
+		err = workflow.RecordPromoteBlockedReason(jxClient, o.Namespace, activity, env.Name, reason)
+	}
+	if err != nil {
+		log.Logger().Warnf("Failed to record the queued promotion to %s on PipelineActivity %s: %s", env.Name, promoteKey.Name, err)
+	}
+}
+
+// checkGates returns an error if the gates of the workflow step which promotes to the environment do not pass for
+// the Facts about the PipelineActivity being promoted
+func (o *PromoteOptions) checkGates(jxClient versioned.Interface, promoteKey *kube.PromoteStepActivityKey) error {
+	if !promoteKey.IsValid() || promoteKey.Environment == "" {
+		return nil
+	}
+	activity, err := jxClient.JenkinsV1().PipelineActivities(o.Namespace).Get(promoteKey.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get PipelineActivity %s", promoteKey.Name)
+	}
+	if activity.Spec.Workflow == "" {
+		return nil
+	}
+	flow, err := jxClient.JenkinsV1().Workflows(o.Namespace).Get(activity.Spec.Workflow, metav1.GetOptions{})
+	if err != nil {
+		// a generated default workflow has no gates
+		if apierrors.IsNotFound(err) {
+			return nil
 		}
+		return errors.Wrapf(err, "failed to get Workflow %s", activity.Spec.Workflow)
+	}
+	step := workflow.FindPromoteStep(flow, promoteKey.Environment)
+	if step == nil {
+		return nil
+	}
+	reason, err := workflow.EvaluatePromotionGates(jxClient, o.Namespace, activity, step)
+	if err != nil {
+		return err
+	}
+	err = workflow.RecordPromoteBlockedReason(jxClient, o.Namespace, activity, promoteKey.Environment, reason)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		return fmt.Errorf("cannot promote %s to %s as %s", o.Application, promoteKey.Environment, reason)
 	}
 	return nil
 }
 
+// recordBlockedReason records the reason the promotion is blocked on the PipelineActivity, clearing it if the reason
+// is empty
+func (o *PromoteOptions) recordBlockedReason(jxClient versioned.Interface, promoteKey *kube.PromoteStepActivityKey, reason string) {
+	if !promoteKey.IsValid() {
+		return
+	}
+	activity, err := jxClient.JenkinsV1().PipelineActivities(o.Namespace).Get(promoteKey.Name, metav1.GetOptions{})
+	if err == nil {
+		err = workflow.RecordPromoteBlockedReason(jxClient, o.Namespace, activity, promoteKey.Environment, reason)
+	}
+	if err != nil {
+		log.Logger().Warnf("Failed to record the blocked reason on PipelineActivity %s: %s", promoteKey.Name, err)
+	}
+}
+
+// notifyPromotion posts the promotion to the team's chat channel if chat notifications are configured
+func (o *PromoteOptions) notifyPromotion(version string, envName string, step *v1.PromoteActivityStep) {
+	notifications, provider, err := o.CreateChatNotificationsProvider()
+	if err != nil {
+		log.Logger().Warnf("Failed to create the chat notifications provider: %s", err)
+		return
+	}
+	if notifications == nil {
+		return
+	}
+	message := chats.NewPromotionMessage(o.Application, version, envName, step)
+	err = provider.PostMessage(notifications.PromotionChannelOrDefault(), message)
+	if err != nil {
+		log.Logger().Warnf("Failed to post the promotion of %s to chat: %s", o.Application, err)
+	}
+}
+
 func (o *PromoteOptions) findLatestVersion(app string) (string, error) {
 	versions, err := o.Helm().SearchChartVersions(app)
 	if err != nil {
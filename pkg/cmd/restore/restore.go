@@ -0,0 +1,145 @@
+package restore
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/jenkins-x/jx/pkg/backup"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	optionGitURL = "git-url"
+	optionDir    = "dir"
+)
+
+// RestoreOptions contains the command line options
+type RestoreOptions struct {
+	*opts.CommonOptions
+
+	GitURL          string
+	Dir             string
+	SourceNamespace string
+	Namespace       string
+	DryRun          bool
+}
+
+var (
+	restoreLong = templates.LongDesc(`
+		Restores the Environments, Teams, Users, Environment Role Bindings, Schedulers, Source Repositories, Apps
+		and team settings from a backup repository created by 'jx controller backup' into the current cluster.
+
+		Resources in the backup are created or updated. Resources which are not in the backup are left alone. Use
+		--dry-run to see the differences between the backup and the cluster without changing anything.
+
+`)
+
+	restoreExample = templates.Examples(`
+		# Shows what would be restored from a backup repository
+		jx restore --git-url https://github.com/myorg/organisation-myorg-backup.git --dry-run
+
+		# Restores the backup of the jx namespace from a local clone of the backup repository
+		jx restore --dir ~/.jx/backup/organisation-myorg-backup
+
+		# Restores the backup of the jx namespace into the jx-staging namespace
+		jx restore --git-url https://github.com/myorg/organisation-myorg-backup.git --source-namespace jx --namespace jx-staging
+	`)
+)
+
+// NewCmdRestore creates the command
+func NewCmdRestore(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &RestoreOptions{
+		CommonOptions: commonOpts,
+	}
+	cmd := &cobra.Command{
+		Use:     "restore",
+		Short:   "Restores the resources in a backup repository into the current cluster",
+		Long:    restoreLong,
+		Example: restoreExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.GitURL, optionGitURL, "u", "", "The URL of the backup repository to clone")
+	cmd.Flags().StringVarP(&options.Dir, optionDir, "d", "", "The directory of a clone of the backup repository")
+	cmd.Flags().StringVarP(&options.SourceNamespace, "source-namespace", "s", "", "The namespace in the backup to restore. Defaults to the namespace being restored into")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace to restore into. Defaults to the current namespace")
+	cmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false, "Shows the differences between the backup and the cluster without changing anything")
+	return cmd
+}
+
+// Run implements this command
+func (o *RestoreOptions) Run() error {
+	dir := o.Dir
+	if dir == "" {
+		if o.GitURL == "" {
+			return util.MissingOption(optionGitURL)
+		}
+		tempDir, err := ioutil.TempDir("", "jx-restore-")
+		if err != nil {
+			return errors.Wrap(err, "failed to create a temporary directory")
+		}
+		defer os.RemoveAll(tempDir)
+
+		log.Logger().Infof("Cloning backup repository %s", util.ColorInfo(o.GitURL))
+		err = o.Git().Clone(o.GitURL, tempDir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to clone %s", o.GitURL)
+		}
+		dir = tempDir
+	}
+
+	jxClient, devNs, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	ns := o.Namespace
+	if ns == "" {
+		ns = devNs
+	}
+	sourceNs := o.SourceNamespace
+	if sourceNs == "" {
+		sourceNs = ns
+	}
+
+	if !o.DryRun {
+		// a fresh cluster may not have the CRDs of the resources yet
+		apisClient, err := o.ApiExtensionsClient()
+		if err != nil {
+			return err
+		}
+		err = kube.RegisterAllCRDs(apisClient)
+		if err != nil {
+			return err
+		}
+	}
+
+	restorer := &backup.Restorer{
+		JXClient:        jxClient,
+		Dir:             dir,
+		SourceNamespace: sourceNs,
+		Namespace:       ns,
+		DryRun:          o.DryRun,
+		Out:             o.Out,
+	}
+	results, err := restorer.Restore()
+	if err != nil {
+		return err
+	}
+	if o.DryRun {
+		log.Logger().Infof("Dry run: %d resources would be created, %d updated and %d are unchanged", results.Created, results.Updated, results.Unchanged)
+		return nil
+	}
+	log.Logger().Infof("Restored namespace %s: %d resources created, %d updated and %d unchanged", util.ColorInfo(ns), results.Created, results.Updated, results.Unchanged)
+	return nil
+}
@@ -0,0 +1,484 @@
+package rollback
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/buildnum"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/environments"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+const (
+	optionToVersion = "to-version"
+	optionSteps     = "steps"
+
+	// rollbackBranch is the branch name used in the pipeline name of the PipelineActivity recording a rollback
+	rollbackBranch = "rollback"
+)
+
+// RollbackOptions contains the command line options
+type RollbackOptions struct {
+	*opts.CommonOptions
+
+	Environment        string
+	Application        string
+	ToVersion          string
+	Steps              int
+	Alias              string
+	ReleaseName        string
+	LocalHelmRepoName  string
+	Pipeline           string
+	Build              string
+	NoMergePullRequest bool
+}
+
+var (
+	rollbackLong = templates.LongDesc(`
+		Rolls back an application in an environment to a previous version.
+
+		The previous version is found from the history of the environment's requirements and the Release resources
+		of the application, skipping any versions which failed to release. For environments managed via GitOps a
+		Pull Request is created on the environment repository, otherwise the chart is upgraded directly to the
+		previous version.
+
+		The rollback is recorded as a PipelineActivity so that it can be audited via 'jx get activity'.
+
+`)
+
+	rollbackExample = templates.Examples(`
+		# Rolls back myapp in staging to the version before the current one
+		jx rollback myapp --env staging
+
+		# Rolls back myapp in production by 2 versions
+		jx rollback myapp --env production --steps 2
+
+		# Rolls back myapp in production to a specific version
+		jx rollback myapp --env production --to-version 1.2.3
+	`)
+)
+
+// NewCmdRollback creates the command
+func NewCmdRollback(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &RollbackOptions{
+		CommonOptions: commonOpts,
+	}
+	cmd := &cobra.Command{
+		Use:     "rollback [application]",
+		Short:   "Rolls back an application in an Environment to a previous version",
+		Long:    rollbackLong,
+		Example: rollbackExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Environment, opts.OptionEnvironment, "e", "", "The Environment to roll back")
+	cmd.Flags().StringVarP(&options.Application, opts.OptionApplication, "a", "", "The Application to roll back")
+	cmd.Flags().StringVarP(&options.ToVersion, optionToVersion, "", "", "The version to roll back to. Defaults to the previous good version")
+	cmd.Flags().IntVarP(&options.Steps, optionSteps, "", 1, "The number of good versions to roll back by if no --"+optionToVersion+" is specified")
+	cmd.Flags().StringVarP(&options.Alias, "alias", "", "", "The optional alias used in the 'requirements.yaml' file")
+	cmd.Flags().StringVarP(&options.ReleaseName, "release", "", "", "The name of the helm release. Defaults to the namespace of the environment and the application name")
+	cmd.Flags().StringVarP(&options.LocalHelmRepoName, "helm-repo-name", "r", kube.LocalHelmRepoName, "The name of the helm repository that contains the app")
+	cmd.Flags().StringVarP(&options.Pipeline, "pipeline", "", "", "The Pipeline string in the form 'folderName/repoName/branch' used to record the rollback PipelineActivity. Defaults to 'owner/app/"+rollbackBranch+"'")
+	cmd.Flags().StringVarP(&options.Build, "build", "", "", "The Build number used to record the rollback PipelineActivity. Defaults to the next build number of the pipeline")
+	cmd.Flags().BoolVarP(&options.NoMergePullRequest, "no-merge", "", false, "Disables automatic merge of the rollback Pull Request")
+	return cmd
+}
+
+// Run implements this command
+func (o *RollbackOptions) Run() error {
+	app := o.Application
+	if app == "" && len(o.Args) > 0 {
+		app = o.Args[0]
+	}
+	if app == "" {
+		var err error
+		app, err = o.DiscoverAppName()
+		if err != nil {
+			return err
+		}
+	}
+	o.Application = app
+	if o.ToVersion == "" && o.Steps < 1 {
+		return util.InvalidOptionf(optionSteps, o.Steps, "must be at least 1")
+	}
+
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	if o.Environment == "" && !o.BatchMode {
+		envs, err := kube.GetPermanentEnvironments(jxClient, ns)
+		if err != nil {
+			return err
+		}
+		names := []string{}
+		for _, env := range envs {
+			names = append(names, env.Name)
+		}
+		o.Environment, err = kube.PickEnvironment(names, "", o.In, o.Out, o.Err)
+		if err != nil {
+			return err
+		}
+	}
+	if o.Environment == "" {
+		return util.MissingOption(opts.OptionEnvironment)
+	}
+	env, err := jxClient.JenkinsV1().Environments(ns).Get(o.Environment, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to find Environment %s", o.Environment)
+	}
+	if env.Spec.Namespace == "" {
+		return fmt.Errorf("environment %s does not have a namespace associated with it", env.Name)
+	}
+
+	releases, err := FindAppReleases(jxClient, ns, app)
+	if err != nil {
+		return err
+	}
+	if o.ToVersion != "" {
+		checkTargetRelease(o.ToVersion, releases)
+	}
+	key, err := o.createActivityKey(jxClient, ns, env, releases)
+	if err != nil {
+		return err
+	}
+	if env.Spec.Source.URL != "" && env.Spec.Kind.IsPermanent() {
+		return o.rollbackViaPullRequest(jxClient, ns, env, releases, key)
+	}
+	return o.rollbackViaHelm(jxClient, ns, env, releases, key)
+}
+
+// rollbackViaPullRequest creates a Pull Request on the environment repository which sets the application back to
+// the previous version
+func (o *RollbackOptions) rollbackViaPullRequest(jxClient versioned.Interface, ns string, env *v1.Environment,
+	releases []v1.Release, key *kube.PromoteStepActivityKey) error {
+	app := o.Application
+	version := o.ToVersion
+	details := gits.PullRequestDetails{
+		BranchName: rollbackBranchName(app, env.Name, version),
+		Title:      app + " rollback",
+		Message:    fmt.Sprintf("Roll back %s in environment %s", app, env.Name),
+	}
+
+	modifyChartFn := func(requirements *helm.Requirements, metadata *chart.Metadata, values map[string]interface{},
+		templates map[string]string, dir string, details *gits.PullRequestDetails) error {
+		dep := findDependency(requirements, app, o.Alias)
+		if dep == nil {
+			return fmt.Errorf("application %s is not deployed in environment %s", app, env.Name)
+		}
+		current := dep.Version
+		if version == "" {
+			history, err := environments.RequirementsVersionHistory(dir, dependencyName(dep))
+			if err != nil {
+				return err
+			}
+			version, err = PreviousVersion(current, history, releases, o.Steps)
+			if err != nil {
+				return errors.Wrapf(err, "failed to find the version of %s to roll back to in environment %s", app, env.Name)
+			}
+		}
+		if version == current {
+			return fmt.Errorf("application %s is already at version %s in environment %s", app, version, env.Name)
+		}
+		log.Logger().Infof("Rolling back %s in environment %s from version %s to %s", util.ColorInfo(app),
+			util.ColorInfo(env.Name), util.ColorInfo(current), util.ColorInfo(version))
+		dep.Version = version
+		// the branch is pushed after the chart is modified so it can include the version found from the history
+		details.BranchName = rollbackBranchName(app, env.Name, version)
+		details.Title = fmt.Sprintf("%s rollback to %s", app, version)
+		details.Message = fmt.Sprintf("Roll back %s in environment %s from version %s to %s", app, env.Name, current, version)
+		return nil
+	}
+
+	gitProvider, _, err := o.CreateGitProviderForURLWithoutKind(env.Spec.Source.URL)
+	if err != nil {
+		return errors.Wrapf(err, "creating git provider for %s", env.Spec.Source.URL)
+	}
+	environmentsDir, err := o.EnvironmentsDir()
+	if err != nil {
+		return errors.Wrapf(err, "getting environments dir")
+	}
+	options := environments.EnvironmentPullRequestOptions{
+		Gitter:        o.Git(),
+		GitProvider:   gitProvider,
+		ModifyChartFn: modifyChartFn,
+	}
+	info, err := options.Create(env, environmentsDir, &details, &gits.PullRequestFilter{}, "", !o.NoMergePullRequest)
+	if err != nil {
+		failErr := key.OnPromotePullRequest(jxClient, ns, failedRollbackPullRequest)
+		if failErr != nil {
+			log.Logger().Warnf("Failed to update PipelineActivity %s: %s", key.Name, failErr)
+		}
+		return err
+	}
+
+	prURL := ""
+	if info != nil && info.PullRequest != nil {
+		prURL = info.PullRequest.URL
+	}
+	startRollbackPR := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromotePullRequestStep) error {
+		err := kube.StartPromotionPullRequest(a, s, ps, p)
+		ps.Name = rollbackStepName(env.Name, version)
+		if p.PullRequestURL == "" {
+			p.PullRequestURL = prURL
+		}
+		a.Spec.Version = version
+		return err
+	}
+	err = key.OnPromotePullRequest(jxClient, ns, startRollbackPR)
+	if err != nil {
+		return errors.Wrapf(err, "failed to record the rollback on PipelineActivity %s", key.Name)
+	}
+	log.Logger().Infof("Created Pull Request %s to roll back %s to version %s, recorded as PipelineActivity %s",
+		util.ColorInfo(prURL), app, util.ColorInfo(version), util.ColorInfo(key.Name))
+	return nil
+}
+
+// rollbackViaHelm upgrades the helm release of the application directly to the previous version
+func (o *RollbackOptions) rollbackViaHelm(jxClient versioned.Interface, ns string, env *v1.Environment,
+	releases []v1.Release, key *kube.PromoteStepActivityKey) error {
+	app := o.Application
+	targetNS := env.Spec.Namespace
+	releaseName := o.ReleaseName
+	if releaseName == "" {
+		releaseName = targetNS + "-" + app
+	}
+	helmReleases, _, err := o.Helm().ListReleases(targetNS)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the helm releases in namespace %s", targetNS)
+	}
+	helmRelease, ok := helmReleases[releaseName]
+	if !ok {
+		return fmt.Errorf("application %s is not deployed in environment %s as there is no helm release %s", app, env.Name, releaseName)
+	}
+	current := helmRelease.ChartVersion
+	version := o.ToVersion
+	if version == "" {
+		version, err = PreviousVersion(current, nil, releases, o.Steps)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find the version of %s to roll back to in environment %s", app, env.Name)
+		}
+	}
+	if version == current {
+		return fmt.Errorf("application %s is already at version %s in environment %s", app, version, env.Name)
+	}
+	log.Logger().Infof("Rolling back %s in environment %s from version %s to %s", util.ColorInfo(app),
+		util.ColorInfo(env.Name), util.ColorInfo(current), util.ColorInfo(version))
+
+	startRollback := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteUpdateStep) error {
+		err := kube.StartPromotionUpdate(a, s, ps, p)
+		ps.Name = rollbackStepName(env.Name, version)
+		a.Spec.Version = version
+		return err
+	}
+	err = key.OnPromoteUpdate(jxClient, ns, startRollback)
+	if err != nil {
+		log.Logger().Warnf("Failed to update PipelineActivity %s: %s", key.Name, err)
+	}
+
+	chartName := app
+	if o.LocalHelmRepoName != "" {
+		chartName = o.LocalHelmRepoName + "/" + app
+	}
+	err = o.InstallChartWithOptions(helm.InstallChartOptions{
+		Chart:       chartName,
+		ReleaseName: releaseName,
+		Ns:          targetNS,
+		Version:     version,
+		NoForce:     true,
+		Wait:        true,
+	})
+	if err != nil {
+		failErr := key.OnPromoteUpdate(jxClient, ns, failedRollbackUpdate)
+		if failErr != nil {
+			log.Logger().Warnf("Failed to update PipelineActivity %s: %s", key.Name, failErr)
+		}
+		return errors.Wrapf(err, "failed to roll back helm release %s to version %s", releaseName, version)
+	}
+	err = key.OnPromoteUpdate(jxClient, ns, completeRollbackUpdate)
+	if err != nil {
+		return errors.Wrapf(err, "failed to record the rollback on PipelineActivity %s", key.Name)
+	}
+	log.Logger().Infof("Rolled back %s in environment %s to version %s, recorded as PipelineActivity %s",
+		util.ColorInfo(app), util.ColorInfo(env.Name), util.ColorInfo(version), util.ColorInfo(key.Name))
+	return nil
+}
+
+// createActivityKey creates the PipelineActivity which records the rollback, using the next build of the pipeline
+func (o *RollbackOptions) createActivityKey(jxClient versioned.Interface, ns string, env *v1.Environment,
+	releases []v1.Release) (*kube.PromoteStepActivityKey, error) {
+	pipeline := o.Pipeline
+	if pipeline == "" {
+		owner := ns
+		for _, release := range releases {
+			if release.Spec.GitOwner != "" {
+				owner = release.Spec.GitOwner
+				break
+			}
+		}
+		pipeline = fmt.Sprintf("%s/%s/%s", owner, o.Application, rollbackBranch)
+	}
+	pipelineID := kube.NewPipelineIDFromString(pipeline)
+	build := o.Build
+	if build == "" {
+		var err error
+		build, err = buildnum.NewBuildCounterBuildNumGen(jxClient, ns).NextBuildNumber(pipelineID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create the PipelineActivity for pipeline %s", pipeline)
+		}
+	}
+	key := &kube.PromoteStepActivityKey{
+		PipelineActivityKey: kube.PipelineActivityKey{
+			Name:     pipelineID.GetActivityName(build),
+			Pipeline: pipeline,
+			Build:    build,
+		},
+		Environment: env.Name,
+	}
+	if len(releases) > 0 {
+		key.ReleaseNotesURL = releases[0].Spec.ReleaseNotesURL
+	}
+	return key, nil
+}
+
+// FindAppReleases returns the Release resources of the application, newest version first
+func FindAppReleases(jxClient versioned.Interface, ns string, app string) ([]v1.Release, error) {
+	list, err := jxClient.JenkinsV1().Releases(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the Releases in namespace %s", ns)
+	}
+	answer := []v1.Release{}
+	for _, release := range list.Items {
+		if release.Spec.Name == app {
+			answer = append(answer, release)
+		}
+	}
+	kube.SortReleases(answer)
+	return answer, nil
+}
+
+// PreviousVersion returns the version to roll back to, which is the given number of good versions older than the
+// current version. The versions previously deployed in the environment, newest first, are preferred and then the
+// versions of the Release resources. Versions whose Release failed or which are newer than the current version,
+// such as a version which was already rolled back, are skipped.
+func PreviousVersion(current string, history []string, releases []v1.Release, steps int) (string, error) {
+	failed := map[string]bool{}
+	for _, release := range releases {
+		if release.Status.Status == v1.ReleaseStatusTypeFailed {
+			failed[release.Spec.Version] = true
+		}
+	}
+	candidates := []string{}
+	seen := map[string]bool{current: true}
+	add := func(version string) {
+		if version != "" && !seen[version] && !failed[version] && isOlderVersion(version, current) {
+			seen[version] = true
+			candidates = append(candidates, version)
+		}
+	}
+	for _, version := range history {
+		add(version)
+	}
+	for _, release := range releases {
+		add(release.Spec.Version)
+	}
+	if steps < 1 {
+		steps = 1
+	}
+	if len(candidates) < steps {
+		return "", fmt.Errorf("only found %d good versions older than %s but need to roll back by %d", len(candidates), current, steps)
+	}
+	return candidates[steps-1], nil
+}
+
+// isOlderVersion returns true if the version is older than the current version, or if either is not a semantic
+// version so cannot be compared
+func isOlderVersion(version string, current string) bool {
+	v, err := semver.Parse(strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return true
+	}
+	c, err := semver.Parse(strings.TrimPrefix(current, "v"))
+	if err != nil {
+		return true
+	}
+	return v.LT(c)
+}
+
+// checkTargetRelease warns if the version to roll back to has no Release or its Release failed
+func checkTargetRelease(version string, releases []v1.Release) {
+	for _, release := range releases {
+		if release.Spec.Version == version {
+			if release.Status.Status == v1.ReleaseStatusTypeFailed {
+				log.Logger().Warnf("The Release of version %s failed", version)
+			}
+			return
+		}
+	}
+	log.Logger().Warnf("No Release resource found for version %s", version)
+}
+
+func findDependency(requirements *helm.Requirements, app string, alias string) *helm.Dependency {
+	for _, dep := range requirements.Dependencies {
+		if dep != nil && dep.Name == app && (alias == "" || dep.Alias == alias) {
+			return dep
+		}
+	}
+	return nil
+}
+
+// dependencyName returns the name used to find the dependency in older revisions of the requirements
+func dependencyName(dep *helm.Dependency) string {
+	if dep.Alias != "" {
+		return dep.Alias
+	}
+	return dep.Name
+}
+
+// rollbackBranchName returns the name of the branch of the Pull Request rolling back the application in the
+// environment, which includes the version so that rollbacks to different versions don't reuse the same branch
+func rollbackBranchName(app string, envName string, version string) string {
+	name := "rollback-" + app + "-" + envName
+	if version != "" {
+		name += "-" + version
+	}
+	return name
+}
+
+func rollbackStepName(envName string, version string) string {
+	return fmt.Sprintf("Rollback %s to %s", envName, version)
+}
+
+func failedRollbackPullRequest(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromotePullRequestStep) error {
+	kube.FailedPromote(ps)
+	a.Spec.Status = v1.ActivityStatusTypeFailed
+	return kube.FailedPromotionPullRequest(a, s, ps, p)
+}
+
+func failedRollbackUpdate(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteUpdateStep) error {
+	a.Spec.Status = v1.ActivityStatusTypeFailed
+	return kube.FailedPromotionUpdate(a, s, ps, p)
+}
+
+func completeRollbackUpdate(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteUpdateStep) error {
+	a.Spec.Status = v1.ActivityStatusTypeSucceeded
+	return kube.CompletePromotionUpdate(a, s, ps, p)
+}
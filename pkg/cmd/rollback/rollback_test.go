@@ -0,0 +1,123 @@
+package rollback
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func createRelease(app string, version string, status v1.ReleaseStatusType) *v1.Release {
+	return &v1.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      app + "-" + version,
+			Namespace: "jx",
+		},
+		Spec: v1.ReleaseSpec{
+			Name:     app,
+			Version:  version,
+			GitOwner: "myorg",
+		},
+		Status: v1.ReleaseStatus{
+			Status: status,
+		},
+	}
+}
+
+func TestPreviousVersion(t *testing.T) {
+	t.Parallel()
+
+	releases := []v1.Release{
+		*createRelease("myapp", "1.3.0", v1.ReleaseStatusTypeDeployed),
+		*createRelease("myapp", "1.2.0", v1.ReleaseStatusTypeFailed),
+		*createRelease("myapp", "1.1.0", v1.ReleaseStatusTypeDeployed),
+		*createRelease("myapp", "1.0.0", v1.ReleaseStatusTypeDeployed),
+		*createRelease("myapp", "0.9.0", v1.ReleaseStatusTypeDeployed),
+	}
+
+	testCases := []struct {
+		name     string
+		current  string
+		history  []string
+		steps    int
+		expected string
+		err      bool
+	}{
+		{name: "previous release", current: "1.3.0", steps: 1, expected: "1.1.0"},
+		{name: "two releases back", current: "1.3.0", steps: 2, expected: "1.0.0"},
+		{name: "environment history first", current: "1.3.0", history: []string{"1.3.0", "1.0.0", "0.9.0"}, steps: 1, expected: "1.0.0"},
+		{name: "history then releases", current: "1.3.0", history: []string{"1.3.0", "1.0.0"}, steps: 2, expected: "1.1.0"},
+		{name: "skip failed history", current: "1.3.0", history: []string{"1.3.0", "1.2.0", "1.1.0"}, steps: 1, expected: "1.1.0"},
+		{name: "skip rolled back version", current: "1.1.0", history: []string{"1.1.0", "1.3.0", "1.1.0"}, steps: 1, expected: "1.0.0"},
+		{name: "not enough versions", current: "1.0.0", steps: 2, err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			version, err := PreviousVersion(tc.current, tc.history, releases, tc.steps)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, version)
+		})
+	}
+}
+
+func TestFindAppReleases(t *testing.T) {
+	t.Parallel()
+
+	jxClient := fake.NewSimpleClientset(
+		createRelease("myapp", "1.0.0", v1.ReleaseStatusTypeDeployed),
+		createRelease("other", "2.0.0", v1.ReleaseStatusTypeDeployed),
+		createRelease("myapp", "1.10.0", v1.ReleaseStatusTypeDeployed),
+		createRelease("myapp", "1.9.0", v1.ReleaseStatusTypeDeployed),
+	)
+	releases, err := FindAppReleases(jxClient, "jx", "myapp")
+	require.NoError(t, err)
+
+	versions := []string{}
+	for _, release := range releases {
+		versions = append(versions, release.Spec.Version)
+	}
+	assert.Equal(t, []string{"1.10.0", "1.9.0", "1.0.0"}, versions)
+}
+
+func TestCreateActivityKeyUsesNextBuild(t *testing.T) {
+	t.Parallel()
+
+	existing := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myorg-myapp-rollback-3",
+			Namespace: "jx",
+		},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline: "myorg/myapp/rollback",
+			Build:    "3",
+		},
+	}
+	jxClient := fake.NewSimpleClientset(existing)
+	env := &v1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "staging"}}
+	releases := []v1.Release{*createRelease("myapp", "1.0.0", v1.ReleaseStatusTypeDeployed)}
+
+	o := &RollbackOptions{Application: "myapp"}
+	key, err := o.createActivityKey(jxClient, "jx", env, releases)
+	require.NoError(t, err)
+	assert.Equal(t, "myorg/myapp/rollback", key.Pipeline)
+	assert.Equal(t, "4", key.Build)
+	assert.Equal(t, "myorg-myapp-rollback-4", key.Name)
+	assert.Equal(t, "staging", key.Environment)
+
+	_, err = jxClient.JenkinsV1().PipelineActivities("jx").Get(key.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func TestRollbackBranchName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "rollback-myapp-staging-1.2.3", rollbackBranchName("myapp", "staging", "1.2.3"))
+	assert.Equal(t, "rollback-myapp-staging", rollbackBranchName("myapp", "staging", ""))
+}
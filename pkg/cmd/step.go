@@ -8,6 +8,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/cmd/step/boot"
 	"github.com/jenkins-x/jx/pkg/cmd/step/buildpack"
 	"github.com/jenkins-x/jx/pkg/cmd/step/create"
+	"github.com/jenkins-x/jx/pkg/cmd/step/cve"
 	"github.com/jenkins-x/jx/pkg/cmd/step/e2e"
 	"github.com/jenkins-x/jx/pkg/cmd/step/env"
 	"github.com/jenkins-x/jx/pkg/cmd/step/get"
@@ -50,6 +51,7 @@ func NewCmdStep(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(step.NewCmdStepCredential(commonOpts))
 	cmd.AddCommand(create.NewCmdStepCreate(commonOpts))
 	cmd.AddCommand(step.NewCmdStepCustomPipeline(commonOpts))
+	cmd.AddCommand(cve.NewCmdStepCVE(commonOpts))
 	cmd.AddCommand(env.NewCmdStepEnv(commonOpts))
 	cmd.AddCommand(get.NewCmdStepGet(commonOpts))
 	cmd.AddCommand(git.NewCmdStepGit(commonOpts))
@@ -59,6 +61,7 @@ func NewCmdStep(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(nexus.NewCmdStepNexus(commonOpts))
 	cmd.AddCommand(step.NewCmdStepNextVersion(commonOpts))
 	cmd.AddCommand(step.NewCmdStepNextBuildNumber(commonOpts))
+	cmd.AddCommand(step.NewCmdStepNotify(commonOpts))
 	cmd.AddCommand(pre.NewCmdStepPre(commonOpts))
 	cmd.AddCommand(pr.NewCmdStepPR(commonOpts))
 	cmd.AddCommand(post.NewCmdStepPost(commonOpts))
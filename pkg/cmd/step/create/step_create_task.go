@@ -296,6 +296,19 @@ func (o *StepCreateTaskOptions) Run() error {
 		return errors.Wrapf(err, "failed to set the version on release pipelines")
 	}
 
+	skippedStages, stagesRemaining, err := o.removeSkippedStages(effectiveProjectConfig, pr)
+	if err != nil {
+		return errors.Wrap(err, "failed to evaluate the when conditions of the pipeline stages")
+	}
+	if !stagesRemaining {
+		log.Logger().Infof("All stages were skipped as their when conditions were not met, so no pipeline will be run")
+		if viper.GetBool(noApplyOptionName) || o.DryRun || o.InterpretMode || o.ViewSteps {
+			return nil
+		}
+		activityKey := tekton.GeneratePipelineActivity(o.BuildNumber, o.Branch, o.GitInfo, pr, tekton.BuildPipeline)
+		return tekton.MarkStagesSkipped(jxClient, ns, activityKey, skippedStages, true)
+	}
+
 	log.Logger().Debug("creating Tekton CRDs")
 	tektonCRDs, err := o.generateTektonCRDs(effectiveProjectConfig, ns, pipelineName)
 	if err != nil {
@@ -332,6 +345,10 @@ func (o *StepCreateTaskOptions) Run() error {
 		if err != nil {
 			return errors.Wrapf(err, "failed to apply Tekton CRDs")
 		}
+		err = tekton.MarkStagesSkipped(jxClient, ns, activityKey, skippedStages, false)
+		if err != nil {
+			return errors.Wrapf(err, "failed to record the skipped stages")
+		}
 		tektonCRDs.AddLabels(o.labels)
 
 		log.Logger().Debugf(" for %s", tektonCRDs.PipelineRun().Name)
@@ -548,6 +565,68 @@ func (o *StepCreateTaskOptions) generateTektonCRDs(effectiveProjectConfig *confi
 	return tektonCRDs, nil
 }
 
+// removeSkippedStages removes the stages whose when conditions are not met by this build from the effective pipeline.
+// It returns the stages which were removed and whether there are any stages left to run.
+func (o *StepCreateTaskOptions) removeSkippedStages(effectiveProjectConfig *config.ProjectConfig, pr *prow.PullRefs) ([]syntax.SkippedStage, bool, error) {
+	if effectiveProjectConfig == nil || effectiveProjectConfig.PipelineConfig == nil {
+		return nil, true, nil
+	}
+	parsed, err := effectiveProjectConfig.GetPipeline(o.PipelineKind)
+	if err != nil || parsed == nil {
+		return nil, true, err
+	}
+
+	env := make(map[string]string)
+	for _, e := range effectiveProjectConfig.PipelineConfig.Env {
+		env[e.Name] = e.Value
+	}
+	for _, e := range parsed.GetEnv() {
+		env[e.Name] = e.Value
+	}
+	for _, e := range o.CustomEnvs {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+
+	ctx := &syntax.WhenContext{
+		Branch:       o.Branch,
+		Kind:         o.PipelineKind,
+		ChangedPaths: o.changedPaths(pr),
+		Env:          env,
+	}
+	skipped, err := parsed.RemoveSkippedStages(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, s := range skipped {
+		log.Logger().Infof("Skipping stage %s as its when conditions were not met", util.ColorInfo(tekton.StageNameIncludingParents(s.Parents, s.Name)))
+	}
+	return skipped, len(parsed.Stages) > 0, nil
+}
+
+// changedPaths returns the files changed by the pull request being built, or nil if they cannot be determined
+func (o *StepCreateTaskOptions) changedPaths(pr *prow.PullRefs) []string {
+	if pr == nil || pr.BaseSha == "" {
+		return nil
+	}
+	out, err := o.Git().ListChangedFilesFromBranch(o.CloneDir, pr.BaseSha)
+	if err != nil {
+		log.Logger().Warnf("Unable to find the files changed since %s in %s: %s", pr.BaseSha, o.CloneDir, err)
+		return nil
+	}
+	paths := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		// each line is the status followed by one path, or two for renames and copies, all separated by tabs
+		fields := strings.Split(line, "\t")
+		if len(fields) > 1 {
+			paths = append(paths, fields[1:]...)
+		}
+	}
+	return paths
+}
+
 func (o *StepCreateTaskOptions) loadProjectConfig() (*config.ProjectConfig, string, error) {
 	if o.Context != "" {
 		fileName := filepath.Join(o.CloneDir, fmt.Sprintf("jenkins-x-%s.yml", o.Context))
@@ -0,0 +1,41 @@
+package cve
+
+import (
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/spf13/cobra"
+)
+
+// StepCVEOptions contains the command line flags
+type StepCVEOptions struct {
+	opts.StepOptions
+}
+
+// NewCmdStepCVE Steps a command object for the "step cve" command
+func NewCmdStepCVE(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepCVEOptions{
+		StepOptions: opts.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "cve",
+		Short: "pipeline steps for Common Vulnerabilities and Exposures (CVEs)",
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.AddCommand(NewCmdStepCVEGate(commonOpts))
+
+	return cmd
+}
+
+// Run implements this command
+func (o *StepCVEOptions) Run() error {
+	return o.Cmd.Help()
+}
@@ -0,0 +1,140 @@
+package cve
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/cve"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// StepCVEGateOptions contains the command line flags
+type StepCVEGateOptions struct {
+	StepCVEOptions
+	ImageName   string
+	ImageID     string
+	Version     string
+	Severity    string
+	Ignore      []string
+	FixableOnly bool
+	CVEProvider opts.CVEProviderOptions
+}
+
+var (
+	stepCVEGateLong = templates.LongDesc(`
+		Fails if an image has any Common Vulnerabilities and Exposures (CVEs) at or above the given severity.
+
+		The vulnerabilities can come from any CVE provider, including the Trivy or Grype JSON reports of a scan earlier in the pipeline.
+`)
+
+	stepCVEGateExample = templates.Examples(`
+		# fail the build if the Trivy scan of the image found any high or critical vulnerabilities
+		jx step cve gate --provider report --report trivy.json --image-name myorg/myapp --version 1.0.1
+
+		# fail the build on critical vulnerabilities which have a fix available, ignoring one we have accepted
+		jx step cve gate --image-name myorg/myapp --severity critical --fixable-only --ignore CVE-2019-1234
+	`)
+)
+
+// NewCmdStepCVEGate creates the command
+func NewCmdStepCVEGate(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepCVEGateOptions{
+		StepCVEOptions: StepCVEOptions{
+			StepOptions: opts.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "gate",
+		Short:   "Fails if an image has vulnerabilities at or above a severity",
+		Long:    stepCVEGateLong,
+		Example: stepCVEGateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.ImageName, "image-name", "", "", "Full image name e.g. jenkinsxio/nexus")
+	cmd.Flags().StringVarP(&options.ImageID, "image-id", "", "", "Image ID in CVE engine if already known")
+	cmd.Flags().StringVarP(&options.Version, "version", "", "", "Version or tag e.g. 0.0.1")
+	cmd.Flags().StringVarP(&options.Severity, "severity", "s", string(cve.SeverityHigh), "The lowest severity which fails the gate. Valid values: "+severityNames())
+	cmd.Flags().StringArrayVarP(&options.Ignore, "ignore", "", nil, "The IDs of vulnerabilities to ignore, such as CVE-2019-1234")
+	cmd.Flags().BoolVarP(&options.FixableOnly, "fixable-only", "", false, "Only fail the gate for vulnerabilities which have a fixed version available")
+	options.CVEProvider.AddCVEProviderFlags(cmd)
+
+	return cmd
+}
+
+// Run implements this command
+func (o *StepCVEGateOptions) Run() error {
+	if o.ImageID == "" && o.ImageName == "" {
+		return util.MissingOption("image-name")
+	}
+	threshold := cve.ParseSeverity(o.Severity)
+	if !strings.EqualFold(string(threshold), o.Severity) {
+		return util.InvalidOption("severity", o.Severity, strings.Split(severityNames(), ", "))
+	}
+
+	p, err := o.CreateCVEProvider(&o.CVEProvider)
+	if err != nil {
+		return err
+	}
+
+	query := cve.CVEQuery{
+		ImageID:   o.ImageID,
+		ImageName: o.ImageName,
+		Vesion:    o.Version,
+	}
+	vulnerabilities, err := p.GetImageVulnerabilities(nil, nil, query)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the vulnerabilities for image %s%s", o.ImageName, o.ImageID)
+	}
+
+	failures := o.failures(vulnerabilities, threshold)
+	if len(failures) == 0 {
+		log.Logger().Infof("No vulnerabilities found with severity %s or above", util.ColorInfo(threshold))
+		return nil
+	}
+
+	cve.SortVulnerabilities(failures)
+	table := o.CreateTable()
+	table.AddRow("Image", util.ColorInfo("Severity"), "Vulnerability", "URL", "Package", "Fix")
+	cve.AddVulnerabilityTableRows(&table, failures)
+	table.Render()
+
+	return fmt.Errorf("found %d vulnerabilities with severity %s or above", len(failures), threshold)
+}
+
+// failures returns the vulnerabilities which fail the gate
+func (o *StepCVEGateOptions) failures(vulnerabilities []cve.ImageVulnerability, threshold cve.Severity) []cve.ImageVulnerability {
+	var answer []cve.ImageVulnerability
+	for _, v := range cve.FilterBySeverity(vulnerabilities, threshold) {
+		if util.StringArrayIndex(o.Ignore, v.ID) >= 0 {
+			continue
+		}
+		if o.FixableOnly && v.FixedVersion == "" {
+			continue
+		}
+		answer = append(answer, v)
+	}
+	return answer
+}
+
+func severityNames() string {
+	var names []string
+	for _, s := range cve.Severities {
+		names = append(names, strings.ToLower(string(s)))
+	}
+	return strings.Join(names, ", ")
+}
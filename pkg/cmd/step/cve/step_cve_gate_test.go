@@ -0,0 +1,77 @@
+package cve_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/cmd/clients"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	stepcve "github.com/jenkins-x/jx/pkg/cmd/step/cve"
+	"github.com/jenkins-x/jx/pkg/cve"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepCVEGate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		severity    string
+		ignore      []string
+		fixableOnly bool
+		fails       bool
+	}{
+		{name: "high vulnerability fails the gate", severity: "high", fails: true},
+		{name: "low vulnerability fails the gate", severity: "low", fails: true},
+		{name: "no critical vulnerabilities", severity: "critical"},
+		{name: "high vulnerability ignored", severity: "High", ignore: []string{"CVE-2019-14697"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commonOpts := opts.NewCommonOptionsWithFactory(clients.NewFactory())
+			commonOpts.Out = os.Stdout
+			o := &stepcve.StepCVEGateOptions{
+				StepCVEOptions: stepcve.StepCVEOptions{
+					StepOptions: opts.StepOptions{
+						CommonOptions: &commonOpts,
+					},
+				},
+				ImageName:   "jenkinsxio/nexus",
+				Version:     "0.0.5",
+				Severity:    tt.severity,
+				Ignore:      tt.ignore,
+				FixableOnly: tt.fixableOnly,
+				CVEProvider: opts.CVEProviderOptions{
+					Provider: cve.ProviderKindReport,
+					Reports:  []string{filepath.Join("test_data", "trivy.json")},
+				},
+			}
+
+			err := o.Run()
+			if tt.fails {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestStepCVEGateInvalidSeverity(t *testing.T) {
+	t.Parallel()
+
+	o := &stepcve.StepCVEGateOptions{
+		StepCVEOptions: stepcve.StepCVEOptions{
+			StepOptions: opts.StepOptions{
+				CommonOptions: &opts.CommonOptions{},
+			},
+		},
+		ImageName: "jenkinsxio/nexus",
+		Severity:  "urgent",
+	}
+
+	err := o.Run()
+	assert.Error(t, err)
+}
@@ -7,11 +7,13 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
 
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
@@ -126,15 +128,17 @@ func (o *StepGitCredentialsOptions) CreateGitCredentialsFromSecrets(secretList *
 							log.Logger().Warnf("Ignoring invalid Git service URL %s for pipeline credential %s", u, secret.Name)
 						} else {
 							u2.User = url.UserPassword(string(username), string(pwd))
-							buffer.WriteString(u2.String() + "\n")
-
-							// lets write the other http protocol for completeness
-							if u2.Scheme == "https" {
-								u2.Scheme = "http"
-							} else {
-								u2.Scheme = "https"
+							writeGitCredentials(&buffer, *u2)
+
+							if labels[kube.LabelServiceKind] == gits.KindAzureDevOps || gits.SaasGitKind(u) == gits.KindAzureDevOps {
+								// Azure DevOps remote URLs include the organisation as the user so git only
+								// uses credentials stored for that user
+								org := azureDevOpsOrganisation(u2)
+								if org != "" && org != string(username) {
+									u2.User = url.UserPassword(org, string(pwd))
+									writeGitCredentials(&buffer, *u2)
+								}
 							}
-							buffer.WriteString(u2.String() + "\n")
 						}
 					}
 				}
@@ -143,3 +147,23 @@ func (o *StepGitCredentialsOptions) CreateGitCredentialsFromSecrets(secretList *
 	}
 	return buffer.Bytes()
 }
+
+// writeGitCredentials writes the credentials for the URL and for the other http protocol for completeness
+func writeGitCredentials(buffer *bytes.Buffer, u url.URL) {
+	buffer.WriteString(u.String() + "\n")
+	if u.Scheme == "https" {
+		u.Scheme = "http"
+	} else {
+		u.Scheme = "https"
+	}
+	buffer.WriteString(u.String() + "\n")
+}
+
+// azureDevOpsOrganisation returns the organisation of an Azure DevOps server URL of the form
+// https://dev.azure.com/{organisation} or https://{organisation}.visualstudio.com
+func azureDevOpsOrganisation(u *url.URL) string {
+	if strings.HasSuffix(u.Host, ".visualstudio.com") {
+		return strings.TrimSuffix(u.Host, ".visualstudio.com")
+	}
+	return strings.Split(strings.Trim(u.Path, "/"), "/")[0]
+}
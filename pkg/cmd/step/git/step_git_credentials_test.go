@@ -45,6 +45,28 @@ func TestStepGitCredentials(t *testing.T) {
 	tests.Debugf("Generated git credentials: %s\n", actual)
 }
 
+func TestStepGitCredentialsAzureDevOps(t *testing.T) {
+	t.Parallel()
+	scheme := "https://"
+	host := "dev.azure.com/myorg"
+	user := "jstrachan"
+	pwd := "lovelyLager"
+
+	expected := createGitCredentialLine(scheme, host, user, pwd) +
+		createGitCredentialLine(scheme, host, "myorg", pwd)
+
+	secretList := &corev1.SecretList{
+		Items: []corev1.Secret{
+			testkube.CreateTestPipelineGitSecret(gits.KindAzureDevOps, "azure", scheme+host, user, pwd),
+		},
+	}
+
+	options := &git.StepGitCredentialsOptions{}
+	actual := string(options.CreateGitCredentialsFromSecrets(secretList))
+
+	assert.Equal(t, expected, actual, "generated git credentials file")
+}
+
 func createGitCredentialLine(scheme string, host string, user string, pwd string) string {
 	answer := scheme + user + ":" + pwd + "@" + host + "\n"
 	if scheme == "https://" {
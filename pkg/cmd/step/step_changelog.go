@@ -7,7 +7,6 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"text/template"
 	"time"
@@ -30,6 +29,8 @@ import (
 	"github.com/jenkins-x/jx/pkg/issues"
 	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/log"
+	pipeline_events "github.com/jenkins-x/jx/pkg/pipeline_events"
+	"github.com/jenkins-x/jx/pkg/semrel"
 	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/spf13/cobra"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
@@ -143,8 +144,8 @@ e.g. define environment variables GIT_USERNAME and GIT_API_TOKEN
 
 `)
 
-	GitHubIssueRegex = regexp.MustCompile(`(\#\d+)`)
-	JIRAIssueRegex   = regexp.MustCompile(`[A-Z][A-Z]+-(\d+)`)
+	GitHubIssueRegex = issues.GitIssueKeyRegex
+	JIRAIssueRegex   = issues.JiraIssueKeyRegex
 )
 
 func NewCmdStepChangelog(commonOpts *opts.CommonOptions) *cobra.Command {
@@ -392,7 +393,11 @@ func (o *StepChangelogOptions) Run() error {
 	}
 
 	// lets try to update the release
-	markdown, err := gits.GenerateMarkdown(&release.Spec, gitInfo)
+	rules, err := semrel.LoadRules(dir)
+	if err != nil {
+		return err
+	}
+	markdown, err := gits.GenerateMarkdownWithGroups(&release.Spec, gitInfo, rules.CommitGroups(), rules.ParseChangelogCommit)
 	if err != nil {
 		return err
 	}
@@ -533,11 +538,14 @@ func (o *StepChangelogOptions) Run() error {
 		devRelease.Namespace = devNs
 		devRelease.Name = naming.ToValidName(appName + "-" + cleanVersion)
 		devRelease.Spec.Name = appName
-		_, err := kube.GetOrCreateRelease(jxClient, devNs, &devRelease)
+		created, err := kube.GetOrCreateRelease(jxClient, devNs, &devRelease)
 		if err != nil {
 			log.Logger().Warnf("%s", err)
 		} else {
 			log.Logger().Infof("Created Release %s resource in namespace %s", devRelease.Name, devNs)
+			o.SendPipelineEvent(func(provider pipeline_events.PipelineEventsProvider) error {
+				return provider.SendRelease(created)
+			})
 		}
 	}
 	releaseNotesURL := release.Spec.ReleaseNotesURL
@@ -655,18 +663,15 @@ func (o *StepChangelogOptions) addIssuesAndPullRequests(spec *v1.ReleaseSpec, co
 	tracker := o.State.Tracker
 
 	gitProvider := o.State.GitProvider
-	if gitProvider == nil || !gitProvider.HasIssues() {
+	issueKind := issues.GetIssueProvider(tracker)
+	if gitProvider == nil || (issueKind == issues.Git && !gitProvider.HasIssues()) {
 		return nil
 	}
-	regex := GitHubIssueRegex
-	issueKind := issues.GetIssueProvider(tracker)
+	regex := issues.IssueKeyRegex(tracker)
 	if !o.State.LoggedIssueKind {
 		o.State.LoggedIssueKind = true
 		log.Logger().Infof("Finding issues in commit messages using %s format", issueKind)
 	}
-	if issueKind == issues.Jira {
-		regex = JIRAIssueRegex
-	}
 	message := fullCommitMessageText(rawCommit)
 
 	matches := regex.FindAllStringSubmatch(message, -1)
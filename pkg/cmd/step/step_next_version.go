@@ -43,6 +43,8 @@ type StepNextVersionOptions struct {
 	UseGitTagOnly   bool
 	NewVersion      string
 	SemanticRelease bool
+	RulesFile       string
+	Branch          string
 	opts.StepOptions
 }
 
@@ -64,6 +66,9 @@ var (
 		jx step next-version --filename package.json
 		jx step next-version --filename package.json --tag
 		jx step next-version --filename package.json --tag --version 1.2.3
+
+		# use the conventional commits since the last tag, configured by the semantic-release.yml file if there is one
+		jx step next-version --semantic-release
 `)
 )
 
@@ -91,6 +96,8 @@ func NewCmdStepNextVersion(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().BoolVarP(&options.Tag, "tag", "t", false, "tag and push new version")
 	cmd.Flags().BoolVarP(&options.UseGitTagOnly, "use-git-tag-only", "", false, "only use a git tag so work out new semantic version, else specify filename [pom.xml,package.json,Makefile,Chart.yaml]")
 	cmd.Flags().BoolVarP(&options.SemanticRelease, "semantic-release", "", false, "use conventional commits to determine next version. Ignores the --use-git-tag-only and --version options See https://github.com/angular/angular.js/blob/master/DEVELOPERS.md#-git-commit-guidelines")
+	cmd.Flags().StringVarP(&options.RulesFile, "rules", "", "", "the file configuring how conventional commits change the version. Defaults to "+semrel.RulesFileName+" in the directory")
+	cmd.Flags().StringVarP(&options.Branch, "branch", "", "", "the branch being released, used to find its pre-release channel. Defaults to the current branch")
 	return cmd
 }
 
@@ -110,10 +117,25 @@ func (o *StepNextVersionOptions) Run() error {
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		newVersion, err := semrel.GetNewVersion(o.Dir, cur, o.Git(), tag, rev)
+		rulesFile := o.RulesFile
+		if rulesFile == "" {
+			rulesFile = filepath.Join(o.Dir, semrel.RulesFileName)
+		}
+		rules, err := semrel.LoadRulesFile(rulesFile)
+		if err != nil {
+			return err
+		}
+		branch := o.Branch
+		if branch == "" && len(rules.Branches) > 0 {
+			branch = o.GetBranchName(o.Dir)
+		}
+		newVersion, err := semrel.GetNewVersionWithRules(o.Dir, cur, o.Git(), tag, rev, rules, branch)
 		if err != nil {
 			return errors.Wrapf(err, "getting new semantic release version for %s", tag)
 		}
+		if newVersion == nil {
+			return errors.Errorf("none of the commits since %s change the version", tag)
+		}
 		o.NewVersion = newVersion.String()
 	} else if o.NewVersion == "" {
 		o.NewVersion, err = o.getNewVersionFromTagAndFile()
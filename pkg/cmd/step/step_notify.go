@@ -0,0 +1,196 @@
+package step
+
+import (
+	"fmt"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/chats"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StepNotifyOptions contains the command line flags
+type StepNotifyOptions struct {
+	opts.StepOptions
+
+	Pipeline    string
+	Build       string
+	Status      string
+	Channel     string
+	Environment string
+	Application string
+	Version     string
+}
+
+var (
+	stepNotifyLong = templates.LongDesc(`
+		Posts the outcome of a pipeline, or of a promotion to an environment, to the team's chat channel.
+
+		The chat service and channels are configured in the 'chatNotifications' of the team settings. The credentials
+		of the chat service, or the secret part of the URL of an incoming webhook, are stored in the chat auth config.
+`)
+
+	stepNotifyExample = templates.Examples(`
+		# post the outcome of the current pipeline
+		jx step notify
+
+		# post that the current pipeline failed, before its PipelineActivity has been updated
+		jx step notify --status Failed
+
+		# post the promotion of the current version of an application to staging
+		jx step notify --env staging --app myapp
+`)
+)
+
+// NewCmdStepNotify creates the command
+func NewCmdStepNotify(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := StepNotifyOptions{
+		StepOptions: opts.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "notify",
+		Short:   "Posts the outcome of a pipeline or promotion to the team's chat channel",
+		Long:    stepNotifyLong,
+		Example: stepNotifyExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Pipeline, "pipeline", "p", "", "The pipeline name such as 'myorg/myrepo/master'. Defaults to the current pipeline")
+	cmd.Flags().StringVarP(&options.Build, "build", "", "", "The build number. Defaults to the current build")
+	cmd.Flags().StringVarP(&options.Status, "status", "s", "", "The status to post, such as Succeeded or Failed. Defaults to the status of the PipelineActivity")
+	cmd.Flags().StringVarP(&options.Channel, "channel", "c", "", "The channel to post to. Defaults to the channel in the team's chat notification settings")
+	cmd.Flags().StringVarP(&options.Environment, "env", "e", "", "The environment the application was promoted to. If specified the promotion is posted rather than the pipeline")
+	cmd.Flags().StringVarP(&options.Application, "app", "a", "", "The application which was promoted. Defaults to the repository of the pipeline")
+	cmd.Flags().StringVarP(&options.Version, "version", "v", "", "The version which was promoted. Defaults to the version of the PipelineActivity")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepNotifyOptions) Run() error {
+	notifications, provider, err := o.CreateChatNotificationsProvider()
+	if err != nil {
+		return errors.Wrap(err, "failed to create the chat notifications provider")
+	}
+	if notifications == nil {
+		log.Logger().Infof("No chat notifications are configured for the team")
+		return nil
+	}
+
+	activity, err := o.findActivity()
+	if err != nil {
+		return err
+	}
+	if o.Status != "" {
+		activity.Spec.Status = v1.ActivityStatusType(o.Status)
+	}
+
+	var message *chats.Message
+	channel := o.Channel
+	if o.Environment != "" {
+		message = o.promotionMessage(activity)
+		if channel == "" {
+			channel = notifications.PromotionChannelOrDefault()
+		}
+	} else {
+		if notifications.FailuresOnly && chats.ActivityStatus(activity.Spec.Status) != chats.MessageStatusFailed {
+			log.Logger().Infof("Not posting pipeline %s #%s as only failures are posted", activity.Spec.Pipeline, activity.Spec.Build)
+			return nil
+		}
+		message = chats.NewPipelineMessage(activity)
+		if channel == "" {
+			channel = notifications.Channel
+		}
+	}
+
+	err = provider.PostMessage(channel, message)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("Posted %s to chat", util.ColorInfo(message.Title))
+	return nil
+}
+
+// findActivity returns the PipelineActivity of the build, or an activity with just the pipeline and build if there
+// isn't one yet
+func (o *StepNotifyOptions) findActivity() (*v1.PipelineActivity, error) {
+	pipeline := o.Pipeline
+	if pipeline == "" {
+		pipeline = o.GetJenkinsJobName()
+	}
+	if pipeline == "" {
+		return nil, util.MissingOption("pipeline")
+	}
+	build := o.Build
+	if build == "" {
+		build = o.GetBuildNumber()
+	}
+	if build == "" {
+		return nil, util.MissingOption("build")
+	}
+
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create the JX client")
+	}
+	pipelineID := kube.NewPipelineIDFromString(pipeline)
+	name := pipelineID.GetActivityName(build)
+	activity, err := jxClient.JenkinsV1().PipelineActivities(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		log.Logger().Warnf("Failed to find the PipelineActivity %s: %s", name, err)
+		activity = &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline: pipeline,
+				Build:    build,
+			},
+		}
+	}
+	return activity, nil
+}
+
+// promotionMessage creates the message for the promotion to the environment, using the promote step of the activity
+// if there is one
+func (o *StepNotifyOptions) promotionMessage(activity *v1.PipelineActivity) *chats.Message {
+	app := o.Application
+	if app == "" {
+		app = activity.RepositoryName()
+	}
+	version := o.Version
+	if version == "" {
+		version = activity.Spec.Version
+	}
+	var step *v1.PromoteActivityStep
+	for i := range activity.Spec.Steps {
+		promote := activity.Spec.Steps[i].Promote
+		if promote != nil && promote.Environment == o.Environment {
+			step = promote.DeepCopy()
+		}
+	}
+	if o.Status != "" {
+		if step == nil {
+			step = &v1.PromoteActivityStep{
+				CoreActivityStep: v1.CoreActivityStep{
+					Name: fmt.Sprintf("Promote: %s", o.Environment),
+				},
+				Environment: o.Environment,
+			}
+		}
+		step.Status = v1.ActivityStatusType(o.Status)
+	}
+	return chats.NewPromotionMessage(app, version, o.Environment, step)
+}
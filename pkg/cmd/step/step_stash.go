@@ -34,12 +34,16 @@ type StepStashOptions struct {
 	StorageLocation jenkinsv1.StorageLocation
 	ProjectGitURL   string
 	ProjectBranch   string
+	Name            string
 }
 
 const (
 	envVarBranchName = "BRANCH_NAME"
 	envVarSourceUrl  = "SOURCE_URL"
 
+	// ClassificationStash the default classifier for named stashes passed between pipeline stages
+	ClassificationStash = "stash"
+
 	// storageSupportDescription common text for long command descriptions around storage
 	StorageSupportDescription = `
 Currently Jenkins X supports storing files into a branch of a git repository or in cloud blob storage like S3, GCS, Azure blobs etc.
@@ -72,6 +76,9 @@ var (
 		# lets collect some files to a specific cloud storage bucket and specify the path to store them inside
 		jx step stash -c tests -p "target/test-reports/*" ---bucket-url gs://my-gcp-bucket --to-path tests/mystuff
 
+		# lets stash some files under a name so that a later stage of this build can unstash them
+		jx step stash --name binaries -p "target/*.jar"
+
 `)
 )
 
@@ -104,6 +111,7 @@ func NewCmdStepStash(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Basedir, "basedir", "", "", "The base directory to use to create relative output file names. e.g. if you specify '--pattern \"target/*.xml\" then you may want to supply '--basedir target' to strip the 'target/' prefix from all collected files")
 	cmd.Flags().StringVarP(&options.ProjectGitURL, "project-git-url", "", "", "The project git URL to collect for. Used to default the organisation and repository folders in the storage. If not specified its discovered from the local '.git' folder")
 	cmd.Flags().StringVarP(&options.ProjectBranch, "project-branch", "", "", "The project git branch of the project to collect for. Used to default the branch folder in the storage. If not specified its discovered from the local '.git' folder")
+	cmd.Flags().StringVarP(&options.Name, "name", "n", "", "The name of the stash so that the files can be unstashed by name in a later stage of the same build via 'jx step unstash --name'. Defaults the classifier to '"+ClassificationStash+"'")
 	return cmd
 }
 
@@ -120,6 +128,10 @@ func (o *StepStashOptions) Run() error {
 		return util.MissingOption("pattern")
 	}
 	classifier := o.StorageLocation.Classifier
+	if classifier == "" && o.Name != "" {
+		classifier = ClassificationStash
+		o.StorageLocation.Classifier = classifier
+	}
 	if classifier == "" {
 		return util.MissingOption("classifier")
 	}
@@ -204,6 +216,9 @@ func (o *StepStashOptions) Run() error {
 	storagePath := o.ToPath
 	if storagePath == "" {
 		storagePath = filepath.Join("jenkins-x", classifier, projectOrg, projectRepoName, projectBranchName, buildNo)
+		if o.Name != "" {
+			storagePath = filepath.Join(storagePath, o.Name)
+		}
 	}
 
 	urls, err := coll.CollectFiles(o.Pattern, storagePath, o.Basedir)
@@ -215,28 +230,18 @@ func (o *StepStashOptions) Run() error {
 		log.Logger().Infof("stashed: %s", util.ColorInfo(u))
 	}
 
-	// TODO this pipeline name construction needs moving to a shared lib, and other things refactoring to use it
-	pipeline := fmt.Sprintf("%s-%s-%s-%s", projectOrg, projectRepoName, projectBranchName, buildNo)
-
-	if pipeline != "" && buildNo != "" {
-		name := naming.ToValidName(pipeline)
-		key := &kube.PromoteStepActivityKey{
-			PipelineActivityKey: kube.PipelineActivityKey{
-				Name:     name,
-				Pipeline: pipeline,
-				Build:    buildNo,
-				GitInfo: &gits.GitRepository{
-					Organisation: projectOrg,
-					Name:         projectRepoName,
-				},
-			},
-		}
+	if buildNo != "" {
+		key := stashActivityKey(projectOrg, projectRepoName, projectBranchName, buildNo)
 		a, _, err := key.GetOrCreate(client, ns)
 		if err != nil {
 			return err
 		}
+		attachmentName := classifier
+		if o.Name != "" {
+			attachmentName = StashAttachmentName(o.Name)
+		}
 		a.Spec.Attachments = append(a.Spec.Attachments, jenkinsv1.Attachment{
-			Name: classifier,
+			Name: attachmentName,
 			URLs: urls,
 		})
 		_, err = client.JenkinsV1().PipelineActivities(ns).PatchUpdate(a)
@@ -246,3 +251,25 @@ func (o *StepStashOptions) Run() error {
 	}
 	return nil
 }
+
+// StashAttachmentName returns the name of the PipelineActivity attachment which lists the URLs of the named stash
+func StashAttachmentName(name string) string {
+	return ClassificationStash + "-" + name
+}
+
+// stashActivityKey returns the key of the PipelineActivity which stashed files are attached to
+func stashActivityKey(org string, repoName string, branch string, buildNo string) *kube.PromoteStepActivityKey {
+	// TODO this pipeline name construction needs moving to a shared lib, and other things refactoring to use it
+	pipeline := fmt.Sprintf("%s-%s-%s-%s", org, repoName, branch, buildNo)
+	return &kube.PromoteStepActivityKey{
+		PipelineActivityKey: kube.PipelineActivityKey{
+			Name:     naming.ToValidName(pipeline),
+			Pipeline: pipeline,
+			Build:    buildNo,
+			GitInfo: &gits.GitRepository{
+				Organisation: org,
+				Name:         repoName,
+			},
+		},
+	}
+}
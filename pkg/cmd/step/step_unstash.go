@@ -1,8 +1,11 @@
 package step
 
 import (
+	"fmt"
 	"io/ioutil"
 	"net/url"
+	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -18,6 +21,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // StepUnstashOptions contains the command line flags
@@ -27,6 +31,7 @@ type StepUnstashOptions struct {
 	URL     string
 	OutDir  string
 	Timeout time.Duration
+	Name    string
 }
 
 var (
@@ -40,6 +45,9 @@ var (
 
 		# unstash the file to the from GCS to the console
 		jx step unstash -u gs://mybucket/foo/bar/output.log
+
+		# unstash the files stashed earlier in this build via 'jx step stash --name binaries' into the current directory
+		jx step unstash --name binaries -o .
 `)
 )
 
@@ -66,12 +74,16 @@ func NewCmdStepUnstash(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.URL, "url", "u", "", "The fully qualified URL to the file to unstash including the storage host, path and file name")
 	cmd.Flags().StringVarP(&options.OutDir, "output", "o", "", "The output file or directory")
 	cmd.Flags().DurationVarP(&options.Timeout, "timeout", "t", time.Second*30, "The timeout period before we should fail unstashing the entry")
+	cmd.Flags().StringVarP(&options.Name, "name", "n", "", "The name of a stash created earlier in the current build via 'jx step stash --name' to unstash all of its files")
 	return cmd
 }
 
 // Run runs the command
 func (o *StepUnstashOptions) Run() error {
 	u := o.URL
+	if u == "" && o.Name != "" {
+		return o.unstashByName()
+	}
 	if u == "" {
 		// TODO lets guess from the project etc...
 		return util.MissingOption("url")
@@ -116,6 +128,82 @@ func (o *StepUnstashOptions) Run() error {
 	return nil
 }
 
+// unstashByName copies all the files of a named stash from the current build into the output directory
+func (o *StepUnstashOptions) unstashByName() error {
+	gitInfo, err := o.FindGitInfo("")
+	if err != nil {
+		return errors.Wrap(err, "failed to find the git information in the current directory")
+	}
+	branch := os.Getenv(envVarBranchName)
+	if branch == "" {
+		branch, err = o.Git().Branch(".")
+		if err != nil {
+			return err
+		}
+	}
+	buildNo := o.GetBuildNumber()
+	if buildNo == "" {
+		return fmt.Errorf("could not find the build number to unstash %s from", o.Name)
+	}
+
+	client, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return errors.Wrap(err, "cannot create the JX client")
+	}
+	key := stashActivityKey(gitInfo.Organisation, gitInfo.Name, branch, buildNo)
+	activity, err := client.JenkinsV1().PipelineActivities(ns).Get(key.Name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the PipelineActivity %s", key.Name)
+	}
+
+	attachmentName := StashAttachmentName(o.Name)
+	found := false
+	var urls []string
+	for _, a := range activity.Spec.Attachments {
+		if a.Name == attachmentName {
+			found = true
+			urls = append(urls, a.URLs...)
+		}
+	}
+	if !found {
+		return fmt.Errorf("no stash called %s has been stashed in the PipelineActivity %s", o.Name, key.Name)
+	}
+
+	outDir := o.OutDir
+	if outDir == "" {
+		outDir = "."
+	}
+	// lets keep the paths of the files relative to the stash
+	storagePath := filepath.Join("jenkins-x", ClassificationStash, gitInfo.Organisation, gitInfo.Name, branch, buildNo, o.Name) + "/"
+
+	authSvc, err := o.CreateGitAuthConfigService()
+	if err != nil {
+		return err
+	}
+	for _, u := range urls {
+		data, err := buckets.ReadURL(u, o.Timeout, CreateBucketHTTPFn(authSvc))
+		if err != nil {
+			return err
+		}
+		name := path.Base(u)
+		idx := strings.Index(u, storagePath)
+		if idx >= 0 {
+			name = u[idx+len(storagePath):]
+		}
+		file := filepath.Join(outDir, name)
+		err = os.MkdirAll(filepath.Dir(file), util.DefaultWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create directory for %s", file)
+		}
+		err = ioutil.WriteFile(file, data, util.DefaultWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "failed to write file %s", file)
+		}
+		log.Logger().Infof("unstashed: %s", util.ColorInfo(file))
+	}
+	return nil
+}
+
 // CreateBucketHTTPFn creates a function to transform a git URL to add the token for accessing a git based bucket
 func CreateBucketHTTPFn(authSvc auth.ConfigService) func(string) (string, error) {
 	return func(urlText string) (string, error) {
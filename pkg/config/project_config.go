@@ -49,6 +49,9 @@ type IssueTrackerConfig struct {
 	Kind    string `json:"kind,omitempty"`
 	URL     string `json:"url,omitempty"`
 	Project string `json:"project,omitempty"`
+	// Config is the file which configures the issue tracker, relative to the project directory, such as the
+	// description of the REST API of a rest issue tracker
+	Config string `json:"config,omitempty"`
 }
 
 type WikiConfig struct {
@@ -71,31 +71,31 @@ func NewAnchoreProvider(server *auth.AuthServer, user *auth.UserAuth) (CVEProvid
 	return &provider, nil
 }
 
+// GetImageVulnerabilityTable adds a row to the table for each vulnerability in the images matching the query
 func (a AnchoreProvider) GetImageVulnerabilityTable(jxClient versioned.Interface, client kubernetes.Interface, table *table.Table, query CVEQuery) error {
+	vulnerabilities, err := a.GetImageVulnerabilities(jxClient, client, query)
+	if err != nil {
+		return err
+	}
+	AddVulnerabilityTableRows(table, vulnerabilities)
+	return nil
+}
+
+// GetImageVulnerabilities returns the vulnerabilities the anchore engine has found in the images matching the query
+func (a AnchoreProvider) GetImageVulnerabilities(jxClient versioned.Interface, client kubernetes.Interface, query CVEQuery) ([]ImageVulnerability, error) {
 
 	var err error
-	var vList VulnerabilityList
 	var imageIDs []string
 
 	if query.ImageID != "" {
-		var vList VulnerabilityList
-		subPath := fmt.Sprintf(getVulnerabilitiesByImageID, query.ImageID, vulnerabilityType)
-
-		err = a.AnchoreGet(subPath, &vList)
-		if err != nil {
-			return fmt.Errorf("error getting vulnerabilities for image %s: %v", query.ImageID, err)
-		}
-
-		return a.addVulnerabilitiesTableRows(table, &vList)
+		return a.getCVEsFromImageList([]string{query.ImageID})
 	}
 
 	if query.Environment != "" {
-
-		var vList VulnerabilityList
 		// list pods in the namespace
 		podList, err := client.CoreV1().Pods(query.TargetNamespace).List(meta_v1.ListOptions{})
 		if err != nil {
-			return err
+			return nil, err
 		}
 		// if they have the annotation add the value to a list
 		for _, p := range podList.Items {
@@ -103,52 +103,40 @@ func (a AnchoreProvider) GetImageVulnerabilityTable(jxClient versioned.Interface
 				imageIDs = append(imageIDs, p.Annotations[AnnotationCVEImageId])
 			}
 		}
-		// loop over the list and get the CVEs for each, adding the rows
-		err = a.getCVEsFromImageList(table, &vList, imageIDs)
-		if err != nil {
-			return err
-		}
 	}
 
 	// see if we can match images using an image name and optional version
-	if query.ImageID == "" {
-		// if we have an image name then lets try and match image id(s)
-		if query.ImageName != "" {
+	if query.ImageName != "" {
 
-			var images []Image
-			subPath := fmt.Sprintf(GetImages)
+		var images []Image
+		subPath := fmt.Sprintf(GetImages)
 
-			err = a.AnchoreGet(subPath, &images)
-			if err != nil {
-				return fmt.Errorf("error getting images %v", err)
-			}
+		err = a.AnchoreGet(subPath, &images)
+		if err != nil {
+			return nil, fmt.Errorf("error getting images %v", err)
+		}
 
-			for _, image := range images {
-				for _, d := range image.ImageDetails {
-					if d.Repo == query.ImageName {
-						// if user has provided a version and it doesn't match lets skip this image
-						if query.Vesion != "" && query.Vesion != d.Tag {
-							continue
-						}
-						imageIDs = append(imageIDs, d.ImageId)
+		found := false
+		for _, image := range images {
+			for _, d := range image.ImageDetails {
+				if d.Repo == query.ImageName {
+					// if user has provided a version and it doesn't match lets skip this image
+					if query.Vesion != "" && query.Vesion != d.Tag {
+						continue
 					}
+					imageIDs = append(imageIDs, d.ImageId)
+					found = true
 				}
 			}
-			if len(imageIDs) > 0 {
-				err = a.getCVEsFromImageList(table, &vList, imageIDs)
-				if err != nil {
-					return err
-				}
-			} else {
-				return fmt.Errorf("no matching images found for ImageName %s and Vesion %s", query.ImageName, query.Vesion)
-			}
 		}
-	} else {
-		return fmt.Errorf("choose an image name, an optinal version or anchore image id to find vulnerabilities")
+		if !found {
+			return nil, fmt.Errorf("no matching images found for ImageName %s and Vesion %s", query.ImageName, query.Vesion)
+		}
+	} else if query.Environment == "" {
+		return nil, fmt.Errorf("choose an image name, an optinal version or anchore image id to find vulnerabilities")
 	}
 
-	return nil
-
+	return a.getCVEsFromImageList(imageIDs)
 }
 
 // AnchoreGet get command
@@ -175,45 +163,49 @@ func (a AnchoreProvider) AnchoreGet(subPath string, rs result) error {
 	return nil
 }
 
-func (a AnchoreProvider) addVulnerabilitiesTableRows(table *table.Table, vList *VulnerabilityList) error {
+func (a AnchoreProvider) toImageVulnerabilities(vList *VulnerabilityList) ([]ImageVulnerability, error) {
 
 	var image []Image
 	subPath := fmt.Sprintf(getVulnerabilitiesByImageDigest, vList.ImageDigest)
 
 	err := a.AnchoreGet(subPath, &image)
 	if err != nil {
-		return fmt.Errorf("error getting image for image digest %s: %v", vList.ImageDigest, err)
+		return nil, fmt.Errorf("error getting image for image digest %s: %v", vList.ImageDigest, err)
+	}
+	if len(image) == 0 || len(image[0].ImageDetails) == 0 {
+		return nil, fmt.Errorf("no image details found for image digest %s", vList.ImageDigest)
 	}
-	// TODO sort vList on severity and version?
 
+	var answer []ImageVulnerability
 	for _, v := range vList.Vulnerabilities {
-		var sev string
-		switch v.Severity {
-		case "High":
-			sev = util.ColorError(v.Severity)
-		case "Medium":
-			sev = util.ColorWarning(v.Severity)
-		case "Low":
-			sev = util.ColorStatus(v.Severity)
-		}
-		table.AddRow(image[0].ImageDetails[0].Fulltag, sev, v.Vuln, v.URL, v.Package, v.Fix)
+		answer = append(answer, ImageVulnerability{
+			Image:        image[0].ImageDetails[0].Fulltag,
+			ID:           v.Vuln,
+			Severity:     ParseSeverity(v.Severity),
+			Package:      v.Package,
+			FixedVersion: v.Fix,
+			URL:          v.URL,
+		})
 	}
-	return nil
+	return answer, nil
 }
 
-func (a AnchoreProvider) getCVEsFromImageList(table *table.Table, vList *VulnerabilityList, ids []string) error {
+func (a AnchoreProvider) getCVEsFromImageList(ids []string) ([]ImageVulnerability, error) {
+	var answer []ImageVulnerability
 	for _, imageID := range ids {
+		var vList VulnerabilityList
 		subPath := fmt.Sprintf(getVulnerabilitiesByImageID, imageID, vulnerabilityType)
 
 		err := a.AnchoreGet(subPath, &vList)
 		if err != nil {
-			return fmt.Errorf("error getting vulnerabilities for image %s: %v", imageID, err)
+			return nil, fmt.Errorf("error getting vulnerabilities for image %s: %v", imageID, err)
 		}
 
-		err = a.addVulnerabilitiesTableRows(table, vList)
+		vulnerabilities, err := a.toImageVulnerabilities(&vList)
 		if err != nil {
-			return fmt.Errorf("error building vulnerabilities table for image digest %s: %v", vList.ImageDigest, err)
+			return nil, fmt.Errorf("error building vulnerabilities for image digest %s: %v", vList.ImageDigest, err)
 		}
+		answer = append(answer, vulnerabilities...)
 	}
-	return nil
+	return answer, nil
 }
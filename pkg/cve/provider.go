@@ -1,15 +1,28 @@
 package cve
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
 	"github.com/jenkins-x/jx/pkg/table"
+	"github.com/jenkins-x/jx/pkg/util"
 	"k8s.io/client-go/kubernetes"
 )
 
 const (
 	AnnotationCVEImageId = "jenkins-x.io/cve-image-id"
+
+	// ProviderKindAnchore the provider which queries an Anchore engine
+	ProviderKindAnchore = "anchore"
+	// ProviderKindReport the provider which reads Trivy or Grype JSON reports
+	ProviderKindReport = "report"
 )
 
+// ProviderKinds the kinds of CVE provider
+var ProviderKinds = []string{ProviderKindAnchore, ProviderKindReport}
+
 type CVEQuery struct {
 	ImageName       string
 	ImageID         string
@@ -17,6 +30,123 @@ type CVEQuery struct {
 	Environment     string
 	TargetNamespace string
 }
+
 type CVEProvider interface {
+	// GetImageVulnerabilities returns the vulnerabilities found in the images matching the query
+	GetImageVulnerabilities(jxClient versioned.Interface, client kubernetes.Interface, query CVEQuery) ([]ImageVulnerability, error)
+
 	GetImageVulnerabilityTable(jxClient versioned.Interface, client kubernetes.Interface, table *table.Table, query CVEQuery) error
 }
+
+// Severity is how serious a vulnerability is
+type Severity string
+
+const (
+	// SeverityUnknown the scanner did not know the severity of the vulnerability
+	SeverityUnknown Severity = "Unknown"
+	// SeverityNegligible the vulnerability is not considered a risk
+	SeverityNegligible Severity = "Negligible"
+	// SeverityLow a low severity vulnerability
+	SeverityLow Severity = "Low"
+	// SeverityMedium a medium severity vulnerability
+	SeverityMedium Severity = "Medium"
+	// SeverityHigh a high severity vulnerability
+	SeverityHigh Severity = "High"
+	// SeverityCritical a critical severity vulnerability
+	SeverityCritical Severity = "Critical"
+)
+
+// Severities the severities in increasing order of seriousness
+var Severities = []Severity{SeverityUnknown, SeverityNegligible, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// ParseSeverity returns the severity with the given name, ignoring case. Names which scanners use but which don't
+// match any of the severities are treated as unknown.
+func ParseSeverity(name string) Severity {
+	for _, s := range Severities {
+		if strings.EqualFold(string(s), name) {
+			return s
+		}
+	}
+	return SeverityUnknown
+}
+
+// Rank returns the position of the severity in Severities, so that a higher rank is more serious
+func (s Severity) Rank() int {
+	for i, sev := range Severities {
+		if sev == s {
+			return i
+		}
+	}
+	return 0
+}
+
+// AtLeast returns true if the severity is as serious or more serious than the given threshold
+func (s Severity) AtLeast(threshold Severity) bool {
+	return s.Rank() >= threshold.Rank()
+}
+
+// ImageVulnerability is a vulnerability found in a package in an image
+type ImageVulnerability struct {
+	// The image, usually including its tag
+	Image string
+	// The ID of the vulnerability, such as CVE-2019-1234
+	ID       string
+	Severity Severity
+	// The package the vulnerability was found in
+	Package string
+	// The version of the package in the image
+	InstalledVersion string
+	// The version of the package the vulnerability is fixed in, if there is one
+	FixedVersion string
+	URL          string
+}
+
+// SortVulnerabilities sorts the vulnerabilities by image, then with the most serious first, then by ID
+func SortVulnerabilities(vulnerabilities []ImageVulnerability) {
+	sort.SliceStable(vulnerabilities, func(i, j int) bool {
+		a, b := vulnerabilities[i], vulnerabilities[j]
+		if a.Image != b.Image {
+			return a.Image < b.Image
+		}
+		if a.Severity != b.Severity {
+			return a.Severity.Rank() > b.Severity.Rank()
+		}
+		return a.ID < b.ID
+	})
+}
+
+// FilterBySeverity returns the vulnerabilities which are at least as serious as the threshold
+func FilterBySeverity(vulnerabilities []ImageVulnerability, threshold Severity) []ImageVulnerability {
+	var answer []ImageVulnerability
+	for _, v := range vulnerabilities {
+		if v.Severity.AtLeast(threshold) {
+			answer = append(answer, v)
+		}
+	}
+	return answer
+}
+
+// AddVulnerabilityTableRows adds a row to the table for each vulnerability
+func AddVulnerabilityTableRows(table *table.Table, vulnerabilities []ImageVulnerability) {
+	for _, v := range vulnerabilities {
+		pkg := v.Package
+		if v.InstalledVersion != "" {
+			pkg = fmt.Sprintf("%s-%s", v.Package, v.InstalledVersion)
+		}
+		table.AddRow(v.Image, ColorSeverity(v.Severity), v.ID, v.URL, pkg, v.FixedVersion)
+	}
+}
+
+// ColorSeverity returns the severity colored for display in the terminal
+func ColorSeverity(s Severity) string {
+	switch s {
+	case SeverityCritical, SeverityHigh:
+		return util.ColorError(string(s))
+	case SeverityMedium:
+		return util.ColorWarning(string(s))
+	case SeverityLow:
+		return util.ColorStatus(string(s))
+	default:
+		return string(s)
+	}
+}
@@ -0,0 +1,257 @@
+package cve
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/table"
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ReportProvider implements CVEProvider for the JSON reports written by scanners such as Trivy and Grype, so that
+// images can be scanned offline, for example in a pipeline, and the results queried later
+type ReportProvider struct {
+	Reports []ImageReport
+}
+
+// ImageReport is the vulnerabilities found in a single image by a scanner
+type ImageReport struct {
+	Image           string
+	Vulnerabilities []ImageVulnerability
+}
+
+// trivyResult is a target in a Trivy JSON report. Older versions of Trivy write an array of these, newer versions
+// write a trivyReport
+type trivyResult struct {
+	Target          string               `json:"Target"`
+	Vulnerabilities []trivyVulnerability `json:"Vulnerabilities"`
+}
+
+type trivyReport struct {
+	ArtifactName string        `json:"ArtifactName"`
+	Results      []trivyResult `json:"Results"`
+}
+
+type trivyVulnerability struct {
+	VulnerabilityID  string   `json:"VulnerabilityID"`
+	PkgName          string   `json:"PkgName"`
+	InstalledVersion string   `json:"InstalledVersion"`
+	FixedVersion     string   `json:"FixedVersion"`
+	Severity         string   `json:"Severity"`
+	PrimaryURL       string   `json:"PrimaryURL"`
+	References       []string `json:"References"`
+}
+
+type grypeReport struct {
+	Matches []grypeMatch `json:"matches"`
+	Source  struct {
+		Type   string `json:"type"`
+		Target struct {
+			UserInput string `json:"userInput"`
+		} `json:"target"`
+	} `json:"source"`
+}
+
+type grypeMatch struct {
+	Vulnerability struct {
+		ID       string   `json:"id"`
+		Severity string   `json:"severity"`
+		URLs     []string `json:"urls"`
+		Fix      struct {
+			Versions []string `json:"versions"`
+		} `json:"fix"`
+	} `json:"vulnerability"`
+	Artifact struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"artifact"`
+}
+
+// NewReportProvider creates a provider which reads the vulnerabilities from the given Trivy or Grype JSON report files
+func NewReportProvider(files []string) (CVEProvider, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no vulnerability report files specified")
+	}
+	provider := &ReportProvider{}
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read vulnerability report %s", f)
+		}
+		reports, err := ParseReport(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse vulnerability report %s", f)
+		}
+		provider.Reports = append(provider.Reports, reports...)
+	}
+	return provider, nil
+}
+
+// ParseReport parses a Trivy or Grype JSON report, detecting which scanner wrote it from its structure
+func ParseReport(data []byte) ([]ImageReport, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		var results []trivyResult
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal Trivy report")
+		}
+		return trivyImageReports("", results), nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal report")
+	}
+	if _, ok := fields["matches"]; ok {
+		var report grypeReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal Grype report")
+		}
+		return []ImageReport{grypeImageReport(&report)}, nil
+	}
+	if _, ok := fields["Results"]; ok {
+		var report trivyReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal Trivy report")
+		}
+		return trivyImageReports(report.ArtifactName, report.Results), nil
+	}
+	return nil, errors.New("the report is not a Trivy or Grype JSON report")
+}
+
+func trivyImageReports(artifactName string, results []trivyResult) []ImageReport {
+	var answer []ImageReport
+	for _, r := range results {
+		image := artifactName
+		if image == "" {
+			// older versions use a target such as "alpine:3.10 (alpine 3.10.2)"
+			image = strings.TrimSpace(strings.SplitN(r.Target, " (", 2)[0])
+		}
+		report := ImageReport{Image: image}
+		for _, v := range r.Vulnerabilities {
+			url := v.PrimaryURL
+			if url == "" && len(v.References) > 0 {
+				url = v.References[0]
+			}
+			report.Vulnerabilities = append(report.Vulnerabilities, ImageVulnerability{
+				Image:            image,
+				ID:               v.VulnerabilityID,
+				Severity:         ParseSeverity(v.Severity),
+				Package:          v.PkgName,
+				InstalledVersion: v.InstalledVersion,
+				FixedVersion:     v.FixedVersion,
+				URL:              url,
+			})
+		}
+		answer = append(answer, report)
+	}
+	return answer
+}
+
+func grypeImageReport(r *grypeReport) ImageReport {
+	image := r.Source.Target.UserInput
+	report := ImageReport{Image: image}
+	for _, m := range r.Matches {
+		url := ""
+		if len(m.Vulnerability.URLs) > 0 {
+			url = m.Vulnerability.URLs[0]
+		}
+		report.Vulnerabilities = append(report.Vulnerabilities, ImageVulnerability{
+			Image:            image,
+			ID:               m.Vulnerability.ID,
+			Severity:         ParseSeverity(m.Vulnerability.Severity),
+			Package:          m.Artifact.Name,
+			InstalledVersion: m.Artifact.Version,
+			FixedVersion:     strings.Join(m.Vulnerability.Fix.Versions, ", "),
+			URL:              url,
+		})
+	}
+	return report
+}
+
+// GetImageVulnerabilityTable adds a row to the table for each vulnerability in the images matching the query
+func (r *ReportProvider) GetImageVulnerabilityTable(jxClient versioned.Interface, client kubernetes.Interface, table *table.Table, query CVEQuery) error {
+	vulnerabilities, err := r.GetImageVulnerabilities(jxClient, client, query)
+	if err != nil {
+		return err
+	}
+	AddVulnerabilityTableRows(table, vulnerabilities)
+	return nil
+}
+
+// GetImageVulnerabilities returns the vulnerabilities in the reports for the images matching the query. Images are
+// matched by name and optional version, or by the images of the pods running in the environment's namespace.
+func (r *ReportProvider) GetImageVulnerabilities(jxClient versioned.Interface, client kubernetes.Interface, query CVEQuery) ([]ImageVulnerability, error) {
+	if query.ImageName == "" && query.ImageID == "" && query.Environment == "" {
+		var answer []ImageVulnerability
+		for _, report := range r.Reports {
+			answer = append(answer, report.Vulnerabilities...)
+		}
+		return answer, nil
+	}
+
+	var images []string
+	if query.Environment != "" {
+		podList, err := client.CoreV1().Pods(query.TargetNamespace).List(meta_v1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range podList.Items {
+			for _, c := range p.Spec.Containers {
+				images = append(images, c.Image)
+			}
+		}
+	}
+
+	var answer []ImageVulnerability
+	found := false
+	for _, report := range r.Reports {
+		if r.matches(report.Image, query, images) {
+			found = true
+			answer = append(answer, report.Vulnerabilities...)
+		}
+	}
+	if !found && query.Environment == "" {
+		return nil, fmt.Errorf("no reports found for image %s%s version %s", query.ImageName, query.ImageID, query.Vesion)
+	}
+	return answer, nil
+}
+
+func (r *ReportProvider) matches(image string, query CVEQuery, images []string) bool {
+	for _, i := range images {
+		if sameImage(i, image) {
+			return true
+		}
+	}
+	if query.ImageID != "" && image == query.ImageID {
+		return true
+	}
+	if query.ImageName != "" {
+		name, tag := splitImage(image)
+		queryName, _ := splitImage(query.ImageName)
+		if name != queryName && !strings.HasSuffix(name, "/"+queryName) {
+			return false
+		}
+		return query.Vesion == "" || query.Vesion == tag
+	}
+	return false
+}
+
+// sameImage returns true if the images are the same, allowing for an implicit docker.io registry
+func sameImage(a string, b string) bool {
+	return strings.TrimPrefix(a, "docker.io/") == strings.TrimPrefix(b, "docker.io/")
+}
+
+// splitImage splits an image into its name and tag
+func splitImage(image string) (string, string) {
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return image, ""
+	}
+	return image[:i], image[i+1:]
+}
@@ -0,0 +1,76 @@
+package cve_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/cve"
+	"github.com/jenkins-x/jx/pkg/table"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportProvider(t *testing.T) {
+	p, err := cve.NewReportProvider([]string{
+		filepath.Join("test_data", "reports", "trivy.json"),
+		filepath.Join("test_data", "reports", "grype.json"),
+	})
+	require.NoError(t, err)
+
+	all, err := p.GetImageVulnerabilities(nil, nil, cve.CVEQuery{})
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	vulnerabilities, err := p.GetImageVulnerabilities(nil, nil, cve.CVEQuery{ImageName: "jenkinsxio/nexus", Vesion: "0.0.5"})
+	require.NoError(t, err)
+	require.Len(t, vulnerabilities, 2)
+	assert.Equal(t, cve.ImageVulnerability{
+		Image:            "jenkinsxio/nexus:0.0.5",
+		ID:               "CVE-2019-14697",
+		Severity:         cve.SeverityHigh,
+		Package:          "musl",
+		InstalledVersion: "1.1.20-r4",
+		FixedVersion:     "1.1.20-r5",
+		URL:              "https://www.openwall.com/lists/musl/2019/08/06/1",
+	}, vulnerabilities[0])
+	assert.Equal(t, cve.SeverityLow, vulnerabilities[1].Severity)
+
+	vulnerabilities, err = p.GetImageVulnerabilities(nil, nil, cve.CVEQuery{ImageName: "rawlingsj/jr1-rust"})
+	require.NoError(t, err)
+	require.Len(t, vulnerabilities, 2)
+	assert.Equal(t, cve.ImageVulnerability{
+		Image:            "rawlingsj/jr1-rust:0.0.11",
+		ID:               "CVE-2019-5481",
+		Severity:         cve.SeverityCritical,
+		Package:          "curl",
+		InstalledVersion: "7.64.0-r2",
+		FixedVersion:     "7.66.0-r0",
+		URL:              "https://curl.haxx.se/docs/CVE-2019-5481.html",
+	}, vulnerabilities[0])
+
+	_, err = p.GetImageVulnerabilities(nil, nil, cve.CVEQuery{ImageName: "jenkinsxio/nexus", Vesion: "0.0.6"})
+	assert.Error(t, err)
+
+	vTable := table.CreateTable(os.Stdout)
+	err = p.GetImageVulnerabilityTable(nil, nil, &vTable, cve.CVEQuery{ImageName: "rawlingsj/jr1-rust"})
+	require.NoError(t, err)
+	vTable.Render()
+}
+
+func TestFilterBySeverity(t *testing.T) {
+	vulnerabilities := []cve.ImageVulnerability{
+		{ID: "a", Severity: cve.SeverityLow},
+		{ID: "b", Severity: cve.SeverityCritical},
+		{ID: "c", Severity: cve.SeverityHigh},
+		{ID: "d", Severity: cve.ParseSeverity("weird")},
+	}
+
+	filtered := cve.FilterBySeverity(vulnerabilities, cve.ParseSeverity("HIGH"))
+	cve.SortVulnerabilities(filtered)
+
+	require.Len(t, filtered, 2)
+	assert.Equal(t, "b", filtered[0].ID)
+	assert.Equal(t, "c", filtered[1].ID)
+	assert.Equal(t, cve.SeverityUnknown, vulnerabilities[3].Severity)
+}
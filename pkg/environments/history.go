@@ -0,0 +1,72 @@
+package environments
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// RequirementsVersionHistory returns the versions of the app in the requirements file of the environment chart in
+// the given directory of a git clone, newest first, by walking the git history of the file. Consecutive commits
+// which do not change the version of the app are collapsed, so the first entry is the current version.
+func RequirementsVersionHistory(dir string, app string) ([]string, error) {
+	requirementsFile, err := helm.FindRequirementsFileName(dir)
+	if err != nil {
+		return nil, err
+	}
+	relativePath, err := filepath.Rel(dir, requirementsFile)
+	if err != nil {
+		return nil, err
+	}
+	// a path starting with ./ is resolved relative to the directory by both 'git log' and 'git show'
+	relativePath = "./" + filepath.ToSlash(relativePath)
+	text, err := runGit(dir, "log", "--format=%H", "--", relativePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the git history of %s", requirementsFile)
+	}
+	answer := []string{}
+	for _, sha := range strings.Fields(text) {
+		content, err := runGit(dir, "show", sha+":"+relativePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get %s at revision %s", requirementsFile, sha)
+		}
+		requirements := &helm.Requirements{}
+		err = yaml.Unmarshal([]byte(content), requirements)
+		if err != nil {
+			// lets ignore revisions which were not valid YAML
+			continue
+		}
+		version := requirementsAppVersion(requirements, app)
+		if version == "" {
+			// the app was not deployed at this revision
+			continue
+		}
+		if len(answer) == 0 || answer[len(answer)-1] != version {
+			answer = append(answer, version)
+		}
+	}
+	return answer, nil
+}
+
+// requirementsAppVersion returns the version of the app or alias in the requirements or a blank string
+func requirementsAppVersion(requirements *helm.Requirements, app string) string {
+	for _, dep := range requirements.Dependencies {
+		if dep != nil && (dep.Name == app || (dep.Alias != "" && dep.Alias == app)) {
+			return dep.Version
+		}
+	}
+	return ""
+}
+
+func runGit(dir string, args ...string) (string, error) {
+	cmd := util.Command{
+		Dir:  dir,
+		Name: "git",
+		Args: args,
+	}
+	return cmd.RunWithoutRetry()
+}
@@ -0,0 +1,58 @@
+// +build integration
+
+package environments_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/environments"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequirementsVersionHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-requirements-history-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	gitter := gits.NewGitCLI()
+	require.NoError(t, gitter.Init(dir))
+	require.NoError(t, gitter.SetUsername(dir, "test"))
+	require.NoError(t, gitter.SetEmail(dir, "test@example.com"))
+
+	envDir := filepath.Join(dir, "env")
+	require.NoError(t, os.MkdirAll(envDir, util.DefaultWritePermissions))
+
+	commit := func(appVersion string, otherVersion string) {
+		requirements := fmt.Sprintf(`dependencies:
+- name: myapp
+  repository: http://chartmuseum
+  version: %s
+- name: other
+  repository: http://chartmuseum
+  version: %s
+`, appVersion, otherVersion)
+		err := ioutil.WriteFile(filepath.Join(envDir, "requirements.yaml"), []byte(requirements), util.DefaultWritePermissions)
+		require.NoError(t, err)
+		require.NoError(t, gitter.Add(dir, "."))
+		require.NoError(t, gitter.CommitDir(dir, fmt.Sprintf("myapp %s other %s", appVersion, otherVersion)))
+	}
+	commit("1.0.0", "0.1.0")
+	commit("1.0.1", "0.1.0")
+	commit("1.0.1", "0.2.0")
+	commit("1.1.0", "0.2.0")
+
+	versions, err := environments.RequirementsVersionHistory(dir, "myapp")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.1.0", "1.0.1", "1.0.0"}, versions)
+
+	versions, err = environments.RequirementsVersionHistory(envDir, "other")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0.2.0", "0.1.0"}, versions)
+}
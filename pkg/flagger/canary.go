@@ -0,0 +1,195 @@
+package flagger
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/pkg/errors"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// CanaryAPIVersion the API group and version of the Flagger Canary resources
+	CanaryAPIVersion = "flagger.app/v1alpha3"
+	// CanaryCRDName the name of the CustomResourceDefinition of the Flagger Canary resources
+	CanaryCRDName = "canaries.flagger.app"
+)
+
+// CanaryPhase is the phase of the analysis of a Canary
+type CanaryPhase string
+
+const (
+	// CanaryPhaseInitializing the canary is being initialized
+	CanaryPhaseInitializing CanaryPhase = "Initializing"
+	// CanaryPhaseInitialized the canary was initialized with the first version so no analysis was run
+	CanaryPhaseInitialized CanaryPhase = "Initialized"
+	// CanaryPhaseWaiting the canary analysis is waiting for confirmation to start
+	CanaryPhaseWaiting CanaryPhase = "Waiting"
+	// CanaryPhaseProgressing the canary analysis is in progress
+	CanaryPhaseProgressing CanaryPhase = "Progressing"
+	// CanaryPhasePromoting the canary analysis succeeded and the primary is being updated
+	CanaryPhasePromoting CanaryPhase = "Promoting"
+	// CanaryPhaseFinalising the canary analysis succeeded and traffic is being routed back to the primary
+	CanaryPhaseFinalising CanaryPhase = "Finalising"
+	// CanaryPhaseSucceeded the canary analysis succeeded and the new version was promoted
+	CanaryPhaseSucceeded CanaryPhase = "Succeeded"
+	// CanaryPhaseFailed the canary analysis failed and the new version was rolled back
+	CanaryPhaseFailed CanaryPhase = "Failed"
+)
+
+// Canary is the subset of a Flagger Canary resource used to track the analysis of a promotion
+type Canary struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CanarySpec   `json:"spec,omitempty"`
+	Status CanaryStatus `json:"status,omitempty"`
+}
+
+// CanaryList is a list of Canary resources
+type CanaryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Canary `json:"items"`
+}
+
+// CanarySpec is the specification of a Canary
+type CanarySpec struct {
+	TargetRef      CanaryTargetReference `json:"targetRef"`
+	CanaryAnalysis CanaryAnalysis        `json:"canaryAnalysis"`
+}
+
+// CanaryTargetReference references the Deployment the Canary analyses
+type CanaryTargetReference struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+}
+
+// CanaryAnalysis is the configuration of the analysis of a Canary
+type CanaryAnalysis struct {
+	Threshold  int `json:"threshold"`
+	MaxWeight  int `json:"maxWeight"`
+	StepWeight int `json:"stepWeight"`
+	Iterations int `json:"iterations,omitempty"`
+}
+
+// CanaryStatus is the status of the analysis of a Canary
+type CanaryStatus struct {
+	Phase              CanaryPhase       `json:"phase"`
+	FailedChecks       int               `json:"failedChecks"`
+	CanaryWeight       int               `json:"canaryWeight"`
+	Iterations         int               `json:"iterations"`
+	LastAppliedSpec    string            `json:"lastAppliedSpec,omitempty"`
+	LastTransitionTime metav1.Time       `json:"lastTransitionTime,omitempty"`
+	Conditions         []CanaryCondition `json:"conditions,omitempty"`
+}
+
+// CanaryCondition is a condition of a Canary
+type CanaryCondition struct {
+	Type               string      `json:"type"`
+	Status             string      `json:"status"`
+	LastUpdateTime     metav1.Time `json:"lastUpdateTime,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	Reason             string      `json:"reason,omitempty"`
+	Message            string      `json:"message,omitempty"`
+}
+
+// IsComplete returns true if the canary is not analysing a version
+func (c *Canary) IsComplete() bool {
+	switch c.Status.Phase {
+	case CanaryPhaseInitialized, CanaryPhaseSucceeded, CanaryPhaseFailed:
+		return true
+	}
+	return false
+}
+
+// IsFailed returns true if the canary analysis failed and the version was rolled back
+func (c *Canary) IsFailed() bool {
+	return c.Status.Phase == CanaryPhaseFailed
+}
+
+// AnalysedSince returns true if the canary analysis started or completed at or after the given time
+func (c *Canary) AnalysedSince(t time.Time) bool {
+	if !c.IsComplete() {
+		return true
+	}
+	// the transition time is only accurate to the second
+	return !c.Status.LastTransitionTime.Time.Before(t.Truncate(time.Second))
+}
+
+// Message returns the message of the Promoted condition of the canary which describes the analysis
+func (c *Canary) Message() string {
+	for _, condition := range c.Status.Conditions {
+		if condition.Type == "Promoted" && condition.Message != "" {
+			return condition.Message
+		}
+	}
+	return ""
+}
+
+// IsCanaryCRDInstalled returns true if Flagger has registered the Canary CustomResourceDefinition
+func IsCanaryCRDInstalled(apiClient apiextensions.Interface) (bool, error) {
+	_, err := apiClient.ApiextensionsV1beta1().CustomResourceDefinitions().Get(CanaryCRDName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "getting CustomResourceDefinition %s", CanaryCRDName)
+	}
+	return true, nil
+}
+
+// ListCanaries returns the Canary resources in the namespace, or an empty list if Flagger is not installed
+func ListCanaries(kubeClient kubernetes.Interface, ns string) ([]Canary, error) {
+	data, err := kubeClient.CoreV1().RESTClient().Get().
+		RequestURI(fmt.Sprintf("/apis/%s/namespaces/%s/canaries", CanaryAPIVersion, ns)).DoRaw()
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "listing canaries in namespace %s: %s", ns, string(data))
+	}
+	list := CanaryList{}
+	err = json.Unmarshal(data, &list)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshalling canaries in namespace %s", ns)
+	}
+	return list.Items, nil
+}
+
+// GetCanary returns the Canary resource with the given name in the namespace
+func GetCanary(kubeClient kubernetes.Interface, ns string, name string) (*Canary, error) {
+	data, err := kubeClient.CoreV1().RESTClient().Get().
+		RequestURI(fmt.Sprintf("/apis/%s/namespaces/%s/canaries/%s", CanaryAPIVersion, ns, name)).DoRaw()
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting canary %s in namespace %s: %s", name, ns, string(data))
+	}
+	canary := &Canary{}
+	err = json.Unmarshal(data, canary)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshalling canary %s in namespace %s", name, ns)
+	}
+	return canary, nil
+}
+
+// FindCanary returns the Canary which analyses the Deployment of the application in the namespace or nil if there
+// is none
+func FindCanary(canaries []Canary, ns string, app string) *Canary {
+	for i := range canaries {
+		canary := &canaries[i]
+		if canary.Spec.TargetRef.Kind != "" && canary.Spec.TargetRef.Kind != "Deployment" {
+			continue
+		}
+		if kube.GetAppName(canary.Spec.TargetRef.Name, ns) == app {
+			return canary
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package flagger_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/flagger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apifake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const canariesJSON = `{
+  "apiVersion": "flagger.app/v1alpha3",
+  "kind": "CanaryList",
+  "items": [
+    {
+      "metadata": {"name": "jx-other", "namespace": "jx-staging"},
+      "spec": {"targetRef": {"apiVersion": "apps/v1", "kind": "Deployment", "name": "jx-other"}},
+      "status": {"phase": "Succeeded"}
+    },
+    {
+      "metadata": {"name": "jx-myapp", "namespace": "jx-staging"},
+      "spec": {
+        "targetRef": {"apiVersion": "apps/v1", "kind": "Deployment", "name": "jx-myapp"},
+        "canaryAnalysis": {"threshold": 5, "maxWeight": 50, "stepWeight": 10}
+      },
+      "status": {
+        "phase": "Progressing",
+        "canaryWeight": 20,
+        "failedChecks": 1,
+        "iterations": 2,
+        "lastTransitionTime": "2019-08-01T10:00:00Z",
+        "conditions": [{"type": "Promoted", "status": "Unknown", "reason": "Progressing", "message": "New revision detected, progressing canary analysis."}]
+      }
+    }
+  ]
+}`
+
+func TestListAndFindCanaries(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apis/flagger.app/v1alpha3/namespaces/jx-staging/canaries":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(canariesJSON))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	canaries, err := flagger.ListCanaries(kubeClient, "jx-staging")
+	require.NoError(t, err)
+	require.Len(t, canaries, 2)
+
+	canary := flagger.FindCanary(canaries, "jx-staging", "myapp")
+	require.NotNil(t, canary)
+	assert.Equal(t, "jx-myapp", canary.Name)
+	assert.Equal(t, flagger.CanaryPhaseProgressing, canary.Status.Phase)
+	assert.Equal(t, 20, canary.Status.CanaryWeight)
+	assert.Equal(t, 1, canary.Status.FailedChecks)
+	assert.Equal(t, "New revision detected, progressing canary analysis.", canary.Message())
+	assert.False(t, canary.IsComplete())
+
+	assert.Nil(t, flagger.FindCanary(canaries, "jx-staging", "unknown"))
+
+	// namespaces without Flagger have no canaries
+	canaries, err = flagger.ListCanaries(kubeClient, "jx-production")
+	require.NoError(t, err)
+	assert.Empty(t, canaries)
+}
+
+func TestCanaryAnalysedSince(t *testing.T) {
+	t.Parallel()
+
+	promoted := time.Date(2019, time.August, 1, 10, 0, 0, 500, time.UTC)
+	canary := &flagger.Canary{
+		Status: flagger.CanaryStatus{
+			Phase:              flagger.CanaryPhaseSucceeded,
+			LastTransitionTime: metav1.Time{Time: promoted.Add(-time.Hour)},
+		},
+	}
+	assert.False(t, canary.AnalysedSince(promoted), "the analysis of the previous version")
+
+	canary.Status.LastTransitionTime = metav1.Time{Time: promoted.Truncate(time.Second)}
+	assert.True(t, canary.AnalysedSince(promoted), "transition times are truncated to the second")
+
+	canary.Status.Phase = flagger.CanaryPhaseFailed
+	assert.True(t, canary.IsComplete())
+	assert.True(t, canary.IsFailed())
+
+	canary.Status.Phase = flagger.CanaryPhaseProgressing
+	canary.Status.LastTransitionTime = metav1.Time{Time: promoted.Add(-time.Hour)}
+	assert.True(t, canary.AnalysedSince(promoted), "an analysis in progress")
+}
+
+func TestIsCanaryCRDInstalled(t *testing.T) {
+	t.Parallel()
+
+	apiClient := apifake.NewSimpleClientset()
+	installed, err := flagger.IsCanaryCRDInstalled(apiClient)
+	require.NoError(t, err)
+	assert.False(t, installed)
+
+	apiClient = apifake.NewSimpleClientset(&v1beta1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: flagger.CanaryCRDName,
+		},
+	})
+	installed, err = flagger.IsCanaryCRDInstalled(apiClient)
+	require.NoError(t, err)
+	assert.True(t, installed)
+}
@@ -0,0 +1,1303 @@
+package gits
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// azureDevOpsAPIVersion the version of the Azure DevOps REST API used by the provider
+	azureDevOpsAPIVersion = "5.1"
+
+	// azureDevOpsProfileURL the URL of the Azure DevOps profile service used to find the organisations of a user
+	azureDevOpsProfileURL = "https://app.vssps.visualstudio.com"
+
+	// azureDevOpsStatusGenre the genre of the commit and pull request statuses created by jx
+	azureDevOpsStatusGenre = "jenkins-x"
+
+	// AzureDevOpsWebHookEventHeader is the header the service hooks created by jx add to the webhooks with the event
+	// type so that they can be told apart from the webhooks of other git providers
+	AzureDevOpsWebHookEventHeader = "X-AzureDevOps-Event"
+)
+
+// azureDevOpsWebHookEvents are the service hook events sent to the webhooks created by jx with the version of the
+// resource sent for each event. Pull request updates are only sent when the source branch is pushed to
+var azureDevOpsWebHookEvents = []struct {
+	EventType        string
+	ResourceVersion  string
+	NotificationType string
+}{
+	{"git.push", "1.0", ""},
+	{"git.pullrequest.created", "1.0", ""},
+	{"git.pullrequest.updated", "1.0", "PushNotification"},
+	{"ms.vss-code.git-pullrequest-comment-event", "2.0", ""},
+}
+
+// AzureDevOpsProvider is a GitProvider for Azure DevOps Repos.
+//
+// The jx organisation of a repository is its Azure DevOps organisation. The Azure DevOps project of a repository is
+// looked up by the repository name, or an owner of the form {organisation}/{project} can be used to pick the project.
+// The owners of the pull requests and webhooks returned by the provider are always of the form {organisation}/{project}
+// as repositories with the same name can exist in several projects.
+type AzureDevOpsProvider struct {
+	Username string
+	// ProfileURL is the URL of the profile service used to list the organisations of the user
+	ProfileURL string
+	HTTPClient *http.Client
+
+	Server auth.AuthServer
+	User   auth.UserAuth
+	Git    Gitter
+}
+
+type azureDevOpsProject struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Visibility string `json:"visibility"`
+}
+
+type azureDevOpsRepository struct {
+	ID            string             `json:"id"`
+	Name          string             `json:"name"`
+	URL           string             `json:"url"`
+	DefaultBranch string             `json:"defaultBranch"`
+	RemoteURL     string             `json:"remoteUrl"`
+	SSHURL        string             `json:"sshUrl"`
+	WebURL        string             `json:"webUrl"`
+	IsFork        bool               `json:"isFork"`
+	Project       azureDevOpsProject `json:"project"`
+}
+
+type azureDevOpsIdentity struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+	ImageURL    string `json:"imageUrl"`
+}
+
+type azureDevOpsCommitRef struct {
+	CommitID string `json:"commitId"`
+}
+
+type azureDevOpsLabel struct {
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name"`
+	Active bool   `json:"active,omitempty"`
+}
+
+type azureDevOpsPullRequest struct {
+	PullRequestID         int                    `json:"pullRequestId"`
+	Status                string                 `json:"status"`
+	CreatedBy             azureDevOpsIdentity    `json:"createdBy"`
+	CreationDate          *time.Time             `json:"creationDate"`
+	ClosedDate            *time.Time             `json:"closedDate"`
+	Title                 string                 `json:"title"`
+	Description           string                 `json:"description"`
+	SourceRefName         string                 `json:"sourceRefName"`
+	TargetRefName         string                 `json:"targetRefName"`
+	MergeStatus           string                 `json:"mergeStatus"`
+	LastMergeSourceCommit *azureDevOpsCommitRef  `json:"lastMergeSourceCommit"`
+	LastMergeCommit       *azureDevOpsCommitRef  `json:"lastMergeCommit"`
+	Reviewers             []azureDevOpsIdentity  `json:"reviewers"`
+	Labels                []azureDevOpsLabel     `json:"labels"`
+	Repository            *azureDevOpsRepository `json:"repository"`
+}
+
+type azureDevOpsGitUserDate struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+type azureDevOpsCommit struct {
+	CommitID  string                 `json:"commitId"`
+	Comment   string                 `json:"comment"`
+	Author    azureDevOpsGitUserDate `json:"author"`
+	Committer azureDevOpsGitUserDate `json:"committer"`
+	RemoteURL string                 `json:"remoteUrl"`
+}
+
+type azureDevOpsStatusContext struct {
+	Name  string `json:"name"`
+	Genre string `json:"genre,omitempty"`
+}
+
+type azureDevOpsStatus struct {
+	ID           int64                    `json:"id,omitempty"`
+	State        string                   `json:"state"`
+	Description  string                   `json:"description"`
+	TargetURL    string                   `json:"targetUrl,omitempty"`
+	Context      azureDevOpsStatusContext `json:"context"`
+	CreationDate *time.Time               `json:"creationDate,omitempty"`
+}
+
+type azureDevOpsSubscription struct {
+	ID               string            `json:"id,omitempty"`
+	PublisherID      string            `json:"publisherId"`
+	EventType        string            `json:"eventType"`
+	ResourceVersion  string            `json:"resourceVersion"`
+	ConsumerID       string            `json:"consumerId"`
+	ConsumerActionID string            `json:"consumerActionId"`
+	PublisherInputs  map[string]string `json:"publisherInputs"`
+	ConsumerInputs   map[string]string `json:"consumerInputs"`
+}
+
+type azureDevOpsItem struct {
+	ObjectID string `json:"objectId"`
+	CommitID string `json:"commitId"`
+	Path     string `json:"path"`
+	Content  string `json:"content"`
+	URL      string `json:"url"`
+}
+
+// NewAzureDevOpsProvider creates a GitProvider for Azure DevOps
+func NewAzureDevOpsProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter) (GitProvider, error) {
+	provider := AzureDevOpsProvider{
+		Server:     *server,
+		User:       *user,
+		Username:   user.Username,
+		ProfileURL: azureDevOpsProfileURL,
+		HTTPClient: util.GetClient(),
+		Git:        git,
+	}
+	return &provider, nil
+}
+
+// AzureDevOpsAccessTokenURL returns the URL to create personal access tokens
+func AzureDevOpsAccessTokenURL(url string) string {
+	return util.UrlJoin(url, "/_usersSettings/tokens")
+}
+
+// splitAzureDevOpsOwner splits an owner of the form {organisation}/{project} into the organisation and the project
+func splitAzureDevOpsOwner(owner string) (string, string) {
+	paths := strings.SplitN(owner, "/", 2)
+	if len(paths) == 2 {
+		return paths[0], paths[1]
+	}
+	return owner, ""
+}
+
+// organisationURL returns the URL of the organisation on the server
+func (p *AzureDevOpsProvider) organisationURL(org string) string {
+	serverURL := strings.TrimSuffix(p.Server.URL, "/")
+	u, err := url.Parse(serverURL)
+	if err == nil && u.Host != "" {
+		// ignore any organisation the server URL was registered with
+		serverURL = u.Scheme + "://" + u.Host
+	}
+	if strings.HasSuffix(serverURL, azureDevOpsLegacyHostSuffix) {
+		// the organisation is the sub domain of legacy visualstudio.com URLs
+		return serverURL
+	}
+	return util.UrlJoin(serverURL, org)
+}
+
+// ListOrganisations lists the Azure DevOps organisations the user is a member of
+func (p *AzureDevOpsProvider) ListOrganisations() ([]GitOrganisation, error) {
+	profile := struct {
+		ID string `json:"id"`
+	}{}
+	err := p.apiRequest(http.MethodGet, util.UrlJoin(p.ProfileURL, "_apis/profile/profiles/me"), nil, nil, &profile)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the profile of the current user")
+	}
+	accounts := struct {
+		Value []struct {
+			AccountName string `json:"accountName"`
+		} `json:"value"`
+	}{}
+	query := url.Values{}
+	query.Set("memberId", profile.ID)
+	err = p.apiRequest(http.MethodGet, util.UrlJoin(p.ProfileURL, "_apis/accounts"), query, nil, &accounts)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing the organisations of the current user")
+	}
+	answer := []GitOrganisation{}
+	for _, account := range accounts.Value {
+		answer = append(answer, GitOrganisation{Login: account.AccountName})
+	}
+	return answer, nil
+}
+
+// ListRepositories lists the repositories of all projects of the organisation
+func (p *AzureDevOpsProvider) ListRepositories(org string) ([]*GitRepository, error) {
+	repos, err := p.listRepositories(org)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*GitRepository{}
+	for i := range repos {
+		answer = append(answer, p.toGitRepository(org, &repos[i]))
+	}
+	return answer, nil
+}
+
+func (p *AzureDevOpsProvider) listRepositories(owner string) ([]azureDevOpsRepository, error) {
+	org, project := splitAzureDevOpsOwner(owner)
+	u := p.organisationURL(org)
+	if project != "" {
+		u = util.UrlJoin(u, project)
+	}
+	repos := struct {
+		Value []azureDevOpsRepository `json:"value"`
+	}{}
+	err := p.apiRequest(http.MethodGet, util.UrlJoin(u, "_apis/git/repositories"), nil, nil, &repos)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the repositories of %s", owner)
+	}
+	return repos.Value, nil
+}
+
+// findRepository finds the repository with the name in the organisation returning nil if it does not exist. Returns
+// an error if the owner has no project and more than one project of the organisation has a repository with the name
+func (p *AzureDevOpsProvider) findRepository(owner string, name string) (*azureDevOpsRepository, error) {
+	repos, err := p.listRepositories(owner)
+	if err != nil {
+		return nil, err
+	}
+	var answer *azureDevOpsRepository
+	for i := range repos {
+		// repository names are case insensitive
+		if !strings.EqualFold(repos[i].Name, name) {
+			continue
+		}
+		if answer != nil {
+			return nil, fmt.Errorf("repository %s exists in projects %s and %s of Azure DevOps organisation %s so the owner must be of the form %s/<project>",
+				name, answer.Project.Name, repos[i].Project.Name, owner, owner)
+		}
+		answer = &repos[i]
+	}
+	return answer, nil
+}
+
+func (p *AzureDevOpsProvider) getRepository(owner string, name string) (*azureDevOpsRepository, error) {
+	repo, err := p.findRepository(owner, name)
+	if err != nil {
+		return nil, err
+	}
+	if repo == nil {
+		return nil, fmt.Errorf("repository %s not found in Azure DevOps organisation %s", name, owner)
+	}
+	return repo, nil
+}
+
+// repositoryOwner returns the owner of the repository of the form {organisation}/{project}
+func repositoryOwner(owner string, repo *azureDevOpsRepository) string {
+	org, _ := splitAzureDevOpsOwner(owner)
+	return org + "/" + repo.Project.Name
+}
+
+// gitRepositoryOwner returns the owner of the repository including its project if it is known
+func gitRepositoryOwner(owner string, repo *GitRepository) string {
+	if owner == "" {
+		owner = repo.Organisation
+	}
+	if strings.Contains(owner, "/") || repo.Project == "" {
+		return owner
+	}
+	return owner + "/" + repo.Project
+}
+
+// repositoryURL returns the URL of an API of the repository
+func (p *AzureDevOpsProvider) repositoryURL(owner string, repo *azureDevOpsRepository, paths ...string) string {
+	org, _ := splitAzureDevOpsOwner(owner)
+	return util.UrlJoin(append([]string{p.organisationURL(org), "_apis/git/repositories", repo.ID}, paths...)...)
+}
+
+func (p *AzureDevOpsProvider) toGitRepository(owner string, repo *azureDevOpsRepository) *GitRepository {
+	org, _ := splitAzureDevOpsOwner(owner)
+	answer := &GitRepository{
+		Name:             repo.Name,
+		AllowMergeCommit: true,
+		HTMLURL:          repo.WebURL,
+		CloneURL:         repo.RemoteURL,
+		SSHURL:           repo.SSHURL,
+		Fork:             repo.IsFork,
+		URL:              repo.WebURL,
+		Organisation:     org,
+		Project:          repo.Project.Name,
+		Private:          repo.Project.Visibility != "public",
+	}
+	u, err := url.Parse(repo.RemoteURL)
+	if err == nil {
+		// the remote URL includes the organisation as the user which would stop git from using the credentials of the
+		// pipeline user
+		u.User = nil
+		answer.CloneURL = u.String()
+		answer.Scheme = u.Scheme
+		answer.Host = u.Host
+	}
+	return answer
+}
+
+// getProject returns the project of the organisation or the only project of the organisation if no project is given
+func (p *AzureDevOpsProvider) getProject(org string, project string) (*azureDevOpsProject, error) {
+	if project != "" {
+		answer := &azureDevOpsProject{}
+		err := p.apiRequest(http.MethodGet, util.UrlJoin(p.organisationURL(org), "_apis/projects", project), nil, nil, answer)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting project %s of organisation %s", project, org)
+		}
+		return answer, nil
+	}
+	projects := struct {
+		Value []azureDevOpsProject `json:"value"`
+	}{}
+	err := p.apiRequest(http.MethodGet, util.UrlJoin(p.organisationURL(org), "_apis/projects"), nil, nil, &projects)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the projects of organisation %s", org)
+	}
+	if len(projects.Value) != 1 {
+		return nil, fmt.Errorf("organisation %s has %d projects so the owner must be of the form %s/<project>", org, len(projects.Value), org)
+	}
+	return &projects.Value[0], nil
+}
+
+// CreateRepository creates a repository in the project of the owner. The visibility of repositories is inherited from
+// their project so private is ignored
+func (p *AzureDevOpsProvider) CreateRepository(owner string, name string, private bool) (*GitRepository, error) {
+	org, projectName := splitAzureDevOpsOwner(owner)
+	project, err := p.getProject(org, projectName)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"name": name,
+		"project": map[string]string{
+			"id": project.ID,
+		},
+	}
+	repo := &azureDevOpsRepository{}
+	err = p.apiRequest(http.MethodPost, util.UrlJoin(p.organisationURL(org), project.Name, "_apis/git/repositories"), nil, body, repo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating repository %s in %s/%s", name, org, project.Name)
+	}
+	return p.toGitRepository(org, repo), nil
+}
+
+// GetRepository gets the repository with the name in the organisation
+func (p *AzureDevOpsProvider) GetRepository(org string, name string) (*GitRepository, error) {
+	repo, err := p.getRepository(org, name)
+	if err != nil {
+		return nil, err
+	}
+	return p.toGitRepository(org, repo), nil
+}
+
+// DeleteRepository deletes the repository
+func (p *AzureDevOpsProvider) DeleteRepository(org string, name string) error {
+	repo, err := p.getRepository(org, name)
+	if err != nil {
+		return err
+	}
+	err = p.apiRequest(http.MethodDelete, p.repositoryURL(org, repo), nil, nil, nil)
+	if err != nil {
+		return errors.Wrapf(err, "deleting repository %s/%s", org, name)
+	}
+	return nil
+}
+
+// ForkRepository is not supported as pull requests are created from branches of the repository
+func (p *AzureDevOpsProvider) ForkRepository(originalOrg string, name string, destinationOrg string) (*GitRepository, error) {
+	return nil, fmt.Errorf("Forking repositories is not supported for Azure DevOps")
+}
+
+// RenameRepository renames the repository
+func (p *AzureDevOpsProvider) RenameRepository(org string, name string, newName string) (*GitRepository, error) {
+	repo, err := p.getRepository(org, name)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]string{
+		"name": newName,
+	}
+	renamed := &azureDevOpsRepository{}
+	err = p.apiRequest(http.MethodPatch, p.repositoryURL(org, repo), nil, body, renamed)
+	if err != nil {
+		return nil, errors.Wrapf(err, "renaming repository %s/%s to %s", org, name, newName)
+	}
+	return p.toGitRepository(org, renamed), nil
+}
+
+// ValidateRepositoryName returns an error if the repository already exists
+func (p *AzureDevOpsProvider) ValidateRepositoryName(org string, name string) error {
+	repo, err := p.findRepository(org, name)
+	if err != nil {
+		return err
+	}
+	if repo != nil {
+		return fmt.Errorf("repository %s already exists", p.toGitRepository(org, repo).HTMLURL)
+	}
+	return nil
+}
+
+func (p *AzureDevOpsProvider) toGitPullRequest(owner string, repo *azureDevOpsRepository, pr *azureDevOpsPullRequest) *GitPullRequest {
+	org, _ := splitAzureDevOpsOwner(owner)
+	number := pr.PullRequestID
+	headRef := strings.TrimPrefix(pr.SourceRefName, "refs/heads/")
+	state := "open"
+	if pr.Status != "active" {
+		state = "closed"
+	}
+	merged := pr.Status == "completed"
+	mergeable := pr.MergeStatus == "succeeded"
+	answer := &GitPullRequest{
+		URL:       util.UrlJoin(p.toGitRepository(org, repo).HTMLURL, "pullrequest", strconv.Itoa(number)),
+		Owner:     repositoryOwner(org, repo),
+		Repo:      repo.Name,
+		Number:    &number,
+		Title:     pr.Title,
+		Body:      pr.Description,
+		HeadRef:   &headRef,
+		State:     &state,
+		Merged:    &merged,
+		Mergeable: &mergeable,
+		Author:    toAzureDevOpsGitUser(pr.CreatedBy),
+		UpdatedAt: pr.CreationDate,
+	}
+	if pr.LastMergeSourceCommit != nil {
+		answer.LastCommitSha = pr.LastMergeSourceCommit.CommitID
+	}
+	if pr.Status != "active" && pr.ClosedDate != nil {
+		answer.ClosedAt = pr.ClosedDate
+		answer.UpdatedAt = pr.ClosedDate
+		if merged {
+			answer.MergedAt = pr.ClosedDate
+			if pr.LastMergeCommit != nil {
+				answer.MergeCommitSHA = &pr.LastMergeCommit.CommitID
+			}
+		}
+	}
+	for _, reviewer := range pr.Reviewers {
+		answer.RequestedReviewers = append(answer.RequestedReviewers, toAzureDevOpsGitUser(reviewer))
+	}
+	for _, label := range pr.Labels {
+		name := label.Name
+		answer.Labels = append(answer.Labels, &Label{Name: &name})
+	}
+	return answer
+}
+
+func toAzureDevOpsGitUser(identity azureDevOpsIdentity) *GitUser {
+	return &GitUser{
+		Login:     identity.UniqueName,
+		Name:      identity.DisplayName,
+		AvatarURL: identity.ImageURL,
+	}
+}
+
+func toAzureDevOpsRefName(branch string) string {
+	if strings.HasPrefix(branch, "refs/") {
+		return branch
+	}
+	return "refs/heads/" + branch
+}
+
+// CreatePullRequest creates a pull request
+func (p *AzureDevOpsProvider) CreatePullRequest(data *GitPullRequestArguments) (*GitPullRequest, error) {
+	owner := gitRepositoryOwner("", data.GitRepository)
+	repo, err := p.getRepository(owner, data.GitRepository.Name)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"sourceRefName": toAzureDevOpsRefName(data.Head),
+		"targetRefName": toAzureDevOpsRefName(data.Base),
+		"title":         data.Title,
+		"description":   data.Body,
+	}
+	if len(data.Labels) > 0 {
+		labels := []azureDevOpsLabel{}
+		for _, label := range data.Labels {
+			labels = append(labels, azureDevOpsLabel{Name: label})
+		}
+		body["labels"] = labels
+	}
+	pr := &azureDevOpsPullRequest{}
+	err = p.apiRequest(http.MethodPost, p.repositoryURL(owner, repo, "pullrequests"), nil, body, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating pull request from %s to %s in %s/%s", data.Head, data.Base, owner, repo.Name)
+	}
+	return p.toGitPullRequest(owner, repo, pr), nil
+}
+
+// UpdatePullRequest updates the title and description of the pull request
+func (p *AzureDevOpsProvider) UpdatePullRequest(data *GitPullRequestArguments, number int) (*GitPullRequest, error) {
+	owner := gitRepositoryOwner("", data.GitRepository)
+	repo, err := p.getRepository(owner, data.GitRepository.Name)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]string{
+		"title":       data.Title,
+		"description": data.Body,
+	}
+	pr := &azureDevOpsPullRequest{}
+	err = p.apiRequest(http.MethodPatch, p.repositoryURL(owner, repo, "pullrequests", strconv.Itoa(number)), nil, body, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "updating pull request %d in %s/%s", number, owner, repo.Name)
+	}
+	return p.toGitPullRequest(owner, repo, pr), nil
+}
+
+func (p *AzureDevOpsProvider) getPullRequest(owner string, repo *azureDevOpsRepository, number int) (*azureDevOpsPullRequest, error) {
+	pr := &azureDevOpsPullRequest{}
+	err := p.apiRequest(http.MethodGet, p.repositoryURL(owner, repo, "pullrequests", strconv.Itoa(number)), nil, nil, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting pull request %d in %s/%s", number, owner, repo.Name)
+	}
+	return pr, nil
+}
+
+// UpdatePullRequestStatus refreshes the pull request from the server
+func (p *AzureDevOpsProvider) UpdatePullRequestStatus(pr *GitPullRequest) error {
+	if pr.Number == nil {
+		return fmt.Errorf("Missing Number for GitPullRequest %#v", pr)
+	}
+	repo, err := p.getRepository(pr.Owner, pr.Repo)
+	if err != nil {
+		return err
+	}
+	result, err := p.getPullRequest(pr.Owner, repo, *pr.Number)
+	if err != nil {
+		return err
+	}
+	*pr = *p.toGitPullRequest(pr.Owner, repo, result)
+	return nil
+}
+
+// GetPullRequest gets the pull request with the number
+func (p *AzureDevOpsProvider) GetPullRequest(owner string, repo *GitRepository, number int) (*GitPullRequest, error) {
+	owner = gitRepositoryOwner(owner, repo)
+	azureRepo, err := p.getRepository(owner, repo.Name)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := p.getPullRequest(owner, azureRepo, number)
+	if err != nil {
+		return nil, err
+	}
+	return p.toGitPullRequest(owner, azureRepo, pr), nil
+}
+
+// ListOpenPullRequests lists the active pull requests
+func (p *AzureDevOpsProvider) ListOpenPullRequests(owner string, repo string) ([]*GitPullRequest, error) {
+	azureRepo, err := p.getRepository(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*GitPullRequest{}
+	query := url.Values{}
+	query.Set("searchCriteria.status", "active")
+	query.Set("$top", strconv.Itoa(pageSize))
+	for skip := 0; ; skip += pageSize {
+		query.Set("$skip", strconv.Itoa(skip))
+		prs := struct {
+			Value []azureDevOpsPullRequest `json:"value"`
+		}{}
+		err = p.apiRequest(http.MethodGet, p.repositoryURL(owner, azureRepo, "pullrequests"), query, nil, &prs)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the open pull requests of %s/%s", owner, repo)
+		}
+		for i := range prs.Value {
+			answer = append(answer, p.toGitPullRequest(owner, azureRepo, &prs.Value[i]))
+		}
+		if len(prs.Value) < pageSize {
+			return answer, nil
+		}
+	}
+}
+
+func toAzureDevOpsGitCommit(commit *azureDevOpsCommit) *GitCommit {
+	return &GitCommit{
+		SHA:     commit.CommitID,
+		Message: commit.Comment,
+		URL:     commit.RemoteURL,
+		Author: &GitUser{
+			Name:  commit.Author.Name,
+			Email: commit.Author.Email,
+		},
+		Committer: &GitUser{
+			Name:  commit.Committer.Name,
+			Email: commit.Committer.Email,
+		},
+	}
+}
+
+// GetPullRequestCommits lists the commits of the pull request
+func (p *AzureDevOpsProvider) GetPullRequestCommits(owner string, repo *GitRepository, number int) ([]*GitCommit, error) {
+	owner = gitRepositoryOwner(owner, repo)
+	azureRepo, err := p.getRepository(owner, repo.Name)
+	if err != nil {
+		return nil, err
+	}
+	commits := struct {
+		Value []azureDevOpsCommit `json:"value"`
+	}{}
+	err = p.apiRequest(http.MethodGet, p.repositoryURL(owner, azureRepo, "pullrequests", strconv.Itoa(number), "commits"), nil, nil, &commits)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the commits of pull request %d in %s/%s", number, owner, repo.Name)
+	}
+	answer := []*GitCommit{}
+	for i := range commits.Value {
+		answer = append(answer, toAzureDevOpsGitCommit(&commits.Value[i]))
+	}
+	return answer, nil
+}
+
+// PullRequestLastCommitStatus returns the state of the latest status of the pull request, falling back to the
+// latest status of its last commit if the pull request has no statuses
+func (p *AzureDevOpsProvider) PullRequestLastCommitStatus(pr *GitPullRequest) (string, error) {
+	if pr.Number == nil {
+		return "", fmt.Errorf("Missing Number for GitPullRequest %#v", pr)
+	}
+	repo, err := p.getRepository(pr.Owner, pr.Repo)
+	if err != nil {
+		return "", err
+	}
+	statuses := struct {
+		Value []azureDevOpsStatus `json:"value"`
+	}{}
+	err = p.apiRequest(http.MethodGet, p.repositoryURL(pr.Owner, repo, "pullrequests", strconv.Itoa(*pr.Number), "statuses"), nil, nil, &statuses)
+	if err != nil {
+		return "", errors.Wrapf(err, "listing the statuses of pull request %d in %s/%s", *pr.Number, pr.Owner, pr.Repo)
+	}
+	if len(statuses.Value) == 0 {
+		commitStatuses, err := p.listCommitStatuses(pr.Owner, repo, pr.LastCommitSha)
+		if err != nil {
+			return "", err
+		}
+		statuses.Value = commitStatuses
+	}
+	if len(statuses.Value) == 0 {
+		return "", fmt.Errorf("no statuses found for pull request %d in %s/%s", *pr.Number, pr.Owner, pr.Repo)
+	}
+	latest := &statuses.Value[0]
+	for i := range statuses.Value {
+		status := &statuses.Value[i]
+		if status.CreationDate != nil && (latest.CreationDate == nil || status.CreationDate.After(*latest.CreationDate)) {
+			latest = status
+		}
+	}
+	return fromAzureDevOpsState(latest.State), nil
+}
+
+func (p *AzureDevOpsProvider) listCommitStatuses(owner string, repo *azureDevOpsRepository, sha string) ([]azureDevOpsStatus, error) {
+	if sha == "" {
+		return nil, nil
+	}
+	statuses := struct {
+		Value []azureDevOpsStatus `json:"value"`
+	}{}
+	err := p.apiRequest(http.MethodGet, p.repositoryURL(owner, repo, "commits", sha, "statuses"), nil, nil, &statuses)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the statuses of commit %s in %s/%s", sha, owner, repo.Name)
+	}
+	return statuses.Value, nil
+}
+
+// ListCommitStatus lists the statuses of the commit
+func (p *AzureDevOpsProvider) ListCommitStatus(org string, repo string, sha string) ([]*GitRepoStatus, error) {
+	azureRepo, err := p.getRepository(org, repo)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := p.listCommitStatuses(org, azureRepo, sha)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*GitRepoStatus{}
+	for i := range statuses {
+		answer = append(answer, fromAzureDevOpsStatus(&statuses[i]))
+	}
+	return answer, nil
+}
+
+func fromAzureDevOpsStatus(status *azureDevOpsStatus) *GitRepoStatus {
+	return &GitRepoStatus{
+		ID:          strconv.FormatInt(status.ID, 10),
+		Context:     status.Context.Name,
+		TargetURL:   status.TargetURL,
+		State:       fromAzureDevOpsState(status.State),
+		Description: status.Description,
+	}
+}
+
+// toAzureDevOpsState converts a commit status state to the state of an Azure DevOps status
+func toAzureDevOpsState(state string) string {
+	switch state {
+	case "pending":
+		return "pending"
+	case "success":
+		return "succeeded"
+	case "failure":
+		return "failed"
+	case "error":
+		return "error"
+	default:
+		return "notSet"
+	}
+}
+
+// fromAzureDevOpsState converts the state of an Azure DevOps status to a commit status state
+func fromAzureDevOpsState(state string) string {
+	switch state {
+	case "succeeded", "notApplicable":
+		return "success"
+	case "failed":
+		return "failure"
+	case "error":
+		return "error"
+	default:
+		return "pending"
+	}
+}
+
+// UpdateCommitStatus creates a status for the commit
+func (p *AzureDevOpsProvider) UpdateCommitStatus(org string, repo string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
+	azureRepo, err := p.getRepository(org, repo)
+	if err != nil {
+		return nil, err
+	}
+	body := &azureDevOpsStatus{
+		State:       toAzureDevOpsState(status.State),
+		Description: status.Description,
+		TargetURL:   status.TargetURL,
+		Context: azureDevOpsStatusContext{
+			Name:  status.Context,
+			Genre: azureDevOpsStatusGenre,
+		},
+	}
+	result := &azureDevOpsStatus{}
+	err = p.apiRequest(http.MethodPost, p.repositoryURL(org, azureRepo, "commits", sha, "statuses"), nil, body, result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "setting the status %s of commit %s in %s/%s", status.Context, sha, org, repo)
+	}
+	return fromAzureDevOpsStatus(result), nil
+}
+
+// MergePullRequest completes the pull request
+func (p *AzureDevOpsProvider) MergePullRequest(pr *GitPullRequest, message string) error {
+	if pr.Number == nil {
+		return fmt.Errorf("Missing Number for GitPullRequest %#v", pr)
+	}
+	repo, err := p.getRepository(pr.Owner, pr.Repo)
+	if err != nil {
+		return err
+	}
+	sha := pr.LastCommitSha
+	if sha == "" {
+		result, err := p.getPullRequest(pr.Owner, repo, *pr.Number)
+		if err != nil {
+			return err
+		}
+		if result.LastMergeSourceCommit != nil {
+			sha = result.LastMergeSourceCommit.CommitID
+		}
+	}
+	body := map[string]interface{}{
+		"status": "completed",
+		"lastMergeSourceCommit": azureDevOpsCommitRef{
+			CommitID: sha,
+		},
+		"completionOptions": map[string]interface{}{
+			"mergeCommitMessage": message,
+		},
+	}
+	err = p.apiRequest(http.MethodPatch, p.repositoryURL(pr.Owner, repo, "pullrequests", strconv.Itoa(*pr.Number)), nil, body, nil)
+	if err != nil {
+		return errors.Wrapf(err, "completing pull request %d in %s/%s", *pr.Number, pr.Owner, pr.Repo)
+	}
+	return nil
+}
+
+// listSubscriptions lists the service hooks which send events of the repository to webhooks
+func (p *AzureDevOpsProvider) listSubscriptions(owner string, repo *azureDevOpsRepository) ([]azureDevOpsSubscription, error) {
+	org, _ := splitAzureDevOpsOwner(owner)
+	subscriptions := struct {
+		Value []azureDevOpsSubscription `json:"value"`
+	}{}
+	err := p.apiRequest(http.MethodGet, util.UrlJoin(p.organisationURL(org), "_apis/hooks/subscriptions"), nil, nil, &subscriptions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the service hooks of organisation %s", org)
+	}
+	answer := []azureDevOpsSubscription{}
+	for _, subscription := range subscriptions.Value {
+		if subscription.ConsumerID == "webHooks" && subscription.PublisherInputs["repository"] == repo.ID {
+			answer = append(answer, subscription)
+		}
+	}
+	return answer, nil
+}
+
+// azureDevOpsConsumerInputs returns the inputs of a service hook which sends the events of the type to the webhook
+func azureDevOpsConsumerInputs(data *GitWebHookArguments, eventType string) map[string]string {
+	inputs := map[string]string{
+		"url":         data.URL,
+		"httpHeaders": AzureDevOpsWebHookEventHeader + ":" + eventType,
+	}
+	if data.Secret != "" {
+		inputs["basicAuthUsername"] = "jenkins-x"
+		inputs["basicAuthPassword"] = data.Secret
+	}
+	return inputs
+}
+
+// CreateWebHook creates a service hook for each of the events jx needs which sends the event to the webhook URL
+func (p *AzureDevOpsProvider) CreateWebHook(data *GitWebHookArguments) error {
+	if data.Repo == nil || data.Repo.Name == "" {
+		return fmt.Errorf("Missing property Repo")
+	}
+	owner := gitRepositoryOwner(data.Owner, data.Repo)
+	repo, err := p.getRepository(owner, data.Repo.Name)
+	if err != nil {
+		return err
+	}
+	subscriptions, err := p.listSubscriptions(owner, repo)
+	if err != nil {
+		return err
+	}
+	org, _ := splitAzureDevOpsOwner(owner)
+	for _, event := range azureDevOpsWebHookEvents {
+		var existing *azureDevOpsSubscription
+		for i := range subscriptions {
+			if subscriptions[i].EventType == event.EventType && subscriptions[i].ConsumerInputs["url"] == data.URL {
+				existing = &subscriptions[i]
+			}
+		}
+		inputs := azureDevOpsConsumerInputs(data, event.EventType)
+		if existing != nil {
+			if existing.ConsumerInputs["httpHeaders"] == inputs["httpHeaders"] {
+				log.Logger().Infof("Already has a service hook for %s events registered for %s", event.EventType, data.URL)
+				continue
+			}
+			// service hooks created by older versions of jx do not send the event header
+			existing.ConsumerInputs = inputs
+			log.Logger().Infof("Updating Azure DevOps service hook for %s events of %s/%s for url %s", util.ColorInfo(event.EventType), util.ColorInfo(owner), util.ColorInfo(repo.Name), util.ColorInfo(data.URL))
+			err = p.apiRequest(http.MethodPut, util.UrlJoin(p.organisationURL(org), "_apis/hooks/subscriptions", existing.ID), nil, existing, nil)
+			if err != nil {
+				return errors.Wrapf(err, "updating service hook %s of %s/%s", existing.ID, owner, repo.Name)
+			}
+			continue
+		}
+		publisherInputs := map[string]string{
+			"projectId":  repo.Project.ID,
+			"repository": repo.ID,
+		}
+		if event.NotificationType != "" {
+			publisherInputs["notificationType"] = event.NotificationType
+		}
+		subscription := &azureDevOpsSubscription{
+			PublisherID:      "tfs",
+			EventType:        event.EventType,
+			ResourceVersion:  event.ResourceVersion,
+			ConsumerID:       "webHooks",
+			ConsumerActionID: "httpRequest",
+			PublisherInputs:  publisherInputs,
+			ConsumerInputs:   inputs,
+		}
+		log.Logger().Infof("Creating Azure DevOps service hook for %s events of %s/%s for url %s", util.ColorInfo(event.EventType), util.ColorInfo(owner), util.ColorInfo(repo.Name), util.ColorInfo(data.URL))
+		err = p.apiRequest(http.MethodPost, util.UrlJoin(p.organisationURL(org), "_apis/hooks/subscriptions"), nil, subscription, nil)
+		if err != nil {
+			return errors.Wrapf(err, "creating service hook for %s events of %s/%s", event.EventType, owner, repo.Name)
+		}
+	}
+	return nil
+}
+
+// ListWebHooks lists the webhook URLs the events of the repository are sent to. Service hooks are identified by a
+// GUID so the IDs of the webhooks are not set
+func (p *AzureDevOpsProvider) ListWebHooks(owner string, repo string) ([]*GitWebHookArguments, error) {
+	azureRepo, err := p.getRepository(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions, err := p.listSubscriptions(owner, azureRepo)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*GitWebHookArguments{}
+	urls := map[string]bool{}
+	for _, subscription := range subscriptions {
+		u := subscription.ConsumerInputs["url"]
+		if u == "" || urls[u] {
+			continue
+		}
+		urls[u] = true
+		answer = append(answer, &GitWebHookArguments{
+			Owner: repositoryOwner(owner, azureRepo),
+			Repo:  p.toGitRepository(owner, azureRepo),
+			URL:   u,
+		})
+	}
+	return answer, nil
+}
+
+// UpdateWebHook updates the service hooks which send events to the existing URL of the webhook
+func (p *AzureDevOpsProvider) UpdateWebHook(data *GitWebHookArguments) error {
+	if data.Repo == nil || data.Repo.Name == "" {
+		return fmt.Errorf("Missing property Repo")
+	}
+	owner := gitRepositoryOwner(data.Owner, data.Repo)
+	repo, err := p.getRepository(owner, data.Repo.Name)
+	if err != nil {
+		return err
+	}
+	subscriptions, err := p.listSubscriptions(owner, repo)
+	if err != nil {
+		return err
+	}
+	org, _ := splitAzureDevOpsOwner(owner)
+	updated := false
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		if subscription.ConsumerInputs["url"] != data.ExistingURL {
+			continue
+		}
+		subscription.ConsumerInputs = azureDevOpsConsumerInputs(data, subscription.EventType)
+		log.Logger().Infof("Updating Azure DevOps service hook for %s events of %s/%s for url %s", util.ColorInfo(subscription.EventType), util.ColorInfo(owner), util.ColorInfo(repo.Name), util.ColorInfo(data.URL))
+		err = p.apiRequest(http.MethodPut, util.UrlJoin(p.organisationURL(org), "_apis/hooks/subscriptions", subscription.ID), nil, subscription, nil)
+		if err != nil {
+			return errors.Wrapf(err, "updating service hook %s of %s/%s", subscription.ID, owner, repo.Name)
+		}
+		updated = true
+	}
+	if !updated {
+		log.Logger().Warn("No webhooks found to update")
+	}
+	return nil
+}
+
+func (p *AzureDevOpsProvider) IsGitHub() bool {
+	return false
+}
+
+func (p *AzureDevOpsProvider) IsGitea() bool {
+	return false
+}
+
+func (p *AzureDevOpsProvider) IsBitbucketCloud() bool {
+	return false
+}
+
+func (p *AzureDevOpsProvider) IsBitbucketServer() bool {
+	return false
+}
+
+func (p *AzureDevOpsProvider) IsGerrit() bool {
+	return false
+}
+
+func (p *AzureDevOpsProvider) Kind() string {
+	return KindAzureDevOps
+}
+
+// GetIssue is not supported as Azure Boards work items are not git issues
+func (p *AzureDevOpsProvider) GetIssue(org string, name string, number int) (*GitIssue, error) {
+	log.Logger().Warn("Azure DevOps does not support issue tracking via git")
+	return nil, nil
+}
+
+// IssueURL returns the URL of the pull request or work item with the number
+func (p *AzureDevOpsProvider) IssueURL(org string, name string, number int, isPull bool) string {
+	if !isPull {
+		return util.UrlJoin(p.organisationURL(org), "_workitems/edit", strconv.Itoa(number))
+	}
+	repo, err := p.getRepository(org, name)
+	if err != nil {
+		log.Logger().Warnf("Unable to find repository %s/%s: %s", org, name, err)
+		return ""
+	}
+	return util.UrlJoin(p.toGitRepository(org, repo).HTMLURL, "pullrequest", strconv.Itoa(number))
+}
+
+func (p *AzureDevOpsProvider) SearchIssues(org string, name string, query string) ([]*GitIssue, error) {
+	log.Logger().Warn("Azure DevOps does not support issue tracking via git")
+	return nil, nil
+}
+
+func (p *AzureDevOpsProvider) SearchIssuesClosedSince(org string, name string, t time.Time) ([]*GitIssue, error) {
+	log.Logger().Warn("Azure DevOps does not support issue tracking via git")
+	return nil, nil
+}
+
+func (p *AzureDevOpsProvider) CreateIssue(owner string, repo string, issue *GitIssue) (*GitIssue, error) {
+	log.Logger().Warn("Azure DevOps does not support issue tracking via git")
+	return nil, nil
+}
+
+func (p *AzureDevOpsProvider) HasIssues() bool {
+	return false
+}
+
+// AddPRComment adds a comment thread to the pull request
+func (p *AzureDevOpsProvider) AddPRComment(pr *GitPullRequest, comment string) error {
+	if pr.Number == nil {
+		return fmt.Errorf("Missing Number for GitPullRequest %#v", pr)
+	}
+	repo, err := p.getRepository(pr.Owner, pr.Repo)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{
+		"comments": []map[string]interface{}{
+			{
+				"parentCommentId": 0,
+				"content":         comment,
+				"commentType":     "text",
+			},
+		},
+		"status": "active",
+	}
+	err = p.apiRequest(http.MethodPost, p.repositoryURL(pr.Owner, repo, "pullrequests", strconv.Itoa(*pr.Number), "threads"), nil, body, nil)
+	if err != nil {
+		return errors.Wrapf(err, "commenting on pull request %d in %s/%s", *pr.Number, pr.Owner, pr.Repo)
+	}
+	return nil
+}
+
+func (p *AzureDevOpsProvider) CreateIssueComment(owner string, repo string, number int, comment string) error {
+	log.Logger().Warn("Azure DevOps does not support issue tracking via git")
+	return nil
+}
+
+// AddLabelsToIssue adds labels to the pull request with the number
+func (p *AzureDevOpsProvider) AddLabelsToIssue(owner, repo string, number int, labels []string) error {
+	azureRepo, err := p.getRepository(owner, repo)
+	if err != nil {
+		return err
+	}
+	for _, label := range labels {
+		err = p.apiRequest(http.MethodPost, p.repositoryURL(owner, azureRepo, "pullrequests", strconv.Itoa(number), "labels"), nil, azureDevOpsLabel{Name: label}, nil)
+		if err != nil {
+			return errors.Wrapf(err, "adding label %s to pull request %d in %s/%s", label, number, owner, repo)
+		}
+	}
+	return nil
+}
+
+func (p *AzureDevOpsProvider) UpdateRelease(owner string, repo string, tag string, releaseInfo *GitRelease) error {
+	log.Logger().Warn("Azure DevOps doesn't support releases")
+	return nil
+}
+
+func (p *AzureDevOpsProvider) ListReleases(org string, name string) ([]*GitRelease, error) {
+	log.Logger().Warn("Azure DevOps doesn't support releases")
+	return nil, nil
+}
+
+// GetRelease returns the release info for org, repo name and tag
+func (p *AzureDevOpsProvider) GetRelease(org string, name string, tag string) (*GitRelease, error) {
+	log.Logger().Warn("Azure DevOps doesn't support releases")
+	return nil, nil
+}
+
+// GetLatestRelease fetches the latest release from the git provider for org and name
+func (p *AzureDevOpsProvider) GetLatestRelease(org string, name string) (*GitRelease, error) {
+	log.Logger().Warn("Azure DevOps doesn't support releases")
+	return nil, nil
+}
+
+// UploadReleaseAsset will upload an asset to org/repo to a release with id, giving it a name, it will return the release asset from the git provider
+func (p *AzureDevOpsProvider) UploadReleaseAsset(org string, repo string, id int64, name string, asset *os.File) (*GitReleaseAsset, error) {
+	log.Logger().Warn("Azure DevOps doesn't support releases")
+	return nil, nil
+}
+
+func (p *AzureDevOpsProvider) JenkinsWebHookPath(gitURL string, secret string) string {
+	return "/generic-webhook-trigger/invoke"
+}
+
+func (p *AzureDevOpsProvider) Label() string {
+	return p.Server.Label()
+}
+
+func (p *AzureDevOpsProvider) ServerURL() string {
+	return p.Server.URL
+}
+
+// BranchArchiveURL returns the URL to download a ZIP archive of the branch
+func (p *AzureDevOpsProvider) BranchArchiveURL(org string, name string, branch string) string {
+	repo, err := p.getRepository(org, name)
+	if err != nil {
+		log.Logger().Warnf("Unable to find repository %s/%s: %s", org, name, err)
+		return ""
+	}
+	query := url.Values{}
+	query.Set("path", "/")
+	query.Set("versionDescriptor.version", branch)
+	query.Set("$format", "zip")
+	query.Set("download", "true")
+	query.Set("api-version", azureDevOpsAPIVersion)
+	return p.repositoryURL(org, repo, "items") + "?" + query.Encode()
+}
+
+func (p *AzureDevOpsProvider) CurrentUsername() string {
+	return p.Username
+}
+
+func (p *AzureDevOpsProvider) UserAuth() auth.UserAuth {
+	return p.User
+}
+
+// UserInfo returns the login of the user as Azure DevOps users are only known by their identities
+func (p *AzureDevOpsProvider) UserInfo(username string) *GitUser {
+	return &GitUser{
+		Login: username,
+	}
+}
+
+func (p *AzureDevOpsProvider) AddCollaborator(user string, organisation string, repo string) error {
+	log.Logger().Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for Azure DevOps. Please add user: %v as a contributor to this project.", user)
+	return nil
+}
+
+func (p *AzureDevOpsProvider) ListInvitations() ([]*github.RepositoryInvitation, *github.Response, error) {
+	log.Logger().Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for Azure DevOps.")
+	return []*github.RepositoryInvitation{}, &github.Response{}, nil
+}
+
+func (p *AzureDevOpsProvider) AcceptInvitation(ID int64) (*github.Response, error) {
+	log.Logger().Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for Azure DevOps.")
+	return &github.Response{}, nil
+}
+
+// GetContent returns the base64 encoded content of a file at the branch or commit
+func (p *AzureDevOpsProvider) GetContent(org string, name string, path string, ref string) (*GitFileContent, error) {
+	repo, err := p.getRepository(org, name)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("path", path)
+	query.Set("includeContent", "true")
+	if ref != "" {
+		query.Set("versionDescriptor.version", ref)
+		if isCommitSHA(ref) {
+			query.Set("versionDescriptor.versionType", "commit")
+		} else {
+			query.Set("versionDescriptor.versionType", "branch")
+		}
+	}
+	item := &azureDevOpsItem{}
+	err = p.apiRequest(http.MethodGet, p.repositoryURL(org, repo, "items"), query, nil, item)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting %s at %s from %s/%s", path, ref, org, name)
+	}
+	_, fileName := filepath.Split(item.Path)
+	htmlURL := p.toGitRepository(org, repo).HTMLURL + "?path=" + url.QueryEscape(item.Path)
+	return &GitFileContent{
+		Type:     "file",
+		Name:     fileName,
+		Path:     item.Path,
+		Encoding: "base64",
+		Content:  base64.StdEncoding.EncodeToString([]byte(item.Content)),
+		Size:     len(item.Content),
+		Sha:      item.ObjectID,
+		Url:      item.URL,
+		HtmlUrl:  htmlURL,
+	}, nil
+}
+
+// isCommitSHA returns true if the ref is a full commit SHA rather than a branch name
+func isCommitSHA(ref string) bool {
+	if len(ref) != 40 {
+		return false
+	}
+	for _, c := range ref {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// ShouldForkForPullRequest returns false as pull requests are created from branches of the repository
+func (p *AzureDevOpsProvider) ShouldForkForPullRequest(originalOwner string, repoName string, username string) bool {
+	return false
+}
+
+// ListCommits lists the commits for the specified repo and owner, newest first
+func (p *AzureDevOpsProvider) ListCommits(owner, repo string, opt *ListCommitsArguments) ([]*GitCommit, error) {
+	azureRepo, err := p.getRepository(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	if opt.SHA != "" {
+		query.Set("searchCriteria.itemVersion.version", opt.SHA)
+		query.Set("searchCriteria.itemVersion.versionType", "commit")
+	}
+	if opt.Path != "" {
+		query.Set("searchCriteria.itemPath", opt.Path)
+	}
+	if opt.Author != "" {
+		query.Set("searchCriteria.author", opt.Author)
+	}
+	if !opt.Since.IsZero() {
+		query.Set("searchCriteria.fromDate", opt.Since.Format(time.RFC3339))
+	}
+	if !opt.Until.IsZero() {
+		query.Set("searchCriteria.toDate", opt.Until.Format(time.RFC3339))
+	}
+	limit := opt.PerPage
+	if limit <= 0 {
+		limit = pageSize
+	}
+	query.Set("searchCriteria.$top", strconv.Itoa(limit))
+	page := opt.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	answer := []*GitCommit{}
+	for {
+		query.Set("searchCriteria.$skip", strconv.Itoa((page-1)*limit))
+		commits := struct {
+			Value []azureDevOpsCommit `json:"value"`
+		}{}
+		err = p.apiRequest(http.MethodGet, p.repositoryURL(owner, azureRepo, "commits"), query, nil, &commits)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the commits of %s/%s", owner, repo)
+		}
+		for i := range commits.Value {
+			answer = append(answer, toAzureDevOpsGitCommit(&commits.Value[i]))
+		}
+		// only return the requested page if the caller is paging through the commits
+		if opt.Page > 0 || len(commits.Value) < limit {
+			return answer, nil
+		}
+		page++
+	}
+}
+
+// apiRequest invokes a REST API of Azure DevOps authenticating with the personal access token of the user. The request
+// body is encoded as JSON and the response is decoded as JSON into result if it is not nil.
+func (p *AzureDevOpsProvider) apiRequest(method string, u string, query url.Values, body interface{}, result interface{}) error {
+	values := url.Values{}
+	for k, v := range query {
+		values[k] = v
+	}
+	values.Set("api-version", azureDevOpsAPIVersion)
+	client := &restClient{
+		httpClient: p.HTTPClient,
+		authorize: func(req *http.Request) {
+			req.SetBasicAuth(p.Username, p.User.ApiToken)
+		},
+	}
+	_, err := client.do(method, u, values, body, result)
+	return err
+}
@@ -0,0 +1,341 @@
+package gits_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	azureDevOpsRepoID = "5febef5a-833d-4e14-b9c0-14cb638f91e6"
+	azureDevOpsSHA    = "d6f24ee03d76a2caf0a4e1975fb43e8f61759b9c"
+)
+
+type AzureDevOpsProviderTestSuite struct {
+	suite.Suite
+	mux      *http.ServeMux
+	server   *httptest.Server
+	provider *gits.AzureDevOpsProvider
+
+	subscriptionRequests []map[string]interface{}
+	requestPaths         []string
+}
+
+var azureDevOpsRouter = util.Router{
+	"/_apis/profile/profiles/me": util.MethodMap{
+		"GET": "profile.json",
+	},
+	"/_apis/accounts": util.MethodMap{
+		"GET": "accounts.json",
+	},
+	"/myorg/_apis/projects": util.MethodMap{
+		"GET": "projects.json",
+	},
+	"/myorg/_apis/git/repositories": util.MethodMap{
+		"GET": "repos.json",
+	},
+	"/myorg/myproject/_apis/git/repositories": util.MethodMap{
+		"GET":  "repos.json",
+		"POST": "repo.json",
+	},
+	"/myorg/_apis/git/repositories/" + azureDevOpsRepoID + "/pullrequests": util.MethodMap{
+		"GET":  "prs.json",
+		"POST": "pr.json",
+	},
+	"/myorg/_apis/git/repositories/" + azureDevOpsRepoID + "/pullrequests/1": util.MethodMap{
+		"GET": "pr.json",
+	},
+	"/myorg/_apis/git/repositories/" + azureDevOpsRepoID + "/pullrequests/1/statuses": util.MethodMap{
+		"GET": "empty-list.json",
+	},
+	"/myorg/_apis/git/repositories/" + azureDevOpsRepoID + "/commits/" + azureDevOpsSHA + "/statuses": util.MethodMap{
+		"GET":  "statuses.json",
+		"POST": "status.json",
+	},
+	"/myorg/_apis/git/repositories/" + azureDevOpsRepoID + "/items": util.MethodMap{
+		"GET": "item.json",
+	},
+	"/myorg/_apis/git/repositories/" + azureDevOpsRepoID + "/commits": util.MethodMap{
+		"GET": "commits.json",
+	},
+}
+
+func (suite *AzureDevOpsProviderTestSuite) SetupSuite() {
+	suite.mux = http.NewServeMux()
+
+	for path, methodMap := range azureDevOpsRouter {
+		suite.mux.HandleFunc(path, util.GetMockAPIResponseFromFile("test_data/azure_devops", methodMap))
+	}
+	subscriptions := util.GetMockAPIResponseFromFile("test_data/azure_devops", util.MethodMap{
+		"GET":  "subscriptions.json",
+		"POST": "subscriptions.json",
+	})
+	suite.mux.HandleFunc("/myorg/_apis/hooks/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			request := map[string]interface{}{}
+			err := json.NewDecoder(r.Body).Decode(&request)
+			suite.Require().Nil(err)
+			suite.subscriptionRequests = append(suite.subscriptionRequests, request)
+		}
+		subscriptions(w, r)
+	})
+
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.requestPaths = append(suite.requestPaths, r.URL.Path)
+		suite.mux.ServeHTTP(w, r)
+	}))
+	suite.Require().NotNil(suite.server)
+
+	as := auth.AuthServer{
+		URL:         suite.server.URL,
+		Name:        "Test Azure DevOps Server",
+		Kind:        gits.KindAzureDevOps,
+		CurrentUser: "test-user",
+	}
+	ua := auth.UserAuth{
+		Username: "test-user",
+		ApiToken: "0123456789abdef",
+	}
+
+	ap, err := gits.NewAzureDevOpsProvider(&as, &ua, gits.NewGitCLI())
+	suite.Require().Nil(err)
+	suite.Require().NotNil(ap)
+
+	var ok bool
+	suite.provider, ok = ap.(*gits.AzureDevOpsProvider)
+	suite.Require().True(ok)
+	suite.provider.ProfileURL = suite.server.URL
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TearDownSuite() {
+	suite.server.Close()
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestListOrganisations() {
+	orgs, err := suite.provider.ListOrganisations()
+	suite.Require().Nil(err)
+	suite.Require().Equal([]gits.GitOrganisation{{Login: "myorg"}, {Login: "otherorg"}}, orgs)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestListRepositories() {
+	repos, err := suite.provider.ListRepositories("myorg")
+	suite.Require().Nil(err)
+	suite.Require().Len(repos, 2)
+	suite.Require().Equal("test-repo", repos[0].Name)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestGetRepository() {
+	repo, err := suite.provider.GetRepository("myorg", "Test-Repo")
+	suite.Require().Nil(err)
+	suite.Require().NotNil(repo)
+	suite.Require().Equal("test-repo", repo.Name)
+	suite.Require().Equal("myorg", repo.Organisation)
+	suite.Require().Equal("myproject", repo.Project)
+	suite.Require().Equal("https://dev.azure.com/myorg/myproject/_git/test-repo", repo.CloneURL, "the organisation is removed from the clone URL")
+	suite.Require().Equal("https://dev.azure.com/myorg/myproject/_git/test-repo", repo.HTMLURL)
+	suite.Require().True(repo.Private)
+
+	_, err = suite.provider.GetRepository("myorg", "missing-repo")
+	suite.Require().NotNil(err)
+
+	repo, err = suite.provider.GetRepository("myorg/myproject", "test-repo")
+	suite.Require().Nil(err)
+	suite.Require().Equal("myproject", repo.Project)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestValidateRepositoryName() {
+	err := suite.provider.ValidateRepositoryName("myorg", "test-repo")
+	suite.Require().NotNil(err)
+
+	err = suite.provider.ValidateRepositoryName("myorg", "new-repo")
+	suite.Require().Nil(err)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestCreateRepository() {
+	repo, err := suite.provider.CreateRepository("myorg", "new-repo", true)
+	suite.Require().Nil(err)
+	suite.Require().NotNil(repo)
+	suite.Require().Equal("new-repo", repo.Name)
+	suite.Require().Equal("myproject", repo.Project)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestCreatePullRequest() {
+	pr, err := suite.provider.CreatePullRequest(&gits.GitPullRequestArguments{
+		Title: "Add a pipeline",
+		Body:  "Adds the jenkins-x.yml",
+		Head:  "feature",
+		Base:  "master",
+		GitRepository: &gits.GitRepository{
+			Name:         "test-repo",
+			Organisation: "myorg",
+		},
+	})
+	suite.Require().Nil(err)
+	suite.Require().NotNil(pr)
+	suite.Require().Equal(1, *pr.Number)
+	suite.Require().Equal("https://dev.azure.com/myorg/myproject/_git/test-repo/pullrequest/1", pr.URL)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestGetPullRequest() {
+	pr, err := suite.provider.GetPullRequest("myorg", &gits.GitRepository{Name: "test-repo"}, 1)
+	suite.Require().Nil(err)
+	suite.Require().NotNil(pr)
+	suite.Require().Equal("feature", *pr.HeadRef)
+	suite.Require().Equal("open", *pr.State)
+	suite.Require().True(*pr.Mergeable)
+	suite.Require().False(*pr.Merged)
+	suite.Require().Nil(pr.ClosedAt)
+	suite.Require().Equal("myorg/myproject", pr.Owner, "the owner includes the project of the repository")
+	suite.Require().Equal(azureDevOpsSHA, pr.LastCommitSha)
+	suite.Require().Equal("test-user@example.com", pr.Author.Login)
+	suite.Require().Len(pr.RequestedReviewers, 1)
+	suite.Require().Len(pr.Labels, 1)
+	suite.Require().Equal("approved", *pr.Labels[0].Name)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestListOpenPullRequests() {
+	prs, err := suite.provider.ListOpenPullRequests("myorg", "test-repo")
+	suite.Require().Nil(err)
+	suite.Require().Len(prs, 1)
+	suite.Require().Equal("Add a pipeline", prs[0].Title)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestListCommitStatus() {
+	statuses, err := suite.provider.ListCommitStatus("myorg", "test-repo", azureDevOpsSHA)
+	suite.Require().Nil(err)
+	suite.Require().Len(statuses, 2)
+	suite.Require().Equal(&gits.GitRepoStatus{
+		ID:          "2",
+		Context:     "pr-build",
+		State:       "success",
+		TargetURL:   "https://jx.example.com/teams/jx/projects/myorg/test-repo/PR-1/1",
+		Description: "Succeeded",
+	}, statuses[0])
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestUpdateCommitStatus() {
+	status, err := suite.provider.UpdateCommitStatus("myorg", "test-repo", azureDevOpsSHA, &gits.GitRepoStatus{
+		State:       "failure",
+		Context:     "pr-build",
+		Description: "step unit-tests exited with code 1",
+		TargetURL:   "https://jx.example.com/teams/jx/projects/myorg/test-repo/PR-1/2",
+	})
+	suite.Require().Nil(err)
+	suite.Require().NotNil(status)
+	suite.Require().Equal("3", status.ID)
+	suite.Require().Equal("failure", status.State)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestPullRequestLastCommitStatus() {
+	number := 1
+	state, err := suite.provider.PullRequestLastCommitStatus(&gits.GitPullRequest{
+		Owner:         "myorg",
+		Repo:          "test-repo",
+		Number:        &number,
+		LastCommitSha: azureDevOpsSHA,
+	})
+	suite.Require().Nil(err)
+	suite.Require().Equal("success", state, "the pull request has no statuses so the latest status of its last commit is used")
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestWebHooks() {
+	hooks, err := suite.provider.ListWebHooks("myorg", "test-repo")
+	suite.Require().Nil(err)
+	suite.Require().Len(hooks, 1)
+	suite.Require().Equal("http://hook.jx.example.com/hook", hooks[0].URL)
+	suite.Require().Equal("myorg/myproject", hooks[0].Owner)
+
+	suite.subscriptionRequests = nil
+	err = suite.provider.CreateWebHook(&gits.GitWebHookArguments{
+		Owner:  "myorg",
+		Repo:   &gits.GitRepository{Name: "test-repo"},
+		URL:    "http://hook.jx.example.com/hook",
+		Secret: "s3cr3t",
+	})
+	suite.Require().Nil(err)
+	suite.Require().Len(suite.subscriptionRequests, 3, "a service hook is created for each event without one")
+	request := suite.subscriptionRequests[0]
+	suite.Require().Equal("git.pullrequest.created", request["eventType"])
+	suite.Require().Equal(map[string]interface{}{
+		"projectId":  "6ce954b1-ce1f-45d1-b94d-e6bf2464ba2c",
+		"repository": azureDevOpsRepoID,
+	}, request["publisherInputs"])
+	suite.Require().Equal(map[string]interface{}{
+		"url":               "http://hook.jx.example.com/hook",
+		"basicAuthUsername": "jenkins-x",
+		"basicAuthPassword": "s3cr3t",
+		"httpHeaders":       "X-AzureDevOps-Event:git.pullrequest.created",
+	}, request["consumerInputs"])
+	suite.Require().Equal("PushNotification", suite.subscriptionRequests[1]["publisherInputs"].(map[string]interface{})["notificationType"],
+		"pull request updates are only sent for pushes to the source branch")
+}
+
+// TestImport uses the repository parsed from the URL of the repository to import to create the webhook and pull
+// request, as jx import does, so the project of the repository is part of their owner
+func (suite *AzureDevOpsProviderTestSuite) TestImport() {
+	gitInfo, err := gits.ParseGitURL("https://dev.azure.com/myorg/myproject/_git/test-repo")
+	suite.Require().Nil(err)
+	suite.Require().Equal("myorg", gitInfo.Organisation)
+	suite.Require().Equal("myproject", gitInfo.Project)
+
+	suite.requestPaths = nil
+	suite.subscriptionRequests = nil
+	err = suite.provider.CreateWebHook(&gits.GitWebHookArguments{
+		Owner:  gitInfo.Organisation,
+		Repo:   gitInfo,
+		URL:    "http://hook.jx.example.com/hook",
+		Secret: "s3cr3t",
+	})
+	suite.Require().Nil(err)
+	suite.Require().Len(suite.subscriptionRequests, 3)
+	suite.Require().Contains(suite.requestPaths, "/myorg/myproject/_apis/git/repositories")
+	suite.Require().NotContains(suite.requestPaths, "/myorg/_apis/git/repositories", "the repository is looked up in its project")
+
+	suite.requestPaths = nil
+	pr, err := suite.provider.CreatePullRequest(&gits.GitPullRequestArguments{
+		Title:         "Add a pipeline",
+		Head:          "feature",
+		Base:          "master",
+		GitRepository: gitInfo,
+	})
+	suite.Require().Nil(err)
+	suite.Require().Equal("myorg/myproject", pr.Owner)
+	suite.Require().Contains(suite.requestPaths, "/myorg/myproject/_apis/git/repositories")
+	suite.Require().NotContains(suite.requestPaths, "/myorg/_apis/git/repositories", "the repository is looked up in its project")
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestGetContent() {
+	content, err := suite.provider.GetContent("myorg", "test-repo", "jenkins-x.yml", "master")
+	suite.Require().Nil(err)
+	suite.Require().NotNil(content)
+	suite.Require().Equal("jenkins-x.yml", content.Name)
+	suite.Require().Equal("base64", content.Encoding)
+
+	data, err := base64.StdEncoding.DecodeString(content.Content)
+	suite.Require().Nil(err)
+	suite.Require().Equal("buildPack: go\n", string(data))
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestListCommits() {
+	commits, err := suite.provider.ListCommits("myorg", "test-repo", &gits.ListCommitsArguments{})
+	suite.Require().Nil(err)
+	suite.Require().Len(commits, 2)
+	suite.Require().Equal(azureDevOpsSHA, commits[0].SHA)
+	suite.Require().Equal("fix: handle empty pipelines", commits[0].Message)
+	suite.Require().Equal("test-user@example.com", commits[0].Author.Email)
+}
+
+func TestAzureDevOpsProviderTestSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping AzureDevOpsProviderTestSuite in short mode")
+	} else {
+		suite.Run(t, new(AzureDevOpsProviderTestSuite))
+	}
+}
@@ -2,9 +2,13 @@ package gits
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -32,6 +36,12 @@ type BitbucketServerProvider struct {
 	Server auth.AuthServer
 	User   auth.UserAuth
 	Git    Gitter
+
+	// RestURL is the base URL of the REST APIs which are not supported by the Client, it defaults to the server URL
+	// followed by /rest
+	RestURL string
+	// HTTPClient is used by the Client and for the REST APIs the Client does not support
+	HTTPClient *http.Client
 }
 
 type projectsPage struct {
@@ -103,14 +113,16 @@ func NewBitbucketServerProvider(server *auth.AuthServer, user *auth.UserAuth, gi
 	apiKeyAuthContext := context.WithValue(ctx, bitbucket.ContextAccessToken, user.ApiToken)
 
 	provider := BitbucketServerProvider{
-		Server:   *server,
-		User:     *user,
-		Username: user.Username,
-		Context:  apiKeyAuthContext,
-		Git:      git,
+		Server:     *server,
+		User:       *user,
+		Username:   user.Username,
+		Context:    apiKeyAuthContext,
+		Git:        git,
+		HTTPClient: util.GetClient(),
 	}
 
 	cfg := bitbucket.NewConfiguration(server.URL + "/rest")
+	cfg.HTTPClient = provider.HTTPClient
 	provider.Client = bitbucket.NewAPIClient(apiKeyAuthContext, cfg)
 
 	return &provider, nil
@@ -562,39 +574,40 @@ func (b *BitbucketServerProvider) toPullRequest(bPR bitbucket.PullRequest) *GitP
 // ListOpenPullRequests lists the open pull requests
 func (b *BitbucketServerProvider) ListOpenPullRequests(owner string, repo string) ([]*GitPullRequest, error) {
 	answer := []*GitPullRequest{}
-	var pullRequests pullRequestPage
-
-	paginationOptions := make(map[string]interface{})
-
-	paginationOptions["start"] = 0
-	paginationOptions["limit"] = pageLimit
-
-	// TODO how to pass in the owner and repo and status? these are total guesses
-	paginationOptions["owner"] = owner
-	paginationOptions["repo"] = repo
-	paginationOptions["state"] = "open"
+	pullRequests, err := b.listPullRequests(owner, repo, "OPEN", "")
+	if err != nil {
+		return nil, err
+	}
+	for _, pr := range pullRequests {
+		answer = append(answer, b.toPullRequest(pr))
+	}
+	return answer, nil
+}
 
+// listPullRequests lists the pull requests of a repository in a state, optionally only those matching the filter text
+func (b *BitbucketServerProvider) listPullRequests(projectKey string, repo string, state string, filterText string) ([]bitbucket.PullRequest, error) {
+	var answer []bitbucket.PullRequest
+	query := url.Values{}
+	query.Set("state", state)
+	query.Set("limit", strconv.Itoa(pageLimit))
+	if filterText != "" {
+		query.Set("filterText", filterText)
+	}
+	path := fmt.Sprintf("/api/1.0/projects/%s/repos/%s/pull-requests", projectKey, repo)
+	start := 0
 	for {
-		apiResponse, err := b.Client.DefaultApi.GetPullRequests(paginationOptions)
-		if err != nil {
-			return nil, err
-		}
-
-		err = mapstructure.Decode(apiResponse.Values, &pullRequests)
+		var pullRequests pullRequestPage
+		query.Set("start", strconv.Itoa(start))
+		_, err := b.restRequest(http.MethodGet, path, query, nil, &pullRequests)
 		if err != nil {
-			return nil, err
+			return nil, errors.Wrapf(err, "listing the pull requests of %s/%s", projectKey, repo)
 		}
-
-		for _, pr := range pullRequests.Values {
-			answer = append(answer, b.toPullRequest(pr))
+		answer = append(answer, pullRequests.Values...)
+		if pullRequests.IsLastPage || len(pullRequests.Values) == 0 {
+			return answer, nil
 		}
-
-		if pullRequests.IsLastPage {
-			break
-		}
-		paginationOptions["start"] = pullRequests.NextPageStart
+		start = pullRequests.NextPageStart
 	}
-	return answer, nil
 }
 
 func convertBitBucketCommitToGitCommit(bCommit *bitbucket.Commit, repo *GitRepository) *GitCommit {
@@ -703,14 +716,49 @@ func (b *BitbucketServerProvider) ListCommitStatus(org, repo, sha string) ([]*Gi
 	return statuses, nil
 }
 
+// UpdateCommitStatus reports the status of a commit using the build status API, the context of the status is used
+// as the key of the build
 func (b *BitbucketServerProvider) UpdateCommitStatus(org string, repo string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
-	return &GitRepoStatus{}, errors.New("TODO")
+	targetURL := status.TargetURL
+	if targetURL == "" {
+		// the build status API requires a URL
+		targetURL = util.UrlJoin(b.Server.URL, "projects", org, "repos", repo, "commits", sha)
+	}
+	buildStatus := map[string]interface{}{
+		"state":       toBitbucketServerBuildState(status.State),
+		"key":         status.Context,
+		"name":        status.Context,
+		"url":         targetURL,
+		"description": status.Description,
+	}
+	_, err := b.restRequest(http.MethodPost, "/build-status/1.0/commits/"+sha, nil, buildStatus, nil)
+	if err != nil {
+		return &GitRepoStatus{}, errors.Wrapf(err, "setting the build status %s of commit %s in %s/%s", status.Context, sha, org, repo)
+	}
+	return convertBitBucketBuildStatusToGitStatus(&bitbucket.BuildStatus{
+		State:       toBitbucketServerBuildState(status.State),
+		Key:         status.Context,
+		Url:         targetURL,
+		Description: status.Description,
+	}), nil
+}
+
+// toBitbucketServerBuildState converts the state of a commit status into the state of a build
+func toBitbucketServerBuildState(state string) string {
+	switch state {
+	case "success":
+		return "SUCCESSFUL"
+	case "failure", "error", "stopped":
+		return "FAILED"
+	}
+	return "INPROGRESS"
 }
 
 func convertBitBucketBuildStatusToGitStatus(buildStatus *bitbucket.BuildStatus) *GitRepoStatus {
 	return &GitRepoStatus{
-		ID:  buildStatus.Key,
-		URL: buildStatus.Url,
+		ID:      buildStatus.Key,
+		Context: buildStatus.Key,
+		URL:     buildStatus.Url,
 		// var from BitBucketCloudProvider
 		State:       stateMap[buildStatus.State],
 		TargetURL:   buildStatus.Url,
@@ -894,12 +942,16 @@ func (b *BitbucketServerProvider) UpdateWebHook(data *GitWebHookArguments) error
 	return nil
 }
 
+// SearchIssues searches the pull requests of the repository as Bitbucket Server has no issue tracker of its own
 func (b *BitbucketServerProvider) SearchIssues(org string, name string, query string) ([]*GitIssue, error) {
-
 	gitIssues := []*GitIssue{}
-
-	log.Logger().Warn("Searching issues on bitbucket server is not supported at this moment")
-
+	pullRequests, err := b.listPullRequests(org, name, "ALL", query)
+	if err != nil {
+		return gitIssues, err
+	}
+	for _, pr := range pullRequests {
+		gitIssues = append(gitIssues, b.pullRequestToIssue(org, name, pr))
+	}
 	return gitIssues, nil
 }
 
@@ -911,10 +963,50 @@ func (b *BitbucketServerProvider) SearchIssuesClosedSince(org string, name strin
 	return FilterIssuesClosedSince(issues, t), nil
 }
 
+// GetIssue returns the pull request with the number as Bitbucket Server has no issue tracker of its own
 func (b *BitbucketServerProvider) GetIssue(org string, name string, number int) (*GitIssue, error) {
+	var bPR bitbucket.PullRequest
 
-	log.Logger().Warn("Finding an issue on bitbucket server is not supported at this moment")
-	return &GitIssue{}, nil
+	apiResponse, err := b.Client.DefaultApi.GetPullRequest(org, name, number)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting pull request %d of %s/%s", number, org, name)
+	}
+	err = mapstructure.Decode(apiResponse.Values, &bPR)
+	if err != nil {
+		return nil, err
+	}
+	return b.pullRequestToIssue(org, name, bPR), nil
+}
+
+func (b *BitbucketServerProvider) pullRequestToIssue(org string, name string, bPR bitbucket.PullRequest) *GitIssue {
+	number := bPR.ID
+	state := strings.ToLower(bPR.State)
+	createdAt := time.Unix(0, bPR.CreatedDate*int64(time.Millisecond))
+	updatedAt := time.Unix(0, bPR.UpdatedDate*int64(time.Millisecond))
+	issue := &GitIssue{
+		Owner:         org,
+		Repo:          name,
+		Number:        &number,
+		Key:           strconv.Itoa(number),
+		Title:         bPR.Title,
+		Body:          bPR.Description,
+		State:         &state,
+		CreatedAt:     &createdAt,
+		UpdatedAt:     &updatedAt,
+		IsPullRequest: true,
+		User: &GitUser{
+			Login: bPR.Author.User.Slug,
+			Name:  bPR.Author.User.Name,
+			Email: bPR.Author.User.Email,
+		},
+	}
+	if len(bPR.Links.Self) > 0 {
+		issue.URL = bPR.Links.Self[0].Href
+	}
+	if state != "open" {
+		issue.ClosedAt = &updatedAt
+	}
+	return issue
 }
 
 func (b *BitbucketServerProvider) IssueURL(org string, name string, number int, isPull bool) string {
@@ -1042,8 +1134,16 @@ func (b *BitbucketServerProvider) GetRelease(org string, name string, tag string
 	return nil, nil
 }
 
+// AddCollaborator grants the user write permission on the repository
 func (b *BitbucketServerProvider) AddCollaborator(user string, organisation string, repo string) error {
-	log.Logger().Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for bitbucket. Please add user: %v as a collaborator to this project.", user)
+	query := url.Values{}
+	query.Set("name", user)
+	query.Set("permission", "REPO_WRITE")
+	path := fmt.Sprintf("/api/1.0/projects/%s/repos/%s/permissions/users", organisation, repo)
+	_, err := b.restRequest(http.MethodPut, path, query, nil, nil)
+	if err != nil {
+		return errors.Wrapf(err, "granting %s write permission on %s/%s", user, organisation, repo)
+	}
 	return nil
 }
 
@@ -1057,8 +1157,30 @@ func (b *BitbucketServerProvider) AcceptInvitation(ID int64) (*github.Response,
 	return &github.Response{}, nil
 }
 
+// GetContent returns the base64 encoded raw content of a file
 func (b *BitbucketServerProvider) GetContent(org string, name string, path string, ref string) (*GitFileContent, error) {
-	return nil, fmt.Errorf("Getting content not supported on bitbucket")
+	query := url.Values{}
+	if ref != "" {
+		query.Set("at", ref)
+	}
+	data, err := b.restRequest(http.MethodGet, fmt.Sprintf("/api/1.0/projects/%s/repos/%s/raw/%s", org, name, strings.TrimPrefix(path, "/")), query, nil, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting %s at %s from %s/%s", path, ref, org, name)
+	}
+	browseURL := util.UrlJoin(b.Server.URL, "projects", org, "repos", name, "browse", path)
+	if ref != "" {
+		browseURL += "?at=" + url.QueryEscape(ref)
+	}
+	_, fileName := filepath.Split(path)
+	return &GitFileContent{
+		Type:     "file",
+		Name:     fileName,
+		Path:     path,
+		Encoding: "base64",
+		Content:  base64.StdEncoding.EncodeToString(data),
+		Size:     len(data),
+		HtmlUrl:  browseURL,
+	}, nil
 }
 
 // ShouldForkForPullReques treturns true if we should create a personal fork of this repository
@@ -1077,9 +1199,53 @@ func BitBucketServerAccessTokenURL(url string) string {
 	return util.UrlJoin(url, "/plugins/servlet/access-tokens/manage")
 }
 
-// ListCommits lists the commits for the specified repo and owner
+// ListCommits lists the commits for the specified repo and owner, newest first
 func (b *BitbucketServerProvider) ListCommits(owner, repo string, opt *ListCommitsArguments) ([]*GitCommit, error) {
-	return nil, fmt.Errorf("Listing commits not supported on bitbucket")
+	if opt == nil {
+		opt = &ListCommitsArguments{}
+	}
+	query := url.Values{}
+	if opt.SHA != "" {
+		query.Set("until", opt.SHA)
+	}
+	if opt.Path != "" {
+		query.Set("path", opt.Path)
+	}
+	limit := opt.PerPage
+	if limit <= 0 {
+		limit = pageLimit
+	}
+	query.Set("limit", strconv.Itoa(limit))
+	if opt.Page > 1 {
+		query.Set("start", strconv.Itoa((opt.Page-1)*limit))
+	}
+
+	repository := &GitRepository{
+		URL: util.UrlJoin(b.Server.URL, "projects", owner, "repos", repo),
+	}
+	path := fmt.Sprintf("/api/1.0/projects/%s/repos/%s/commits", owner, repo)
+	var commitsPage commitsPage
+	_, err := b.restRequest(http.MethodGet, path, query, nil, &commitsPage)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the commits of %s/%s", owner, repo)
+	}
+	commits := []*GitCommit{}
+	for i := range commitsPage.Values {
+		commit := &commitsPage.Values[i]
+		authored := time.Unix(0, commit.AuthorTimestamp*int64(time.Millisecond))
+		if !opt.Since.IsZero() && authored.Before(opt.Since) {
+			// the commits are ordered newest first so the rest are older too
+			break
+		}
+		if !opt.Until.IsZero() && authored.After(opt.Until) {
+			continue
+		}
+		if opt.Author != "" && commit.Author.Name != opt.Author && commit.Author.Email != opt.Author {
+			continue
+		}
+		commits = append(commits, convertBitBucketCommitToGitCommit(commit, repository))
+	}
+	return commits, nil
 }
 
 // AddLabelsToIssue adds labels to issues or pullrequests
@@ -1096,3 +1262,25 @@ func (b *BitbucketServerProvider) GetLatestRelease(org string, name string) (*Gi
 func (b *BitbucketServerProvider) UploadReleaseAsset(org string, repo string, id int64, name string, asset *os.File) (*GitReleaseAsset, error) {
 	return nil, nil
 }
+
+// restURL returns the base URL of the REST APIs of the server
+func (b *BitbucketServerProvider) restURL() string {
+	if b.RestURL != "" {
+		return strings.TrimSuffix(b.RestURL, "/")
+	}
+	return strings.TrimSuffix(b.Server.URL, "/") + "/rest"
+}
+
+// restRequest invokes a REST API of the server which is not supported by the Client. The request body is encoded as
+// JSON and the response is decoded as JSON into result if it is not nil. The raw response body is returned.
+func (b *BitbucketServerProvider) restRequest(method string, path string, query url.Values, body interface{}, result interface{}) ([]byte, error) {
+	client := &restClient{
+		httpClient: b.HTTPClient,
+		authorize: func(req *http.Request) {
+			if b.User.ApiToken != "" {
+				req.Header.Set("Authorization", "Bearer "+b.User.ApiToken)
+			}
+		},
+	}
+	return client.do(method, b.restURL()+path, query, body, result)
+}
@@ -2,9 +2,11 @@ package gits_test
 
 import (
 	"context"
+	"encoding/base64"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	bitbucket "github.com/gfleury/go-bitbucket-v1"
 	"github.com/jenkins-x/jx/pkg/auth"
@@ -43,6 +45,7 @@ var bitbucketServerRouter = util.Router{
 	},
 	"/rest/api/1.0/projects/TEST-ORG/repos/test-repo/pull-requests": util.MethodMap{
 		"POST": "pr.json",
+		"GET":  "prs.json",
 	},
 	"/rest/api/1.0/projects/TEST-ORG/repos/test-repo/pull-requests/1": util.MethodMap{
 		"GET": "pr.json",
@@ -68,7 +71,17 @@ var bitbucketServerRouter = util.Router{
 		"GET": "user.json",
 	},
 	"/rest/build-status/1.0/commits/d6f24ee03d76a2caf0a4e1975fb43e8f61759b9c": util.MethodMap{
-		"GET": "build-statuses.json",
+		"GET":  "build-statuses.json",
+		"POST": "repos.test-repo.nil.json",
+	},
+	"/rest/api/1.0/projects/TEST-ORG/repos/test-repo/raw/jenkins-x.yml": util.MethodMap{
+		"GET": "jenkins-x.yml",
+	},
+	"/rest/api/1.0/projects/TEST-ORG/repos/test-repo/commits": util.MethodMap{
+		"GET": "commits.json",
+	},
+	"/rest/api/1.0/projects/test-org/repos/repo/permissions/users": util.MethodMap{
+		"PUT": "repos.test-repo.nil.json",
 	},
 }
 
@@ -109,6 +122,7 @@ func (suite *BitbucketServerProviderTestSuite) SetupSuite() {
 
 	apiKeyAuthContext := context.WithValue(ctx, bitbucket.ContextAccessToken, ua.ApiToken)
 	suite.provider.Client = bitbucket.NewAPIClient(apiKeyAuthContext, cfg)
+	suite.provider.RestURL = suite.server.URL + "/rest"
 }
 
 func (suite *BitbucketServerProviderTestSuite) TestGetRepository() {
@@ -272,6 +286,72 @@ func (suite *BitbucketServerProviderTestSuite) TestListCommitStatuses() {
 	}
 }
 
+func (suite *BitbucketServerProviderTestSuite) TestUpdateCommitStatus() {
+	status, err := suite.provider.UpdateCommitStatus("TEST-ORG", "test-repo", "d6f24ee03d76a2caf0a4e1975fb43e8f61759b9c", &gits.GitRepoStatus{
+		Context:     "pr-build",
+		State:       "failure",
+		Description: "Pipeline failed",
+	})
+	suite.Require().Nil(err)
+	suite.Require().Equal("pr-build", status.Context)
+	suite.Require().Equal("failure", status.State)
+	suite.Require().Equal("http://auth.example.com/projects/TEST-ORG/repos/test-repo/commits/d6f24ee03d76a2caf0a4e1975fb43e8f61759b9c", status.TargetURL)
+}
+
+func (suite *BitbucketServerProviderTestSuite) TestListOpenPullRequests() {
+	prs, err := suite.provider.ListOpenPullRequests("TEST-ORG", "test-repo")
+	suite.Require().Nil(err)
+	suite.Require().Len(prs, 1)
+	suite.Require().Equal(1, *prs[0].Number)
+}
+
+func (suite *BitbucketServerProviderTestSuite) TestSearchIssues() {
+	issues, err := suite.provider.SearchIssues("TEST-ORG", "test-repo", "Test")
+	suite.Require().Nil(err)
+	suite.Require().Len(issues, 1)
+	suite.Require().Equal("Test Pull Request", issues[0].Title)
+	suite.Require().True(issues[0].IsPullRequest)
+}
+
+func (suite *BitbucketServerProviderTestSuite) TestGetIssue() {
+	issue, err := suite.provider.GetIssue("TEST-ORG", "test-repo", 1)
+	suite.Require().Nil(err)
+	suite.Require().Equal(1, *issue.Number)
+	suite.Require().Equal("open", *issue.State)
+	suite.Require().Nil(issue.ClosedAt)
+}
+
+func (suite *BitbucketServerProviderTestSuite) TestGetContent() {
+	content, err := suite.provider.GetContent("TEST-ORG", "test-repo", "jenkins-x.yml", "master")
+	suite.Require().Nil(err)
+	suite.Require().Equal("jenkins-x.yml", content.Name)
+	suite.Require().Equal("base64", content.Encoding)
+
+	data, err := base64.StdEncoding.DecodeString(content.Content)
+	suite.Require().Nil(err)
+	suite.Require().Equal("buildPack: maven\n", string(data))
+}
+
+func (suite *BitbucketServerProviderTestSuite) TestListCommits() {
+	commits, err := suite.provider.ListCommits("TEST-ORG", "test-repo", &gits.ListCommitsArguments{
+		SHA: "master",
+	})
+	suite.Require().Nil(err)
+	suite.Require().Len(commits, 2)
+	suite.Require().Equal("d6f24ee03d76a2caf0a4e1975fb43e8f61759b9c", commits[0].SHA)
+	suite.Require().Equal("http://auth.example.com/projects/TEST-ORG/repos/test-repo/commits/d6f24ee03d76a2caf0a4e1975fb43e8f61759b9c", commits[0].URL)
+
+	commits, err = suite.provider.ListCommits("TEST-ORG", "test-repo", &gits.ListCommitsArguments{
+		Since: time.Unix(1528202960, 0),
+	})
+	suite.Require().Nil(err)
+	suite.Require().Len(commits, 1, "older commits are not listed")
+
+	commits, err = suite.provider.ListCommits("TEST-ORG", "test-repo", nil)
+	suite.Require().Nil(err)
+	suite.Require().Len(commits, 2)
+}
+
 func (suite *BitbucketServerProviderTestSuite) TestMergePullRequest() {
 
 	id := 1
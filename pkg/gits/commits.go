@@ -15,7 +15,9 @@ type CommitInfo struct {
 	Kind    string
 	Feature string
 	Message string
-	group   *CommitGroup
+	// Breaking is true if the kind or feature is followed by a ! to mark a breaking change
+	Breaking bool
+	group    *CommitGroup
 }
 
 type CommitGroup struct {
@@ -23,6 +25,13 @@ type CommitGroup struct {
 	Order int
 }
 
+// CommitParser parses the message of a commit for the changelog, returning nil if the commit is left out of it
+type CommitParser func(message string) *CommitInfo
+
+// BreakingChangesGroupKey is the key of the group which breaking changes are listed in if the groups have one,
+// instead of the group of their kind
+const BreakingChangesGroupKey = "!"
+
 var (
 	groupCounter = 0
 
@@ -72,6 +81,10 @@ func ParseCommit(message string) *CommitInfo {
 	idx := strings.Index(message, ":")
 	if idx > 0 {
 		kind := message[0:idx]
+		if strings.HasSuffix(kind, "!") {
+			answer.Breaking = true
+			kind = kind[0 : len(kind)-1]
+		}
 		if strings.HasSuffix(kind, ")") {
 			idx := strings.Index(kind, "(")
 			if idx > 0 {
@@ -88,8 +101,15 @@ func ParseCommit(message string) *CommitInfo {
 }
 
 func (c *CommitInfo) Group() *CommitGroup {
+	return c.groupIn(ConventionalCommitTitles)
+}
+
+func (c *CommitInfo) groupIn(groups map[string]*CommitGroup) *CommitGroup {
+	if c.group == nil && c.Breaking {
+		c.group = groups[BreakingChangesGroupKey]
+	}
 	if c.group == nil {
-		c.group = ConventionalCommitTitles[strings.ToLower(c.Kind)]
+		c.group = groups[strings.ToLower(c.Kind)]
 	}
 	return c.group
 }
@@ -109,6 +129,12 @@ type GroupAndCommitInfos struct {
 
 // GenerateMarkdown generates the markdown document for the commits
 func GenerateMarkdown(releaseSpec *v1.ReleaseSpec, gitInfo *GitRepository) (string, error) {
+	return GenerateMarkdownWithGroups(releaseSpec, gitInfo, ConventionalCommitTitles, ParseCommit)
+}
+
+// GenerateMarkdownWithGroups generates the markdown document for the commits parsed with the given parser, grouping
+// them using the given groups keyed by the commit kind. Commits whose kind has no group are left out.
+func GenerateMarkdownWithGroups(releaseSpec *v1.ReleaseSpec, gitInfo *GitRepository, groups map[string]*CommitGroup, parse CommitParser) (string, error) {
 	commitInfos := []*CommitInfo{}
 
 	groupAndCommits := map[int]*GroupAndCommitInfos{}
@@ -123,10 +149,13 @@ func GenerateMarkdown(releaseSpec *v1.ReleaseSpec, gitInfo *GitRepository) (stri
 	for _, cs := range releaseSpec.Commits {
 		message := cs.Message
 		if message != "" {
-			ci := ParseCommit(message)
+			ci := parse(message)
+			if ci == nil {
+				continue
+			}
 
 			description := "* " + describeCommit(gitInfo, &cs, ci, issueMap) + "\n"
-			group := ci.Group()
+			group := ci.groupIn(groups)
 			if group != nil {
 				gac := groupAndCommits[group.Order]
 				if gac == nil {
@@ -151,8 +180,14 @@ func GenerateMarkdown(releaseSpec *v1.ReleaseSpec, gitInfo *GitRepository) (stri
 
 	buffer.WriteString("## Changes\n")
 
+	orders := []int{}
+	for order := range groupAndCommits {
+		orders = append(orders, order)
+	}
+	sort.Ints(orders)
+
 	hasTitle := false
-	for i := 0; i <= unknownKindOrder; i++ {
+	for _, i := range orders {
 		gac := groupAndCommits[i]
 		if gac != nil && len(gac.commits) > 0 {
 			group := gac.group
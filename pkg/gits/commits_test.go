@@ -21,6 +21,17 @@ func TestParseCommits(t *testing.T) {
 		Feature: "beer",
 		Message: "wine is good too",
 	})
+	assertParseCommit(t, "feat!: no more cheese", &gits.CommitInfo{
+		Kind:     "feat",
+		Message:  "no more cheese",
+		Breaking: true,
+	})
+	assertParseCommit(t, "fix(beer)!: only wine", &gits.CommitInfo{
+		Kind:     "fix",
+		Feature:  "beer",
+		Message:  "only wine",
+		Breaking: true,
+	})
 }
 
 func assertParseCommit(t *testing.T, input string, expected *gits.CommitInfo) {
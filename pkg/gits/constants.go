@@ -1,10 +1,14 @@
 package gits
 
 const (
+	// KindAzureDevOps git kind for Azure DevOps
+	KindAzureDevOps = "azuredevops"
 	// KindBitBucketCloud git kind for BitBucket Cloud
 	KindBitBucketCloud = "bitbucketcloud"
 	// KindBitBucketServer git kind for BitBucket Server
 	KindBitBucketServer = "bitbucketserver"
+	// KindGerrit git kind for Gerrit
+	KindGerrit = "gerrit"
 	// KindGitea git kind for gitea
 	KindGitea = "gitea"
 	// KindGitlab git kind for gitlab
@@ -19,10 +23,13 @@ const (
 	// BitbucketCloudURL the default URL for BitBucket Cloud
 	BitbucketCloudURL = "https://bitbucket.org"
 
+	// AzureDevOpsURL the default URL for Azure DevOps Services
+	AzureDevOpsURL = "https://dev.azure.com"
+
 	// FakeGitURL the default URL for the fake git provider
 	FakeGitURL = "https://fake.git"
 )
 
 var (
-	KindGits = []string{KindBitBucketCloud, KindBitBucketServer, KindGitea, KindGitHub, KindGitlab}
+	KindGits = []string{KindAzureDevOps, KindBitBucketCloud, KindBitBucketServer, KindGerrit, KindGitea, KindGitHub, KindGitlab}
 )
@@ -2,22 +2,148 @@ package gits
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	gerrit "github.com/andygrunwald/go-gerrit"
 	"github.com/google/go-github/github"
 	"github.com/jenkins-x/jx/pkg/auth"
 	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/pkg/errors"
 )
 
+const (
+	// gerritMagicPrefix is prepended by Gerrit to every JSON response to prevent XSSI
+	gerritMagicPrefix = ")]}'"
+
+	// gerritTimestampLayout is the layout of the timestamps returned by the Gerrit REST API
+	gerritTimestampLayout = "2006-01-02 15:04:05.000000000"
+
+	gerritVerifiedLabel   = "Verified"
+	gerritCodeReviewLabel = "Code-Review"
+
+	// gerritReviewTag marks the review messages posted by Jenkins X so that Gerrit can collapse them
+	gerritReviewTag = "autogenerated:jenkins-x"
+)
+
+// gerritChangeOptions are the additional fields requested when querying changes
+var gerritChangeOptions = []string{"CURRENT_REVISION", "CURRENT_COMMIT", "DETAILED_LABELS", "DETAILED_ACCOUNTS"}
+
+// gerritStatusPattern matches the line of the review messages posted by UpdateCommitStatus with the state and context
+// of a commit status
+var gerritStatusPattern = regexp.MustCompile(`(?m)^\[(pending|success|failure|error)\] (.+)$`)
+
+// gerritTimestamp parses the timestamp format used by the Gerrit REST API
+type gerritTimestamp struct {
+	time.Time
+}
+
+// UnmarshalJSON parses a Gerrit timestamp which is always in UTC
+func (t *gerritTimestamp) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), "\"")
+	if text == "" || text == "null" {
+		return nil
+	}
+	parsed, err := time.Parse(gerritTimestampLayout, text)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+type gerritAccount struct {
+	AccountID int    `json:"_account_id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+}
+
+type gerritApproval struct {
+	gerritAccount
+	Value int `json:"value"`
+}
+
+type gerritLabel struct {
+	Approved    *gerritAccount   `json:"approved"`
+	Rejected    *gerritAccount   `json:"rejected"`
+	Recommended *gerritAccount   `json:"recommended"`
+	Disliked    *gerritAccount   `json:"disliked"`
+	All         []gerritApproval `json:"all"`
+}
+
+type gerritGitPerson struct {
+	Name  string          `json:"name"`
+	Email string          `json:"email"`
+	Date  gerritTimestamp `json:"date"`
+}
+
+type gerritCommit struct {
+	Commit    string          `json:"commit"`
+	Subject   string          `json:"subject"`
+	Message   string          `json:"message"`
+	Author    gerritGitPerson `json:"author"`
+	Committer gerritGitPerson `json:"committer"`
+}
+
+type gerritRevision struct {
+	Number int          `json:"_number"`
+	Ref    string       `json:"ref"`
+	Commit gerritCommit `json:"commit"`
+}
+
+type gerritChange struct {
+	ID              string                    `json:"id"`
+	Project         string                    `json:"project"`
+	Branch          string                    `json:"branch"`
+	Topic           string                    `json:"topic"`
+	Hashtags        []string                  `json:"hashtags"`
+	ChangeID        string                    `json:"change_id"`
+	Subject         string                    `json:"subject"`
+	Status          string                    `json:"status"`
+	Created         gerritTimestamp           `json:"created"`
+	Updated         gerritTimestamp           `json:"updated"`
+	Submitted       *gerritTimestamp          `json:"submitted"`
+	Mergeable       *bool                     `json:"mergeable"`
+	Number          int                       `json:"_number"`
+	Owner           gerritAccount             `json:"owner"`
+	Labels          map[string]gerritLabel    `json:"labels"`
+	CurrentRevision string                    `json:"current_revision"`
+	Revisions       map[string]gerritRevision `json:"revisions"`
+	MoreChanges     bool                      `json:"_more_changes"`
+}
+
+type gerritChangeMessage struct {
+	ID             string `json:"id"`
+	Tag            string `json:"tag"`
+	Message        string `json:"message"`
+	RevisionNumber int    `json:"_revision_number"`
+}
+
+type gerritReviewInput struct {
+	Message      string         `json:"message,omitempty"`
+	Tag          string         `json:"tag,omitempty"`
+	Labels       map[string]int `json:"labels,omitempty"`
+	StrictLabels bool           `json:"strict_labels"`
+}
+
 type GerritProvider struct {
 	Client   *gerrit.Client
 	Username string
 	Context  context.Context
+	// HTTPClient is used by the Client and for the REST APIs the Client does not cover
+	HTTPClient *http.Client
 
 	Server auth.AuthServer
 	User   auth.UserAuth
@@ -28,14 +154,15 @@ func NewGerritProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter)
 	ctx := context.Background()
 
 	provider := GerritProvider{
-		Server:   *server,
-		User:     *user,
-		Context:  ctx,
-		Username: user.Username,
-		Git:      git,
+		Server:     *server,
+		User:       *user,
+		Context:    ctx,
+		Username:   user.Username,
+		Git:        git,
+		HTTPClient: util.GetClient(),
 	}
 
-	client, err := gerrit.NewClient(server.URL, nil)
+	client, err := gerrit.NewClient(server.URL, provider.HTTPClient)
 	if err != nil {
 		return nil, err
 	}
@@ -65,6 +192,14 @@ func buildEncodedProjectName(org, name string) string {
 	return fullNamePathEscaped
 }
 
+// gerritProjectName returns the unescaped name of the Gerrit project for the given org and repository name
+func gerritProjectName(org, name string) string {
+	if org != "" {
+		return org + "/" + name
+	}
+	return name
+}
+
 func (p *GerritProvider) projectInfoToGitRepository(project *gerrit.ProjectInfo) *GitRepository {
 	return &GitRepository{
 		Name:     project.Name,
@@ -139,8 +274,53 @@ func (p *GerritProvider) ValidateRepositoryName(org string, name string) error {
 	return nil
 }
 
+// CreatePullRequest pushes the head branch to refs/for/<base> which creates a Gerrit change, using the head branch
+// as the topic of the change and the labels as its hashtags. The commit message of the head commit becomes the subject
+// and description of the change, so the commit must carry a Change-Id footer if the project requires one.
 func (p *GerritProvider) CreatePullRequest(data *GitPullRequestArguments) (*GitPullRequest, error) {
-	return nil, nil
+	project := gerritProjectName(data.GitRepository.Organisation, data.GitRepository.Name)
+
+	dir, err := ioutil.TempDir("", "jx-gerrit-")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating a temporary directory to push the change from")
+	}
+	defer os.RemoveAll(dir)
+
+	err = p.Git.Init(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "initialising git repository in %s", dir)
+	}
+	pushURL, err := p.Git.CreatePushURL(p.restURL(project), &p.User)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating the push URL of %s", project)
+	}
+	err = p.Git.SetRemoteURL(dir, "origin", pushURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "setting the remote URL of %s", dir)
+	}
+	err = p.Git.FetchBranch(dir, "origin", data.Head)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetching branch %s of %s", data.Head, project)
+	}
+	options := []string{"topic=" + data.Head}
+	for _, label := range data.Labels {
+		options = append(options, "hashtag="+label)
+	}
+	refSpec := fmt.Sprintf("refs/for/%s%%%s", data.Base, strings.Join(options, ","))
+	err = p.Git.ForcePushBranch(dir, "FETCH_HEAD", refSpec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "pushing branch %s to refs/for/%s of %s", data.Head, data.Base, project)
+	}
+
+	query := fmt.Sprintf("project:\"%s\" topic:\"%s\" status:open", project, data.Head)
+	changes, err := p.queryChanges(query, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, errors.Errorf("no open change found in %s for topic %s after pushing to refs/for/%s", project, data.Head, data.Base)
+	}
+	return p.toGitPullRequest(changes[0]), nil
 }
 
 // UpdatePullRequest updates pull request with number using data
@@ -148,40 +328,443 @@ func (p *GerritProvider) UpdatePullRequest(data *GitPullRequestArguments, number
 	return nil, errors.Errorf("Not yet implemented for gerrit")
 }
 
+// UpdatePullRequestStatus refreshes the pull request from the Gerrit change with the same number
 func (p *GerritProvider) UpdatePullRequestStatus(pr *GitPullRequest) error {
+	if pr.Number == nil {
+		return errors.Errorf("missing change number for %s", pr.URL)
+	}
+	change, err := p.getChange(*pr.Number)
+	if err != nil {
+		return err
+	}
+	updated := p.toGitPullRequest(change)
+	pr.URL = updated.URL
+	pr.Author = updated.Author
+	pr.Mergeable = updated.Mergeable
+	pr.Merged = updated.Merged
+	pr.HeadRef = updated.HeadRef
+	pr.State = updated.State
+	pr.ClosedAt = updated.ClosedAt
+	pr.MergedAt = updated.MergedAt
+	pr.LastCommitSha = updated.LastCommitSha
+	pr.Title = updated.Title
+	pr.Body = updated.Body
+	pr.Labels = updated.Labels
+	pr.UpdatedAt = updated.UpdatedAt
 	return nil
 }
 
+// GetPullRequest returns the Gerrit change with the given number
 func (p *GerritProvider) GetPullRequest(owner string, repo *GitRepository, number int) (*GitPullRequest, error) {
-	return nil, nil
+	change, err := p.getChange(number)
+	if err != nil {
+		return nil, err
+	}
+	return p.toGitPullRequest(change), nil
 }
 
 // ListOpenPullRequests lists the open pull requests
 func (p *GerritProvider) ListOpenPullRequests(owner string, repo string) ([]*GitPullRequest, error) {
-	return nil, nil
+	query := fmt.Sprintf("project:\"%s\" status:open", gerritProjectName(owner, repo))
+	changes, err := p.queryChanges(query, 0)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*GitPullRequest{}
+	for _, change := range changes {
+		answer = append(answer, p.toGitPullRequest(change))
+	}
+	return answer, nil
 }
 
+// GetPullRequestCommits returns the commit of the current patch set, as a Gerrit change always holds a single commit
 func (p *GerritProvider) GetPullRequestCommits(owner string, repo *GitRepository, number int) ([]*GitCommit, error) {
-	return nil, nil
+	commit := &gerritCommit{}
+	_, err := p.restRequest(http.MethodGet, fmt.Sprintf("changes/%d/revisions/current/commit", number), nil, nil, commit)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting the commit of change %d", number)
+	}
+	project := gerritProjectName(owner, repo.Name)
+	return []*GitCommit{p.toGitCommit(commit, p.changeURL(project, number))}, nil
 }
 
+// PullRequestLastCommitStatus returns the status of the change derived from its Verified and Code-Review votes
 func (p *GerritProvider) PullRequestLastCommitStatus(pr *GitPullRequest) (string, error) {
-	return "", nil
+	if pr.Number == nil {
+		return "", errors.Errorf("missing change number for %s", pr.URL)
+	}
+	change, err := p.getChange(*pr.Number)
+	if err != nil {
+		return "", err
+	}
+	verified, hasVerified := change.Labels[gerritVerifiedLabel]
+	codeReview, hasCodeReview := change.Labels[gerritCodeReviewLabel]
+	if !hasVerified && !hasCodeReview {
+		return "", errors.Errorf("no %s or %s votes found for change %d", gerritVerifiedLabel, gerritCodeReviewLabel, change.Number)
+	}
+	if hasVerified && gerritLabelState(verified) == "failure" {
+		return "failure", nil
+	}
+	if hasCodeReview && gerritLabelState(codeReview) == "failure" {
+		return "failure", nil
+	}
+	if hasVerified {
+		return gerritLabelState(verified), nil
+	}
+	return gerritLabelState(codeReview), nil
 }
 
+// ListCommitStatus returns a status for each label of the change containing the commit
 func (p *GerritProvider) ListCommitStatus(org string, repo string, sha string) ([]*GitRepoStatus, error) {
-	return nil, nil
+	change, err := p.findChangeByCommit(gerritProjectName(org, repo), sha)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*GitRepoStatus{}
+	if change == nil {
+		return answer, nil
+	}
+	names := []string{}
+	for name := range change.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		answer = append(answer, &GitRepoStatus{
+			ID:      name,
+			Context: name,
+			URL:     p.changeURL(change.Project, change.Number),
+			State:   gerritLabelState(change.Labels[name]),
+		})
+	}
+	return answer, nil
 }
 
-// UpdateCommitStatus updates the status of a specified commit in a specified repo.
+// UpdateCommitStatus reviews the patch set of the commit. As Gerrit has no commit statuses the states of all the
+// contexts reported for the patch set are combined into a single vote on the Verified label: -1 once any context
+// fails, +1 once all contexts succeed and no vote while any context is pending. A status with the Code-Review context
+// votes on the Code-Review label instead. The state, description and target URL are posted as a review message each
+// time the state of the context changes. As the states of the other contexts may be reported concurrently the
+// messages are read again after posting and the Verified vote is corrected if they combine into a different one.
 func (p *GerritProvider) UpdateCommitStatus(org, repo, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
-	return nil, nil
+	project := gerritProjectName(org, repo)
+	change, err := p.findChangeByCommit(project, sha)
+	if err != nil {
+		return nil, err
+	}
+	if change == nil {
+		return nil, errors.Errorf("no change found in %s for commit %s", project, sha)
+	}
+	answer := &GitRepoStatus{
+		ID:          gerritVerifiedLabel,
+		Context:     status.Context,
+		URL:         p.changeURL(change.Project, change.Number),
+		State:       status.State,
+		TargetURL:   status.TargetURL,
+		Description: status.Description,
+	}
+
+	states, err := p.commitStatusStates(change, sha)
+	if err != nil {
+		return nil, err
+	}
+	if states[status.Context] == status.State {
+		return answer, nil
+	}
+	states[status.Context] = status.State
+
+	message := fmt.Sprintf("[%s] %s", status.State, status.Context)
+	if status.Description != "" {
+		message = fmt.Sprintf("%s\n\n%s", message, status.Description)
+	}
+	if status.TargetURL != "" {
+		message = fmt.Sprintf("%s\n\n%s", message, status.TargetURL)
+	}
+	review := &gerritReviewInput{
+		Message: message,
+		Tag:     gerritReviewTag,
+		Labels:  map[string]int{},
+	}
+	if strings.EqualFold(status.Context, gerritCodeReviewLabel) {
+		answer.ID = gerritCodeReviewLabel
+		if status.State != "pending" {
+			review.Labels[gerritCodeReviewLabel] = gerritVote(status.State)
+		}
+		err = p.reviewCommit(change, sha, review)
+		if err != nil {
+			return nil, err
+		}
+		return answer, nil
+	}
+	state := gerritVerifiedState(states)
+	if state != "pending" {
+		review.Labels[gerritVerifiedLabel] = gerritVote(state)
+	}
+	err = p.reviewCommit(change, sha, review)
+	if err != nil {
+		return nil, err
+	}
+
+	states, err = p.commitStatusStates(change, sha)
+	if err != nil {
+		return nil, err
+	}
+	latestState := gerritVerifiedState(states)
+	if latestState == "pending" || latestState == state {
+		return answer, nil
+	}
+	review = &gerritReviewInput{
+		Tag:    gerritReviewTag,
+		Labels: map[string]int{gerritVerifiedLabel: gerritVote(latestState)},
+	}
+	err = p.reviewCommit(change, sha, review)
+	if err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// reviewCommit posts the review for the patch set of the commit
+func (p *GerritProvider) reviewCommit(change *gerritChange, sha string, review *gerritReviewInput) error {
+	path := fmt.Sprintf("changes/%d/revisions/%s/review", change.Number, sha)
+	_, err := p.restRequest(http.MethodPost, path, nil, review, nil)
+	if err != nil {
+		return errors.Wrapf(err, "reviewing commit %s of change %d", sha, change.Number)
+	}
+	return nil
+}
+
+// commitStatusStates returns the latest state of each context reported by UpdateCommitStatus for the patch set of the
+// commit, parsed from the review messages tagged by Jenkins X
+func (p *GerritProvider) commitStatusStates(change *gerritChange, sha string) (map[string]string, error) {
+	answer := map[string]string{}
+	revision, ok := change.Revisions[sha]
+	if !ok {
+		return answer, nil
+	}
+	messages := []gerritChangeMessage{}
+	_, err := p.restRequest(http.MethodGet, fmt.Sprintf("changes/%d/messages", change.Number), nil, nil, &messages)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the messages of change %d", change.Number)
+	}
+	for _, message := range messages {
+		if message.Tag != gerritReviewTag || message.RevisionNumber != revision.Number {
+			continue
+		}
+		match := gerritStatusPattern.FindStringSubmatch(message.Message)
+		if match != nil {
+			answer[match[2]] = match[1]
+		}
+	}
+	return answer, nil
+}
+
+// gerritVerifiedState returns the combined state of the contexts voting on the Verified label
+func gerritVerifiedState(states map[string]string) string {
+	verifiedStates := map[string]string{}
+	for context, state := range states {
+		if !strings.EqualFold(context, gerritCodeReviewLabel) {
+			verifiedStates[context] = state
+		}
+	}
+	return gerritCombinedState(verifiedStates)
+}
+
+// gerritCombinedState returns failure if any of the states failed, pending if any is pending and otherwise success
+func gerritCombinedState(states map[string]string) string {
+	answer := "success"
+	for _, state := range states {
+		switch state {
+		case "failure", "error":
+			return "failure"
+		case "success":
+		default:
+			answer = "pending"
+		}
+	}
+	return answer
 }
 
+// MergePullRequest submits the change, adding the message as a review comment first as Gerrit does not take a message
+// on submit.
 func (p *GerritProvider) MergePullRequest(pr *GitPullRequest, message string) error {
+	if pr.Number == nil {
+		return errors.Errorf("missing change number for %s", pr.URL)
+	}
+	if message != "" {
+		err := p.AddPRComment(pr, message)
+		if err != nil {
+			return err
+		}
+	}
+	change := &gerritChange{}
+	_, err := p.restRequest(http.MethodPost, fmt.Sprintf("changes/%d/submit", *pr.Number), nil, map[string]string{}, change)
+	if err != nil {
+		return errors.Wrapf(err, "submitting change %d", *pr.Number)
+	}
+	if change.Status != "MERGED" {
+		return errors.Errorf("change %d has status %s after being submitted", *pr.Number, change.Status)
+	}
 	return nil
 }
 
+// getChange returns the change with the given number including its current revision and labels
+func (p *GerritProvider) getChange(number int) (*gerritChange, error) {
+	change := &gerritChange{}
+	query := url.Values{"o": gerritChangeOptions}
+	_, err := p.restRequest(http.MethodGet, fmt.Sprintf("changes/%d", number), query, nil, change)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting change %d", number)
+	}
+	return change, nil
+}
+
+// queryChanges returns the changes matching the query, returning at most limit changes if limit is positive
+func (p *GerritProvider) queryChanges(q string, limit int) ([]*gerritChange, error) {
+	answer := []*gerritChange{}
+	for {
+		query := url.Values{
+			"q": []string{q},
+			"o": gerritChangeOptions,
+			"n": []string{strconv.Itoa(pageSize)},
+			"S": []string{strconv.Itoa(len(answer))},
+		}
+		if limit > 0 {
+			query.Set("n", strconv.Itoa(limit))
+		}
+		changes := []*gerritChange{}
+		_, err := p.restRequest(http.MethodGet, "changes/", query, nil, &changes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "querying changes with %s", q)
+		}
+		answer = append(answer, changes...)
+		// Gerrit flags the last change of the page if there are more changes to fetch
+		more := len(changes) > 0 && changes[len(changes)-1].MoreChanges
+		if !more || (limit > 0 && len(answer) >= limit) {
+			return answer, nil
+		}
+	}
+}
+
+// findChangeByCommit returns the change in the project containing the commit or nil if there is none
+func (p *GerritProvider) findChangeByCommit(project string, sha string) (*gerritChange, error) {
+	changes, err := p.queryChanges(fmt.Sprintf("project:\"%s\" commit:%s", project, sha), 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return changes[0], nil
+}
+
+func (p *GerritProvider) toGitPullRequest(change *gerritChange) *GitPullRequest {
+	owner := ""
+	repo := change.Project
+	if idx := strings.LastIndex(change.Project, "/"); idx >= 0 {
+		owner = change.Project[:idx]
+		repo = change.Project[idx+1:]
+	}
+	number := change.Number
+	merged := change.Status == "MERGED"
+	state := "open"
+	if change.Status != "NEW" {
+		state = "closed"
+	}
+	// prefer the topic which holds the branch the change was created from, falling back to the patch set ref
+	headRef := change.Topic
+	revision, hasRevision := change.Revisions[change.CurrentRevision]
+	if headRef == "" && hasRevision {
+		headRef = revision.Ref
+	}
+	body := ""
+	if hasRevision {
+		body = revision.Commit.Message
+	}
+	labels := []*Label{}
+	for i := range change.Hashtags {
+		labels = append(labels, &Label{Name: &change.Hashtags[i]})
+	}
+	updatedAt := change.Updated.Time
+
+	pr := &GitPullRequest{
+		URL:           p.changeURL(change.Project, number),
+		Author:        p.toGitUser(&change.Owner),
+		Owner:         owner,
+		Repo:          repo,
+		Number:        &number,
+		Mergeable:     change.Mergeable,
+		Merged:        &merged,
+		HeadRef:       &headRef,
+		State:         &state,
+		LastCommitSha: change.CurrentRevision,
+		Title:         change.Subject,
+		Body:          body,
+		Labels:        labels,
+		UpdatedAt:     &updatedAt,
+	}
+	if state == "closed" {
+		pr.ClosedAt = &updatedAt
+	}
+	if merged && change.Submitted != nil {
+		mergedAt := change.Submitted.Time
+		pr.MergedAt = &mergedAt
+	}
+	return pr
+}
+
+func (p *GerritProvider) toGitUser(account *gerritAccount) *GitUser {
+	return &GitUser{
+		Login: account.Username,
+		Name:  account.Name,
+		Email: account.Email,
+	}
+}
+
+func (p *GerritProvider) toGitCommit(commit *gerritCommit, changeURL string) *GitCommit {
+	return &GitCommit{
+		SHA:     commit.Commit,
+		Message: commit.Message,
+		URL:     changeURL,
+		Author: &GitUser{
+			Name:  commit.Author.Name,
+			Email: commit.Author.Email,
+		},
+		Committer: &GitUser{
+			Name:  commit.Committer.Name,
+			Email: commit.Committer.Email,
+		},
+	}
+}
+
+// changeURL returns the URL of the change in the Gerrit web UI
+func (p *GerritProvider) changeURL(project string, number int) string {
+	return fmt.Sprintf("%s/c/%s/+/%d", strings.TrimSuffix(p.Server.URL, "/"), project, number)
+}
+
+// gerritLabelState maps the votes on a label to a commit status state
+func gerritLabelState(label gerritLabel) string {
+	if label.Rejected != nil {
+		return "failure"
+	}
+	if label.Approved != nil {
+		return "success"
+	}
+	return "pending"
+}
+
+// gerritVote maps a commit status state to a vote on the Verified or Code-Review label
+func gerritVote(state string) int {
+	switch state {
+	case "success":
+		return 1
+	case "failure", "error":
+		return -1
+	default:
+		return 0
+	}
+}
+
 func (p *GerritProvider) CreateWebHook(data *GitWebHookArguments) error {
 	return nil
 }
@@ -222,7 +805,7 @@ func (p *GerritProvider) IsGerrit() bool {
 }
 
 func (p *GerritProvider) Kind() string {
-	return "gerrit"
+	return KindGerrit
 }
 
 func (p *GerritProvider) GetIssue(org string, name string, number int) (*GitIssue, error) {
@@ -231,6 +814,9 @@ func (p *GerritProvider) GetIssue(org string, name string, number int) (*GitIssu
 }
 
 func (p *GerritProvider) IssueURL(org string, name string, number int, isPull bool) string {
+	if isPull {
+		return p.changeURL(gerritProjectName(org, name), number)
+	}
 	log.Logger().Warn("Gerrit does not support issue tracking")
 	return ""
 }
@@ -255,7 +841,18 @@ func (p *GerritProvider) HasIssues() bool {
 	return false
 }
 
+// AddPRComment adds the comment as a review message on the current patch set of the change
 func (p *GerritProvider) AddPRComment(pr *GitPullRequest, comment string) error {
+	if pr.Number == nil {
+		return errors.Errorf("missing change number for %s", pr.URL)
+	}
+	review := &gerritReviewInput{
+		Message: comment,
+	}
+	_, err := p.restRequest(http.MethodPost, fmt.Sprintf("changes/%d/revisions/current/review", *pr.Number), nil, review, nil)
+	if err != nil {
+		return errors.Wrapf(err, "commenting on change %d", *pr.Number)
+	}
 	return nil
 }
 
@@ -282,11 +879,11 @@ func (p *GerritProvider) JenkinsWebHookPath(gitURL string, secret string) string
 }
 
 func (p *GerritProvider) Label() string {
-	return ""
+	return p.Server.Label()
 }
 
 func (p *GerritProvider) ServerURL() string {
-	return ""
+	return p.Server.URL
 }
 
 func (p *GerritProvider) BranchArchiveURL(org string, name string, branch string) string {
@@ -294,11 +891,11 @@ func (p *GerritProvider) BranchArchiveURL(org string, name string, branch string
 }
 
 func (p *GerritProvider) CurrentUsername() string {
-	return ""
+	return p.Username
 }
 
 func (p *GerritProvider) UserAuth() auth.UserAuth {
-	return auth.UserAuth{}
+	return p.User
 }
 
 func (p *GerritProvider) UserInfo(username string) *GitUser {
@@ -320,8 +917,43 @@ func (p *GerritProvider) AcceptInvitation(ID int64) (*github.Response, error) {
 	return &github.Response{}, nil
 }
 
+// GetContent returns the content of the file at the given branch or commit using the Gerrit files API,
+// defaulting to the branch HEAD points to
 func (p *GerritProvider) GetContent(org string, name string, path string, ref string) (*GitFileContent, error) {
-	return nil, fmt.Errorf("Getting content not supported on gerrit")
+	project := gerritProjectName(org, name)
+	projectPath := "projects/" + url.PathEscape(project)
+	if ref == "" {
+		_, err := p.restRequest(http.MethodGet, projectPath+"/HEAD", nil, nil, &ref)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting the HEAD of %s", project)
+		}
+	}
+	var filesPath string
+	if isCommitSHA(ref) {
+		filesPath = fmt.Sprintf("%s/commits/%s/files/%s/content", projectPath, ref, url.PathEscape(path))
+	} else {
+		filesPath = fmt.Sprintf("%s/branches/%s/files/%s/content", projectPath, url.PathEscape(ref), url.PathEscape(path))
+	}
+	// the files API returns the content already base64 encoded rather than as JSON
+	data, err := p.restRequest(http.MethodGet, filesPath, nil, nil, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting %s at %s from %s", path, ref, project)
+	}
+	content := strings.TrimSpace(string(data))
+	decoded, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding %s at %s from %s", path, ref, project)
+	}
+	_, fileName := filepath.Split(path)
+	return &GitFileContent{
+		Type:     "file",
+		Name:     fileName,
+		Path:     path,
+		Encoding: "base64",
+		Content:  content,
+		Size:     len(decoded),
+		Url:      p.restURL(filesPath),
+	}, nil
 }
 
 // ShouldForkForPullReques treturns true if we should create a personal fork of this repository
@@ -348,3 +980,26 @@ func (p *GerritProvider) GetLatestRelease(org string, name string) (*GitRelease,
 func (p *GerritProvider) UploadReleaseAsset(org string, repo string, id int64, name string, asset *os.File) (*GitReleaseAsset, error) {
 	return nil, nil
 }
+
+// GerritAccessTokenURL returns the URL of the page to generate the HTTP password used to access the REST API
+func GerritAccessTokenURL(url string) string {
+	return strings.TrimSuffix(url, "/") + "/#/settings/http-password"
+}
+
+// restURL returns the URL of the authenticated REST API for the given path
+func (p *GerritProvider) restURL(path string) string {
+	return strings.TrimSuffix(p.Server.URL, "/") + "/a/" + path
+}
+
+// restRequest invokes the authenticated Gerrit REST API directly for the operations the go-gerrit client does not cover,
+// stripping the XSSI prefix from the response before decoding it into the result
+func (p *GerritProvider) restRequest(method string, path string, query url.Values, body interface{}, result interface{}) ([]byte, error) {
+	client := &restClient{
+		httpClient: p.HTTPClient,
+		authorize: func(req *http.Request) {
+			req.SetBasicAuth(p.User.Username, p.User.ApiToken)
+		},
+		responsePrefix: gerritMagicPrefix,
+	}
+	return client.do(method, p.restURL(path), query, body, result)
+}
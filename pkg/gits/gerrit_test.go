@@ -1,11 +1,17 @@
 package gits_test
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/jenkins-x/jx/pkg/auth"
 	"github.com/jenkins-x/jx/pkg/gits"
@@ -19,6 +25,13 @@ type GerritProviderTestSuite struct {
 	mux      *http.ServeMux
 	server   *httptest.Server
 	provider *gits.GerritProvider
+
+	reviews []map[string]interface{}
+	// messages are the review messages posted by the tests, which are returned after the ones in messages.json
+	messages []string
+	// concurrentMessage is added to the messages when the next review is posted, as if another context was reported
+	// at the same time
+	concurrentMessage string
 }
 
 var gerritRouter = util.Router{
@@ -28,8 +41,31 @@ var gerritRouter = util.Router{
 	"/a/projects/test-org%2Ftest-user/": util.MethodMap{
 		"PUT": "create-project.json",
 	},
+	"/a/projects/test-repo/HEAD": util.MethodMap{
+		"GET": "head.json",
+	},
+	"/a/projects/test-repo/branches/master/files/jenkins-x.yml/content": util.MethodMap{
+		"GET": "jenkins-x.yml.base64",
+	},
+	"/a/changes/": util.MethodMap{
+		"GET": "changes.json",
+	},
+	"/a/changes/42": util.MethodMap{
+		"GET": "change.json",
+	},
+	"/a/changes/42/submit": util.MethodMap{
+		"POST": "change-merged.json",
+	},
+	"/a/changes/42/revisions/current/commit": util.MethodMap{
+		"GET": "commit.json",
+	},
+	"/a/changes/42/revisions/current/review": util.MethodMap{
+		"POST": "review.json",
+	},
 }
 
+const gerritTestCommitSHA = "3e8c5b3a1d2f4e6a7b8c9d0e1f2a3b4c5d6e7f80"
+
 func (suite *GerritProviderTestSuite) SetupSuite() {
 	suite.mux = http.NewServeMux()
 	suite.server = httptest.NewServer(suite.mux)
@@ -38,6 +74,40 @@ func (suite *GerritProviderTestSuite) SetupSuite() {
 	for path, methodMap := range gerritRouter {
 		suite.mux.HandleFunc(path, util.GetMockAPIResponseFromFile("test_data/gerrit", methodMap))
 	}
+	review := util.GetMockAPIResponseFromFile("test_data/gerrit", util.MethodMap{
+		"POST": "review.json",
+	})
+	suite.mux.HandleFunc("/a/changes/42/revisions/"+gerritTestCommitSHA+"/review", func(w http.ResponseWriter, r *http.Request) {
+		request := map[string]interface{}{}
+		err := json.NewDecoder(r.Body).Decode(&request)
+		suite.Require().Nil(err)
+		suite.reviews = append(suite.reviews, request)
+		if message, ok := request["message"].(string); ok {
+			suite.messages = append(suite.messages, message)
+		}
+		if suite.concurrentMessage != "" {
+			suite.messages = append(suite.messages, suite.concurrentMessage)
+			suite.concurrentMessage = ""
+		}
+		review(w, r)
+	})
+	suite.mux.HandleFunc("/a/changes/42/messages", func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadFile(filepath.Join("test_data", "gerrit", "messages.json"))
+		suite.Require().Nil(err)
+		messages := []map[string]interface{}{}
+		err = json.Unmarshal(bytes.TrimPrefix(data, []byte(")]}'")), &messages)
+		suite.Require().Nil(err)
+		for _, message := range suite.messages {
+			messages = append(messages, map[string]interface{}{
+				"message":          message,
+				"tag":              "autogenerated:jenkins-x",
+				"_revision_number": 2,
+			})
+		}
+		data, err = json.Marshal(messages)
+		suite.Require().Nil(err)
+		w.Write(append([]byte(")]}'\n"), data...))
+	})
 
 	as := auth.AuthServer{
 		URL:         suite.server.URL,
@@ -63,6 +133,12 @@ func (suite *GerritProviderTestSuite) SetupSuite() {
 	suite.Require().NotNil(suite.provider.Client)
 }
 
+func (suite *GerritProviderTestSuite) SetupTest() {
+	suite.reviews = nil
+	suite.messages = nil
+	suite.concurrentMessage = ""
+}
+
 func (suite *GerritProviderTestSuite) TestListRepositories() {
 	repos, err := suite.provider.ListRepositories("")
 
@@ -93,6 +169,177 @@ func (suite *GerritProviderTestSuite) TestCreateRepository() {
 	suite.Require().Equal(fmt.Sprintf("%s:test-org/test-repo", suite.server.URL), repo.SSHURL)
 }
 
+func (suite *GerritProviderTestSuite) TestCreatePullRequest() {
+	// the fake gitter skips the push to refs/for so the change is looked up straight away
+	provider, err := gits.NewGerritProvider(&auth.AuthServer{URL: suite.server.URL}, &auth.UserAuth{
+		Username: "test-user",
+		ApiToken: "0123456789abdef",
+	}, &gits.GitFake{})
+	suite.Require().Nil(err)
+
+	pr, err := provider.CreatePullRequest(&gits.GitPullRequestArguments{
+		Title: "Update the chart version",
+		Head:  "feature",
+		Base:  "master",
+		GitRepository: &gits.GitRepository{
+			Organisation: "test-org",
+			Name:         "test-repo",
+		},
+		Labels: []string{"updatebot"},
+	})
+	suite.Require().Nil(err)
+	suite.Require().NotNil(pr)
+	suite.Require().Equal(42, *pr.Number)
+	suite.Require().Equal("feature", *pr.HeadRef)
+}
+
+func (suite *GerritProviderTestSuite) TestGetPullRequest() {
+	pr, err := suite.provider.GetPullRequest("test-org", &gits.GitRepository{Name: "test-repo"}, 42)
+	suite.Require().Nil(err)
+	suite.Require().NotNil(pr)
+	suite.Require().Equal(42, *pr.Number)
+	suite.Require().Equal("test-org", pr.Owner)
+	suite.Require().Equal("test-repo", pr.Repo)
+	suite.Require().Equal("Update the chart version", pr.Title)
+	suite.Require().Equal("open", *pr.State)
+	suite.Require().False(*pr.Merged)
+	suite.Require().True(*pr.Mergeable)
+	suite.Require().Nil(pr.ClosedAt)
+	suite.Require().Equal("feature", *pr.HeadRef)
+	suite.Require().Equal(gerritTestCommitSHA, pr.LastCommitSha)
+	suite.Require().Equal("test-user", pr.Author.Login)
+	suite.Require().Equal(fmt.Sprintf("%s/c/test-org/test-repo/+/42", suite.server.URL), pr.URL)
+	suite.Require().Equal(time.Date(2019, 6, 12, 14, 31, 45, 123000000, time.UTC), *pr.UpdatedAt)
+	suite.Require().Len(pr.Labels, 1)
+	suite.Require().Equal("updatebot", *pr.Labels[0].Name)
+}
+
+func (suite *GerritProviderTestSuite) TestListOpenPullRequests() {
+	prs, err := suite.provider.ListOpenPullRequests("test-org", "test-repo")
+	suite.Require().Nil(err)
+	suite.Require().Len(prs, 1)
+	suite.Require().Equal(42, *prs[0].Number)
+}
+
+func (suite *GerritProviderTestSuite) TestUpdatePullRequestStatus() {
+	number := 42
+	pr := &gits.GitPullRequest{
+		Owner:  "test-org",
+		Repo:   "test-repo",
+		Number: &number,
+	}
+	err := suite.provider.UpdatePullRequestStatus(pr)
+	suite.Require().Nil(err)
+	suite.Require().Equal("open", *pr.State)
+	suite.Require().Equal(gerritTestCommitSHA, pr.LastCommitSha)
+}
+
+func (suite *GerritProviderTestSuite) TestGetPullRequestCommits() {
+	commits, err := suite.provider.GetPullRequestCommits("test-org", &gits.GitRepository{Name: "test-repo"}, 42)
+	suite.Require().Nil(err)
+	suite.Require().Len(commits, 1)
+	suite.Require().Equal(gerritTestCommitSHA, commits[0].SHA)
+	suite.Require().Equal("test-user@example.com", commits[0].Author.Email)
+}
+
+func (suite *GerritProviderTestSuite) TestPullRequestLastCommitStatus() {
+	number := 42
+	status, err := suite.provider.PullRequestLastCommitStatus(&gits.GitPullRequest{Number: &number})
+	suite.Require().Nil(err)
+	suite.Require().Equal("success", status)
+}
+
+func (suite *GerritProviderTestSuite) TestListCommitStatus() {
+	statuses, err := suite.provider.ListCommitStatus("test-org", "test-repo", gerritTestCommitSHA)
+	suite.Require().Nil(err)
+	suite.Require().Len(statuses, 2)
+	suite.Require().Equal("Code-Review", statuses[0].Context)
+	suite.Require().Equal("pending", statuses[0].State)
+	suite.Require().Equal("Verified", statuses[1].Context)
+	suite.Require().Equal("success", statuses[1].State)
+}
+
+func (suite *GerritProviderTestSuite) TestUpdateCommitStatus() {
+	status, err := suite.provider.UpdateCommitStatus("test-org", "test-repo", gerritTestCommitSHA, &gits.GitRepoStatus{
+		State:       "success",
+		Context:     "pr-build",
+		Description: "Succeeded",
+		TargetURL:   "http://jx.example.com/teams/jx/projects/test-org/test-repo/PR-42/1",
+	})
+	suite.Require().Nil(err)
+	suite.Require().NotNil(status)
+	suite.Require().Equal("success", status.State)
+	suite.Require().Equal("pr-build", status.Context)
+	suite.Require().Equal(fmt.Sprintf("%s/c/test-org/test-repo/+/42", suite.server.URL), status.URL)
+	suite.Require().Len(suite.reviews, 1)
+	suite.Require().Equal(map[string]interface{}{
+		"message":       "[success] pr-build\n\nSucceeded\n\nhttp://jx.example.com/teams/jx/projects/test-org/test-repo/PR-42/1",
+		"tag":           "autogenerated:jenkins-x",
+		"labels":        map[string]interface{}{"Verified": float64(1)},
+		"strict_labels": false,
+	}, suite.reviews[0], "the patch set is verified as all contexts have succeeded")
+
+	suite.reviews = nil
+	_, err = suite.provider.UpdateCommitStatus("test-org", "test-repo", gerritTestCommitSHA, &gits.GitRepoStatus{
+		State:   "success",
+		Context: "lint",
+	})
+	suite.Require().Nil(err)
+	suite.Require().Len(suite.reviews, 0, "nothing is posted when the state of the context has not changed")
+
+	_, err = suite.provider.UpdateCommitStatus("test-org", "test-repo", gerritTestCommitSHA, &gits.GitRepoStatus{
+		State:   "pending",
+		Context: "integration",
+	})
+	suite.Require().Nil(err)
+	suite.Require().Len(suite.reviews, 1)
+	suite.Require().Nil(suite.reviews[0]["labels"], "there is no vote while a context is pending")
+
+	suite.reviews = nil
+	_, err = suite.provider.UpdateCommitStatus("test-org", "test-repo", gerritTestCommitSHA, &gits.GitRepoStatus{
+		State:   "failure",
+		Context: "Code-Review",
+	})
+	suite.Require().Nil(err)
+	suite.Require().Len(suite.reviews, 1)
+	suite.Require().Equal(map[string]interface{}{"Code-Review": float64(-1)}, suite.reviews[0]["labels"])
+}
+
+func (suite *GerritProviderTestSuite) TestUpdateCommitStatusConcurrently() {
+	suite.messages = []string{"[success] pr-build"}
+	suite.concurrentMessage = "[failure] integration"
+	_, err := suite.provider.UpdateCommitStatus("test-org", "test-repo", gerritTestCommitSHA, &gits.GitRepoStatus{
+		State:   "success",
+		Context: "e2e",
+	})
+	suite.Require().Nil(err)
+	suite.Require().Len(suite.reviews, 2)
+	suite.Require().Equal(map[string]interface{}{"Verified": float64(1)}, suite.reviews[0]["labels"])
+	suite.Require().Equal(map[string]interface{}{
+		"tag":           "autogenerated:jenkins-x",
+		"labels":        map[string]interface{}{"Verified": float64(-1)},
+		"strict_labels": false,
+	}, suite.reviews[1], "the vote is corrected for the context reported while the review was posted")
+}
+
+func (suite *GerritProviderTestSuite) TestMergePullRequest() {
+	number := 42
+	err := suite.provider.MergePullRequest(&gits.GitPullRequest{Number: &number}, "Promoted by Jenkins X")
+	suite.Require().Nil(err)
+}
+
+func (suite *GerritProviderTestSuite) TestGetContent() {
+	content, err := suite.provider.GetContent("", "test-repo", "jenkins-x.yml", "")
+	suite.Require().Nil(err)
+	suite.Require().NotNil(content)
+	suite.Require().Equal("jenkins-x.yml", content.Name)
+	suite.Require().Equal("base64", content.Encoding)
+
+	data, err := base64.StdEncoding.DecodeString(content.Content)
+	suite.Require().Nil(err)
+	suite.Require().Equal("buildPack: go\n", string(data))
+}
+
 func TestGerritProviderTestSuite(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping GerritProviderTestSuite in short mode")
@@ -13,19 +13,37 @@ const (
 	GitHubURL  = "https://github.com"
 
 	gitPrefix = "git@"
+
+	// AzureDevOpsHost the host of Azure DevOps Services
+	AzureDevOpsHost = "dev.azure.com"
+
+	azureDevOpsSSHHost          = "ssh.dev.azure.com"
+	azureDevOpsLegacyHostSuffix = ".visualstudio.com"
 )
 
 func (i *GitRepository) IsGitHub() bool {
 	return GitHubHost == i.Host || strings.HasSuffix(i.URL, "https://github.com")
 }
 
+// IsAzureDevOps returns true if the repository is hosted on Azure DevOps
+func (i *GitRepository) IsAzureDevOps() bool {
+	return isAzureDevOpsHost(i.Host)
+}
+
 // PullRequestURL returns the URL of a pull request of the given name/number
 func (i *GitRepository) PullRequestURL(prName string) string {
+	if i.IsAzureDevOps() {
+		return i.azureDevOpsURL("https://"+i.Host, "pullrequest", prName)
+	}
 	return util.UrlJoin("https://"+i.Host, i.Organisation, i.Name, "pull", prName)
 }
 
 // HttpCloneURL returns the HTTPS git URL this repository
 func (i *GitRepository) HttpCloneURL() string {
+	if i.IsAzureDevOps() {
+		// Azure DevOps does not accept a .git suffix on clone URLs
+		return i.HttpsURL()
+	}
 	return i.HttpsURL() + ".git"
 }
 
@@ -35,6 +53,9 @@ func (i *GitRepository) HttpURL() string {
 	if !strings.Contains(host, ":/") {
 		host = "http://" + host
 	}
+	if i.IsAzureDevOps() {
+		return i.azureDevOpsURL(host)
+	}
 	return util.UrlJoin(host, i.Organisation, i.Name)
 }
 
@@ -44,9 +65,24 @@ func (i *GitRepository) HttpsURL() string {
 	if !strings.Contains(host, ":/") {
 		host = "https://" + host
 	}
+	if i.IsAzureDevOps() {
+		return i.azureDevOpsURL(host)
+	}
 	return util.UrlJoin(host, i.Organisation, i.Name)
 }
 
+// azureDevOpsURL returns the URL of an Azure DevOps repository which is of the form
+// https://dev.azure.com/{organisation}/{project}/_git/{name}
+func (i *GitRepository) azureDevOpsURL(host string, paths ...string) string {
+	parts := []string{host}
+	if !strings.HasSuffix(i.Host, azureDevOpsLegacyHostSuffix) {
+		// the organisation is the sub domain of legacy visualstudio.com URLs
+		parts = append(parts, i.Organisation)
+	}
+	parts = append(parts, i.Project, "_git", i.Name)
+	return util.UrlJoin(append(parts, paths...)...)
+}
+
 // HostURL returns the URL to the host
 func (i *GitRepository) HostURL() string {
 	answer := i.Host
@@ -114,6 +150,9 @@ func ParseGitURL(text string) (*GitRepository, error) {
 			answer.Scheme = "https"
 		}
 		answer.Scheme = u.Scheme
+		if isAzureDevOpsHost(answer.Host) {
+			return parseAzureDevOpsPath(u.Path, &answer)
+		}
 		return parsePath(u.Path, &answer)
 	}
 
@@ -126,6 +165,15 @@ func ParseGitURL(text string) (*GitRepository, error) {
 		t = strings.TrimSuffix(t, ".git")
 
 		arr := util.RegexpSplit(t, ":|/")
+		if len(arr) >= 5 && arr[0] == azureDevOpsSSHHost && arr[1] == "v3" {
+			// Azure DevOps SSH URLs are of the form git@ssh.dev.azure.com:v3/{organisation}/{project}/{name}
+			answer.Scheme = "git"
+			answer.Host = AzureDevOpsHost
+			answer.Organisation = arr[2]
+			answer.Project = arr[3]
+			answer.Name = arr[4]
+			return &answer, nil
+		}
 		if len(arr) >= 3 {
 			answer.Scheme = "git"
 			answer.Host = arr[0]
@@ -166,6 +214,44 @@ func parsePath(path string, info *GitRepository) (*GitRepository, error) {
 	return info, fmt.Errorf("Invalid path %s could not determine organisation and repository name", path)
 }
 
+// parseAzureDevOpsPath parses the path of an Azure DevOps URL which is of the form /{organisation}/{project}/_git/{name}
+// or /{project}/_git/{name} on the legacy {organisation}.visualstudio.com hosts. The project is omitted if it has the
+// same name as the repository.
+func parseAzureDevOpsPath(path string, info *GitRepository) (*GitRepository, error) {
+	trimPath := strings.TrimPrefix(path, "/")
+	trimPath = strings.TrimSuffix(trimPath, "/")
+	trimPath = strings.TrimSuffix(trimPath, ".git")
+	arr := strings.Split(trimPath, "/")
+
+	idx := util.StringArrayIndex(arr, "_git")
+	if idx < 0 || idx+1 >= len(arr) {
+		return info, fmt.Errorf("Invalid path %s could not determine the Azure DevOps project and repository name", path)
+	}
+	info.Name = arr[idx+1]
+	prefix := arr[:idx]
+	if strings.HasSuffix(info.Host, azureDevOpsLegacyHostSuffix) {
+		info.Organisation = strings.TrimSuffix(info.Host, azureDevOpsLegacyHostSuffix)
+		if len(prefix) > 0 && prefix[0] == "DefaultCollection" {
+			prefix = prefix[1:]
+		}
+	} else {
+		if len(prefix) == 0 {
+			return info, fmt.Errorf("Invalid path %s could not determine the Azure DevOps organisation", path)
+		}
+		info.Organisation = prefix[0]
+		prefix = prefix[1:]
+	}
+	info.Project = info.Name
+	if len(prefix) > 0 {
+		info.Project = prefix[0]
+	}
+	return info, nil
+}
+
+func isAzureDevOpsHost(host string) bool {
+	return host == AzureDevOpsHost || strings.HasSuffix(host, azureDevOpsLegacyHostSuffix)
+}
+
 // SaasGitKind returns the kind for SaaS Git providers or "" if the URL could not be deduced
 func SaasGitKind(gitServiceUrl string) string {
 	gitServiceUrl = strings.TrimSuffix(gitServiceUrl, "/")
@@ -182,10 +268,15 @@ func SaasGitKind(gitServiceUrl string) string {
 		return KindBitBucketCloud
 	case "http://fake.git", FakeGitURL:
 		return KindGitFake
+	case AzureDevOpsURL:
+		return KindAzureDevOps
 	default:
 		if strings.HasPrefix(gitServiceUrl, "https://github") {
 			return KindGitHub
 		}
+		if strings.HasPrefix(gitServiceUrl, AzureDevOpsURL+"/") || strings.HasSuffix(gitServiceUrl, azureDevOpsLegacyHostSuffix) {
+			return KindAzureDevOps
+		}
 		return ""
 	}
 }
@@ -54,6 +54,18 @@ func TestParseGitURL(t *testing.T) {
 		{
 			"https://bitbucketserver.com/projects/myproject/repos/foo/pull-requests/1", "bitbucketserver.com", "myproject", "foo",
 		},
+		{
+			"https://dev.azure.com/myorg/myproject/_git/foo", "dev.azure.com", "myorg", "foo",
+		},
+		{
+			"https://myorg@dev.azure.com/myorg/myproject/_git/foo", "dev.azure.com", "myorg", "foo",
+		},
+		{
+			"git@ssh.dev.azure.com:v3/myorg/myproject/foo", "dev.azure.com", "myorg", "foo",
+		},
+		{
+			"https://myorg.visualstudio.com/DefaultCollection/myproject/_git/foo", "myorg.visualstudio.com", "myorg", "foo",
+		},
 	}
 	for _, data := range testCases {
 		info, err := gits.ParseGitURL(data.url)
@@ -79,6 +91,14 @@ func TestSaasKind(t *testing.T) {
 			gitURL: "https://github.test.com",
 			kind:   gits.KindGitHub,
 		},
+		"Azure DevOps": {
+			gitURL: "https://dev.azure.com",
+			kind:   gits.KindAzureDevOps,
+		},
+		"Azure DevOps organisation": {
+			gitURL: "https://dev.azure.com/myorg",
+			kind:   gits.KindAzureDevOps,
+		},
 	}
 
 	for name, tc := range tests {
@@ -96,3 +116,28 @@ func TestGitInfoProviderURL(t *testing.T) {
 		assert.Equal(t, "https://github.com", info.ProviderURL(), "ProviderURL() for %s", u)
 	}
 }
+
+func TestParseAzureDevOpsGitURL(t *testing.T) {
+	t.Parallel()
+	for _, u := range []string{
+		"https://dev.azure.com/myorg/myproject/_git/foo",
+		"https://myorg@dev.azure.com/myorg/myproject/_git/foo/pullrequest/1",
+		"git@ssh.dev.azure.com:v3/myorg/myproject/foo",
+	} {
+		info, err := gits.ParseGitURL(u)
+		require.NoError(t, err, "for URL %s", u)
+		assert.True(t, info.IsAzureDevOps(), "IsAzureDevOps() for %s", u)
+		assert.Equal(t, "myproject", info.Project, "Project for %s", u)
+		assert.Equal(t, "https://dev.azure.com", info.HostURL(), "HostURL() for %s", u)
+		assert.Equal(t, "https://dev.azure.com/myorg/myproject/_git/foo", info.HttpsURL(), "HttpsURL() for %s", u)
+		assert.Equal(t, "https://dev.azure.com/myorg/myproject/_git/foo", info.HttpCloneURL(), "HttpCloneURL() for %s", u)
+		assert.Equal(t, "https://dev.azure.com/myorg/myproject/_git/foo/pullrequest/1", info.PullRequestURL("1"), "PullRequestURL() for %s", u)
+	}
+
+	info, err := gits.ParseGitURL("https://dev.azure.com/myorg/_git/foo")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", info.Project, "the project defaults to the repository name")
+
+	_, err = gits.ParseGitURL("https://dev.azure.com/myorg/myproject")
+	assert.Error(t, err)
+}
@@ -1,9 +1,12 @@
 package gits
 
 import (
-	"errors"
+	"encoding/base64"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +23,8 @@ import (
 type GiteaProvider struct {
 	Username string
 	Client   *gitea.Client
+	// HTTPClient is used by the Client and for the APIs the Client does not support
+	HTTPClient *http.Client
 
 	Server auth.AuthServer
 	User   auth.UserAuth
@@ -27,14 +32,17 @@ type GiteaProvider struct {
 }
 
 func NewGiteaProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter) (GitProvider, error) {
+	httpClient := util.GetClient()
 	client := gitea.NewClient(server.URL, user.ApiToken)
+	client.SetHTTPClient(httpClient)
 
 	provider := GiteaProvider{
-		Client:   client,
-		Server:   *server,
-		User:     *user,
-		Username: user.Username,
-		Git:      git,
+		Client:     client,
+		HTTPClient: httpClient,
+		Server:     *server,
+		User:       *user,
+		Username:   user.Username,
+		Git:        git,
 	}
 
 	return &provider, nil
@@ -246,7 +254,7 @@ func (p *GiteaProvider) CreateWebHook(data *GitWebHookArguments) error {
 	hook := gitea.CreateHookOption{
 		Type:   "gitea",
 		Config: config,
-		Events: []string{"create", "push", "pull_request"},
+		Events: giteaWebHookEvents,
 		Active: true,
 	}
 	log.Logger().Infof("Creating Gitea webhook for %s/%s for url %s", util.ColorInfo(owner), util.ColorInfo(repo), util.ColorInfo(webhookUrl))
@@ -257,13 +265,85 @@ func (p *GiteaProvider) CreateWebHook(data *GitWebHookArguments) error {
 	return err
 }
 
+// giteaWebHookEvents are the events the webhooks created by jx are sent for
+var giteaWebHookEvents = []string{"create", "push", "pull_request"}
+
+// ListWebHooks lists the webhooks of a repository
 func (p *GiteaProvider) ListWebHooks(owner string, repo string) ([]*GitWebHookArguments, error) {
 	webHooks := []*GitWebHookArguments{}
-	return webHooks, fmt.Errorf("not implemented!")
+	if owner == "" {
+		owner = p.Username
+	}
+	if repo == "" {
+		return webHooks, fmt.Errorf("Missing property Repo")
+	}
+	hooks, err := p.Client.ListRepoHooks(owner, repo)
+	if err != nil {
+		return webHooks, errors2.Wrapf(err, "listing the webhooks of %s/%s", owner, repo)
+	}
+	for _, hook := range hooks {
+		webHooks = append(webHooks, &GitWebHookArguments{
+			ID:     hook.ID,
+			Owner:  owner,
+			URL:    hook.Config["url"],
+			Secret: hook.Config["secret"],
+		})
+	}
+	return webHooks, nil
 }
 
+// UpdateWebHook updates the webhook with the ID, or the webhook with the existing URL if there is no ID
 func (p *GiteaProvider) UpdateWebHook(data *GitWebHookArguments) error {
-	return fmt.Errorf("not implemented!")
+	owner := data.Owner
+	if owner == "" {
+		owner = p.Username
+	}
+	if data.Repo == nil || data.Repo.Name == "" {
+		return fmt.Errorf("Missing property Repo")
+	}
+	repo := data.Repo.Name
+	webhookUrl := data.URL
+	if webhookUrl == "" {
+		return fmt.Errorf("Missing property URL")
+	}
+
+	id := data.ID
+	if id == 0 {
+		hooks, err := p.Client.ListRepoHooks(owner, repo)
+		if err != nil {
+			return errors2.Wrapf(err, "listing the webhooks of %s/%s", owner, repo)
+		}
+		for _, hook := range hooks {
+			if hook.Config["url"] == data.ExistingURL {
+				log.Logger().Warnf("Found existing webhook for url %s", data.ExistingURL)
+				id = hook.ID
+			}
+		}
+	}
+	if id == 0 {
+		log.Logger().Warn("No webhooks found to update")
+		return nil
+	}
+
+	config := map[string]string{
+		"url":          webhookUrl,
+		"content_type": "json",
+	}
+	if data.Secret != "" {
+		config["secret"] = data.Secret
+	}
+	active := true
+	hook := gitea.EditHookOption{
+		Config: config,
+		Events: giteaWebHookEvents,
+		Active: &active,
+	}
+	log.Logger().Infof("Updating Gitea webhook for %s/%s for url %s", util.ColorInfo(owner), util.ColorInfo(repo), util.ColorInfo(webhookUrl))
+	err := p.Client.EditRepoHook(owner, repo, id, hook)
+	if err != nil {
+		return errors2.Wrapf(err, "updating webhook %d of %s/%s", id, owner, repo)
+	}
+	return nil
 }
 
 func (p *GiteaProvider) CreatePullRequest(data *GitPullRequestArguments) (*GitPullRequest, error) {
@@ -576,25 +656,51 @@ func (p *GiteaProvider) ListCommitStatus(org string, repo string, sha string) ([
 		return answer, fmt.Errorf("Could not find a status for repository %s/%s with ref %s", org, repo, sha)
 	}
 	for _, result := range results {
-		status := &GitRepoStatus{
-			ID:          string(result.ID),
-			Context:     result.Context,
-			URL:         result.URL,
-			TargetURL:   result.TargetURL,
-			State:       string(result.State),
-			Description: result.Description,
-		}
-		answer = append(answer, status)
+		answer = append(answer, fromGiteaStatus(result))
 	}
 	return answer, nil
 }
 
-func (b *GiteaProvider) UpdateCommitStatus(org string, repo string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
-	return &GitRepoStatus{}, errors.New("TODO")
+// UpdateCommitStatus creates a status for the commit
+func (p *GiteaProvider) UpdateCommitStatus(org string, repo string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
+	options := gitea.CreateStatusOption{
+		State:       gitea.StatusState(status.State),
+		TargetURL:   status.TargetURL,
+		Description: status.Description,
+		Context:     status.Context,
+	}
+	result, err := p.Client.CreateStatus(org, repo, sha, options)
+	if err != nil {
+		return &GitRepoStatus{}, errors2.Wrapf(err, "setting the status %s of commit %s in %s/%s", status.Context, sha, org, repo)
+	}
+	return fromGiteaStatus(result), nil
 }
 
+func fromGiteaStatus(status *gitea.Status) *GitRepoStatus {
+	return &GitRepoStatus{
+		ID:          strconv.FormatInt(status.ID, 10),
+		Context:     status.Context,
+		URL:         status.URL,
+		TargetURL:   status.TargetURL,
+		State:       string(status.State),
+		Description: status.Description,
+	}
+}
+
+// RenameRepository renames a repository, which the SDK does not support yet so the API is invoked directly
 func (p *GiteaProvider) RenameRepository(org string, name string, newName string) (*GitRepository, error) {
-	return nil, fmt.Errorf("Rename of repositories is not supported for Gitea")
+	if org == "" {
+		org = p.Username
+	}
+	options := map[string]string{
+		"name": newName,
+	}
+	repo := &gitea.Repository{}
+	err := p.apiRequest(http.MethodPatch, fmt.Sprintf("/repos/%s/%s", org, name), nil, options, repo)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "renaming repository %s/%s to %s", org, name, newName)
+	}
+	return toGiteaRepo(newName, repo), nil
 }
 
 func (p *GiteaProvider) ValidateRepositoryName(org string, name string) error {
@@ -733,23 +839,60 @@ func (p *GiteaProvider) UserInfo(username string) *GitUser {
 	}
 }
 
+// AddCollaborator adds the user as a collaborator with write permission on the repository
 func (p *GiteaProvider) AddCollaborator(user string, organisation string, repo string) error {
-	log.Logger().Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for Gitea. Please add user: %v as a collaborator to this project.", user)
+	if organisation == "" {
+		organisation = p.Username
+	}
+	permission := "write"
+	err := p.Client.AddCollaborator(organisation, repo, user, gitea.AddCollaboratorOption{
+		Permission: &permission,
+	})
+	if err != nil {
+		return errors2.Wrapf(err, "adding %s as a collaborator to %s/%s", user, organisation, repo)
+	}
 	return nil
 }
 
+// IsCollaborator returns true if the user is a collaborator of the repository
+func (p *GiteaProvider) IsCollaborator(owner string, repo string, user string) (bool, error) {
+	return p.Client.IsCollaborator(owner, repo, user)
+}
+
+// ListInvitations returns no invitations as Gitea adds collaborators without inviting them
 func (p *GiteaProvider) ListInvitations() ([]*github.RepositoryInvitation, *github.Response, error) {
-	log.Logger().Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for Gitea.")
 	return []*github.RepositoryInvitation{}, &github.Response{}, nil
 }
 
+// AcceptInvitation does nothing as Gitea adds collaborators without inviting them
 func (p *GiteaProvider) AcceptInvitation(ID int64) (*github.Response, error) {
-	log.Logger().Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for Gitea.")
 	return &github.Response{}, nil
 }
 
+// GetContent returns the base64 encoded content of a file
 func (p *GiteaProvider) GetContent(org string, name string, path string, ref string) (*GitFileContent, error) {
-	return nil, fmt.Errorf("Getting content not supported on gitea")
+	if ref == "" {
+		repo, err := p.Client.GetRepo(org, name)
+		if err != nil {
+			return nil, errors2.Wrapf(err, "getting the default branch of %s/%s", org, name)
+		}
+		ref = repo.DefaultBranch
+	}
+	data, err := p.Client.GetFile(org, name, ref, path)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "getting %s at %s from %s/%s", path, ref, org, name)
+	}
+	_, fileName := filepath.Split(path)
+	return &GitFileContent{
+		Type:        "file",
+		Name:        fileName,
+		Path:        path,
+		Encoding:    "base64",
+		Content:     base64.StdEncoding.EncodeToString(data),
+		Size:        len(data),
+		HtmlUrl:     util.UrlJoin(p.Server.URL, org, name, "src", ref, path),
+		DownloadUrl: util.UrlJoin(p.Server.URL, org, name, "raw", ref, path),
+	}, nil
 }
 
 // ShouldForkForPullReques treturns true if we should create a personal fork of this repository
@@ -758,8 +901,87 @@ func (p *GiteaProvider) ShouldForkForPullRequest(originalOwner string, repoName
 	return originalOwner != username
 }
 
+// giteaCommit is a commit returned by the commits API which the SDK does not support yet
+type giteaCommit struct {
+	SHA     string `json:"sha"`
+	HTMLURL string `json:"html_url"`
+	Commit  struct {
+		Message   string          `json:"message"`
+		Author    giteaCommitUser `json:"author"`
+		Committer giteaCommitUser `json:"committer"`
+	} `json:"commit"`
+	Author    *gitea.User `json:"author"`
+	Committer *gitea.User `json:"committer"`
+}
+
+type giteaCommitUser struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+// ListCommits lists the commits for the specified repo and owner, newest first
 func (p *GiteaProvider) ListCommits(owner, repo string, opt *ListCommitsArguments) ([]*GitCommit, error) {
-	return nil, fmt.Errorf("Listing commits not supported on gitea")
+	if opt == nil {
+		opt = &ListCommitsArguments{}
+	}
+	query := url.Values{}
+	if opt.SHA != "" {
+		query.Set("sha", opt.SHA)
+	}
+	if opt.Path != "" {
+		query.Set("path", opt.Path)
+	}
+	limit := opt.PerPage
+	if limit <= 0 {
+		limit = pageSize
+	}
+	query.Set("limit", strconv.Itoa(limit))
+	if opt.Page > 0 {
+		query.Set("page", strconv.Itoa(opt.Page))
+	}
+
+	commits := []giteaCommit{}
+	err := p.apiRequest(http.MethodGet, fmt.Sprintf("/repos/%s/%s/commits", owner, repo), query, nil, &commits)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "listing the commits of %s/%s", owner, repo)
+	}
+	answer := []*GitCommit{}
+	for _, commit := range commits {
+		authored := commit.Commit.Author.Date
+		if !opt.Since.IsZero() && authored.Before(opt.Since) {
+			// the commits are ordered newest first so the rest are older too
+			break
+		}
+		if !opt.Until.IsZero() && authored.After(opt.Until) {
+			continue
+		}
+		author := &GitUser{
+			Name:  commit.Commit.Author.Name,
+			Email: commit.Commit.Author.Email,
+		}
+		if commit.Author != nil {
+			author.Login = commit.Author.UserName
+		}
+		if opt.Author != "" && opt.Author != author.Login && opt.Author != author.Email && opt.Author != author.Name {
+			continue
+		}
+		committer := &GitUser{
+			Name:  commit.Commit.Committer.Name,
+			Email: commit.Commit.Committer.Email,
+		}
+		if commit.Committer != nil {
+			committer.Login = commit.Committer.UserName
+		}
+		answer = append(answer, &GitCommit{
+			SHA:       commit.SHA,
+			Message:   commit.Commit.Message,
+			URL:       commit.HTMLURL,
+			Author:    author,
+			Committer: committer,
+		})
+	}
+	return answer, nil
 }
 
 // AddLabelsToIssue adds labels to issues or pullrequests
@@ -780,3 +1002,18 @@ func (p *GiteaProvider) GetLatestRelease(org string, name string) (*GitRelease,
 func (p *GiteaProvider) UploadReleaseAsset(org string, repo string, id int64, name string, asset *os.File) (*GitReleaseAsset, error) {
 	return nil, nil
 }
+
+// apiRequest invokes an API of the server which the SDK does not support yet. The request body is encoded as JSON
+// and the response is decoded as JSON into result if it is not nil.
+func (p *GiteaProvider) apiRequest(method string, path string, query url.Values, body interface{}, result interface{}) error {
+	client := &restClient{
+		httpClient: p.HTTPClient,
+		authorize: func(req *http.Request) {
+			if p.User.ApiToken != "" {
+				req.Header.Set("Authorization", "token "+p.User.ApiToken)
+			}
+		},
+	}
+	_, err := client.do(method, strings.TrimSuffix(p.Server.URL, "/")+"/api/v1"+path, query, body, result)
+	return err
+}
@@ -0,0 +1,173 @@
+package gits_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/stretchr/testify/suite"
+)
+
+type GiteaProviderTestSuite struct {
+	suite.Suite
+	mux      *http.ServeMux
+	server   *httptest.Server
+	provider *gits.GiteaProvider
+}
+
+var giteaRouter = util.Router{
+	"/api/v1/repos/test-org/test-repo": util.MethodMap{
+		"GET":   "repo.json",
+		"PATCH": "repo-renamed.json",
+	},
+	"/api/v1/repos/test-org/test-repo/hooks": util.MethodMap{
+		"GET": "hooks.json",
+	},
+	"/api/v1/repos/test-org/test-repo/hooks/3": util.MethodMap{
+		"PATCH": "empty.json",
+	},
+	"/api/v1/repos/test-org/test-repo/statuses/d6f24ee03d76a2caf0a4e1975fb43e8f61759b9c": util.MethodMap{
+		"POST": "status.json",
+	},
+	"/api/v1/repos/test-org/test-repo/raw/master/jenkins-x.yml": util.MethodMap{
+		"GET": "jenkins-x.yml",
+	},
+	"/api/v1/repos/test-org/test-repo/commits": util.MethodMap{
+		"GET": "commits.json",
+	},
+	"/api/v1/repos/test-org/test-repo/collaborators/test-user": util.MethodMap{
+		"PUT": "empty.json",
+	},
+}
+
+func (suite *GiteaProviderTestSuite) SetupSuite() {
+	suite.mux = http.NewServeMux()
+
+	for path, methodMap := range giteaRouter {
+		suite.mux.HandleFunc(path, util.GetMockAPIResponseFromFile("test_data/gitea", methodMap))
+	}
+
+	suite.server = httptest.NewServer(suite.mux)
+	suite.Require().NotNil(suite.server)
+
+	as := auth.AuthServer{
+		URL:         suite.server.URL,
+		Name:        "Test Gitea Server",
+		Kind:        "gitea",
+		CurrentUser: "test-user",
+	}
+	ua := auth.UserAuth{
+		Username: "test-user",
+		ApiToken: "0123456789abdef",
+	}
+
+	gp, err := gits.NewGiteaProvider(&as, &ua, gits.NewGitCLI())
+	suite.Require().Nil(err)
+	suite.Require().NotNil(gp)
+
+	var ok bool
+	suite.provider, ok = gp.(*gits.GiteaProvider)
+	suite.Require().True(ok)
+}
+
+func (suite *GiteaProviderTestSuite) TearDownSuite() {
+	suite.server.Close()
+}
+
+func (suite *GiteaProviderTestSuite) TestListWebHooks() {
+	hooks, err := suite.provider.ListWebHooks("test-org", "test-repo")
+	suite.Require().Nil(err)
+	suite.Require().Len(hooks, 2)
+	suite.Require().Equal(int64(3), hooks[0].ID)
+	suite.Require().Equal("http://hook.jx.example.com/hook", hooks[0].URL)
+	suite.Require().Equal("test-org", hooks[0].Owner)
+}
+
+func (suite *GiteaProviderTestSuite) TestUpdateWebHook() {
+	err := suite.provider.UpdateWebHook(&gits.GitWebHookArguments{
+		Owner:       "test-org",
+		Repo:        &gits.GitRepository{Name: "test-repo"},
+		URL:         "http://hook-jx.jx.example.com/hook",
+		ExistingURL: "http://hook.jx.example.com/hook",
+		Secret:      "s3cr3t",
+	})
+	suite.Require().Nil(err)
+}
+
+func (suite *GiteaProviderTestSuite) TestRenameRepository() {
+	repo, err := suite.provider.RenameRepository("test-org", "test-repo", "test-repo-renamed")
+	suite.Require().Nil(err)
+	suite.Require().NotNil(repo)
+	suite.Require().Equal("test-repo-renamed", repo.Name)
+	suite.Require().Equal("http://gitea.example.com/test-org/test-repo-renamed", repo.HTMLURL)
+}
+
+func (suite *GiteaProviderTestSuite) TestUpdateCommitStatus() {
+	status, err := suite.provider.UpdateCommitStatus("test-org", "test-repo", "d6f24ee03d76a2caf0a4e1975fb43e8f61759b9c", &gits.GitRepoStatus{
+		State:       "success",
+		Context:     "pr-build",
+		Description: "Succeeded",
+		TargetURL:   "http://jx.example.com/teams/jx/projects/test-org/test-repo/PR-1/1",
+	})
+	suite.Require().Nil(err)
+	suite.Require().NotNil(status)
+	suite.Require().Equal("12", status.ID)
+	suite.Require().Equal("success", status.State)
+	suite.Require().Equal("pr-build", status.Context)
+}
+
+func (suite *GiteaProviderTestSuite) TestGetContent() {
+	content, err := suite.provider.GetContent("test-org", "test-repo", "jenkins-x.yml", "")
+	suite.Require().Nil(err)
+	suite.Require().NotNil(content)
+	suite.Require().Equal("jenkins-x.yml", content.Name)
+	suite.Require().Equal("base64", content.Encoding)
+
+	data, err := base64.StdEncoding.DecodeString(content.Content)
+	suite.Require().Nil(err)
+	suite.Require().Equal("buildPack: go\n", string(data))
+}
+
+func (suite *GiteaProviderTestSuite) TestListCommits() {
+	commits, err := suite.provider.ListCommits("test-org", "test-repo", &gits.ListCommitsArguments{})
+	suite.Require().Nil(err)
+	suite.Require().Len(commits, 2)
+	suite.Require().Equal("d6f24ee03d76a2caf0a4e1975fb43e8f61759b9c", commits[0].SHA)
+	suite.Require().Equal("test-user", commits[0].Author.Login)
+	suite.Require().Equal("Other User", commits[1].Author.Name)
+
+	commits, err = suite.provider.ListCommits("test-org", "test-repo", &gits.ListCommitsArguments{
+		Since: time.Date(2019, 6, 11, 0, 0, 0, 0, time.UTC),
+	})
+	suite.Require().Nil(err)
+	suite.Require().Len(commits, 1)
+
+	commits, err = suite.provider.ListCommits("test-org", "test-repo", &gits.ListCommitsArguments{
+		Author: "other-user@example.com",
+	})
+	suite.Require().Nil(err)
+	suite.Require().Len(commits, 1)
+	suite.Require().Equal("8c2d1b0d6a7e4b5f3e1a9c0b7d6e5f4a3b2c1d0e", commits[0].SHA)
+
+	commits, err = suite.provider.ListCommits("test-org", "test-repo", nil)
+	suite.Require().Nil(err)
+	suite.Require().Len(commits, 2)
+}
+
+func (suite *GiteaProviderTestSuite) TestAddCollaborator() {
+	err := suite.provider.AddCollaborator("test-user", "test-org", "test-repo")
+	suite.Require().Nil(err)
+}
+
+func TestGiteaProviderTestSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping GiteaProviderTestSuite in short mode")
+	} else {
+		suite.Run(t, new(GiteaProviderTestSuite))
+	}
+}
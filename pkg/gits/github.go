@@ -1282,6 +1282,12 @@ func (p *GitHubProvider) AddCollaborator(user string, organisation string, repo
 	return nil
 }
 
+// IsCollaborator returns true if the user is a collaborator of the repository
+func (p *GitHubProvider) IsCollaborator(owner string, repo string, user string) (bool, error) {
+	answer, _, err := p.Client.Repositories.IsCollaborator(p.Context, owner, repo, user)
+	return answer, err
+}
+
 func (p *GitHubProvider) ListInvitations() ([]*github.RepositoryInvitation, *github.Response, error) {
 	return p.Client.Users.ListInvitations(p.Context, &github.ListOptions{})
 }
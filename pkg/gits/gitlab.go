@@ -2,7 +2,6 @@ package gits
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -81,24 +80,56 @@ func (g *GitlabProvider) GetRelease(org string, name string, tag string) (*GitRe
 }
 
 func getRepositories(g *gitlab.Client, username string, org string, searchFilter string) ([]*gitlab.Project, *gitlab.Response, error) {
-	// TODO: handle the case of more than "pageSize" results, similarly to ListOpenPullRequests().
 	gitlabSearchFilter := gitlab.String(searchFilter)
-	listOpts := gitlab.ListOptions{PerPage: pageSize}
-	listProjectOpts := &gitlab.ListProjectsOptions{
-		Owned:       gitlab.Bool(true),
-		Search:      gitlabSearchFilter,
-		ListOptions: listOpts,
+	if org != "" {
+		projects, resp, err := getGroupProjects(g, org, gitlabSearchFilter)
+		if err == nil {
+			return projects, resp, nil
+		}
+		username = org
 	}
+	return getUserProjects(g, username, gitlabSearchFilter)
+}
 
-	if org != "" {
-		projects, resp, err := g.Groups.ListGroupProjects(org, &gitlab.ListGroupProjectsOptions{Search: gitlabSearchFilter, ListOptions: listOpts})
+// getGroupProjects returns all the pages of the projects of a group
+func getGroupProjects(g *gitlab.Client, group string, searchFilter *string) ([]*gitlab.Project, *gitlab.Response, error) {
+	opt := &gitlab.ListGroupProjectsOptions{
+		Search:      searchFilter,
+		ListOptions: gitlab.ListOptions{PerPage: pageSize},
+	}
+	var answer []*gitlab.Project
+	for {
+		projects, resp, err := g.Groups.ListGroupProjects(group, opt)
 		if err != nil {
-			return g.Projects.ListUserProjects(org, listProjectOpts)
+			return nil, resp, err
 		}
-		return projects, resp, err
+		answer = append(answer, projects...)
+		if resp == nil || resp.NextPage == 0 {
+			return answer, resp, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
 
+// getUserProjects returns all the pages of the projects owned by a user
+func getUserProjects(g *gitlab.Client, username string, searchFilter *string) ([]*gitlab.Project, *gitlab.Response, error) {
+	opt := &gitlab.ListProjectsOptions{
+		Owned:       gitlab.Bool(true),
+		Search:      searchFilter,
+		ListOptions: gitlab.ListOptions{PerPage: pageSize},
+	}
+	var answer []*gitlab.Project
+	for {
+		projects, resp, err := g.Projects.ListUserProjects(username, opt)
+		if err != nil {
+			return nil, resp, err
+		}
+		answer = append(answer, projects...)
+		if resp == nil || resp.NextPage == 0 {
+			return answer, resp, nil
+		}
+		opt.Page = resp.NextPage
 	}
-	return g.Projects.ListUserProjects(username, listProjectOpts)
 }
 
 func GetOwnerNamespaceID(g *gitlab.Client, owner string) (int, error) {
@@ -441,18 +472,60 @@ func (g *GitlabProvider) ListCommitStatus(org string, repo string, sha string) (
 
 // UpdateCommitStatus updates the commit status
 func (g *GitlabProvider) UpdateCommitStatus(org string, repo string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
-	return &GitRepoStatus{}, errors.New("TODO")
+	pid, err := g.projectId(org, g.Username, repo)
+	if err != nil {
+		return &GitRepoStatus{}, err
+	}
+	opt := &gitlab.SetCommitStatusOptions{
+		State:       toGitlabBuildState(status.State),
+		Name:        gitlab.String(status.Context),
+		Description: gitlab.String(status.Description),
+	}
+	if status.TargetURL != "" {
+		opt.TargetURL = gitlab.String(status.TargetURL)
+	}
+	result, _, err := g.Client.Commits.SetCommitStatus(pid, sha, opt)
+	if err != nil {
+		return &GitRepoStatus{}, errors2.Wrapf(err, "setting the status %s of commit %s in %s/%s", status.Context, sha, org, repo)
+	}
+	return fromCommitStatus(result), nil
 }
 
 func fromCommitStatus(status *gitlab.CommitStatus) *GitRepoStatus {
 	return &GitRepoStatus{
-		ID:          string(status.ID),
+		ID:          strconv.Itoa(status.ID),
+		Context:     status.Name,
 		URL:         status.TargetURL,
-		State:       status.Status,
+		TargetURL:   status.TargetURL,
+		State:       fromGitlabBuildState(status.Status),
 		Description: status.Description,
 	}
 }
 
+// toGitlabBuildState converts the state of a commit status into the equivalent GitLab build state
+func toGitlabBuildState(state string) gitlab.BuildStateValue {
+	switch state {
+	case "failure", "error":
+		return gitlab.BuildStateValue("failed")
+	case "":
+		return gitlab.BuildStateValue("pending")
+	}
+	return gitlab.BuildStateValue(state)
+}
+
+// fromGitlabBuildState converts a GitLab build state into the state of a commit status
+func fromGitlabBuildState(state string) string {
+	switch state {
+	case "running", "created", "manual":
+		return "pending"
+	case "failed":
+		return "failure"
+	case "canceled", "skipped":
+		return "error"
+	}
+	return state
+}
+
 func (g *GitlabProvider) MergePullRequest(pr *GitPullRequest, message string) error {
 	pid, err := g.projectId(pr.Owner, g.Username, pr.Repo)
 	if err != nil {
@@ -466,16 +539,33 @@ func (g *GitlabProvider) MergePullRequest(pr *GitPullRequest, message string) er
 }
 
 func (g *GitlabProvider) CreateWebHook(data *GitWebHookArguments) error {
+	if data.Repo == nil || data.Repo.Name == "" {
+		return fmt.Errorf("Missing property Repo")
+	}
 	pid, err := g.projectId(data.Owner, g.Username, data.Repo.Name)
 	if err != nil {
-		return nil
+		return err
+	}
+
+	webhookURL := g.webHookURL(data)
+	hooks, err := g.listProjectHooks(pid)
+	if err != nil {
+		log.Logger().Warnf("Querying webhooks on %s/%s: %s", data.Owner, data.Repo.Name, err)
+	}
+	for _, hook := range hooks {
+		if hook.URL == webhookURL {
+			log.Logger().Warnf("Already has a webhook registered for %s", webhookURL)
+			return nil
+		}
 	}
 
-	owner := owner(data.Owner, g.Username)
-	webhookURL := util.UrlJoin(data.URL, owner, data.Repo.Name)
 	opt := &gitlab.AddProjectHookOptions{
-		URL:   &webhookURL,
-		Token: &data.Secret,
+		URL:                 &webhookURL,
+		Token:               &data.Secret,
+		PushEvents:          gitlab.Bool(true),
+		TagPushEvents:       gitlab.Bool(true),
+		MergeRequestsEvents: gitlab.Bool(true),
+		NoteEvents:          gitlab.Bool(true),
 	}
 
 	_, _, err = g.Client.Projects.AddProjectHook(pid, opt)
@@ -485,11 +575,94 @@ func (g *GitlabProvider) CreateWebHook(data *GitWebHookArguments) error {
 // ListWebHooks lists the webhooks
 func (g *GitlabProvider) ListWebHooks(owner string, repo string) ([]*GitWebHookArguments, error) {
 	webHooks := []*GitWebHookArguments{}
-	return webHooks, fmt.Errorf("not implemented!")
+	if repo == "" {
+		return webHooks, fmt.Errorf("Missing property Repo")
+	}
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return webHooks, err
+	}
+	hooks, err := g.listProjectHooks(pid)
+	if err != nil {
+		return webHooks, errors2.Wrapf(err, "listing the webhooks of %s/%s", owner, repo)
+	}
+	for _, hook := range hooks {
+		webHooks = append(webHooks, &GitWebHookArguments{
+			ID:    int64(hook.ID),
+			Owner: owner,
+			URL:   hook.URL,
+		})
+	}
+	return webHooks, nil
 }
 
+// UpdateWebHook updates the webhook with the ID, or the webhook with the existing URL if there is no ID
 func (g *GitlabProvider) UpdateWebHook(data *GitWebHookArguments) error {
-	return fmt.Errorf("not implemented!")
+	if data.Repo == nil || data.Repo.Name == "" {
+		return fmt.Errorf("Missing property Repo")
+	}
+	if data.URL == "" {
+		return fmt.Errorf("Missing property URL")
+	}
+	pid, err := g.projectId(data.Owner, g.Username, data.Repo.Name)
+	if err != nil {
+		return err
+	}
+
+	hookID := int(data.ID)
+	if hookID == 0 {
+		hooks, err := g.listProjectHooks(pid)
+		if err != nil {
+			return errors2.Wrapf(err, "listing the webhooks of %s/%s", data.Owner, data.Repo.Name)
+		}
+		for _, hook := range hooks {
+			if hook.URL == data.ExistingURL {
+				log.Logger().Warnf("Found existing webhook for url %s", data.ExistingURL)
+				hookID = hook.ID
+			}
+		}
+	}
+	if hookID == 0 {
+		log.Logger().Warn("No webhooks found to update")
+		return nil
+	}
+
+	webhookURL := g.webHookURL(data)
+	opt := &gitlab.EditProjectHookOptions{
+		URL:                 &webhookURL,
+		PushEvents:          gitlab.Bool(true),
+		TagPushEvents:       gitlab.Bool(true),
+		MergeRequestsEvents: gitlab.Bool(true),
+		NoteEvents:          gitlab.Bool(true),
+	}
+	if data.Secret != "" {
+		opt.Token = &data.Secret
+	}
+	log.Logger().Infof("Updating GitLab webhook for %s/%s for url %s", util.ColorInfo(data.Owner), util.ColorInfo(data.Repo.Name), util.ColorInfo(webhookURL))
+	_, _, err = g.Client.Projects.EditProjectHook(pid, hookID, opt)
+	return err
+}
+
+// webHookURL returns the URL of the webhook of the repository
+func (g *GitlabProvider) webHookURL(data *GitWebHookArguments) string {
+	return util.UrlJoin(data.URL, owner(data.Owner, g.Username), data.Repo.Name)
+}
+
+// listProjectHooks returns all the pages of the webhooks of a project
+func (g *GitlabProvider) listProjectHooks(pid string) ([]*gitlab.ProjectHook, error) {
+	opt := &gitlab.ListProjectHooksOptions{PerPage: pageSize}
+	var answer []*gitlab.ProjectHook
+	for {
+		hooks, resp, err := g.Client.Projects.ListProjectHooks(pid, opt)
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, hooks...)
+		if resp == nil || resp.NextPage == 0 {
+			return answer, nil
+		}
+		opt.Page = resp.NextPage
+	}
 }
 
 func (g *GitlabProvider) SearchIssues(org, repo, query string) ([]*GitIssue, error) {
@@ -713,7 +886,31 @@ func (g *GitlabProvider) AcceptInvitation(ID int64) (*github.Response, error) {
 
 // GetContent returns the content of a file
 func (g *GitlabProvider) GetContent(org string, name string, path string, ref string) (*GitFileContent, error) {
-	return nil, fmt.Errorf("Getting content not supported on gitlab")
+	pid, err := g.projectId(org, g.Username, name)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		project, _, err := g.Client.Projects.GetProject(pid)
+		if err != nil {
+			return nil, errors2.Wrapf(err, "getting the default branch of %s/%s", org, name)
+		}
+		ref = project.DefaultBranch
+	}
+	file, _, err := g.Client.RepositoryFiles.GetFile(pid, path, &gitlab.GetFileOptions{Ref: gitlab.String(ref)})
+	if err != nil {
+		return nil, errors2.Wrapf(err, "getting %s at %s from %s/%s", path, ref, org, name)
+	}
+	return &GitFileContent{
+		Type:     "file",
+		Name:     file.FileName,
+		Path:     file.FilePath,
+		Encoding: file.Encoding,
+		Content:  file.Content,
+		Size:     file.Size,
+		Sha:      file.BlobID,
+		HtmlUrl:  util.UrlJoin(g.Server.URL, owner(org, g.Username), name, "blob", ref, path),
+	}, nil
 }
 
 // ShouldForkForPullReques treturns true if we should create a personal fork of this repository
@@ -731,7 +928,81 @@ func GitlabAccessTokenURL(url string) string {
 
 // ListCommits lists the commits for the specified repo and owner
 func (g *GitlabProvider) ListCommits(owner, repo string, opt *ListCommitsArguments) ([]*GitCommit, error) {
-	return nil, fmt.Errorf("Listing commits not supported on gitlab")
+	if opt == nil {
+		opt = &ListCommitsArguments{}
+	}
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return nil, err
+	}
+	gitlabOpt := &gitlab.ListCommitsOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    opt.Page,
+			PerPage: opt.PerPage,
+		},
+	}
+	if opt.SHA != "" {
+		gitlabOpt.RefName = gitlab.String(opt.SHA)
+	}
+	if !opt.Since.IsZero() {
+		gitlabOpt.Since = &opt.Since
+	}
+	if !opt.Until.IsZero() {
+		gitlabOpt.Until = &opt.Until
+	}
+	if gitlabOpt.PerPage == 0 {
+		gitlabOpt.PerPage = pageSize
+	}
+
+	gitlabCommits, _, err := g.Client.Commits.ListCommits(pid, gitlabOpt)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "listing the commits of %s/%s", owner, repo)
+	}
+	var commits []*GitCommit
+	logins := map[string]string{}
+	for _, commit := range gitlabCommits {
+		if opt.Author != "" && commit.AuthorName != opt.Author && commit.AuthorEmail != opt.Author {
+			continue
+		}
+		answer := g.fromGitlabCommit(owner, repo, commit)
+		answer.Author.Login = g.userLogin(commit.AuthorEmail, logins)
+		commits = append(commits, answer)
+	}
+	return commits, nil
+}
+
+// userLogin returns the username of the GitLab user with the email, caching the usernames which have been looked up.
+// As GitLab commits only carry the name and email of their author the username is empty if no user has the email
+func (g *GitlabProvider) userLogin(email string, logins map[string]string) string {
+	if email == "" {
+		return ""
+	}
+	login, ok := logins[email]
+	if ok {
+		return login
+	}
+	users, _, err := g.Client.Users.ListUsers(&gitlab.ListUsersOptions{Search: &email})
+	if err == nil && len(users) == 1 {
+		login = users[0].Username
+	}
+	logins[email] = login
+	return login
+}
+
+func (g *GitlabProvider) fromGitlabCommit(org string, repo string, commit *gitlab.Commit) *GitCommit {
+	return &GitCommit{
+		SHA:     commit.ID,
+		Message: commit.Message,
+		URL:     util.UrlJoin(g.Server.URL, owner(org, g.Username), repo, "commit", commit.ID),
+		Author: &GitUser{
+			Name:  commit.AuthorName,
+			Email: commit.AuthorEmail,
+		},
+		Committer: &GitUser{
+			Name:  commit.CommitterName,
+			Email: commit.CommitterEmail,
+		},
+	}
 }
 
 // AddLabelsToIssue adds labels to issues or pullrequests
@@ -3,10 +3,12 @@ package gits_test
 import (
 	"testing"
 
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	"github.com/jenkins-x/jx/pkg/auth"
 	"github.com/jenkins-x/jx/pkg/gits"
@@ -20,6 +22,7 @@ const (
 	gitlabOrgName     = "testorg"
 	gitlabProjectName = "test-project"
 	gitlabProjectID   = "5690870"
+	gitlabPagedOrg    = "pagedorg"
 )
 
 type GitlabProviderSuite struct {
@@ -27,6 +30,9 @@ type GitlabProviderSuite struct {
 	mux      *http.ServeMux
 	server   *httptest.Server
 	provider *gits.GitlabProvider
+
+	commitStatusRequest map[string]interface{}
+	hookRequest         map[string]interface{}
 }
 
 func (suite *GitlabProviderSuite) SetupSuite() {
@@ -93,16 +99,77 @@ func configureGitlabMock(suite *GitlabProviderSuite, mux *http.ServeMux) {
 		w.Write(src)
 	})
 
+	mux.HandleFunc("/api/v4/users", func(w http.ResponseWriter, r *http.Request) {
+		users := "[]"
+		if r.URL.Query().Get("search") == "testperson@example.com" {
+			users = `[{"id": 2, "username": "testperson", "name": "Test person"}]`
+		}
+		w.Write([]byte(users))
+	})
+
+	mux.HandleFunc(fmt.Sprintf("/api/v4/groups/%s/projects", gitlabPagedOrg), func(w http.ResponseWriter, r *http.Request) {
+		file := "test_data/gitlab/user-projects.json"
+		if r.URL.Query().Get("page") == "2" {
+			file = "test_data/gitlab/group-projects.json"
+		} else {
+			w.Header().Set("X-Next-Page", "2")
+		}
+		src, err := ioutil.ReadFile(file)
+
+		suite.Require().Nil(err)
+		w.Write(src)
+	})
+
+	mux.HandleFunc(fmt.Sprintf("/api/v4/projects/%s/statuses/06b5fa6804aa0bd1f4f533010d1b335918a433e2", gitlabProjectID), func(w http.ResponseWriter, r *http.Request) {
+		suite.commitStatusRequest = readGitlabRequest(suite, r)
+		src, err := ioutil.ReadFile("test_data/gitlab/commit-status.json")
+
+		suite.Require().Nil(err)
+		w.Write(src)
+	})
+
+	mux.HandleFunc(fmt.Sprintf("/api/v4/projects/%s/hooks/1", gitlabProjectID), func(w http.ResponseWriter, r *http.Request) {
+		suite.Require().Equal("PUT", r.Method)
+		suite.hookRequest = readGitlabRequest(suite, r)
+		src, err := ioutil.ReadFile("test_data/gitlab/hook.json")
+
+		suite.Require().Nil(err)
+		w.Write(src)
+	})
+
 	gitlabRouter := util.Router{
 		fmt.Sprintf("/api/v4/projects/%s", gitlabProjectID): util.MethodMap{
 			"GET": "project.json",
 		},
+		fmt.Sprintf("/api/v4/projects/%s/hooks", gitlabProjectID): util.MethodMap{
+			"GET": "hooks.json",
+		},
+		fmt.Sprintf("/api/v4/projects/%s/repository/files/jenkins-x.yml", gitlabProjectID): util.MethodMap{
+			"GET": "file.json",
+		},
+		fmt.Sprintf("/api/v4/projects/%s/repository/commits", gitlabProjectID): util.MethodMap{
+			"GET": "commits.json",
+		},
 	}
 	for path, methodMap := range gitlabRouter {
 		mux.HandleFunc(path, util.GetMockAPIResponseFromFile("test_data/gitlab", methodMap))
 	}
 }
 
+// readGitlabRequest returns the options sent in the body of a request to the GitLab API
+func readGitlabRequest(suite *GitlabProviderSuite, r *http.Request) map[string]interface{} {
+	body, err := ioutil.ReadAll(r.Body)
+	suite.Require().Nil(err)
+	values := map[string]interface{}{}
+	if len(body) > 0 {
+		suite.Require().Nil(json.Unmarshal(body, &values))
+	}
+	for key := range r.URL.Query() {
+		values[key] = r.URL.Query().Get(key)
+	}
+	return values
+}
+
 func (suite *GitlabProviderSuite) TestListOrganizations() {
 	orgs, err := suite.provider.ListOrganisations()
 
@@ -145,6 +212,15 @@ func (suite *GitlabProviderSuite) TestListRepositories() {
 	}
 }
 
+func (suite *GitlabProviderSuite) TestListRepositoriesReadsAllPages() {
+	repositories, err := suite.provider.ListRepositories(gitlabPagedOrg)
+
+	suite.Require().Nil(err)
+	suite.Require().Len(repositories, 4)
+	suite.Require().Equal("userproject", repositories[0].Name)
+	suite.Require().Equal("orgproject", repositories[2].Name)
+}
+
 func (suite *GitlabProviderSuite) TestGetRepository() {
 	repo, err := suite.provider.GetRepository(gitlabUserName, gitlabProjectName)
 
@@ -172,6 +248,82 @@ func (suite *GitlabProviderSuite) TestAcceptInvitations() {
 	suite.Require().Nil(err)
 }
 
+func (suite *GitlabProviderSuite) TestListWebHooks() {
+	hooks, err := suite.provider.ListWebHooks(gitlabUserName, gitlabProjectName)
+
+	suite.Require().Nil(err)
+	suite.Require().Len(hooks, 1)
+	suite.Require().Equal(int64(1), hooks[0].ID)
+	suite.Require().Equal("http://hook.jx.example.com/hook/testperson/test-project", hooks[0].URL)
+}
+
+func (suite *GitlabProviderSuite) TestUpdateWebHook() {
+	err := suite.provider.UpdateWebHook(&gits.GitWebHookArguments{
+		Owner:       gitlabUserName,
+		Repo:        &gits.GitRepository{Name: gitlabProjectName},
+		URL:         "http://hook.jx.example.org/hook",
+		ExistingURL: "http://hook.jx.example.com/hook/testperson/test-project",
+		Secret:      "my-hmac-token",
+	})
+
+	suite.Require().Nil(err)
+	suite.Require().Equal("http://hook.jx.example.org/hook/testperson/test-project", suite.hookRequest["url"])
+	suite.Require().Equal("my-hmac-token", suite.hookRequest["token"])
+}
+
+func (suite *GitlabProviderSuite) TestUpdateCommitStatus() {
+	status, err := suite.provider.UpdateCommitStatus(gitlabUserName, gitlabProjectName, "06b5fa6804aa0bd1f4f533010d1b335918a433e2", &gits.GitRepoStatus{
+		Context:     "pr-build/Tests",
+		State:       "failure",
+		Description: "Failed",
+		TargetURL:   "https://jx.example.com/teams/jx/projects/testperson/test-project/PR-1/1",
+	})
+
+	suite.Require().Nil(err)
+	suite.Require().Equal("failed", suite.commitStatusRequest["state"])
+	suite.Require().Equal("pr-build/Tests", suite.commitStatusRequest["name"])
+	suite.Require().Equal("93", status.ID)
+	suite.Require().Equal("pr-build/Tests", status.Context)
+	suite.Require().Equal("failure", status.State)
+}
+
+func (suite *GitlabProviderSuite) TestGetContent() {
+	content, err := suite.provider.GetContent(gitlabUserName, gitlabProjectName, "jenkins-x.yml", "master")
+
+	suite.Require().Nil(err)
+	suite.Require().Equal("jenkins-x.yml", content.Path)
+	suite.Require().Equal("base64", content.Encoding)
+	suite.Require().Equal("YnVpbGRQYWNrOiBtYXZlbgo=", content.Content)
+	suite.Require().Equal("79f7bbd25901e8334750839545a9bd021f0e4c83", content.Sha)
+}
+
+func (suite *GitlabProviderSuite) TestListCommits() {
+	commits, err := suite.provider.ListCommits(gitlabUserName, gitlabProjectName, &gits.ListCommitsArguments{
+		SHA:   "master",
+		Since: time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	suite.Require().Nil(err)
+	suite.Require().Len(commits, 2)
+	suite.Require().Equal("3f00363d651280ab2a8ee67f395de1689156d762", commits[0].SHA)
+	suite.Require().Equal("testperson@example.com", commits[0].Author.Email)
+	suite.Require().Equal("testperson", commits[0].Author.Login)
+	suite.Require().Equal("", commits[1].Author.Login, "no user has the email of the author")
+
+	commits, err = suite.provider.ListCommits(gitlabUserName, gitlabProjectName, &gits.ListCommitsArguments{
+		Author: "another@example.com",
+	})
+
+	suite.Require().Nil(err)
+	suite.Require().Len(commits, 1)
+	suite.Require().Equal("Initial commit\n", commits[0].Message)
+
+	commits, err = suite.provider.ListCommits(gitlabUserName, gitlabProjectName, nil)
+
+	suite.Require().Nil(err)
+	suite.Require().Len(commits, 2)
+}
+
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
 func TestGitlabProviderSuite(t *testing.T) {
@@ -23,6 +23,11 @@ type OrganisationChecker interface {
 	IsUserInOrganisation(user string, organisation string) (bool, error)
 }
 
+// CollaboratorChecker verifies if a user is a collaborator of a repository
+type CollaboratorChecker interface {
+	IsCollaborator(owner string, repo string, user string) (bool, error)
+}
+
 // GitProvider is the interface for abstracting use of different git provider APIs
 //go:generate pegomock generate github.com/jenkins-x/jx/pkg/gits GitProvider -o mocks/git_provider.go
 type GitProvider interface {
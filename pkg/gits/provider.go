@@ -220,10 +220,14 @@ func CreateProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter) (G
 	if server.Kind == "" {
 		server.Kind = SaasGitKind(server.URL)
 	}
-	if server.Kind == KindBitBucketCloud {
+	if server.Kind == KindAzureDevOps {
+		return NewAzureDevOpsProvider(server, user, git)
+	} else if server.Kind == KindBitBucketCloud {
 		return NewBitbucketCloudProvider(server, user, git)
 	} else if server.Kind == KindBitBucketServer {
 		return NewBitbucketServerProvider(server, user, git)
+	} else if server.Kind == KindGerrit {
+		return NewGerritProvider(server, user, git)
 	} else if server.Kind == KindGitea {
 		return NewGiteaProvider(server, user, git)
 	} else if server.Kind == KindGitlab {
@@ -253,11 +257,15 @@ func GetHost(gitProvider GitProvider) (string, error) {
 
 func ProviderAccessTokenURL(kind string, url string, username string) string {
 	switch kind {
+	case KindAzureDevOps:
+		return AzureDevOpsAccessTokenURL(url)
 	case KindBitBucketCloud:
 		// TODO pass in the username
 		return BitBucketCloudAccessTokenURL(url, username)
 	case KindBitBucketServer:
 		return BitBucketServerAccessTokenURL(url)
+	case KindGerrit:
+		return GerritAccessTokenURL(url)
 	case KindGitea:
 		return GiteaAccessTokenURL(url)
 	case KindGitlab:
@@ -0,0 +1,76 @@
+package gits
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// restClient invokes the REST APIs of a git provider which its client library does not support, using the same HTTP
+// client as the client library
+type restClient struct {
+	httpClient *http.Client
+	// authorize adds the credentials of the user to a request
+	authorize func(req *http.Request)
+	// responsePrefix is removed from successful responses before they are decoded, such as the XSSI prefix of Gerrit
+	responsePrefix string
+}
+
+// do invokes the API at the URL. The request body is encoded as JSON and the response is decoded as JSON into result
+// if it is not nil. The raw response body is returned, including when the API returns an error status
+func (c *restClient) do(method string, u string, query url.Values, body interface{}, result interface{}) ([]byte, error) {
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to JSON encode the request body for %s", u)
+		}
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		req.Header.Set("Accept", "application/json")
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.authorize != nil {
+		c.authorize(req)
+	}
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = util.GetClient()
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s", method, u)
+	}
+	defer resp.Body.Close()
+	data, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the response of %s %s", method, u)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return data, errors.Errorf("%s %s returned status %d: %s", method, u, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	data = bytes.TrimPrefix(data, []byte(c.responsePrefix))
+	if result != nil && len(bytes.TrimSpace(data)) > 0 {
+		err = json.Unmarshal(data, result)
+		if err != nil {
+			return data, errors.Wrapf(err, "decoding the response of %s %s", method, u)
+		}
+	}
+	return data, nil
+}
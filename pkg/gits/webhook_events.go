@@ -0,0 +1,520 @@
+package gits
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// WebHookEventPush is the kind of a WebHookEvent for commits pushed to a branch
+	WebHookEventPush = "push"
+	// WebHookEventPullRequest is the kind of a WebHookEvent for a Pull Request being opened or updated
+	WebHookEventPullRequest = "pull_request"
+
+	// WebHookActionOpened is the action of a Pull Request which was opened
+	WebHookActionOpened = "opened"
+	// WebHookActionReopened is the action of a Pull Request which was reopened
+	WebHookActionReopened = "reopened"
+	// WebHookActionSynchronize is the action of a Pull Request whose source branch was updated
+	WebHookActionSynchronize = "synchronize"
+	// WebHookActionClosed is the action of a Pull Request which was merged or closed
+	WebHookActionClosed = "closed"
+	// WebHookActionLabeled is the action of a Pull Request which had a label added
+	WebHookActionLabeled = "labeled"
+
+	// OkToTestLabel is the label a trusted user adds to a Pull Request from an untrusted author to approve building it
+	OkToTestLabel = "ok-to-test"
+
+	// zeroSHA is the SHA of a deleted branch in push events
+	zeroSHA = "0000000000000000000000000000000000000000"
+)
+
+// ErrInvalidWebHookSignature is returned when a webhook is not signed with the webhook secret
+var ErrInvalidWebHookSignature = errors.New("invalid webhook signature")
+
+// WebHookEvent is a push or Pull Request event parsed from the webhook payload of a git provider
+type WebHookEvent struct {
+	// Kind is the kind of the git provider which sent the webhook
+	Kind string
+	// Event is either WebHookEventPush or WebHookEventPullRequest
+	Event string
+	// Action is the action of a Pull Request event such as WebHookActionOpened
+	Action   string
+	Owner    string
+	Repo     string
+	CloneURL string
+	// Branch is the branch pushed to or the base branch of the Pull Request
+	Branch string
+	// SHA is the commit pushed or the head commit of the Pull Request
+	SHA string
+	// BaseSHA is the commit of the base branch of the Pull Request if the git provider sends it
+	BaseSHA           string
+	PullRequestNumber int
+	// Author is the username of the user who opened the Pull Request, or for GitLab the user who triggered the event
+	Author string
+	// Label is the label added to the Pull Request by a WebHookActionLabeled event
+	Label string
+	// Deleted is true if the push deleted the branch
+	Deleted bool
+}
+
+// IsPullRequest returns true if the event is for a Pull Request
+func (e *WebHookEvent) IsPullRequest() bool {
+	return e.Event == WebHookEventPullRequest
+}
+
+// ShouldBuild returns true if the event is for new commits which should be built, or for a Pull Request which was
+// approved for building with the OkToTestLabel
+func (e *WebHookEvent) ShouldBuild() bool {
+	if e.IsPullRequest() {
+		switch e.Action {
+		case WebHookActionOpened, WebHookActionReopened, WebHookActionSynchronize:
+			return true
+		case WebHookActionLabeled:
+			return e.Label == OkToTestLabel
+		}
+		return false
+	}
+	return !e.Deleted && e.SHA != ""
+}
+
+// WebHookKind returns the kind of the git provider which sent the webhook with the given headers or an empty string
+// if the webhook is not from a supported git provider
+func WebHookKind(header http.Header) string {
+	switch {
+	case header.Get("X-Gitea-Event") != "":
+		return KindGitea
+	case header.Get("X-GitHub-Event") != "":
+		return KindGitHub
+	case header.Get("X-Gitlab-Event") != "":
+		return KindGitlab
+	case header.Get("X-Event-Key") != "" && header.Get("X-Request-Id") != "":
+		return KindBitBucketServer
+	case header.Get(AzureDevOpsWebHookEventHeader) != "":
+		return KindAzureDevOps
+	}
+	return ""
+}
+
+// ParseWebHook validates the signature of the webhook payload with the secret and parses the push or Pull Request
+// event it contains. Returns nil if the webhook is for any other kind of event
+func ParseWebHook(header http.Header, payload []byte, secret []byte) (*WebHookEvent, error) {
+	kind := WebHookKind(header)
+	if kind == "" {
+		return nil, errors.New("the webhook is not from a supported git provider")
+	}
+	if !ValidWebHookSignature(kind, header, payload, secret) {
+		return nil, ErrInvalidWebHookSignature
+	}
+	var event *WebHookEvent
+	var err error
+	switch kind {
+	case KindGitHub:
+		event, err = parseGitHubWebHook(header.Get("X-GitHub-Event"), payload)
+	case KindGitea:
+		event, err = parseGitHubWebHook(header.Get("X-Gitea-Event"), payload)
+	case KindGitlab:
+		event, err = parseGitlabWebHook(header.Get("X-Gitlab-Event"), payload)
+	case KindBitBucketServer:
+		event, err = parseBitbucketServerWebHook(header.Get("X-Event-Key"), payload)
+	case KindAzureDevOps:
+		event, err = parseAzureDevOpsWebHook(payload)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the %s webhook", kind)
+	}
+	if event != nil {
+		event.Kind = kind
+	}
+	return event, nil
+}
+
+// ValidWebHookSignature returns true if the webhook payload is signed with the secret the way the git provider signs
+// its webhooks
+func ValidWebHookSignature(kind string, header http.Header, payload []byte, secret []byte) bool {
+	if len(secret) == 0 {
+		return false
+	}
+	switch kind {
+	case KindGitHub:
+		if sig := header.Get("X-Hub-Signature-256"); sig != "" {
+			return validHMAC(sha256.New, strings.TrimPrefix(sig, "sha256="), payload, secret)
+		}
+		sig := header.Get("X-Hub-Signature")
+		return strings.HasPrefix(sig, "sha1=") && validHMAC(sha1.New, strings.TrimPrefix(sig, "sha1="), payload, secret)
+	case KindGitea:
+		return validHMAC(sha256.New, header.Get("X-Gitea-Signature"), payload, secret)
+	case KindGitlab:
+		return subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), secret) == 1
+	case KindBitBucketServer:
+		sig := header.Get("X-Hub-Signature")
+		return strings.HasPrefix(sig, "sha256=") && validHMAC(sha256.New, strings.TrimPrefix(sig, "sha256="), payload, secret)
+	case KindAzureDevOps:
+		// Azure DevOps service hooks do not sign their payload so the secret is sent as the basic auth password
+		r := http.Request{Header: header}
+		_, password, ok := r.BasicAuth()
+		return ok && subtle.ConstantTimeCompare([]byte(password), secret) == 1
+	}
+	return false
+}
+
+func validHMAC(h func() hash.Hash, sig string, payload []byte, secret []byte) bool {
+	actual, err := hex.DecodeString(sig)
+	if err != nil || len(actual) == 0 {
+		return false
+	}
+	mac := hmac.New(h, secret)
+	mac.Write(payload)
+	return hmac.Equal(actual, mac.Sum(nil))
+}
+
+// gitHubWebHookRepository is the repository of GitHub and Gitea webhooks
+type gitHubWebHookRepository struct {
+	Name     string            `json:"name"`
+	Owner    gitHubWebHookUser `json:"owner"`
+	CloneURL string            `json:"clone_url"`
+}
+
+// gitHubWebHookUser is a user of GitHub and Gitea webhooks
+type gitHubWebHookUser struct {
+	Login    string `json:"login"`
+	Username string `json:"username"`
+}
+
+// name returns the username of the user, which Gitea may only send as the username
+func (u *gitHubWebHookUser) name() string {
+	if u.Login != "" {
+		return u.Login
+	}
+	return u.Username
+}
+
+// gitHubWebHook is the payload of GitHub and Gitea push and Pull Request webhooks
+type gitHubWebHook struct {
+	Ref     string `json:"ref"`
+	After   string `json:"after"`
+	Deleted bool   `json:"deleted"`
+	Action  string `json:"action"`
+	Number  int    `json:"number"`
+	Label   struct {
+		Name string `json:"name"`
+	} `json:"label"`
+	PullRequest struct {
+		User gitHubWebHookUser `json:"user"`
+		Head struct {
+			Ref string `json:"ref"`
+			Sha string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+			Sha string `json:"sha"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Repository gitHubWebHookRepository `json:"repository"`
+}
+
+func parseGitHubWebHook(eventType string, payload []byte) (*WebHookEvent, error) {
+	if eventType != WebHookEventPush && eventType != WebHookEventPullRequest {
+		return nil, nil
+	}
+	hook := gitHubWebHook{}
+	err := json.Unmarshal(payload, &hook)
+	if err != nil {
+		return nil, err
+	}
+	repo := hook.Repository
+	event := &WebHookEvent{
+		Event:    eventType,
+		Owner:    repo.Owner.name(),
+		Repo:     repo.Name,
+		CloneURL: repo.CloneURL,
+	}
+	if eventType == WebHookEventPush {
+		event.Branch = strings.TrimPrefix(hook.Ref, "refs/heads/")
+		event.SHA = hook.After
+		event.Deleted = hook.Deleted || hook.After == zeroSHA
+		return event, nil
+	}
+	event.Action = hook.Action
+	if event.Action == "synchronized" {
+		// gitea uses a different tense
+		event.Action = WebHookActionSynchronize
+	}
+	event.PullRequestNumber = hook.Number
+	event.Author = hook.PullRequest.User.name()
+	event.Label = hook.Label.Name
+	event.Branch = hook.PullRequest.Base.Ref
+	event.BaseSHA = hook.PullRequest.Base.Sha
+	event.SHA = hook.PullRequest.Head.Sha
+	return event, nil
+}
+
+// gitlabWebHook is the payload of GitLab push and Merge Request webhooks
+type gitlabWebHook struct {
+	Ref   string `json:"ref"`
+	After string `json:"after"`
+	User  struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+		GitHTTPURL        string `json:"git_http_url"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID          int    `json:"iid"`
+		Action       string `json:"action"`
+		TargetBranch string `json:"target_branch"`
+		LastCommit   struct {
+			ID string `json:"id"`
+		} `json:"last_commit"`
+	} `json:"object_attributes"`
+}
+
+func parseGitlabWebHook(eventType string, payload []byte) (*WebHookEvent, error) {
+	if eventType != "Push Hook" && eventType != "Merge Request Hook" {
+		return nil, nil
+	}
+	hook := gitlabWebHook{}
+	err := json.Unmarshal(payload, &hook)
+	if err != nil {
+		return nil, err
+	}
+	// the owner of a GitLab project may be a nested group
+	path := hook.Project.PathWithNamespace
+	idx := strings.LastIndex(path, "/")
+	if idx < 0 {
+		return nil, errors.Errorf("invalid project path %s", path)
+	}
+	event := &WebHookEvent{
+		Owner:    path[:idx],
+		Repo:     path[idx+1:],
+		CloneURL: hook.Project.GitHTTPURL,
+	}
+	if eventType == "Push Hook" {
+		event.Event = WebHookEventPush
+		event.Branch = strings.TrimPrefix(hook.Ref, "refs/heads/")
+		event.SHA = hook.After
+		event.Deleted = hook.After == zeroSHA
+		return event, nil
+	}
+	attributes := hook.ObjectAttributes
+	event.Event = WebHookEventPullRequest
+	event.PullRequestNumber = attributes.IID
+	event.Author = hook.User.Username
+	event.Branch = attributes.TargetBranch
+	event.SHA = attributes.LastCommit.ID
+	switch attributes.Action {
+	case "open":
+		event.Action = WebHookActionOpened
+	case "reopen":
+		event.Action = WebHookActionReopened
+	case "update":
+		event.Action = WebHookActionSynchronize
+	case "close", "merge":
+		event.Action = WebHookActionClosed
+	default:
+		event.Action = attributes.Action
+	}
+	return event, nil
+}
+
+// bitbucketServerWebHookRepository is the repository of Bitbucket Server webhooks
+type bitbucketServerWebHookRepository struct {
+	Slug    string `json:"slug"`
+	Project struct {
+		Key string `json:"key"`
+	} `json:"project"`
+	Links struct {
+		Clone []struct {
+			Href string `json:"href"`
+			Name string `json:"name"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+// cloneURL returns the HTTP clone URL of the repository
+func (r *bitbucketServerWebHookRepository) cloneURL() string {
+	for _, link := range r.Links.Clone {
+		if link.Name == "http" || link.Name == "https" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// bitbucketServerWebHookRef is a branch of a Bitbucket Server Pull Request
+type bitbucketServerWebHookRef struct {
+	DisplayID    string                           `json:"displayId"`
+	LatestCommit string                           `json:"latestCommit"`
+	Repository   bitbucketServerWebHookRepository `json:"repository"`
+}
+
+// bitbucketServerWebHook is the payload of Bitbucket Server push and Pull Request webhooks
+type bitbucketServerWebHook struct {
+	Repository bitbucketServerWebHookRepository `json:"repository"`
+	Changes    []struct {
+		Ref struct {
+			DisplayID string `json:"displayId"`
+			Type      string `json:"type"`
+		} `json:"ref"`
+		ToHash string `json:"toHash"`
+		Type   string `json:"type"`
+	} `json:"changes"`
+	PullRequest struct {
+		ID     int `json:"id"`
+		Author struct {
+			User struct {
+				Name string `json:"name"`
+			} `json:"user"`
+		} `json:"author"`
+		FromRef bitbucketServerWebHookRef `json:"fromRef"`
+		ToRef   bitbucketServerWebHookRef `json:"toRef"`
+	} `json:"pullRequest"`
+}
+
+func parseBitbucketServerWebHook(eventKey string, payload []byte) (*WebHookEvent, error) {
+	hook := bitbucketServerWebHook{}
+	if eventKey == "repo:refs_changed" {
+		err := json.Unmarshal(payload, &hook)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range hook.Changes {
+			if change.Ref.Type != "BRANCH" {
+				continue
+			}
+			return &WebHookEvent{
+				Event:    WebHookEventPush,
+				Owner:    hook.Repository.Project.Key,
+				Repo:     hook.Repository.Slug,
+				CloneURL: hook.Repository.cloneURL(),
+				Branch:   change.Ref.DisplayID,
+				SHA:      change.ToHash,
+				Deleted:  change.Type == "DELETE",
+			}, nil
+		}
+		return nil, nil
+	}
+
+	var action string
+	switch eventKey {
+	case "pr:opened":
+		action = WebHookActionOpened
+	case "pr:from_ref_updated":
+		action = WebHookActionSynchronize
+	case "pr:merged", "pr:declined", "pr:deleted":
+		action = WebHookActionClosed
+	default:
+		return nil, nil
+	}
+	err := json.Unmarshal(payload, &hook)
+	if err != nil {
+		return nil, err
+	}
+	pr := hook.PullRequest
+	repo := pr.ToRef.Repository
+	return &WebHookEvent{
+		Event:             WebHookEventPullRequest,
+		Action:            action,
+		Owner:             repo.Project.Key,
+		Repo:              repo.Slug,
+		CloneURL:          repo.cloneURL(),
+		Branch:            pr.ToRef.DisplayID,
+		BaseSHA:           pr.ToRef.LatestCommit,
+		SHA:               pr.FromRef.LatestCommit,
+		PullRequestNumber: pr.ID,
+		Author:            pr.Author.User.Name,
+	}, nil
+}
+
+// azureDevOpsWebHook is the payload of Azure DevOps push and pull request service hooks
+type azureDevOpsWebHook struct {
+	EventType string `json:"eventType"`
+	Resource  struct {
+		RefUpdates []struct {
+			Name        string `json:"name"`
+			NewObjectID string `json:"newObjectId"`
+		} `json:"refUpdates"`
+		PullRequestID int    `json:"pullRequestId"`
+		Status        string `json:"status"`
+		CreatedBy     struct {
+			UniqueName string `json:"uniqueName"`
+		} `json:"createdBy"`
+		TargetRefName         string                `json:"targetRefName"`
+		LastMergeSourceCommit *azureDevOpsCommitRef `json:"lastMergeSourceCommit"`
+		LastMergeTargetCommit *azureDevOpsCommitRef `json:"lastMergeTargetCommit"`
+		Repository            azureDevOpsRepository `json:"repository"`
+	} `json:"resource"`
+}
+
+func parseAzureDevOpsWebHook(payload []byte) (*WebHookEvent, error) {
+	hook := azureDevOpsWebHook{}
+	err := json.Unmarshal(payload, &hook)
+	if err != nil {
+		return nil, err
+	}
+	resource := hook.Resource
+	switch hook.EventType {
+	case "git.push", "git.pullrequest.created", "git.pullrequest.updated":
+	default:
+		return nil, nil
+	}
+	// the owner of the repository is of the form {organisation}/{project} as the AzureDevOpsProvider uses
+	repo, err := ParseGitURL(resource.Repository.RemoteURL)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(resource.Repository.RemoteURL)
+	if err != nil {
+		return nil, err
+	}
+	u.User = nil
+	event := &WebHookEvent{
+		Owner:    repo.Organisation + "/" + resource.Repository.Project.Name,
+		Repo:     resource.Repository.Name,
+		CloneURL: u.String(),
+	}
+	if hook.EventType == "git.push" {
+		for _, update := range resource.RefUpdates {
+			if !strings.HasPrefix(update.Name, "refs/heads/") {
+				continue
+			}
+			event.Event = WebHookEventPush
+			event.Branch = strings.TrimPrefix(update.Name, "refs/heads/")
+			event.SHA = update.NewObjectID
+			event.Deleted = update.NewObjectID == zeroSHA
+			return event, nil
+		}
+		return nil, nil
+	}
+	event.Event = WebHookEventPullRequest
+	event.PullRequestNumber = resource.PullRequestID
+	event.Author = resource.CreatedBy.UniqueName
+	event.Branch = strings.TrimPrefix(resource.TargetRefName, "refs/heads/")
+	if resource.LastMergeSourceCommit != nil {
+		event.SHA = resource.LastMergeSourceCommit.CommitID
+	}
+	if resource.LastMergeTargetCommit != nil {
+		event.BaseSHA = resource.LastMergeTargetCommit.CommitID
+	}
+	switch {
+	case resource.Status != "active":
+		event.Action = WebHookActionClosed
+	case hook.EventType == "git.pullrequest.created":
+		event.Action = WebHookActionOpened
+	default:
+		// the service hooks created by jx only send updates for pushes to the source branch
+		event.Action = WebHookActionSynchronize
+	}
+	return event, nil
+}
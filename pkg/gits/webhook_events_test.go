@@ -0,0 +1,241 @@
+package gits_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testWebHookSecret = "my-hmac-token"
+
+	gitHubPushPayload = `{
+  "ref": "refs/heads/master",
+  "after": "3f00363d651280ab2a8ee67f395de1689156d762",
+  "deleted": false,
+  "repository": {
+    "name": "myapp",
+    "owner": {"name": "myorg", "login": "myorg"},
+    "clone_url": "https://github.com/myorg/myapp.git"
+  }
+}`
+
+	giteaPullRequestPayload = `{
+  "action": "synchronized",
+  "number": 3,
+  "pull_request": {
+    "user": {"id": 5, "username": "contributor"},
+    "head": {"ref": "feature", "sha": "06b5fa6804aa0bd1f4f533010d1b335918a433e2"},
+    "base": {"ref": "master", "sha": "3f00363d651280ab2a8ee67f395de1689156d762"}
+  },
+  "repository": {
+    "name": "myapp",
+    "owner": {"username": "myorg"},
+    "clone_url": "https://gitea.example.com/myorg/myapp.git"
+  }
+}`
+
+	gitHubLabeledPayload = `{
+  "action": "labeled",
+  "number": 12,
+  "label": {"name": "ok-to-test"},
+  "pull_request": {
+    "user": {"login": "contributor"},
+    "head": {"ref": "feature", "sha": "06b5fa6804aa0bd1f4f533010d1b335918a433e2"},
+    "base": {"ref": "master", "sha": "3f00363d651280ab2a8ee67f395de1689156d762"}
+  },
+  "repository": {
+    "name": "myapp",
+    "owner": {"login": "myorg"},
+    "clone_url": "https://github.com/myorg/myapp.git"
+  }
+}`
+
+	gitlabMergeRequestPayload = `{
+  "object_kind": "merge_request",
+  "user": {"name": "Maintainer", "username": "maintainer"},
+  "project": {
+    "path_with_namespace": "mygroup/mysubgroup/myapp",
+    "git_http_url": "https://gitlab.example.com/mygroup/mysubgroup/myapp.git"
+  },
+  "object_attributes": {
+    "iid": 7,
+    "action": "open",
+    "target_branch": "master",
+    "last_commit": {"id": "06b5fa6804aa0bd1f4f533010d1b335918a433e2"}
+  }
+}`
+
+	bitbucketServerDeletePayload = `{
+  "repository": {
+    "slug": "myapp",
+    "project": {"key": "PROJ"},
+    "links": {"clone": [
+      {"href": "ssh://git@bitbucket.example.com:7999/proj/myapp.git", "name": "ssh"},
+      {"href": "https://bitbucket.example.com/scm/proj/myapp.git", "name": "http"}
+    ]}
+  },
+  "changes": [
+    {
+      "ref": {"id": "refs/heads/feature", "displayId": "feature", "type": "BRANCH"},
+      "toHash": "0000000000000000000000000000000000000000",
+      "type": "DELETE"
+    }
+  ]
+}`
+
+	azureDevOpsPullRequestPayload = `{
+  "eventType": "git.pullrequest.updated",
+  "resource": {
+    "repository": {
+      "id": "5febef5a-833d-4e14-b9c0-14cb638f91e6",
+      "name": "myapp",
+      "project": {"id": "6ce954b1-ce1f-45d1-b94d-e6bf2464ba2c", "name": "myproject"},
+      "remoteUrl": "https://myorg@dev.azure.com/myorg/myproject/_git/myapp"
+    },
+    "pullRequestId": 4,
+    "status": "active",
+    "createdBy": {"displayName": "Contributor", "uniqueName": "contributor@example.com"},
+    "targetRefName": "refs/heads/master",
+    "lastMergeSourceCommit": {"commitId": "06b5fa6804aa0bd1f4f533010d1b335918a433e2"},
+    "lastMergeTargetCommit": {"commitId": "3f00363d651280ab2a8ee67f395de1689156d762"}
+  }
+}`
+)
+
+func sign(h func() hash.Hash, payload string) string {
+	mac := hmac.New(h, []byte(testWebHookSecret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestParseWebHook(t *testing.T) {
+	t.Parallel()
+
+	header := http.Header{}
+	header.Set("X-GitHub-Event", "push")
+	header.Set("X-Hub-Signature", "sha1="+sign(sha1.New, gitHubPushPayload))
+	event, err := gits.ParseWebHook(header, []byte(gitHubPushPayload), []byte(testWebHookSecret))
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, &gits.WebHookEvent{
+		Kind:     gits.KindGitHub,
+		Event:    gits.WebHookEventPush,
+		Owner:    "myorg",
+		Repo:     "myapp",
+		CloneURL: "https://github.com/myorg/myapp.git",
+		Branch:   "master",
+		SHA:      "3f00363d651280ab2a8ee67f395de1689156d762",
+	}, event)
+	assert.True(t, event.ShouldBuild())
+
+	header = http.Header{}
+	header.Set("X-Gitea-Event", "pull_request")
+	header.Set("X-Gitea-Signature", sign(sha256.New, giteaPullRequestPayload))
+	event, err = gits.ParseWebHook(header, []byte(giteaPullRequestPayload), []byte(testWebHookSecret))
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, gits.KindGitea, event.Kind)
+	assert.Equal(t, "myorg", event.Owner)
+	assert.Equal(t, 3, event.PullRequestNumber)
+	assert.Equal(t, gits.WebHookActionSynchronize, event.Action)
+	assert.Equal(t, "06b5fa6804aa0bd1f4f533010d1b335918a433e2", event.SHA)
+	assert.Equal(t, "contributor", event.Author)
+	assert.True(t, event.ShouldBuild())
+
+	header = http.Header{}
+	header.Set("X-GitHub-Event", "pull_request")
+	header.Set("X-Hub-Signature", "sha1="+sign(sha1.New, gitHubLabeledPayload))
+	event, err = gits.ParseWebHook(header, []byte(gitHubLabeledPayload), []byte(testWebHookSecret))
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, gits.WebHookActionLabeled, event.Action)
+	assert.Equal(t, gits.OkToTestLabel, event.Label)
+	assert.Equal(t, "contributor", event.Author)
+	assert.True(t, event.ShouldBuild(), "adding the ok-to-test label approves the build")
+	event.Label = "bug"
+	assert.False(t, event.ShouldBuild(), "other labels are ignored")
+
+	header = http.Header{}
+	header.Set("X-Gitlab-Event", "Merge Request Hook")
+	header.Set("X-Gitlab-Token", testWebHookSecret)
+	event, err = gits.ParseWebHook(header, []byte(gitlabMergeRequestPayload), []byte(testWebHookSecret))
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, "mygroup/mysubgroup", event.Owner)
+	assert.Equal(t, "myapp", event.Repo)
+	assert.Equal(t, gits.WebHookActionOpened, event.Action)
+	assert.Equal(t, 7, event.PullRequestNumber)
+	assert.Equal(t, "maintainer", event.Author)
+
+	header = http.Header{}
+	header.Set("X-Event-Key", "repo:refs_changed")
+	header.Set("X-Request-Id", "a8e2e5c4")
+	header.Set("X-Hub-Signature", "sha256="+sign(sha256.New, bitbucketServerDeletePayload))
+	event, err = gits.ParseWebHook(header, []byte(bitbucketServerDeletePayload), []byte(testWebHookSecret))
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, gits.KindBitBucketServer, event.Kind)
+	assert.Equal(t, "PROJ", event.Owner)
+	assert.Equal(t, "https://bitbucket.example.com/scm/proj/myapp.git", event.CloneURL)
+	assert.True(t, event.Deleted)
+	assert.False(t, event.ShouldBuild(), "deleted branches are not built")
+
+	header = http.Header{}
+	header.Set(gits.AzureDevOpsWebHookEventHeader, "git.pullrequest.updated")
+	request := http.Request{Header: header}
+	request.SetBasicAuth("jenkins-x", testWebHookSecret)
+	event, err = gits.ParseWebHook(header, []byte(azureDevOpsPullRequestPayload), []byte(testWebHookSecret))
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, &gits.WebHookEvent{
+		Kind:              gits.KindAzureDevOps,
+		Event:             gits.WebHookEventPullRequest,
+		Action:            gits.WebHookActionSynchronize,
+		Owner:             "myorg/myproject",
+		Repo:              "myapp",
+		CloneURL:          "https://dev.azure.com/myorg/myproject/_git/myapp",
+		Branch:            "master",
+		SHA:               "06b5fa6804aa0bd1f4f533010d1b335918a433e2",
+		BaseSHA:           "3f00363d651280ab2a8ee67f395de1689156d762",
+		PullRequestNumber: 4,
+		Author:            "contributor@example.com",
+	}, event)
+	assert.True(t, event.ShouldBuild())
+}
+
+func TestParseWebHookValidatesSignature(t *testing.T) {
+	t.Parallel()
+
+	header := http.Header{}
+	header.Set("X-GitHub-Event", "push")
+	header.Set("X-Hub-Signature", "sha1="+sign(sha1.New, gitHubPushPayload))
+	_, err := gits.ParseWebHook(header, []byte(gitHubPushPayload), []byte("another-token"))
+	assert.Equal(t, gits.ErrInvalidWebHookSignature, err)
+
+	header.Del("X-Hub-Signature")
+	_, err = gits.ParseWebHook(header, []byte(gitHubPushPayload), []byte(testWebHookSecret))
+	assert.Equal(t, gits.ErrInvalidWebHookSignature, err)
+
+	header = http.Header{}
+	header.Set("X-GitHub-Event", "ping")
+	header.Set("X-Hub-Signature", "sha1="+sign(sha1.New, "{}"))
+	event, err := gits.ParseWebHook(header, []byte("{}"), []byte(testWebHookSecret))
+	require.NoError(t, err)
+	assert.Nil(t, event, "other events are ignored")
+
+	header = http.Header{}
+	header.Set(gits.AzureDevOpsWebHookEventHeader, "git.pullrequest.updated")
+	request := http.Request{Header: header}
+	request.SetBasicAuth("jenkins-x", "another-token")
+	_, err = gits.ParseWebHook(header, []byte(azureDevOpsPullRequestPayload), []byte(testWebHookSecret))
+	assert.Equal(t, gits.ErrInvalidWebHookSignature, err, "Azure DevOps service hooks send the secret as the basic auth password")
+}
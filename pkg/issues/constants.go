@@ -5,6 +5,8 @@ const (
 	Jira     = "jira"
 	Trello   = "trello"
 	Git      = "git"
+	// REST is a generic issue tracker with a REST API described by a configuration file
+	REST = "rest"
 )
 
 var (
@@ -76,3 +76,8 @@ func (i *GitIssueProvider) CreateIssueComment(key string, comment string) error
 func (i *GitIssueProvider) HomeURL() string {
 	return util.UrlJoin(i.GitProvider.ServerURL(), i.Owner, i.Repository)
 }
+
+// Kind returns the kind of the issue tracker
+func (i *GitIssueProvider) Kind() string {
+	return Git
+}
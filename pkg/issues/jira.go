@@ -192,3 +192,8 @@ func (i *JiraService) ServerName() string {
 func (i *JiraService) HomeURL() string {
 	return util.UrlJoin(i.Server.URL, "browse", i.Project)
 }
+
+// Kind returns the kind of the issue tracker
+func (i *JiraService) Kind() string {
+	return Jira
+}
@@ -2,6 +2,9 @@ package issues
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/jenkins-x/jx/pkg/auth"
@@ -31,30 +34,157 @@ type IssueProvider interface {
 	HomeURL() string
 }
 
+// KindIssueProvider is implemented by the issue providers which know their kind
+type KindIssueProvider interface {
+	// Kind returns the kind of the issue tracker, such as jira
+	Kind() string
+}
+
+// IssueKeyIssueProvider is implemented by the issue providers whose issue keys are configured per issue tracker
+type IssueKeyIssueProvider interface {
+	// IssueKeyRegex returns the regular expression which matches the keys of issues in commit messages
+	IssueKeyRegex() *regexp.Regexp
+}
+
+var (
+	// GitIssueKeyRegex matches the issue keys of git providers, such as #123
+	GitIssueKeyRegex = regexp.MustCompile(`(\#\d+)`)
+	// JiraIssueKeyRegex matches the issue keys of Jira, such as ABC-123
+	JiraIssueKeyRegex = regexp.MustCompile(`[A-Z][A-Z]+-(\d+)`)
+)
+
+// ProviderArguments are the arguments used to create an issue provider
+type ProviderArguments struct {
+	Server   *auth.AuthServer
+	UserAuth *auth.UserAuth
+	Project  string
+	// ConfigFile is the file which configures the issue tracker, for the kinds of issue tracker which need one
+	ConfigFile string
+	BatchMode  bool
+	Git        gits.Gitter
+}
+
+// ProviderFactory creates an issue provider
+type ProviderFactory func(args *ProviderArguments) (IssueProvider, error)
+
+// ProviderRegistration registers a kind of issue tracker
+type ProviderRegistration struct {
+	Kind    string
+	Factory ProviderFactory
+	// AccessTokenURL returns the URL to create an API token for the issue tracker at the URL, if there is one
+	AccessTokenURL func(url string) string
+	// IssueKeyRegex matches the keys of issues in commit messages, which defaults to GitIssueKeyRegex
+	IssueKeyRegex *regexp.Regexp
+}
+
+var (
+	registry      = map[string]*ProviderRegistration{}
+	registryMutex sync.RWMutex
+)
+
+func init() {
+	RegisterProvider(&ProviderRegistration{
+		Kind: Jira,
+		Factory: func(args *ProviderArguments) (IssueProvider, error) {
+			return CreateJiraIssueProvider(args.Server, args.UserAuth, args.Project, args.BatchMode, args.Git)
+		},
+		AccessTokenURL: func(url string) string {
+			// TODO handle on premise servers too by detecting the URL is at atlassian.com
+			return "https://id.atlassian.com/manage/api-tokens"
+		},
+		IssueKeyRegex: JiraIssueKeyRegex,
+	})
+	RegisterProvider(&ProviderRegistration{
+		Kind:    REST,
+		Factory: CreateRESTIssueProvider,
+	})
+}
+
+// RegisterProvider registers a kind of issue tracker, replacing any previous registration of the kind
+func RegisterProvider(registration *ProviderRegistration) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if _, ok := registry[registration.Kind]; !ok {
+		found := false
+		for _, k := range IssueTrackerKinds {
+			if k == registration.Kind {
+				found = true
+				break
+			}
+		}
+		if !found {
+			IssueTrackerKinds = append(IssueTrackerKinds, registration.Kind)
+		}
+	}
+	registry[registration.Kind] = registration
+}
+
+// GetProviderRegistration returns the registration of the kind of issue tracker, or nil if it is not registered
+func GetProviderRegistration(kind string) *ProviderRegistration {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	return registry[kind]
+}
+
+// RegisteredKinds returns the kinds of issue tracker which have been registered, in order
+func RegisteredKinds() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	var answer []string
+	for k := range registry {
+		answer = append(answer, k)
+	}
+	sort.Strings(answer)
+	return answer
+}
+
 func CreateIssueProvider(kind string, server *auth.AuthServer, userAuth *auth.UserAuth, project string, batchMode bool, git gits.Gitter) (IssueProvider, error) {
-	switch kind {
-	case Jira:
-		return CreateJiraIssueProvider(server, userAuth, project, batchMode, git)
-	default:
+	return CreateIssueProviderWithArguments(kind, &ProviderArguments{
+		Server:    server,
+		UserAuth:  userAuth,
+		Project:   project,
+		BatchMode: batchMode,
+		Git:       git,
+	})
+}
+
+// CreateIssueProviderWithArguments creates an issue provider of the given kind using its registration
+func CreateIssueProviderWithArguments(kind string, args *ProviderArguments) (IssueProvider, error) {
+	registration := GetProviderRegistration(kind)
+	if registration == nil {
 		return nil, fmt.Errorf("Unsupported issue provider kind: %s", kind)
 	}
+	return registration.Factory(args)
 }
 
 func ProviderAccessTokenURL(kind string, url string) string {
-	switch kind {
-	case Jira:
-		// TODO handle on premise servers too by detecting the URL is at atlassian.com
-		return "https://id.atlassian.com/manage/api-tokens"
-	default:
+	registration := GetProviderRegistration(kind)
+	if registration == nil || registration.AccessTokenURL == nil {
 		return ""
 	}
+	return registration.AccessTokenURL(url)
 }
 
 // GetIssueProvider returns the kind of issue provider
 func GetIssueProvider(tracker IssueProvider) string {
-	_, ok := tracker.(*JiraService)
-	if ok {
-		return Jira
+	if k, ok := tracker.(KindIssueProvider); ok {
+		return k.Kind()
 	}
 	return Git
 }
+
+// IssueKeyRegex returns the regular expression which matches the keys of the tracker's issues in commit messages
+func IssueKeyRegex(tracker IssueProvider) *regexp.Regexp {
+	if k, ok := tracker.(IssueKeyIssueProvider); ok {
+		if r := k.IssueKeyRegex(); r != nil {
+			return r
+		}
+	}
+	registration := GetProviderRegistration(GetIssueProvider(tracker))
+	if registration != nil && registration.IssueKeyRegex != nil {
+		return registration.IssueKeyRegex
+	}
+	return GitIssueKeyRegex
+}
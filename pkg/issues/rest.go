@@ -0,0 +1,446 @@
+package issues
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// RESTAuthBasic authenticates with the user name and API token using basic authentication
+	RESTAuthBasic = "basic"
+	// RESTAuthBearer authenticates with the API token as a bearer token
+	RESTAuthBearer = "bearer"
+	// RESTAuthHeader authenticates with the API token in the header named by TokenHeader
+	RESTAuthHeader = "header"
+)
+
+// RESTTrackerConfig describes the REST API of an issue tracker, so that in-house issue trackers can be used without
+// writing a provider for them. The paths and bodies of the requests are Go templates which can use .URL, .Project,
+// .Key, .Query, .Since, .Issue and .Comment, and the json function to quote a value.
+type RESTTrackerConfig struct {
+	// IssueKeyPattern is the regular expression which matches issue keys in commit messages, such as [A-Z]+-\d+
+	IssueKeyPattern string `json:"issueKeyPattern,omitempty"`
+	// IssueURL is a template of the URL of an issue in a browser, such as {{.URL}}/issues/{{.Key}}
+	IssueURL string `json:"issueURL,omitempty"`
+	// HomeURL is a template of the home page of the issue tracker, which defaults to the server URL
+	HomeURL string `json:"homeURL,omitempty"`
+	// Auth is how requests are authenticated: basic, bearer or header. Defaults to basic if there is an API token
+	Auth string `json:"auth,omitempty"`
+	// TokenHeader is the header the API token is sent in when Auth is header
+	TokenHeader string `json:"tokenHeader,omitempty"`
+	// Headers are added to every request
+	Headers map[string]string `json:"headers,omitempty"`
+
+	GetIssue                RESTEndpoint `json:"getIssue,omitempty"`
+	SearchIssues            RESTEndpoint `json:"searchIssues,omitempty"`
+	SearchIssuesClosedSince RESTEndpoint `json:"searchIssuesClosedSince,omitempty"`
+	CreateIssue             RESTEndpoint `json:"createIssue,omitempty"`
+	CreateIssueComment      RESTEndpoint `json:"createIssueComment,omitempty"`
+
+	// Fields are the paths of the fields of an issue in the JSON responses
+	Fields RESTIssueFields `json:"fields,omitempty"`
+	// ClosedStates are the states of the issues which are closed. Other states are reported as open
+	ClosedStates []string `json:"closedStates,omitempty"`
+}
+
+// RESTEndpoint is a request to the REST API of an issue tracker
+type RESTEndpoint struct {
+	// Method is the HTTP method, which defaults to GET, or POST if there is a body
+	Method string `json:"method,omitempty"`
+	// Path is a template of the path of the request relative to the server URL, or of an absolute URL
+	Path string `json:"path,omitempty"`
+	// Body is a template of the JSON body of the request
+	Body string `json:"body,omitempty"`
+	// Items is the path of the array of issues in the response of a search. If empty the response is the array
+	Items string `json:"items,omitempty"`
+}
+
+// RESTIssueFields are the paths of the fields of an issue in a JSON response, using dots to separate the names of
+// nested fields, such as fields.status.name
+type RESTIssueFields struct {
+	Key       string `json:"key,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Body      string `json:"body,omitempty"`
+	State     string `json:"state,omitempty"`
+	URL       string `json:"url,omitempty"`
+	User      string `json:"user,omitempty"`
+	Assignee  string `json:"assignee,omitempty"`
+	Labels    string `json:"labels,omitempty"`
+	CreatedAt string `json:"createdAt,omitempty"`
+	ClosedAt  string `json:"closedAt,omitempty"`
+}
+
+// RESTIssueProvider is an IssueProvider for an issue tracker with a REST API described by a RESTTrackerConfig
+type RESTIssueProvider struct {
+	Client   *http.Client
+	Server   *auth.AuthServer
+	UserAuth *auth.UserAuth
+	Project  string
+	Config   *RESTTrackerConfig
+
+	issueKeyRegex *regexp.Regexp
+}
+
+// restTemplateData is the data the templates of a RESTTrackerConfig are evaluated with
+type restTemplateData struct {
+	URL     string
+	Project string
+	Key     string
+	Query   string
+	Since   string
+	Issue   *gits.GitIssue
+	Comment string
+}
+
+// LoadRESTTrackerConfig loads the YAML or JSON file which describes the REST API of an issue tracker
+func LoadRESTTrackerConfig(fileName string) (*RESTTrackerConfig, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	config := &RESTTrackerConfig{}
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal YAML file %s", fileName)
+	}
+	return config, nil
+}
+
+// CreateRESTIssueProvider creates an issue provider for the issue tracker described by the config file
+func CreateRESTIssueProvider(args *ProviderArguments) (IssueProvider, error) {
+	if args.ConfigFile == "" {
+		return nil, fmt.Errorf("No configuration file for the %s issue tracker at %s", REST, args.Server.URL)
+	}
+	config, err := LoadRESTTrackerConfig(args.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewRESTIssueProvider(args.Server, args.UserAuth, args.Project, config)
+}
+
+// NewRESTIssueProvider creates an issue provider for the issue tracker described by the config
+func NewRESTIssueProvider(server *auth.AuthServer, userAuth *auth.UserAuth, project string, config *RESTTrackerConfig) (*RESTIssueProvider, error) {
+	if server.URL == "" {
+		return nil, fmt.Errorf("No base URL for server!")
+	}
+	provider := &RESTIssueProvider{
+		Client:   util.GetClient(),
+		Server:   server,
+		UserAuth: userAuth,
+		Project:  project,
+		Config:   config,
+	}
+	if config.IssueKeyPattern != "" {
+		r, err := regexp.Compile(config.IssueKeyPattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid issue key pattern %s", config.IssueKeyPattern)
+		}
+		provider.issueKeyRegex = r
+	}
+	switch config.Auth {
+	case "", RESTAuthBasic, RESTAuthBearer:
+	case RESTAuthHeader:
+		if config.TokenHeader == "" {
+			return nil, fmt.Errorf("the tokenHeader must be specified when using %s authentication", RESTAuthHeader)
+		}
+	default:
+		return nil, fmt.Errorf("invalid auth %s, it must be one of %s, %s or %s", config.Auth, RESTAuthBasic, RESTAuthBearer, RESTAuthHeader)
+	}
+	return provider, nil
+}
+
+// Kind returns the kind of the issue tracker
+func (i *RESTIssueProvider) Kind() string {
+	return REST
+}
+
+// IssueKeyRegex returns the configured regular expression which matches issue keys
+func (i *RESTIssueProvider) IssueKeyRegex() *regexp.Regexp {
+	return i.issueKeyRegex
+}
+
+func (i *RESTIssueProvider) GetIssue(key string) (*gits.GitIssue, error) {
+	var answer interface{}
+	err := i.request(i.Config.GetIssue, "get issue", &restTemplateData{Key: key}, &answer)
+	if err != nil {
+		return nil, err
+	}
+	return i.toGitIssue(answer), nil
+}
+
+func (i *RESTIssueProvider) SearchIssues(query string) ([]*gits.GitIssue, error) {
+	return i.search(i.Config.SearchIssues, "search issues", &restTemplateData{Query: query})
+}
+
+func (i *RESTIssueProvider) SearchIssuesClosedSince(t time.Time) ([]*gits.GitIssue, error) {
+	return i.search(i.Config.SearchIssuesClosedSince, "search closed issues", &restTemplateData{Since: t.UTC().Format(time.RFC3339)})
+}
+
+func (i *RESTIssueProvider) CreateIssue(issue *gits.GitIssue) (*gits.GitIssue, error) {
+	var answer interface{}
+	err := i.request(i.Config.CreateIssue, "create issue", &restTemplateData{Issue: issue}, &answer)
+	if err != nil {
+		return nil, err
+	}
+	return i.toGitIssue(answer), nil
+}
+
+func (i *RESTIssueProvider) CreateIssueComment(key string, comment string) error {
+	return i.request(i.Config.CreateIssueComment, "create issue comment", &restTemplateData{Key: key, Comment: comment}, nil)
+}
+
+func (i *RESTIssueProvider) IssueURL(key string) string {
+	if i.Config.IssueURL == "" {
+		return ""
+	}
+	u, err := i.evaluate(i.Config.IssueURL, &restTemplateData{Key: key})
+	if err != nil {
+		return ""
+	}
+	return u
+}
+
+func (i *RESTIssueProvider) HomeURL() string {
+	if i.Config.HomeURL == "" {
+		return i.Server.URL
+	}
+	u, err := i.evaluate(i.Config.HomeURL, &restTemplateData{})
+	if err != nil {
+		return i.Server.URL
+	}
+	return u
+}
+
+func (i *RESTIssueProvider) search(endpoint RESTEndpoint, name string, data *restTemplateData) ([]*gits.GitIssue, error) {
+	var results interface{}
+	err := i.request(endpoint, name, data, &results)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Items != "" {
+		results = lookupPath(results, endpoint.Items)
+	}
+	items, ok := results.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the response to %s at %s is not an array of issues", name, i.Server.URL)
+	}
+	answer := []*gits.GitIssue{}
+	for _, item := range items {
+		answer = append(answer, i.toGitIssue(item))
+	}
+	return answer, nil
+}
+
+// request sends a request to the endpoint, unmarshalling the JSON response into result if it is not nil
+func (i *RESTIssueProvider) request(endpoint RESTEndpoint, name string, data *restTemplateData, result interface{}) error {
+	if endpoint.Path == "" {
+		return fmt.Errorf("the issue tracker at %s does not support %s", i.Server.URL, name)
+	}
+	path, err := i.evaluate(endpoint.Path, data)
+	if err != nil {
+		return err
+	}
+	u := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		u = util.UrlJoin(i.Server.URL, path)
+	}
+	var body []byte
+	if endpoint.Body != "" {
+		text, err := i.evaluate(endpoint.Body, data)
+		if err != nil {
+			return err
+		}
+		body = []byte(text)
+	}
+	method := endpoint.Method
+	if method == "" {
+		method = http.MethodGet
+		if body != nil {
+			method = http.MethodPost
+		}
+	}
+
+	req, err := http.NewRequest(strings.ToUpper(method), u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range i.Config.Headers {
+		req.Header.Set(k, v)
+	}
+	i.authenticate(req)
+
+	resp, err := i.Client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to %s at %s", name, u)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the response to %s at %s", name, u)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to %s at %s: %s %s", name, u, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	if result == nil || len(bytes.TrimSpace(respBody)) == 0 {
+		return nil
+	}
+	err = json.Unmarshal(respBody, result)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unmarshal the response to %s at %s", name, u)
+	}
+	return nil
+}
+
+func (i *RESTIssueProvider) authenticate(req *http.Request) {
+	userAuth := i.UserAuth
+	if userAuth == nil || userAuth.ApiToken == "" {
+		return
+	}
+	switch i.Config.Auth {
+	case RESTAuthBearer:
+		req.Header.Set("Authorization", "Bearer "+userAuth.ApiToken)
+	case RESTAuthHeader:
+		req.Header.Set(i.Config.TokenHeader, userAuth.ApiToken)
+	default:
+		req.SetBasicAuth(userAuth.Username, userAuth.ApiToken)
+	}
+}
+
+func (i *RESTIssueProvider) evaluate(text string, data *restTemplateData) (string, error) {
+	t, err := template.New("rest").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse template %s", text)
+	}
+	d := *data
+	d.URL = strings.TrimSuffix(i.Server.URL, "/")
+	d.Project = i.Project
+	var buf bytes.Buffer
+	err = t.Execute(&buf, &d)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to evaluate template %s", text)
+	}
+	return buf.String(), nil
+}
+
+func (i *RESTIssueProvider) toGitIssue(item interface{}) *gits.GitIssue {
+	fields := i.Config.Fields
+	answer := &gits.GitIssue{
+		Key:   lookupString(item, fields.Key),
+		Title: lookupString(item, fields.Title),
+		Body:  lookupString(item, fields.Body),
+	}
+	if n, err := strconv.Atoi(answer.Key); err == nil {
+		answer.Number = &n
+	}
+	answer.URL = lookupString(item, fields.URL)
+	if answer.URL == "" {
+		answer.URL = i.IssueURL(answer.Key)
+	}
+	if state := lookupString(item, fields.State); state != "" {
+		if len(i.Config.ClosedStates) > 0 {
+			state = "open"
+			for _, s := range i.Config.ClosedStates {
+				if strings.EqualFold(s, lookupString(item, fields.State)) {
+					state = "closed"
+				}
+			}
+		}
+		answer.State = &state
+	}
+	if user := lookupString(item, fields.User); user != "" {
+		answer.User = &gits.GitUser{Login: user}
+	}
+	if assignee := lookupString(item, fields.Assignee); assignee != "" {
+		answer.Assignees = []gits.GitUser{{Login: assignee}}
+	}
+	if labels, ok := lookupPath(item, fields.Labels).([]interface{}); ok {
+		var names []string
+		for _, l := range labels {
+			name := toString(l)
+			if m, ok := l.(map[string]interface{}); ok {
+				name = toString(m["name"])
+			}
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+		answer.Labels = gits.ToGitLabels(names)
+	}
+	answer.CreatedAt = lookupTime(item, fields.CreatedAt)
+	answer.ClosedAt = lookupTime(item, fields.ClosedAt)
+	return answer
+}
+
+// lookupPath returns the value at the dot separated path in the unmarshalled JSON, or nil if there isn't one
+func lookupPath(value interface{}, path string) interface{} {
+	if path == "" {
+		return nil
+	}
+	for _, name := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[name]
+		case []interface{}:
+			idx, err := strconv.Atoi(name)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil
+			}
+			value = v[idx]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+func lookupString(value interface{}, path string) string {
+	return toString(lookupPath(value, path))
+}
+
+func lookupTime(value interface{}, path string) *time.Time {
+	text := lookupString(value, path)
+	if text == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, text)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
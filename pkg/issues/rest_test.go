@@ -0,0 +1,122 @@
+package issues
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createRESTTestProvider(t *testing.T, handler http.HandlerFunc) (IssueProvider, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	provider, err := CreateIssueProviderWithArguments(REST, &ProviderArguments{
+		Server:     &auth.AuthServer{URL: server.URL},
+		UserAuth:   &auth.UserAuth{Username: "jx", ApiToken: "secret"},
+		Project:    "TRK",
+		ConfigFile: filepath.Join("test_data", "rest-tracker.yml"),
+		BatchMode:  true,
+	})
+	require.NoError(t, err)
+	return provider, server
+}
+
+func TestRESTIssueProviderGetIssue(t *testing.T) {
+	t.Parallel()
+
+	provider, server := createRESTTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/projects/TRK/issues/TRK-12", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"id": "TRK-12", "summary": "Broken", "description": "It is broken", "status": {"name": "Done"},
+			"reporter": {"login": "someone"}, "tags": ["bug", {"name": "urgent"}], "created": "2019-07-01T10:00:00Z"}`))
+	})
+	defer server.Close()
+
+	issue, err := provider.GetIssue("TRK-12")
+	require.NoError(t, err)
+
+	assert.Equal(t, "TRK-12", issue.Key)
+	assert.Equal(t, "Broken", issue.Title)
+	assert.Equal(t, "It is broken", issue.Body)
+	assert.Equal(t, server.URL+"/browse/TRK-12", issue.URL)
+	require.NotNil(t, issue.State)
+	assert.Equal(t, "closed", *issue.State)
+	require.NotNil(t, issue.User)
+	assert.Equal(t, "someone", issue.User.Login)
+	assert.Equal(t, []gits.GitLabel{{Name: "bug"}, {Name: "urgent"}}, issue.Labels)
+	require.NotNil(t, issue.CreatedAt)
+	assert.Equal(t, 2019, issue.CreatedAt.Year())
+
+	assert.Equal(t, REST, GetIssueProvider(provider))
+	assert.Equal(t, []string{"TRK-12", "TRK-3"}, IssueKeyRegex(provider).FindAllString("fix: TRK-12 and TRK-3, not #4", -1))
+}
+
+func TestRESTIssueProviderSearchAndCreate(t *testing.T) {
+	t.Parallel()
+
+	var created map[string]interface{}
+	var comment map[string]interface{}
+	provider, server := createRESTTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/projects/TRK/issues":
+			assert.Equal(t, "needs fixing", r.URL.Query().Get("q"))
+			w.Write([]byte(`{"results": [{"id": "TRK-1", "summary": "One", "status": {"name": "Open"}}, {"id": "TRK-2", "summary": "Two"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/projects/TRK/issues":
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.NoError(t, json.Unmarshal(body, &created))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": "TRK-3", "summary": "New \"thing\""}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/projects/TRK/issues/TRK-3/comments":
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.NoError(t, json.Unmarshal(body, &comment))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer server.Close()
+
+	results, err := provider.SearchIssues("needs fixing")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "One", results[0].Title)
+	assert.Equal(t, "open", *results[0].State)
+	assert.Nil(t, results[1].State)
+
+	issue, err := provider.CreateIssue(&gits.GitIssue{Title: `New "thing"`, Body: "Please"})
+	require.NoError(t, err)
+	assert.Equal(t, "TRK-3", issue.Key)
+	assert.Equal(t, map[string]interface{}{"summary": `New "thing"`, "description": "Please"}, created)
+
+	err = provider.CreateIssueComment("TRK-3", "Thanks")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"text": "Thanks"}, comment)
+
+	_, err = provider.SearchIssuesClosedSince(time.Now())
+	assert.Error(t, err)
+}
+
+func TestIssueProviderRegistry(t *testing.T) {
+	t.Parallel()
+
+	assert.Contains(t, RegisteredKinds(), Jira)
+	assert.Contains(t, RegisteredKinds(), REST)
+	assert.Contains(t, IssueTrackerKinds, REST)
+	assert.Equal(t, "https://id.atlassian.com/manage/api-tokens", ProviderAccessTokenURL(Jira, "https://myorg.atlassian.net"))
+	assert.Equal(t, "", ProviderAccessTokenURL(REST, "https://tracker.example.com"))
+
+	_, err := CreateIssueProvider("unknown", &auth.AuthServer{URL: "https://tracker.example.com"}, nil, "", true, nil)
+	assert.Error(t, err)
+
+	gitTracker := &GitIssueProvider{}
+	assert.Equal(t, Git, GetIssueProvider(gitTracker))
+	assert.Equal(t, GitIssueKeyRegex, IssueKeyRegex(gitTracker))
+	assert.Equal(t, JiraIssueKeyRegex, IssueKeyRegex(&JiraService{}))
+}
@@ -56,6 +56,7 @@ type PromoteStepActivityKey struct {
 
 type PromotePullRequestFn func(*v1.PipelineActivity, *v1.PipelineActivityStep, *v1.PromoteActivityStep, *v1.PromotePullRequestStep) error
 type PromoteUpdateFn func(*v1.PipelineActivity, *v1.PipelineActivityStep, *v1.PromoteActivityStep, *v1.PromoteUpdateStep) error
+type PromoteCanaryFn func(*v1.PipelineActivity, *v1.PipelineActivityStep, *v1.PromoteActivityStep, *v1.PromoteCanaryStep) error
 
 type PipelineDetails struct {
 	GitOwner      string
@@ -681,6 +682,25 @@ func (k *PromoteStepActivityKey) GetOrCreatePromoteUpdate(jxClient versioned.Int
 	return a, s, p, p.Update, created, err
 }
 
+// GetOrCreatePromoteCanary gets or creates the PromoteCanary for the key
+func (k *PromoteStepActivityKey) GetOrCreatePromoteCanary(jxClient versioned.Interface, ns string) (*v1.PipelineActivity, *v1.PipelineActivityStep, *v1.PromoteActivityStep, *v1.PromoteCanaryStep, bool, error) {
+	a, s, p, created, err := k.GetOrCreatePromote(jxClient, ns)
+	if err != nil {
+		return nil, nil, nil, nil, created, err
+	}
+	if p.Canary == nil {
+		created = true
+		p.Canary = &v1.PromoteCanaryStep{
+			CoreActivityStep: v1.CoreActivityStep{
+				StartedTimestamp: &metav1.Time{
+					Time: time.Now(),
+				},
+			},
+		}
+	}
+	return a, s, p, p.Canary, created, err
+}
+
 //OnPromotePullRequest updates activities on a Promote PR
 func (k *PromoteStepActivityKey) OnPromotePullRequest(jxClient versioned.Interface, ns string, fn PromotePullRequestFn) error {
 	if !k.IsValid() {
@@ -741,6 +761,33 @@ func (k *PromoteStepActivityKey) OnPromoteUpdate(jxClient versioned.Interface, n
 	return err
 }
 
+// OnPromoteCanary updates activities on the canary analysis of a Promote
+func (k *PromoteStepActivityKey) OnPromoteCanary(jxClient versioned.Interface, ns string, fn PromoteCanaryFn) error {
+	if !k.IsValid() {
+		return nil
+	}
+	activities := jxClient.JenkinsV1().PipelineActivities(ns)
+	if activities == nil {
+		log.Logger().Warn("Warning: no PipelineActivities client available!")
+		return nil
+	}
+	a, s, ps, p, added, err := k.GetOrCreatePromoteCanary(jxClient, ns)
+	if err != nil {
+		return err
+	}
+	p1 := *p
+	err = fn(a, s, ps, p)
+	if err != nil {
+		return err
+	}
+	p2 := *p
+
+	if added || !reflect.DeepEqual(p1, p2) {
+		_, err = activities.PatchUpdate(a)
+	}
+	return err
+}
+
 func asYaml(activity *v1.PipelineActivity) string {
 	data, err := yaml.Marshal(activity)
 	if err == nil {
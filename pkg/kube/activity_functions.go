@@ -27,6 +27,7 @@ func CompletePromote(p *v1.PromoteActivityStep) error {
 		}
 	}
 	p.Status = v1.ActivityStatusTypeSucceeded
+	p.Phase = v1.PromotePhaseSucceeded
 	return nil
 }
 
@@ -38,10 +39,12 @@ func FailedPromote(p *v1.PromoteActivityStep) error {
 		}
 	}
 	p.Status = v1.ActivityStatusTypeFailed
+	p.Phase = v1.PromotePhaseFailed
 	return nil
 }
 
 func StartPromotionPullRequest(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromotePullRequestStep) error {
+	advancePromotePhase(ps, v1.PromotePhasePullRequest)
 	StartPromote(ps)
 	if p.StartedTimestamp == nil {
 		p.StartedTimestamp = &metav1.Time{
@@ -61,6 +64,7 @@ func StartPromotionPullRequest(a *v1.PipelineActivity, s *v1.PipelineActivitySte
 }
 
 func StartPromotionUpdate(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteUpdateStep) error {
+	advancePromotePhase(ps, v1.PromotePhaseMerged)
 	StartPromote(ps)
 	pullRequest := ps.PullRequest
 	if pullRequest != nil {
@@ -84,6 +88,7 @@ func StartPromotionUpdate(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps
 }
 
 func CompletePromotionPullRequest(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromotePullRequestStep) error {
+	advancePromotePhase(ps, v1.PromotePhaseMerged)
 	if p.StartedTimestamp == nil {
 		p.StartedTimestamp = &metav1.Time{
 			Time: time.Now(),
@@ -152,3 +157,77 @@ func FailedPromotionUpdate(a *v1.PipelineActivity, s *v1.PipelineActivityStep, p
 	p.Status = v1.ActivityStatusTypeFailed
 	return nil
 }
+
+func StartPromotionCanary(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteCanaryStep) error {
+	advancePromotePhase(ps, v1.PromotePhaseCanary)
+	StartPromote(ps)
+	update := ps.Update
+	if update != nil {
+		completePromotionUpdateStep(update)
+	}
+	if p.StartedTimestamp == nil {
+		p.StartedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	if p.Status != v1.ActivityStatusTypeRunning {
+		p.Status = v1.ActivityStatusTypeRunning
+	}
+	return nil
+}
+
+func CompletePromotionCanary(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteCanaryStep) error {
+	StartPromotionCanary(a, s, ps, p)
+	if p.CompletedTimestamp == nil {
+		p.CompletedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	p.Status = v1.ActivityStatusTypeSucceeded
+	return nil
+}
+
+func FailedPromotionCanary(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteCanaryStep) error {
+	StartPromotionCanary(a, s, ps, p)
+	FailedPromote(ps)
+	if p.CompletedTimestamp == nil {
+		p.CompletedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	p.Status = v1.ActivityStatusTypeFailed
+	return nil
+}
+
+// completePromotionUpdateStep marks the update as succeeded without completing the promotion as the canary analysis
+// of the new version has still to complete
+func completePromotionUpdateStep(p *v1.PromoteUpdateStep) {
+	if p.StartedTimestamp == nil {
+		p.StartedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	if p.CompletedTimestamp == nil {
+		p.CompletedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	p.Status = v1.ActivityStatusTypeSucceeded
+}
+
+// promotePhaseOrder is the order of the phases of a promotion
+var promotePhaseOrder = map[v1.PromotePhase]int{
+	v1.PromotePhaseNone:        0,
+	v1.PromotePhasePullRequest: 1,
+	v1.PromotePhaseMerged:      2,
+	v1.PromotePhaseCanary:      3,
+	v1.PromotePhaseSucceeded:   4,
+	v1.PromotePhaseFailed:      4,
+}
+
+// advancePromotePhase moves the promotion on to the given phase unless it has already progressed further
+func advancePromotePhase(p *v1.PromoteActivityStep, phase v1.PromotePhase) {
+	if p != nil && promotePhaseOrder[p.Phase] < promotePhaseOrder[phase] {
+		p.Phase = phase
+	}
+}
@@ -509,3 +509,55 @@ func validatePipelineID(t *testing.T, pID kube.PipelineID, expectedID string, ex
 	assert.Equal(t, expectedID, pID.ID)
 	assert.Equal(t, expectedName, pID.Name)
 }
+
+func TestOnPromoteCanary(t *testing.T) {
+	t.Parallel()
+
+	jxClient := jxfake.NewSimpleClientset()
+	ns := "jx"
+	key := kube.PromoteStepActivityKey{
+		PipelineActivityKey: kube.PipelineActivityKey{
+			Name:     "myorg-myapp-master-3",
+			Pipeline: "myorg/myapp/master",
+			Build:    "3",
+			GitInfo: &gits.GitRepository{
+				Name:         "myapp",
+				Organisation: "myorg",
+				URL:          "https://github.com/myorg/myapp",
+			},
+		},
+		Environment: "production",
+	}
+	err := key.OnPromoteUpdate(jxClient, ns, kube.StartPromotionUpdate)
+	require.NoError(t, err)
+
+	progress := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteCanaryStep) error {
+		kube.StartPromotionCanary(a, s, ps, p)
+		p.Phase = "Progressing"
+		p.Weight = 20
+		return nil
+	}
+	err = key.OnPromoteCanary(jxClient, ns, progress)
+	require.NoError(t, err)
+
+	activity, err := jxClient.JenkinsV1().PipelineActivities(ns).Get(key.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, activity.Spec.Steps, 1)
+	promote := activity.Spec.Steps[0].Promote
+	require.NotNil(t, promote)
+	require.NotNil(t, promote.Canary)
+	assert.Equal(t, v1.ActivityStatusTypeSucceeded, promote.Update.Status, "the update completes when the canary analysis starts")
+	assert.Equal(t, v1.ActivityStatusTypeRunning, promote.Canary.Status)
+	assert.Equal(t, v1.ActivityStatusTypeRunning, promote.Status)
+	assert.Equal(t, 20, promote.Canary.Weight)
+
+	err = key.OnPromoteCanary(jxClient, ns, kube.FailedPromotionCanary)
+	require.NoError(t, err)
+
+	activity, err = jxClient.JenkinsV1().PipelineActivities(ns).Get(key.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	promote = activity.Spec.Steps[0].Promote
+	assert.Equal(t, v1.ActivityStatusTypeFailed, promote.Canary.Status)
+	assert.Equal(t, v1.ActivityStatusTypeFailed, promote.Status)
+	assert.NotNil(t, promote.Canary.CompletedTimestamp)
+}
@@ -92,6 +92,10 @@ func RegisterPipelineCRDs(apiClient apiextensionsclientset.Interface) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to register the Pipeline Structure CRD")
 	}
+	err = RegisterBuildCounterCRD(apiClient)
+	if err != nil {
+		return errors.Wrap(err, "failed to register the Build Counter CRD")
+	}
 	err = RegisterPluginCRD(apiClient)
 	if err != nil {
 		return errors.Wrap(err, "failed to register the Plugin CRD")
@@ -248,6 +252,34 @@ func RegisterPipelineStructureCRD(apiClient apiextensionsclientset.Interface) er
 	return RegisterCRD(apiClient, name, names, columns, jenkinsio.GroupName, jenkinsio.Package, jenkinsio.Version)
 }
 
+// RegisterBuildCounterCRD ensures that the CRD is registered for BuildCounter
+func RegisterBuildCounterCRD(apiClient apiextensionsclientset.Interface) error {
+	name := "buildcounters." + jenkinsio.GroupName
+	names := &v1beta1.CustomResourceDefinitionNames{
+		Kind:       "BuildCounter",
+		ListKind:   "BuildCounterList",
+		Plural:     "buildcounters",
+		Singular:   "buildcounter",
+		ShortNames: []string{"buildcounter"},
+		Categories: []string{"all"},
+	}
+	columns := []v1beta1.CustomResourceColumnDefinition{
+		{
+			Name:        "Pipeline",
+			Type:        "string",
+			Description: "The pipeline",
+			JSONPath:    ".spec.pipeline",
+		},
+		{
+			Name:        "Build",
+			Type:        "integer",
+			Description: "The last build number issued for the pipeline",
+			JSONPath:    ".spec.lastBuildNumber",
+		},
+	}
+	return RegisterCRD(apiClient, name, names, columns, jenkinsio.GroupName, jenkinsio.Package, jenkinsio.Version)
+}
+
 // RegisterFactCRD ensures that the CRD is registered for Fact
 func RegisterFactCRD(apiClient apiextensionsclientset.Interface) error {
 	name := "facts." + jenkinsio.GroupName
@@ -0,0 +1,381 @@
+package kube
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LabelDeploymentWindowOverride the label which, when set to true on an Environment or added to a promotion Pull
+	// Request, lets promotions happen outside of the deployment windows of the Environment
+	LabelDeploymentWindowOverride = "jenkins.io/deployment-window-override"
+
+	// maxDeploymentWindowSearch is how far ahead CheckDeploymentWindows looks for the next open deployment window
+	maxDeploymentWindowSearch = 366 * 24 * time.Hour
+)
+
+// DeploymentWindowOverridden returns true if the deployment windows of the Environment are overridden by its label
+func DeploymentWindowOverridden(env *v1.Environment) bool {
+	return env.Labels != nil && strings.ToLower(env.Labels[LabelDeploymentWindowOverride]) == "true"
+}
+
+// PullRequestOverridesDeploymentWindows returns true if the promotion Pull Request has the override label
+func PullRequestOverridesDeploymentWindows(pr *gits.GitPullRequest) bool {
+	if pr == nil {
+		return false
+	}
+	for _, label := range pr.Labels {
+		if label != nil && label.Name != nil && *label.Name == LabelDeploymentWindowOverride {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPullRequestDeploymentWindows returns an empty string if the promotion Pull Request on the repository of the
+// Environment can be merged at the given time, otherwise the reason it cannot
+func CheckPullRequestDeploymentWindows(env *v1.Environment, pr *gits.GitPullRequest, t time.Time) (string, error) {
+	if PullRequestOverridesDeploymentWindows(pr) {
+		return "", nil
+	}
+	return CheckDeploymentWindows(env, t)
+}
+
+// CheckDeploymentWindows returns an empty string if an automatic promotion to the Environment is allowed at the
+// given time, otherwise the reason it is not allowed
+func CheckDeploymentWindows(env *v1.Environment, t time.Time) (string, error) {
+	windows := env.Spec.DeploymentWindows
+	if windows == nil || DeploymentWindowOverridden(env) {
+		return "", nil
+	}
+	compiled, err := compileDeploymentWindows(windows)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid deployment windows for environment %s", env.Name)
+	}
+	reason := compiled.check(env.Name, t)
+	if reason == "" {
+		return "", nil
+	}
+	next := compiled.next(t)
+	if next != nil {
+		reason += fmt.Sprintf(" until %s", next.Format("2006-01-02 15:04 MST"))
+	}
+	return reason, nil
+}
+
+// compiledWindows are deployment windows with their schedules parsed and time zones loaded
+type compiledWindows struct {
+	loc   *time.Location
+	allow []compiledWindow
+	deny  []compiledWindow
+}
+
+type compiledWindow struct {
+	name     string
+	start    *time.Time
+	end      *time.Time
+	loc      *time.Location
+	schedule *WindowSchedule
+}
+
+func compileDeploymentWindows(windows *v1.DeploymentWindows) (*compiledWindows, error) {
+	loc, err := loadLocation(windows.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	answer := &compiledWindows{loc: loc}
+	answer.allow, err = compileWindows(windows.Allow, loc)
+	if err != nil {
+		return nil, err
+	}
+	answer.deny, err = compileWindows(windows.Deny, loc)
+	if err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+func compileWindows(windows []v1.DeploymentWindow, defaultLoc *time.Location) ([]compiledWindow, error) {
+	answer := []compiledWindow{}
+	for i, window := range windows {
+		c := compiledWindow{
+			name: window.Name,
+			loc:  defaultLoc,
+		}
+		if c.name == "" {
+			c.name = strconv.Itoa(i)
+		}
+		if window.Schedule == "" && window.Start == nil && window.End == nil {
+			return nil, fmt.Errorf("deployment window %s has no schedule, start or end", c.name)
+		}
+		if window.Start != nil {
+			c.start = &window.Start.Time
+		}
+		if window.End != nil {
+			c.end = &window.End.Time
+		}
+		if window.TimeZone != "" {
+			loc, err := loadLocation(window.TimeZone)
+			if err != nil {
+				return nil, err
+			}
+			c.loc = loc
+		}
+		if window.Schedule != "" {
+			schedule, err := ParseWindowSchedule(window.Schedule)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid schedule for deployment window %s", c.name)
+			}
+			c.schedule = schedule
+		}
+		answer = append(answer, c)
+	}
+	return answer, nil
+}
+
+// isOpen returns true if the time is within the fixed period and matches the schedule of the window
+func (w *compiledWindow) isOpen(t time.Time) bool {
+	if w.start != nil && t.Before(*w.start) {
+		return false
+	}
+	if w.end != nil && !t.Before(*w.end) {
+		return false
+	}
+	return w.schedule == nil || w.schedule.Matches(t.In(w.loc))
+}
+
+// check returns the reason promotions are not allowed at the given time, or an empty string
+func (c *compiledWindows) check(envName string, t time.Time) string {
+	for i := range c.deny {
+		if c.deny[i].isOpen(t) {
+			return fmt.Sprintf("environment %s is frozen by deployment window %s", envName, c.deny[i].name)
+		}
+	}
+	if len(c.allow) == 0 {
+		return ""
+	}
+	for i := range c.allow {
+		if c.allow[i].isOpen(t) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("environment %s is outside of its deployment windows", envName)
+}
+
+// next returns the first minute at or after the time when promotions are allowed, or nil if there isn't one within
+// a year. Rather than checking every minute it only checks the times at which a window can open or close
+func (c *compiledWindows) next(t time.Time) *time.Time {
+	if c.check("", t) == "" {
+		answer := t.In(c.loc)
+		return &answer
+	}
+	end := t.Add(maxDeploymentWindowSearch)
+	for next := c.nextChange(t, end); next.Before(end); next = c.nextChange(next, end) {
+		if c.check("", next) == "" {
+			answer := next.In(c.loc)
+			return &answer
+		}
+	}
+	return nil
+}
+
+// nextChange returns the first time after the given time at which any of the windows can open or close, or the end
+// if there isn't one before it
+func (c *compiledWindows) nextChange(t time.Time, end time.Time) time.Time {
+	answer := end
+	for _, windows := range [][]compiledWindow{c.allow, c.deny} {
+		for i := range windows {
+			next := windows[i].nextChange(t)
+			if next != nil && next.Before(answer) {
+				answer = *next
+			}
+		}
+	}
+	return answer
+}
+
+// nextChange returns the first time after the given time at which the window can open or close, or nil if it never
+// changes again
+func (w *compiledWindow) nextChange(t time.Time) *time.Time {
+	var answer *time.Time
+	earliest := func(next time.Time) {
+		if next.After(t) && (answer == nil || next.Before(*answer)) {
+			answer = &next
+		}
+	}
+	if w.start != nil {
+		earliest(*w.start)
+	}
+	if w.end != nil {
+		earliest(*w.end)
+	}
+	if w.schedule != nil {
+		earliest(w.schedule.nextChange(t.In(w.loc)))
+	}
+	return answer
+}
+
+func loadLocation(timeZone string) (*time.Location, error) {
+	if timeZone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid time zone %s", timeZone)
+	}
+	return loc, nil
+}
+
+// WindowSchedule is a parsed cron-like deployment window schedule which matches minutes of time
+type WindowSchedule struct {
+	minutes     map[int]bool
+	hours       map[int]bool
+	daysOfMonth map[int]bool
+	months      map[int]bool
+	daysOfWeek  map[int]bool
+	// anyDayOfMonth and anyDayOfWeek are true if the fields start with '*', such as '*' or '*/2', as cron matches
+	// either day field when both are restricted
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+var (
+	monthNames = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8,
+		"sep": 9, "oct": 10, "nov": 11, "dec": 12}
+	dayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+// ParseWindowSchedule parses a cron-like schedule 'minute hour day-of-month month day-of-week'. Each field may be
+// '*', a number, a range such as '9-17', a step such as '*/15' or '0-30/10', or a comma separated list of these.
+// Months and days of the week may also be given by their first three letters such as 'jan' or 'mon-fri'.
+func ParseWindowSchedule(text string) (*WindowSchedule, error) {
+	fields := strings.Fields(text)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule '%s' should have 5 fields 'minute hour day-of-month month day-of-week'", text)
+	}
+	var err error
+	schedule := &WindowSchedule{
+		anyDayOfMonth: strings.HasPrefix(fields[2], "*"),
+		anyDayOfWeek:  strings.HasPrefix(fields[4], "*"),
+	}
+	if schedule.minutes, err = parseScheduleField(fields[0], 0, 59, nil); err != nil {
+		return nil, errors.Wrap(err, "invalid minute")
+	}
+	if schedule.hours, err = parseScheduleField(fields[1], 0, 23, nil); err != nil {
+		return nil, errors.Wrap(err, "invalid hour")
+	}
+	if schedule.daysOfMonth, err = parseScheduleField(fields[2], 1, 31, nil); err != nil {
+		return nil, errors.Wrap(err, "invalid day of month")
+	}
+	if schedule.months, err = parseScheduleField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, errors.Wrap(err, "invalid month")
+	}
+	if schedule.daysOfWeek, err = parseScheduleField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, errors.Wrap(err, "invalid day of week")
+	}
+	// 7 is also Sunday
+	if schedule.daysOfWeek[7] {
+		schedule.daysOfWeek[0] = true
+	}
+	return schedule, nil
+}
+
+// Matches returns true if the minute of the time matches the schedule
+func (s *WindowSchedule) Matches(t time.Time) bool {
+	return s.minutes[t.Minute()] && s.hours[t.Hour()] && s.matchesDay(t)
+}
+
+// matchesDay returns true if the day of the time matches the schedule
+func (s *WindowSchedule) matchesDay(t time.Time) bool {
+	if !s.months[int(t.Month())] {
+		return false
+	}
+	dayOfMonth := s.daysOfMonth[t.Day()]
+	dayOfWeek := s.daysOfWeek[int(t.Weekday())]
+	if s.anyDayOfMonth || s.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+// nextChange returns the next day, hour or minute after the time at which the schedule can start or stop matching,
+// skipping the rest of the day or hour when the whole of it matches or does not match
+func (s *WindowSchedule) nextChange(t time.Time) time.Time {
+	nextDay := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	nextHour := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	nextMinute := t.Truncate(time.Minute).Add(time.Minute)
+	allMinutes := len(s.minutes) == 60
+	allHours := len(s.hours) == 24
+	switch {
+	case !s.matchesDay(t):
+		return nextDay
+	case !s.hours[t.Hour()]:
+		return nextHour
+	case !allMinutes:
+		return nextMinute
+	case allHours:
+		return nextDay
+	default:
+		return nextHour
+	}
+}
+
+func parseScheduleField(field string, min int, max int, names map[string]int) (map[int]bool, error) {
+	answer := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in '%s'", part)
+			}
+			part = part[:i]
+		}
+		from, to := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			from, err = parseScheduleValue(bounds[0], min, max, names)
+			if err != nil {
+				return nil, err
+			}
+			to = from
+			if len(bounds) == 2 {
+				to, err = parseScheduleValue(bounds[1], min, max, names)
+				if err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				to = max
+			}
+			if to < from {
+				return nil, fmt.Errorf("invalid range '%s'", part)
+			}
+		}
+		for v := from; v <= to; v += step {
+			answer[v] = true
+		}
+	}
+	return answer, nil
+}
+
+func parseScheduleValue(text string, min int, max int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(text)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value '%s'", text)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("value %d is not between %d and %d", v, min, max)
+	}
+	return v, nil
+}
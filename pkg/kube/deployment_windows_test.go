@@ -0,0 +1,162 @@
+package kube_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseWindowSchedule(t *testing.T) {
+	t.Parallel()
+
+	// Monday 2019-03-04
+	monday10am := time.Date(2019, time.March, 4, 10, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		schedule string
+		time     time.Time
+		matches  bool
+	}{
+		{"* * * * *", monday10am, true},
+		{"* 9-16 * * 1-5", monday10am, true},
+		{"* 9-16 * * mon-fri", monday10am, true},
+		{"* 9-16 * * sat,sun", monday10am, false},
+		{"* 11-16 * * *", monday10am, false},
+		{"*/15 * * * *", monday10am, true},
+		{"*/20 * * * *", monday10am, false},
+		{"0-30/10 10 * * *", monday10am, true},
+		{"* * 4 mar *", monday10am, true},
+		{"* * 4 apr *", monday10am, false},
+		// when both day fields are restricted either can match
+		{"* * 1 * 1", monday10am, true},
+		{"* * 1 * 2", monday10am, false},
+		{"* * * * 0", time.Date(2019, time.March, 3, 10, 0, 0, 0, time.UTC), true},
+		{"* * * * 7", time.Date(2019, time.March, 3, 10, 0, 0, 0, time.UTC), true},
+		// a step over every day of the month is not a restriction, so the day of the week must match too
+		{"* * */2 * 1", monday10am, false},
+		{"* * */2 * 1", time.Date(2019, time.March, 11, 10, 0, 0, 0, time.UTC), true},
+	}
+	for _, tc := range testCases {
+		schedule, err := kube.ParseWindowSchedule(tc.schedule)
+		require.NoError(t, err, "schedule %s", tc.schedule)
+		assert.Equal(t, tc.matches, schedule.Matches(tc.time), "schedule %s at %s", tc.schedule, tc.time)
+	}
+
+	for _, invalid := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* 5-1 * * *", "*/0 * * * *", "* * * * bob"} {
+		_, err := kube.ParseWindowSchedule(invalid)
+		assert.Error(t, err, "schedule '%s' should be invalid", invalid)
+	}
+}
+
+func TestCheckDeploymentWindows(t *testing.T) {
+	t.Parallel()
+
+	freezeStart := time.Date(2019, time.December, 20, 0, 0, 0, 0, time.UTC)
+	freezeEnd := time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "production",
+		},
+		Spec: v1.EnvironmentSpec{
+			DeploymentWindows: &v1.DeploymentWindows{
+				TimeZone: "America/New_York",
+				Allow: []v1.DeploymentWindow{
+					{Name: "business-hours", Schedule: "* 9-16 * * mon-fri"},
+				},
+				Deny: []v1.DeploymentWindow{
+					{Name: "holidays", Start: &metav1.Time{Time: freezeStart}, End: &metav1.Time{Time: freezeEnd}},
+				},
+			},
+		},
+	}
+
+	// 10am in New York on Tuesday 2019-12-03
+	open := time.Date(2019, time.December, 3, 15, 0, 0, 0, time.UTC)
+	reason, err := kube.CheckDeploymentWindows(env, open)
+	require.NoError(t, err)
+	assert.Equal(t, "", reason)
+
+	// 8pm in New York on the same day
+	evening := time.Date(2019, time.December, 4, 1, 0, 0, 0, time.UTC)
+	reason, err = kube.CheckDeploymentWindows(env, evening)
+	require.NoError(t, err)
+	assert.Equal(t, "environment production is outside of its deployment windows until 2019-12-04 09:00 EST", reason)
+
+	frozen := time.Date(2019, time.December, 23, 15, 0, 0, 0, time.UTC)
+	reason, err = kube.CheckDeploymentWindows(env, frozen)
+	require.NoError(t, err)
+	assert.Equal(t, "environment production is frozen by deployment window holidays until 2020-01-02 09:00 EST", reason)
+
+	// the override label on the environment or the Pull Request lets the promotion through
+	overridden := env.DeepCopy()
+	overridden.Labels = map[string]string{kube.LabelDeploymentWindowOverride: "true"}
+	reason, err = kube.CheckDeploymentWindows(overridden, frozen)
+	require.NoError(t, err)
+	assert.Equal(t, "", reason)
+
+	labelName := kube.LabelDeploymentWindowOverride
+	pr := &gits.GitPullRequest{Labels: []*gits.Label{{Name: &labelName}}}
+	reason, err = kube.CheckPullRequestDeploymentWindows(env, pr, frozen)
+	require.NoError(t, err)
+	assert.Equal(t, "", reason)
+	reason, err = kube.CheckPullRequestDeploymentWindows(env, &gits.GitPullRequest{}, frozen)
+	require.NoError(t, err)
+	assert.NotEqual(t, "", reason)
+}
+
+func TestCheckDeploymentWindowsSkipsAhead(t *testing.T) {
+	t.Parallel()
+
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "production",
+		},
+		Spec: v1.EnvironmentSpec{
+			DeploymentWindows: &v1.DeploymentWindows{
+				Allow: []v1.DeploymentWindow{
+					{Name: "leap-day", Schedule: "30 9 29 feb *"},
+				},
+			},
+		},
+	}
+	reason, err := kube.CheckDeploymentWindows(env, time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "environment production is outside of its deployment windows until 2020-02-29 09:30 UTC", reason)
+
+	reason, err = kube.CheckDeploymentWindows(env, time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "environment production is outside of its deployment windows", reason, "there is no leap day within a year")
+}
+
+func TestCheckDeploymentWindowsInvalid(t *testing.T) {
+	t.Parallel()
+
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "production",
+		},
+		Spec: v1.EnvironmentSpec{
+			DeploymentWindows: &v1.DeploymentWindows{
+				TimeZone: "Nowhere/Special",
+			},
+		},
+	}
+	_, err := kube.CheckDeploymentWindows(env, time.Now())
+	assert.Error(t, err)
+
+	env.Spec.DeploymentWindows = &v1.DeploymentWindows{
+		Deny: []v1.DeploymentWindow{{Name: "empty"}},
+	}
+	_, err = kube.CheckDeploymentWindows(env, time.Now())
+	assert.Error(t, err)
+
+	env.Spec.DeploymentWindows = nil
+	reason, err := kube.CheckDeploymentWindows(env, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "", reason)
+}
@@ -847,6 +847,34 @@ func GetEnvironmentsByPrURL(jxClient versioned.Interface, ns string, prURL strin
 	return nil, fmt.Errorf("no environment found for PR '%s'", prURL)
 }
 
+// GetEnvironmentByGitURL finds the permanent environment whose source repository is the given git URL or returns nil
+// if there is none
+func GetEnvironmentByGitURL(jxClient versioned.Interface, ns string, gitURL string) (*v1.Environment, error) {
+	gitInfo, err := gits.ParseGitURL(gitURL)
+	if err != nil {
+		return nil, err
+	}
+	envs, err := jxClient.JenkinsV1().Environments(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range envs.Items {
+		env := &envs.Items[i]
+		if env.Spec.Kind != v1.EnvironmentKindTypePermanent || env.Spec.Source.URL == "" {
+			continue
+		}
+		envInfo, err := gits.ParseGitURL(env.Spec.Source.URL)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(envInfo.Host, gitInfo.Host) && strings.EqualFold(envInfo.Organisation, gitInfo.Organisation) &&
+			strings.EqualFold(envInfo.Name, gitInfo.Name) {
+			return env, nil
+		}
+	}
+	return nil, nil
+}
+
 // GetEnvironments returns the namespace name for a given environment
 func GetEnvironmentNamespace(jxClient versioned.Interface, ns, environment string) (string, error) {
 	env, err := jxClient.JenkinsV1().Environments(ns).Get(environment, metav1.GetOptions{})
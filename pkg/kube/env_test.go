@@ -283,3 +283,29 @@ func TestGetPreviewEnvironmentReleaseName(t *testing.T) {
 		}
 	}
 }
+
+func TestGetEnvironmentByGitURL(t *testing.T) {
+	t.Parallel()
+
+	jxClient := versiond_mocks.NewSimpleClientset(
+		kube.NewPermanentEnvironmentWithGit("staging", "https://github.com/myorg/environment-mycluster-staging.git"),
+		kube.NewPermanentEnvironmentWithGit("production", "https://github.com/myorg/environment-mycluster-production.git"),
+		kube.NewPreviewEnvironment("jx-myorg-myapp-pr-1"),
+	)
+
+	env, err := kube.GetEnvironmentByGitURL(jxClient, "jx", "https://github.com/MyOrg/environment-mycluster-production")
+	assert.NoError(t, err)
+	if assert.NotNil(t, env) {
+		assert.Equal(t, "production", env.Name)
+	}
+
+	env, err = kube.GetEnvironmentByGitURL(jxClient, "jx", "git@github.com:myorg/environment-mycluster-staging.git")
+	assert.NoError(t, err)
+	if assert.NotNil(t, env) {
+		assert.Equal(t, "staging", env.Name)
+	}
+
+	env, err = kube.GetEnvironmentByGitURL(jxClient, "jx", "https://github.com/myorg/myapp.git")
+	assert.NoError(t, err)
+	assert.Nil(t, env)
+}
@@ -0,0 +1,111 @@
+package pipline_events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// CloudEventsSpecVersion the version of the CloudEvents specification the events are written in
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventsContentType the content type of an event in the CloudEvents structured JSON format
+	CloudEventsContentType = "application/cloudevents+json"
+
+	// EventTypeActivity the type of the events for PipelineActivities
+	EventTypeActivity = "io.jenkins-x.pipeline.activity"
+	// EventTypeRelease the type of the events for Releases
+	EventTypeRelease = "io.jenkins-x.release"
+	// EventTypePromotion the type of the events for promotions
+	EventTypePromotion = "io.jenkins-x.promotion"
+	// EventTypePreviewPrefix the prefix of the type of the events for previews, which is followed by the action
+	EventTypePreviewPrefix = "io.jenkins-x.preview."
+	// EventTypeCommitStatus the type of the events for CommitStatuses
+	EventTypeCommitStatus = "io.jenkins-x.commitstatus"
+
+	defaultEventSource = "jenkins-x"
+)
+
+// CloudEvent is an event in the CloudEvents JSON format, see https://github.com/cloudevents/spec
+type CloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            string      `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data"`
+}
+
+// newCloudEvent creates a new event with a unique ID
+func newCloudEvent(source string, eventType string, subject string, data interface{}) (*CloudEvent, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	if source == "" {
+		source = defaultEventSource
+	}
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              id.String(),
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC().Format(time.RFC3339),
+		DataContentType: "application/json",
+		Data:            data,
+	}, nil
+}
+
+// cloudEventSink sends the events created by a cloudEventsProvider
+type cloudEventSink interface {
+	sendEvent(event *CloudEvent) error
+}
+
+// cloudEventsProvider implements PipelineEventsProvider by wrapping each resource in a CloudEvent and passing it to
+// the sink
+type cloudEventsProvider struct {
+	source string
+	sink   cloudEventSink
+}
+
+func (p *cloudEventsProvider) send(eventType string, subject string, data interface{}) error {
+	event, err := newCloudEvent(p.source, eventType, subject, data)
+	if err != nil {
+		return err
+	}
+	return p.sink.sendEvent(event)
+}
+
+func (p *cloudEventsProvider) SendActivity(a *v1.PipelineActivity) error {
+	return p.send(EventTypeActivity, a.Name, a)
+}
+
+func (p *cloudEventsProvider) SendRelease(r *v1.Release) error {
+	return p.send(EventTypeRelease, r.Name, r)
+}
+
+func (p *cloudEventsProvider) SendPromotion(e *PromotionEvent) error {
+	return p.send(EventTypePromotion, fmt.Sprintf("%s/%s", e.Environment, e.Application), e)
+}
+
+func (p *cloudEventsProvider) SendPreview(e *PreviewEvent) error {
+	subject := ""
+	if e.Environment != nil {
+		subject = e.Environment.Name
+	}
+	return p.send(EventTypePreviewPrefix+string(e.Action), subject, e)
+}
+
+func (p *cloudEventsProvider) SendCommitStatus(s *v1.CommitStatus) error {
+	return p.send(EventTypeCommitStatus, s.Name, s)
+}
+
+// Close does nothing as sending CloudEvents holds no resources unless the sink overrides it
+func (p *cloudEventsProvider) Close() error {
+	return nil
+}
@@ -0,0 +1,153 @@
+package pipline_events
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWebhookProviderPostsCloudEvents(t *testing.T) {
+	t.Parallel()
+
+	var events []map[string]interface{}
+	var contentTypes, authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		event := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(body, &event))
+		events = append(events, event)
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	provider, err := NewWebhookProvider(&auth.AuthServer{URL: server.URL}, &auth.UserAuth{Username: "jx", ApiToken: "secret"})
+	require.NoError(t, err)
+
+	err = provider.SendPromotion(&PromotionEvent{Application: "myapp", Version: "1.2.3", Environment: "staging"})
+	require.NoError(t, err)
+	err = provider.SendPreview(&PreviewEvent{
+		Action:      PreviewDeleted,
+		Environment: &v1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "jx-myorg-myapp-pr-1"}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, []string{CloudEventsContentType, CloudEventsContentType}, contentTypes)
+	assert.Equal(t, []string{"Bearer secret", "Bearer secret"}, authorizations)
+
+	assert.Equal(t, CloudEventsSpecVersion, events[0]["specversion"])
+	assert.Equal(t, EventTypePromotion, events[0]["type"])
+	assert.Equal(t, "staging/myapp", events[0]["subject"])
+	assert.NotEmpty(t, events[0]["id"])
+	assert.Equal(t, map[string]interface{}{"application": "myapp", "version": "1.2.3", "environment": "staging"}, events[0]["data"])
+
+	assert.Equal(t, "io.jenkins-x.preview.deleted", events[1]["type"])
+	assert.Equal(t, "jx-myorg-myapp-pr-1", events[1]["subject"])
+	assert.NotEqual(t, events[0]["id"], events[1]["id"])
+}
+
+func TestWebhookProviderFailsOnErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	provider, err := NewWebhookProvider(&auth.AuthServer{URL: server.URL}, nil)
+	require.NoError(t, err)
+
+	err = provider.SendActivity(&v1.PipelineActivity{ObjectMeta: metav1.ObjectMeta{Name: "myorg-myapp-master-1"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "500")
+}
+
+func TestFileProviderWritesJSONLines(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	provider := NewWriterProvider(&out)
+
+	err := provider.SendCommitStatus(&v1.CommitStatus{ObjectMeta: metav1.ObjectMeta{Name: "myorg-myapp-pr-1"}})
+	require.NoError(t, err)
+	err = provider.SendRelease(&v1.Release{ObjectMeta: metav1.ObjectMeta{Name: "myapp-1-2-3"}})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+
+	event := CloudEvent{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+	assert.Equal(t, EventTypeCommitStatus, event.Type)
+	assert.Equal(t, "myorg-myapp-pr-1", event.Subject)
+	assert.Equal(t, "jenkins-x", event.Source)
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, EventTypeRelease, event.Type)
+	assert.Equal(t, "myapp-1-2-3", event.Subject)
+}
+
+func TestFileProviderClosesFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "pipeline-events")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "events.json")
+
+	provider, err := NewFileProvider(fileName)
+	require.NoError(t, err)
+	err = provider.SendActivity(&v1.PipelineActivity{ObjectMeta: metav1.ObjectMeta{Name: "myorg-myapp-master-1"}})
+	require.NoError(t, err)
+	require.NoError(t, provider.Close())
+	require.NoError(t, provider.Close(), "closing twice is harmless")
+
+	err = provider.SendActivity(&v1.PipelineActivity{ObjectMeta: metav1.ObjectMeta{Name: "myorg-myapp-master-2"}})
+	require.Error(t, err, "the file is closed")
+
+	data, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	event := CloudEvent{}
+	require.NoError(t, json.Unmarshal(data, &event))
+	assert.Equal(t, EventTypeActivity, event.Type)
+	assert.Equal(t, "myorg-myapp-master-1", event.Subject)
+}
+
+func TestQueuedProviderSendsInTheBackground(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	provider := NewQueuedProvider(NewWriterProvider(&out), 10)
+
+	activity := &v1.PipelineActivity{ObjectMeta: metav1.ObjectMeta{Name: "myorg-myapp-master-1"}}
+	require.NoError(t, provider.SendActivity(activity))
+	// the queued event is a copy so later changes by the caller are not sent
+	activity.Name = "changed"
+	require.NoError(t, provider.SendPromotion(&PromotionEvent{Application: "myapp", Version: "1.2.3", Environment: "staging"}))
+
+	require.NoError(t, provider.Close())
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	event := CloudEvent{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+	assert.Equal(t, "myorg-myapp-master-1", event.Subject)
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, EventTypePromotion, event.Type)
+
+	assert.Error(t, provider.SendPromotion(&PromotionEvent{Application: "myapp"}), "the provider is closed")
+}
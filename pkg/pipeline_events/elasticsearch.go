@@ -44,7 +44,7 @@ func NewElasticsearchProvider(server *auth.AuthServer, user *auth.UserAuth) (Pip
 	provider := ElasticsearchProvider{
 		BaseURL:   server.URL,
 		BasicAuth: basicAuth,
-		Client:    http.DefaultClient,
+		Client:    util.GetClient(),
 	}
 
 	return &provider, nil
@@ -95,6 +95,55 @@ func (e ElasticsearchProvider) SendRelease(r *v1.Release) error {
 	return nil
 }
 
+// SendPromotion indexes the promotion, using the environment, application and version as its ID
+func (e ElasticsearchProvider) SendPromotion(p *PromotionEvent) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	id := fmt.Sprintf("%s-%s-%s", p.Environment, p.Application, p.Version)
+	return e.postEvent("promotions", id, data)
+}
+
+// SendPreview indexes the creation or deletion of a preview environment
+func (e ElasticsearchProvider) SendPreview(p *PreviewEvent) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	id := string(p.Action)
+	if p.Environment != nil {
+		id = fmt.Sprintf("%s-%s-%s", p.Environment.UID, p.Action, p.Environment.ResourceVersion)
+	}
+	return e.postEvent("previews", id, data)
+}
+
+// SendCommitStatus indexes the commit status
+func (e ElasticsearchProvider) SendCommitStatus(s *v1.CommitStatus) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return e.postEvent("commitstatuses", fmt.Sprintf("%s-%s", s.UID, s.ResourceVersion), data)
+}
+
+// Close does nothing as the provider holds no resources
+func (e ElasticsearchProvider) Close() error {
+	return nil
+}
+
+func (e ElasticsearchProvider) postEvent(index string, id string, data []byte) error {
+	var idx *Index
+	err := e.post(index, id, data, &idx)
+	if err != nil {
+		return err
+	}
+	if idx == nil || idx.Id == "" {
+		return fmt.Errorf("%s event %s not created, no elasticsearch id returned from POST", index, id)
+	}
+	return nil
+}
+
 func (e ElasticsearchProvider) SendIssue(i *ESIssue) error {
 	id := strings.Replace(i.URL, ":", "-", -1)
 	id = strings.Replace(id, "/", "-", -1)
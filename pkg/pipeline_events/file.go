@@ -0,0 +1,68 @@
+package pipline_events
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// FileProvider implements PipelineEventsProvider by writing each CloudEvent as a line of JSON, which is useful for
+// testing and for collecting the events with a log shipper
+type FileProvider struct {
+	cloudEventsProvider
+	Out   io.Writer
+	mutex sync.Mutex
+	// file is the file opened by NewFileProvider which is closed by Close
+	file *os.File
+}
+
+// NewFileProvider creates a provider which appends the events to the file, or writes them to the standard output if
+// the file name is empty or -
+func NewFileProvider(fileName string) (PipelineEventsProvider, error) {
+	if fileName == "" || fileName == "-" {
+		return NewWriterProvider(os.Stdout), nil
+	}
+	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open pipeline events file %s", fileName)
+	}
+	provider := NewWriterProvider(f)
+	provider.file = f
+	return provider, nil
+}
+
+// NewWriterProvider creates a provider which writes the events to the writer
+func NewWriterProvider(out io.Writer) *FileProvider {
+	provider := &FileProvider{
+		Out: out,
+	}
+	provider.cloudEventsProvider = cloudEventsProvider{sink: provider}
+	return provider
+}
+
+func (f *FileProvider) sendEvent(event *CloudEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s event", event.Type)
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	_, err = f.Out.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the file opened by NewFileProvider, the standard output and writers passed to NewWriterProvider are
+// left open
+func (f *FileProvider) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -4,7 +4,58 @@ import (
 	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 )
 
+const (
+	// ProviderKindElasticsearch the provider which indexes events in the pipeline-events addon's Elasticsearch
+	ProviderKindElasticsearch = "elasticsearch"
+	// ProviderKindWebhook the provider which posts CloudEvents to a webhook
+	ProviderKindWebhook = "webhook"
+	// ProviderKindFile the provider which writes CloudEvents to a file or the standard output, which is useful for testing
+	ProviderKindFile = "file"
+)
+
+// ProviderKinds the kinds of pipeline events provider
+var ProviderKinds = []string{ProviderKindElasticsearch, ProviderKindWebhook, ProviderKindFile}
+
 type PipelineEventsProvider interface {
 	SendActivity(a *v1.PipelineActivity) error
 	SendRelease(a *v1.Release) error
+
+	// SendPromotion sends an event when a version of an application is promoted to an environment
+	SendPromotion(p *PromotionEvent) error
+	// SendPreview sends an event when a preview environment is created or deleted
+	SendPreview(p *PreviewEvent) error
+	// SendCommitStatus sends an event when a commit status is reported for a pull request
+	SendCommitStatus(s *v1.CommitStatus) error
+
+	// Close releases any resources, such as open files, held by the provider
+	Close() error
+}
+
+// PromotionEvent is the promotion of a version of an application to an environment
+type PromotionEvent struct {
+	Application string `json:"application"`
+	Version     string `json:"version"`
+	Environment string `json:"environment"`
+	// Pipeline and Build identify the PipelineActivity which promoted the application
+	Pipeline string                  `json:"pipeline,omitempty"`
+	Build    string                  `json:"build,omitempty"`
+	Step     *v1.PromoteActivityStep `json:"step,omitempty"`
+}
+
+// PreviewAction is what happened to a preview environment
+type PreviewAction string
+
+const (
+	// PreviewCreated the preview environment was created or updated for a new commit
+	PreviewCreated PreviewAction = "created"
+	// PreviewDeleted the preview environment was deleted
+	PreviewDeleted PreviewAction = "deleted"
+)
+
+// PreviewEvent is the creation or deletion of a preview environment
+type PreviewEvent struct {
+	Action      PreviewAction   `json:"action"`
+	Environment *v1.Environment `json:"environment"`
+	// URL is the URL of the preview application, if it is known
+	URL string `json:"url,omitempty"`
 }
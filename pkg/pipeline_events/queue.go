@@ -0,0 +1,106 @@
+package pipline_events
+
+import (
+	"sync"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// QueuedProvider implements PipelineEventsProvider by sending the events to another provider from a background
+// goroutine, so that callers such as the event handlers of informers are not held up by a slow or unavailable events
+// server. Events are dropped when the queue is full.
+type QueuedProvider struct {
+	provider PipelineEventsProvider
+	queue    chan func(PipelineEventsProvider) error
+	done     chan struct{}
+	// lock stops events being queued while the queue is closed
+	lock   sync.RWMutex
+	closed bool
+}
+
+// NewQueuedProvider creates a provider which queues up to size events to send to the provider
+func NewQueuedProvider(provider PipelineEventsProvider, size int) *QueuedProvider {
+	q := &QueuedProvider{
+		provider: provider,
+		queue:    make(chan func(PipelineEventsProvider) error, size),
+		done:     make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (q *QueuedProvider) run() {
+	defer close(q.done)
+	for send := range q.queue {
+		err := send(q.provider)
+		if err != nil {
+			log.Logger().Warnf("Failed to send pipeline event: %s", err)
+		}
+	}
+}
+
+func (q *QueuedProvider) enqueue(send func(PipelineEventsProvider) error) error {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if q.closed {
+		return errors.New("the pipeline events provider is closed")
+	}
+	select {
+	case q.queue <- send:
+		return nil
+	default:
+		return errors.Errorf("dropped the pipeline event as the queue of %d events is full", cap(q.queue))
+	}
+}
+
+// SendActivity queues a copy of the activity, as the caller may change it before it is sent
+func (q *QueuedProvider) SendActivity(a *v1.PipelineActivity) error {
+	a = a.DeepCopy()
+	return q.enqueue(func(p PipelineEventsProvider) error {
+		return p.SendActivity(a)
+	})
+}
+
+// SendRelease queues a copy of the release, as the caller may change it before it is sent
+func (q *QueuedProvider) SendRelease(r *v1.Release) error {
+	r = r.DeepCopy()
+	return q.enqueue(func(p PipelineEventsProvider) error {
+		return p.SendRelease(r)
+	})
+}
+
+func (q *QueuedProvider) SendPromotion(e *PromotionEvent) error {
+	return q.enqueue(func(p PipelineEventsProvider) error {
+		return p.SendPromotion(e)
+	})
+}
+
+func (q *QueuedProvider) SendPreview(e *PreviewEvent) error {
+	return q.enqueue(func(p PipelineEventsProvider) error {
+		return p.SendPreview(e)
+	})
+}
+
+// SendCommitStatus queues a copy of the commit status, as the caller may change it before it is sent
+func (q *QueuedProvider) SendCommitStatus(s *v1.CommitStatus) error {
+	s = s.DeepCopy()
+	return q.enqueue(func(p PipelineEventsProvider) error {
+		return p.SendCommitStatus(s)
+	})
+}
+
+// Close sends the queued events then closes the provider
+func (q *QueuedProvider) Close() error {
+	q.lock.Lock()
+	if q.closed {
+		q.lock.Unlock()
+		return nil
+	}
+	q.closed = true
+	close(q.queue)
+	q.lock.Unlock()
+	<-q.done
+	return q.provider.Close()
+}
@@ -0,0 +1,67 @@
+package pipline_events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// WebhookProvider implements PipelineEventsProvider by posting CloudEvents to a webhook
+type WebhookProvider struct {
+	cloudEventsProvider
+	Client *http.Client
+	URL    string
+	// Token is sent as a bearer token if it is set
+	Token string
+}
+
+// NewWebhookProvider creates a provider which posts events to the server's URL, authenticating with the user's token
+// if there is one
+func NewWebhookProvider(server *auth.AuthServer, user *auth.UserAuth) (PipelineEventsProvider, error) {
+	provider := &WebhookProvider{
+		Client: util.GetClient(),
+		URL:    server.URL,
+	}
+	if user != nil {
+		provider.Token = user.ApiToken
+		if provider.Token == "" {
+			provider.Token = user.BearerToken
+		}
+	}
+	provider.cloudEventsProvider = cloudEventsProvider{sink: provider}
+	return provider, nil
+}
+
+func (w *WebhookProvider) sendEvent(event *CloudEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s event", event.Type)
+	}
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", CloudEventsContentType)
+	if w.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.Token)
+	}
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error POSTing %s event to %s: %v", event.Type, w.URL, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("error response POSTing %s event to %s: %s", event.Type, w.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package semrel
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/gobwas/glob"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// RulesFileName the name of the file in a repository which configures how the next version is calculated from
+	// the conventional commits since the last release
+	RulesFileName = "semantic-release.yml"
+
+	// BumpMajor the commit type causes a new major version
+	BumpMajor Bump = "major"
+	// BumpMinor the commit type causes a new minor version
+	BumpMinor Bump = "minor"
+	// BumpPatch the commit type causes a new patch version
+	BumpPatch Bump = "patch"
+	// BumpNone the commit type does not cause a new version
+	BumpNone Bump = "none"
+)
+
+// Bump is the part of the version which a commit increments
+type Bump string
+
+// Rules configures how conventional commits are turned into a new version
+type Rules struct {
+	// Types are the conventional commit types, in the order they are shown in the changelog. Types which aren't
+	// listed don't change the version and aren't shown in the changelog.
+	Types []TypeRule `json:"types,omitempty"`
+	// Scopes, if specified, are the patterns of the commit scopes which change the version, so that each directory of
+	// a monorepo can be released separately, e.g. 'api' or 'api/*'. Commits without a matching scope are ignored.
+	Scopes []string `json:"scopes,omitempty"`
+	// Branches are the branches which release pre-release versions, e.g. a beta channel built from the next branch
+	Branches []BranchRule `json:"branches,omitempty"`
+	// InitialDevelopment treats 0.x versions as being in initial development, so that breaking changes increment
+	// the minor version. Otherwise any change to a 0.x version releases 1.0.0.
+	InitialDevelopment bool `json:"initialDevelopment,omitempty"`
+}
+
+// TypeRule configures a conventional commit type
+type TypeRule struct {
+	Type string `json:"type"`
+	// Bump is the part of the version which commits of this type increment: major, minor, patch or none
+	Bump Bump `json:"bump,omitempty"`
+	// Title is the heading for commits of this type in the changelog
+	Title string `json:"title,omitempty"`
+}
+
+// BranchRule configures the pre-release channel for the branches matching a pattern
+type BranchRule struct {
+	// Name is the name of the branch, or a pattern such as release/*
+	Name string `json:"name"`
+	// Prerelease is the pre-release channel, such as beta, so that versions like 1.2.0-beta.1 are released
+	Prerelease string `json:"prerelease,omitempty"`
+}
+
+// DefaultRules returns the rules used when a repository has no rules file: feat commits increment the minor version,
+// fix commits increment the patch version, and the other types from https://conventionalcommits.org/ are only
+// shown in the changelog
+func DefaultRules() *Rules {
+	return &Rules{
+		Types: []TypeRule{
+			{Type: "feat", Bump: BumpMinor, Title: "New Features"},
+			{Type: "fix", Bump: BumpPatch, Title: "Bug Fixes"},
+			{Type: "perf", Bump: BumpNone, Title: "Performance Improvements"},
+			{Type: "refactor", Bump: BumpNone, Title: "Code Refactoring"},
+			{Type: "docs", Bump: BumpNone, Title: "Documentation"},
+			{Type: "test", Bump: BumpNone, Title: "Tests"},
+			{Type: "revert", Bump: BumpNone, Title: "Reverts"},
+			{Type: "style", Bump: BumpNone, Title: "Styles"},
+			{Type: "chore", Bump: BumpNone, Title: "Chores"},
+		},
+	}
+}
+
+// LoadRules loads the rules file from the given directory, returning the default rules if there isn't one
+func LoadRules(dir string) (*Rules, error) {
+	return LoadRulesFile(filepath.Join(dir, RulesFileName))
+}
+
+// LoadRulesFile loads the rules from the given file, returning the default rules if it doesn't exist. If the file
+// doesn't specify any types the default types are used.
+func LoadRulesFile(fileName string) (*Rules, error) {
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return DefaultRules(), nil
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	rules := &Rules{}
+	err = yaml.Unmarshal(data, rules)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal YAML file %s", fileName)
+	}
+	if len(rules.Types) == 0 {
+		rules.Types = DefaultRules().Types
+	}
+	err = rules.Validate()
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid rules in %s", fileName)
+	}
+	return rules, nil
+}
+
+// Validate returns an error if the rules are not valid
+func (r *Rules) Validate() error {
+	types := map[string]bool{}
+	for _, t := range r.Types {
+		if t.Type == "" {
+			return errors.New("a type must have a name")
+		}
+		if types[t.Type] {
+			return errors.Errorf("the type %s is defined more than once", t.Type)
+		}
+		types[t.Type] = true
+		switch t.Bump {
+		case "", BumpMajor, BumpMinor, BumpPatch, BumpNone:
+		default:
+			return errors.Errorf("the type %s has an invalid bump %s, it must be one of %s, %s, %s or %s", t.Type, t.Bump,
+				BumpMajor, BumpMinor, BumpPatch, BumpNone)
+		}
+	}
+	for _, s := range r.Scopes {
+		if _, err := glob.Compile(s, '/'); err != nil {
+			return errors.Wrapf(err, "invalid scope pattern %s", s)
+		}
+	}
+	for _, b := range r.Branches {
+		if b.Name == "" {
+			return errors.New("a branch must have a name")
+		}
+		if _, err := glob.Compile(b.Name, '/'); err != nil {
+			return errors.Wrapf(err, "invalid branch pattern %s", b.Name)
+		}
+	}
+	return nil
+}
+
+// Prerelease returns the pre-release channel for the branch, or an empty string if the branch releases normal versions
+func (r *Rules) Prerelease(branch string) string {
+	for _, b := range r.Branches {
+		g, err := glob.Compile(b.Name, '/')
+		if err == nil && g.Match(branch) {
+			return b.Prerelease
+		}
+	}
+	return ""
+}
+
+// IncludesScope returns true if commits with the scope change the version
+func (r *Rules) IncludesScope(scope string) bool {
+	if len(r.Scopes) == 0 {
+		return true
+	}
+	for _, s := range r.Scopes {
+		g, err := glob.Compile(s, '/')
+		if err == nil && g.Match(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// changeFor returns the change to the version caused by the commit
+func (r *Rules) changeFor(commit *ConventionalCommit) change {
+	if commit.Type == "" || !r.IncludesScope(commit.Scope) {
+		return change{}
+	}
+	if commit.Breaking {
+		return change{Major: true}
+	}
+	for _, t := range r.Types {
+		if t.Type == commit.Type {
+			switch t.Bump {
+			case BumpMajor:
+				return change{Major: true}
+			case BumpMinor:
+				return change{Minor: true}
+			case BumpPatch:
+				return change{Patch: true}
+			}
+		}
+	}
+	return change{}
+}
+
+// ParseChangelogCommit parses the message of a commit for the changelog, returning nil for commits without a matching
+// scope so that they are left out of it
+func (r *Rules) ParseChangelogCommit(message string) *gits.CommitInfo {
+	commit := ParseCommit(&gits.GitCommit{Message: message})
+	if !r.IncludesScope(commit.Scope) {
+		return nil
+	}
+	if commit.Type == "" {
+		return &gits.CommitInfo{
+			Message: message,
+		}
+	}
+	return &gits.CommitInfo{
+		Kind:     commit.Type,
+		Feature:  commit.Scope,
+		Message:  commit.MessageBody,
+		Breaking: commit.Breaking,
+	}
+}
+
+// CommitGroups returns the changelog group for each type, keyed by the type, with breaking changes listed first
+func (r *Rules) CommitGroups() map[string]*gits.CommitGroup {
+	groups := map[string]*gits.CommitGroup{
+		gits.BreakingChangesGroupKey: {
+			Title: "Breaking Changes",
+			Order: 0,
+		},
+	}
+	for i, t := range r.Types {
+		title := t.Title
+		if title == "" {
+			title = strings.Title(t.Type)
+		}
+		groups[strings.ToLower(t.Type)] = &gits.CommitGroup{
+			Title: title,
+			Order: i + 1,
+		}
+	}
+	// commits which don't use conventional commits go last
+	groups[""] = &gits.CommitGroup{
+		Order: len(r.Types) + 1,
+	}
+	return groups
+}
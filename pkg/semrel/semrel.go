@@ -13,20 +13,23 @@ import (
 	"github.com/jenkins-x/jx/pkg/gits"
 )
 
-var commitPattern = regexp.MustCompile("^(\\w*)(?:\\((.*)\\))?\\: (.*)$")
+var commitPattern = regexp.MustCompile("^(\\w*)(?:\\((.*)\\))?(!)?\\: (.*)$")
 var breakingPattern = regexp.MustCompile("BREAKING CHANGES?")
 
 type change struct {
 	Major, Minor, Patch bool
 }
 
-type conventionalCommit struct {
+// ConventionalCommit is a commit parsed using the conventional commits format, see https://conventionalcommits.org/
+type ConventionalCommit struct {
 	*gits.GitCommit
 	MessageLines []string
 	Type         string
 	Scope        string
 	MessageBody  string
-	Change       change
+	// Breaking is true if the commit is marked as a breaking change with a ! after its type or scope, or with a
+	// BREAKING CHANGE footer
+	Breaking bool
 }
 
 type release struct {
@@ -34,42 +37,66 @@ type release struct {
 	Version *semver.Version
 }
 
-func calculateChange(commits []*conventionalCommit, latestRelease *release) change {
+func calculateChange(commits []*ConventionalCommit, latestRelease *release, rules *Rules) change {
 	var change change
 	for _, commit := range commits {
 		if latestRelease.SHA == commit.SHA {
 			break
 		}
-		change.Major = change.Major || commit.Change.Major
-		change.Minor = change.Minor || commit.Change.Minor
-		change.Patch = change.Patch || commit.Change.Patch
+		commitChange := rules.changeFor(commit)
+		change.Major = change.Major || commitChange.Major
+		change.Minor = change.Minor || commitChange.Minor
+		change.Patch = change.Patch || commitChange.Patch
 	}
 	return change
 }
 
-func applyChange(version *semver.Version, change change) *semver.Version {
+func applyChange(version *semver.Version, change change, rules *Rules, prerelease string) *semver.Version {
 	if version.Major() == 0 {
-		change.Major = true
+		if !rules.InitialDevelopment {
+			change.Major = true
+		} else if change.Major {
+			change.Major = false
+			change.Minor = true
+		}
 	}
 	if !change.Major && !change.Minor && !change.Patch {
 		return nil
 	}
-	var newVersion semver.Version
 	preRel := version.Prerelease()
-	if preRel == "" {
-		switch {
-		case change.Major:
-			newVersion = version.IncMajor()
-			break
-		case change.Minor:
-			newVersion = version.IncMinor()
-			break
-		case change.Patch:
-			newVersion = version.IncPatch()
-			break
+	if prerelease == "" {
+		if preRel == "" {
+			newVersion := incVersion(version, change)
+			return &newVersion
 		}
-		return &newVersion
+		return incPrerelease(version)
+	}
+
+	if preRel == prerelease || strings.HasPrefix(preRel, prerelease+".") {
+		return incPrerelease(version)
+	}
+	// start a new pre-release of the next version, or of the version being pre-released on another channel
+	newVersion := *version
+	if preRel == "" {
+		newVersion = incVersion(version, change)
+	}
+	newVersion, _ = newVersion.SetPrerelease(prerelease + ".1")
+	return &newVersion
+}
+
+func incVersion(version *semver.Version, change change) semver.Version {
+	switch {
+	case change.Major:
+		return version.IncMajor()
+	case change.Minor:
+		return version.IncMinor()
+	default:
+		return version.IncPatch()
 	}
+}
+
+func incPrerelease(version *semver.Version) *semver.Version {
+	preRel := version.Prerelease()
 	preRelVer := strings.Split(preRel, ".")
 	if len(preRelVer) > 1 {
 		idx, err := strconv.ParseInt(preRelVer[1], 10, 32)
@@ -80,12 +107,19 @@ func applyChange(version *semver.Version, change change) *semver.Version {
 	} else {
 		preRel += ".1"
 	}
-	newVersion, _ = version.SetPrerelease(preRel)
+	newVersion, _ := version.SetPrerelease(preRel)
 	return &newVersion
 }
 
 // GetNewVersion uses the conventional commits in the range of latestTagRev..endSha to increment the version from latestTag
 func GetNewVersion(dir string, endSha string, gitter gits.Gitter, latestTag string, latestTagRev string) (*semver.Version, error) {
+	return GetNewVersionWithRules(dir, endSha, gitter, latestTag, latestTagRev, DefaultRules(), "")
+}
+
+// GetNewVersionWithRules uses the conventional commits in the range of latestTagRev..endSha to increment the version
+// from latestTag using the given rules. If the rules configure a pre-release channel for the branch a pre-release
+// version is returned. Returns nil if none of the commits change the version.
+func GetNewVersionWithRules(dir string, endSha string, gitter gits.Gitter, latestTag string, latestTagRev string, rules *Rules, branch string) (*semver.Version, error) {
 	version, err := semver.NewVersion(strings.TrimPrefix(latestTag, "v"))
 	if err != nil {
 		return nil, errors.Wrapf(err, "parsing %s as semantic version", latestTag)
@@ -94,23 +128,30 @@ func GetNewVersion(dir string, endSha string, gitter gits.Gitter, latestTag stri
 		SHA:     latestTagRev,
 		Version: version,
 	}
+	commits, err := GetCommits(dir, release.SHA, endSha, gitter)
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return nil, err
 	}
-	rawCommits, err := gitter.GetCommits(dir, release.SHA, endSha)
+	return applyChange(release.Version, calculateChange(commits, &release, rules), rules, rules.Prerelease(branch)), nil
+}
+
+// GetCommits returns the conventional commits in the range of startSha..endSha
+func GetCommits(dir string, startSha string, endSha string, gitter gits.Gitter) ([]*ConventionalCommit, error) {
+	rawCommits, err := gitter.GetCommits(dir, startSha, endSha)
 	if err != nil {
-		return nil, errors.Wrapf(err, "getting commits in range %s..%s", release.SHA, endSha)
+		return nil, errors.Wrapf(err, "getting commits in range %s..%s", startSha, endSha)
 	}
-	commits := make([]*conventionalCommit, 0)
-	for _, c := range rawCommits {
-		commits = append(commits, parseCommit(&c))
+	commits := make([]*ConventionalCommit, 0)
+	for i := range rawCommits {
+		commits = append(commits, ParseCommit(&rawCommits[i]))
 	}
-
-	return applyChange(release.Version, calculateChange(commits, &release)), nil
+	return commits, nil
 }
 
-func parseCommit(commit *gits.GitCommit) *conventionalCommit {
-	c := &conventionalCommit{
+// ParseCommit parses the message of the commit as a conventional commit. If the message doesn't use the conventional
+// commits format the Type is empty.
+func ParseCommit(commit *gits.GitCommit) *ConventionalCommit {
+	c := &ConventionalCommit{
 		GitCommit: commit,
 	}
 	c.MessageLines = strings.Split(commit.Message, "\n")
@@ -120,11 +161,7 @@ func parseCommit(commit *gits.GitCommit) *conventionalCommit {
 	}
 	c.Type = strings.ToLower(found[0][1])
 	c.Scope = found[0][2]
-	c.MessageBody = found[0][3]
-	c.Change = change{
-		Major: breakingPattern.MatchString(commit.Message),
-		Minor: c.Type == "feat",
-		Patch: c.Type == "fix",
-	}
+	c.MessageBody = found[0][4]
+	c.Breaking = found[0][3] == "!" || breakingPattern.MatchString(commit.Message)
 	return c
 }
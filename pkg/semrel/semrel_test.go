@@ -0,0 +1,139 @@
+package semrel
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommit(t *testing.T) {
+	t.Parallel()
+
+	c := ParseCommit(&gits.GitCommit{Message: "feat(api)!: remove the v1 endpoints\n\nthey were deprecated"})
+	assert.Equal(t, "feat", c.Type)
+	assert.Equal(t, "api", c.Scope)
+	assert.Equal(t, "remove the v1 endpoints", c.MessageBody)
+	assert.True(t, c.Breaking)
+
+	c = ParseCommit(&gits.GitCommit{Message: "fix: handle nil\n\nBREAKING CHANGE: returns an error"})
+	assert.Equal(t, "fix", c.Type)
+	assert.Equal(t, "", c.Scope)
+	assert.True(t, c.Breaking)
+
+	c = ParseCommit(&gits.GitCommit{Message: "Merge branch 'master'"})
+	assert.Equal(t, "", c.Type)
+	assert.False(t, c.Breaking)
+}
+
+func TestLoadRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := LoadRules("test_data")
+	require.NoError(t, err)
+	assert.Len(t, rules.Types, 4)
+	assert.Equal(t, "beta", rules.Prerelease("next"))
+	assert.Equal(t, "rc", rules.Prerelease("release/2.0"))
+	assert.Equal(t, "", rules.Prerelease("master"))
+	assert.True(t, rules.IncludesScope("api/v2"))
+	assert.False(t, rules.IncludesScope("web"))
+
+	groups := rules.CommitGroups()
+	assert.Equal(t, "Dependency Updates", groups["deps"].Title)
+	assert.Equal(t, "Docs", groups["docs"].Title)
+	assert.True(t, groups["fix"].Order < groups["deps"].Order)
+
+	rules, err = LoadRules(filepath.Join("test_data", "does-not-exist"))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRules(), rules)
+}
+
+func TestChangelogMarkdown(t *testing.T) {
+	t.Parallel()
+
+	rules, err := LoadRules("test_data")
+	require.NoError(t, err)
+
+	releaseSpec := &v1.ReleaseSpec{
+		Commits: []v1.CommitSummary{
+			{Message: "feat(api): add the v2 endpoints", SHA: "123"},
+			{Message: "fix(api/v2)!: rename the id field", SHA: "456"},
+			{Message: "feat(web): add a dark theme", SHA: "789"},
+			{Message: "deps(api): upgrade the client\n\nBREAKING CHANGE: requires go 1.12", SHA: "abc"},
+		},
+	}
+	gitInfo := &gits.GitRepository{
+		Host:         "github.com",
+		Organisation: "myorg",
+		Name:         "myapp",
+	}
+	markdown, err := gits.GenerateMarkdownWithGroups(releaseSpec, gitInfo, rules.CommitGroups(), rules.ParseChangelogCommit)
+	require.NoError(t, err)
+
+	expectedMarkdown := `## Changes
+
+### Breaking Changes
+
+* api/v2: rename the id field
+* api: upgrade the client
+
+### Features
+
+* api: add the v2 endpoints
+`
+	assert.Equal(t, expectedMarkdown, markdown)
+}
+
+func TestNewVersion(t *testing.T) {
+	t.Parallel()
+
+	customRules, err := LoadRules("test_data")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		rules    *Rules
+		version  string
+		branch   string
+		messages []string
+		expected string
+	}{
+		{"feat", DefaultRules(), "1.2.3", "master", []string{"fix: a", "feat: b"}, "1.3.0"},
+		{"fix", DefaultRules(), "1.2.3", "master", []string{"fix: a", "docs: b"}, "1.2.4"},
+		{"breaking footer", DefaultRules(), "1.2.3", "master", []string{"fix: a\n\nBREAKING CHANGE: b"}, "2.0.0"},
+		{"breaking marker", DefaultRules(), "1.2.3", "master", []string{"refactor!: a"}, "2.0.0"},
+		{"no change", DefaultRules(), "1.2.3", "master", []string{"docs: a", "chore: b"}, ""},
+		{"zero major", DefaultRules(), "0.2.3", "master", []string{"fix: a"}, "1.0.0"},
+		{"existing prerelease", DefaultRules(), "1.2.3-alpha.2", "master", []string{"fix: a"}, "1.2.3-alpha.3"},
+		{"custom type", customRules, "1.2.3", "master", []string{"deps(api): a"}, "1.2.4"},
+		{"scope filter", customRules, "1.2.3", "master", []string{"feat(web): a", "fix(api/v2): b"}, "1.2.4"},
+		{"initial development", customRules, "0.2.3", "master", []string{"feat(api)!: a"}, "0.3.0"},
+		{"new prerelease", customRules, "1.2.3", "next", []string{"feat(api): a"}, "1.3.0-beta.1"},
+		{"next prerelease", customRules, "1.3.0-beta.1", "next", []string{"fix(api): a"}, "1.3.0-beta.2"},
+		{"other channel", customRules, "1.3.0-beta.4", "release/1.3", []string{"fix(api): a"}, "1.3.0-rc.1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			version, err := semver.NewVersion(tc.version)
+			require.NoError(t, err)
+			latest := &release{SHA: "abc", Version: version}
+
+			var commits []*ConventionalCommit
+			for _, m := range tc.messages {
+				commits = append(commits, ParseCommit(&gits.GitCommit{Message: m}))
+			}
+
+			newVersion := applyChange(version, calculateChange(commits, latest, tc.rules), tc.rules, tc.rules.Prerelease(tc.branch))
+			if tc.expected == "" {
+				assert.Nil(t, newVersion)
+			} else if assert.NotNil(t, newVersion) {
+				assert.Equal(t, tc.expected, newVersion.String())
+			}
+		})
+	}
+}
@@ -67,11 +67,17 @@ type StageInfo struct {
 // GetStageNameIncludingParents constructs a full stage name including its parents, if they exist.
 func (si *StageInfo) GetStageNameIncludingParents() string {
 	if si.Name != "" {
-		return strings.NewReplacer("-", " ").Replace(strings.Join(append(si.Parents, si.Name), " / "))
+		return StageNameIncludingParents(si.Parents, si.Name)
 	}
 	return si.PodName
 }
 
+// StageNameIncludingParents constructs the name used for a stage in a PipelineActivity from its name and the names of
+// its parents.
+func StageNameIncludingParents(parents []string, name string) string {
+	return strings.NewReplacer("-", " ").Replace(strings.Join(append(append([]string{}, parents...), name), " / "))
+}
+
 // PipelineRunInfoFilter allows specifying criteria on which to filter a list of PipelineRunInfos
 type PipelineRunInfoFilter struct {
 	Owner      string
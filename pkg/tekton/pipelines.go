@@ -7,6 +7,7 @@ import (
 	"time"
 
 	jenkinsio "github.com/jenkins-x/jx/pkg/apis/jenkins.io"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/kube/naming"
 	"github.com/jenkins-x/jx/pkg/prow"
 	"k8s.io/apimachinery/pkg/util/rand"
@@ -416,3 +417,32 @@ func ApplyPipeline(jxClient versioned.Interface, tektonClient tektonclient.Inter
 
 	return nil
 }
+
+// MarkStagesSkipped records the stages which were removed from the pipeline because their when conditions were not met
+// as skipped in the PipelineActivity for the build. If every stage was skipped the activity is marked as complete.
+func MarkStagesSkipped(jxClient versioned.Interface, ns string, activityKey *kube.PromoteStepActivityKey, skipped []syntax.SkippedStage, allSkipped bool) error {
+	if len(skipped) == 0 {
+		return nil
+	}
+	activity, _, err := activityKey.GetOrCreate(jxClient, ns)
+	if err != nil {
+		return err
+	}
+
+	now := metav1.Now()
+	for _, s := range skipped {
+		_, stage, _ := kube.GetOrCreateStage(activity, StageNameIncludingParents(s.Parents, s.Name))
+		stage.Status = v1.ActivityStatusTypeSkipped
+		stage.CompletedTimestamp = &now
+	}
+	if allSkipped {
+		activity.Spec.Status = v1.ActivityStatusTypeSucceeded
+		activity.Spec.CompletedTimestamp = &now
+	}
+
+	_, err = jxClient.JenkinsV1().PipelineActivities(ns).PatchUpdate(activity)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update PipelineActivity %s", activity.Name)
+	}
+	return nil
+}
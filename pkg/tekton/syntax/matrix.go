@@ -0,0 +1,222 @@
+package syntax
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/knative/pkg/apis"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// matrixCell is a single combination of values for the variables in a Matrix.
+type matrixCell struct {
+	Variables []corev1.EnvVar
+}
+
+// cells returns every combination of the axis values which isn't excluded, in the order the axes and values were
+// defined.
+func (m *Matrix) cells() []matrixCell {
+	cells := []matrixCell{{}}
+	for _, axis := range m.Axes {
+		var newCells []matrixCell
+		for _, c := range cells {
+			for _, v := range axis.Values {
+				vars := append(append([]corev1.EnvVar{}, c.Variables...), corev1.EnvVar{Name: axis.Variable, Value: v})
+				newCells = append(newCells, matrixCell{Variables: vars})
+			}
+		}
+		cells = newCells
+	}
+
+	var answer []matrixCell
+	for _, c := range cells {
+		if !m.isExcluded(c) {
+			answer = append(answer, c)
+		}
+	}
+	return answer
+}
+
+func (m *Matrix) isExcluded(cell matrixCell) bool {
+	for _, e := range m.Exclude {
+		if len(e.Values) == 0 {
+			continue
+		}
+		matches := true
+		for k, v := range e.Values {
+			if cell.value(k) != v {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func (c matrixCell) value(name string) string {
+	for _, v := range c.Variables {
+		if v.Name == name {
+			return v.Value
+		}
+	}
+	return ""
+}
+
+// expand replaces any references to the cell's variables, such as ${GO_VERSION}, in the text
+func (c matrixCell) expand(text string) string {
+	return os.Expand(text, func(name string) string {
+		for _, v := range c.Variables {
+			if v.Name == name {
+				return v.Value
+			}
+		}
+		// leave other variables alone so the shell can expand them
+		return "${" + name + "}"
+	})
+}
+
+// stageName returns the name of the stage generated for this cell of the matrix on the given stage
+func (c matrixCell) stageName(parentName string) string {
+	values := []string{parentName}
+	for _, v := range c.Variables {
+		values = append(values, v.Value)
+	}
+	return strings.Join(values, " ")
+}
+
+// matrixStageNames returns the names of the stages generated for each cell of the stage's matrix
+func (s *Stage) matrixStageNames() []string {
+	var names []string
+	if s.Matrix != nil {
+		for _, c := range s.Matrix.cells() {
+			names = append(names, c.stageName(s.Name))
+		}
+	}
+	return names
+}
+
+// expandMatrix returns a copy of the stage with its steps run in a parallel stage for each cell of its matrix. The
+// variables for the cell are added to the environment for that stage, and are expanded in the images used by the
+// stage and its steps.
+func (s *Stage) expandMatrix() (*Stage, error) {
+	cells := s.Matrix.cells()
+	if len(cells) == 0 {
+		return nil, errors.Errorf("the matrix for stage %s has no cells to run", s.Name)
+	}
+
+	expanded := s.DeepCopy()
+	expanded.Matrix = nil
+	expanded.Steps = nil
+	expanded.Post = nil
+
+	var cellOptions *StageOptions
+	if s.Options != nil {
+		cellOptions = &StageOptions{
+			Unstash:   s.Options.Unstash,
+			Workspace: s.Options.Workspace,
+		}
+		if s.Options.RootOptions != nil {
+			cellOptions.RootOptions = &RootOptions{Retry: s.Options.Retry}
+		}
+		expanded.Options.Unstash = nil
+	}
+
+	for _, c := range cells {
+		cell := Stage{
+			Name:    c.stageName(s.Name),
+			Env:     c.Variables,
+			Options: cellOptions.DeepCopy(),
+			Post:    s.DeepCopy().Post,
+		}
+		if s.Agent != nil {
+			cell.Agent = s.Agent.DeepCopy()
+			cell.Agent.Image = c.expand(cell.Agent.Image)
+		}
+		for _, step := range s.DeepCopy().Steps {
+			cell.Steps = append(cell.Steps, expandStepForCell(step, c))
+		}
+		expanded.Parallel = append(expanded.Parallel, cell)
+	}
+
+	return expanded, nil
+}
+
+func expandStepForCell(step Step, cell matrixCell) Step {
+	step.Image = cell.expand(step.Image)
+	if step.Agent != nil {
+		step.Agent.Image = cell.expand(step.Agent.Image)
+	}
+	if step.Loop != nil {
+		for i := range step.Loop.Steps {
+			step.Loop.Steps[i] = expandStepForCell(step.Loop.Steps[i], cell)
+		}
+	}
+	return step
+}
+
+func validateMatrix(s Stage) *apis.FieldError {
+	m := s.Matrix
+	if m == nil {
+		return nil
+	}
+
+	if len(s.Steps) == 0 {
+		return &apis.FieldError{
+			Message: "A matrix can only be used on a stage with steps",
+			Paths:   []string{"matrix"},
+		}
+	}
+
+	if s.Options != nil && s.Options.Stash != nil {
+		return (&apis.FieldError{
+			Message: "A stage with a matrix cannot stash files as the stash name would be used by every cell",
+			Paths:   []string{"stash"},
+		}).ViaField("options")
+	}
+
+	if len(m.Axes) == 0 {
+		return apis.ErrMissingField("axes").ViaField("matrix")
+	}
+
+	variables := make(map[string]bool)
+	for i, axis := range m.Axes {
+		if axis.Variable == "" {
+			return apis.ErrMissingField("variable").ViaFieldIndex("axes", i).ViaField("matrix")
+		}
+		if len(axis.Values) == 0 {
+			return apis.ErrMissingField("values").ViaFieldIndex("axes", i).ViaField("matrix")
+		}
+		if variables[axis.Variable] {
+			return (&apis.FieldError{
+				Message: fmt.Sprintf("The matrix variable %s is defined more than once", axis.Variable),
+				Paths:   []string{"variable"},
+			}).ViaFieldIndex("axes", i).ViaField("matrix")
+		}
+		variables[axis.Variable] = true
+	}
+
+	for i, e := range m.Exclude {
+		for k := range e.Values {
+			if !variables[k] {
+				return (&apis.FieldError{
+					Message: fmt.Sprintf("The matrix exclusion refers to %s which is not a matrix variable", k),
+					Paths:   []string{"values"},
+				}).ViaFieldIndex("exclude", i).ViaField("matrix")
+			}
+		}
+	}
+
+	if len(m.cells()) == 0 {
+		return &apis.FieldError{
+			Message: "Every combination of values in the matrix is excluded",
+			Paths:   []string{"matrix"},
+		}
+	}
+
+	return nil
+}
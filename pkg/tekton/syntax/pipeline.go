@@ -135,7 +135,6 @@ type Unstash struct {
 type StageOptions struct {
 	*RootOptions `json:",inline"`
 
-	// TODO: Not yet implemented in build-pipeline
 	Stash   *Stash   `json:"stash,omitempty"`
 	Unstash *Unstash `json:"unstash,omitempty"`
 
@@ -192,6 +191,39 @@ type Loop struct {
 	Steps []Step `json:"steps"`
 }
 
+// Matrix defines variables and their possible values, with the stage's steps run in parallel once for every
+// combination of those values.
+type Matrix struct {
+	// The variables and the values for each of them
+	Axes []MatrixAxis `json:"axes"`
+	// Combinations of values which should not be run
+	Exclude []MatrixExclusion `json:"exclude,omitempty"`
+}
+
+// MatrixAxis is a variable, and the list of values for that variable, in a Matrix.
+type MatrixAxis struct {
+	Variable string   `json:"variable"`
+	Values   []string `json:"values"`
+}
+
+// MatrixExclusion excludes any cell of a Matrix which has all of the given variable values.
+type MatrixExclusion struct {
+	Values map[string]string `json:"values"`
+}
+
+// StageWhen defines the conditions under which a stage is run. Every condition which is specified must be met, and
+// stages whose conditions are not met are removed from the pipeline before it is generated.
+type StageWhen struct {
+	// Patterns for the branch being built, such as master or release-*, one of which must match
+	Branch []string `json:"branch,omitempty"`
+	// Patterns for files changed by the pull request, such as services/foo/**, one of which must match
+	ChangedPaths []string `json:"changedPaths,omitempty"`
+	// Environment variables which must have the given values
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// The kinds of pipeline, such as pullrequest or release, one of which must match
+	Kind []string `json:"kind,omitempty"`
+}
+
 // Stage is a unit of work in a pipeline, corresponding either to a Task or a set of Tasks to be run sequentially or in
 // parallel with common configuration.
 type Stage struct {
@@ -204,6 +236,8 @@ type Stage struct {
 	Parallel   []Stage         `json:"parallel,omitempty"`
 	Post       []Post          `json:"post,omitempty"`
 	WorkingDir *string         `json:"dir,omitempty"`
+	Matrix     *Matrix         `json:"matrix,omitempty"`
+	When       *StageWhen      `json:"when,omitempty"`
 
 	// Replaced by Env, retained for backwards compatibility
 	Environment []corev1.EnvVar `json:"environment,omitempty"`
@@ -221,14 +255,12 @@ const (
 
 // Post contains a PostCondition and one more actions to be executed after a pipeline or stage if the condition is met.
 type Post struct {
-	// TODO: Conditional execution of something after a Task or Pipeline completes is not yet implemented
 	Condition PostCondition `json:"condition"`
 	Actions   []PostAction  `json:"actions"`
 }
 
 // PostAction contains the name of a built-in post action and options to pass to that action.
 type PostAction struct {
-	// TODO: Notifications are not yet supported in Build Pipeline per se.
 	Name string `json:"name"`
 	// Also, we'll need to do some magic to do type verification during translation - i.e., this action wants a number
 	// for this option, so translate the string value for that option to a number.
@@ -531,10 +563,18 @@ func (j *ParsedPipeline) Validate(context context.Context) *apis.FieldError {
 		return err
 	}
 
+	if err := validateStashNames(j); err != nil {
+		return err
+	}
+
 	if err := validateRootOptions(j.Options).ViaField("options"); err != nil {
 		return err
 	}
 
+	if err := validatePosts(j.Post); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -646,6 +686,18 @@ func validateStage(s Stage, parentAgent *Agent) *apis.FieldError {
 		}
 	}
 
+	if err := validateMatrix(s); err != nil {
+		return err
+	}
+
+	if err := validateWhen(s.When).ViaField("when"); err != nil {
+		return err
+	}
+
+	if err := validatePosts(s.Post); err != nil {
+		return err
+	}
+
 	return validateStageOptions(s.Options).ViaField("options")
 }
 
@@ -889,7 +941,6 @@ func validateTimeout(t *Timeout) *apis.FieldError {
 
 func validateUnstash(u *Unstash) *apis.FieldError {
 	if u != nil {
-		// TODO: Check to make sure the corresponding stash is defined somewhere
 		if u.Name == "" {
 			return &apis.FieldError{
 				Message: "The unstash name must be provided",
@@ -1084,6 +1135,21 @@ func (ts transformedStage) isParallel() bool {
 	return len(ts.Parallel) > 0
 }
 
+// getLinearStages returns the stages with steps, and so a Task, within this stage in the order they were defined
+func (ts *transformedStage) getLinearStages() []*transformedStage {
+	var stages []*transformedStage
+	for _, seqTs := range ts.Sequential {
+		stages = append(stages, seqTs.getLinearStages()...)
+	}
+	for _, parTs := range ts.Parallel {
+		stages = append(stages, parTs.getLinearStages()...)
+	}
+	if ts.Task != nil {
+		stages = append(stages, ts)
+	}
+	return stages
+}
+
 func (ts transformedStage) getLinearTasks() []*tektonv1alpha1.Task {
 	if ts.isSequential() {
 		var tasks []*tektonv1alpha1.Task
@@ -1114,9 +1180,17 @@ func (ts *transformedStage) computeWorkspace(parentWorkspace string) {
 	}
 }
 
-func stageToTask(s Stage, pipelineIdentifier string, buildIdentifier string, namespace string, sourceDir string, baseWorkingDir *string, parentEnv []corev1.EnvVar, parentAgent *Agent, parentWorkspace string, parentContainer *corev1.Container, depth int8, enclosingStage *transformedStage, previousSiblingStage *transformedStage, podTemplates map[string]*corev1.Pod, labels map[string]string, defaultImage string) (*transformedStage, error) {
-	if len(s.Post) != 0 {
-		return nil, errors.New("post on stages not yet supported")
+func stageToTask(s Stage, pipelineIdentifier string, buildIdentifier string, namespace string, sourceDir string, baseWorkingDir *string, parentEnv []corev1.EnvVar, parentAgent *Agent, parentWorkspace string, parentContainer *corev1.Container, depth int8, enclosingStage *transformedStage, previousSiblingStage *transformedStage, podTemplates map[string]*corev1.Pod, labels map[string]string, defaultImage string, inheritedPost []Post) (*transformedStage, error) {
+	if s.Matrix != nil {
+		expanded, err := s.expandMatrix()
+		if err != nil {
+			return nil, err
+		}
+		s = *expanded
+	}
+
+	if len(s.Post) != 0 && len(s.Steps) == 0 {
+		return nil, errors.New("post on stages with nested or parallel stages not yet supported")
 	}
 
 	stageContainer := &corev1.Container{}
@@ -1133,11 +1207,8 @@ func stageToTask(s Stage, pipelineIdentifier string, buildIdentifier string, nam
 				stageContainer = o.ContainerOptions
 			}
 		}
-		if o.Stash != nil {
-			return nil, errors.New("Stash on stage not yet supported")
-		}
-		if o.Unstash != nil {
-			return nil, errors.New("Unstash on stage not yet supported")
+		if (o.Stash != nil || o.Unstash != nil) && len(s.Steps) == 0 {
+			return nil, errors.New("stash and unstash on stages with nested or parallel stages not yet supported")
 		}
 	}
 
@@ -1207,6 +1278,9 @@ func stageToTask(s Stage, pipelineIdentifier string, buildIdentifier string, nam
 			},
 		}
 
+		// Any unstashed files need to be in place before the first of the stage's own steps
+		firstStepIndex := len(t.Spec.Steps)
+
 		// We don't want to dupe volumes for the Task if there are multiple steps
 		volumes := make(map[string]corev1.Volume)
 		for _, step := range s.Steps {
@@ -1234,6 +1308,29 @@ func stageToTask(s Stage, pipelineIdentifier string, buildIdentifier string, nam
 			t.Spec.Volumes = append(t.Spec.Volumes, volumes[v])
 		}
 
+		if s.Options != nil && s.Options.Unstash != nil {
+			c, err := unstashStepContainer(s.Options.Unstash, sourceDir, env, stageContainer, defaultImage)
+			if err != nil {
+				return nil, err
+			}
+			t.Spec.Steps = append(t.Spec.Steps[:firstStepIndex], append([]corev1.Container{*c}, t.Spec.Steps[firstStepIndex:]...)...)
+		}
+
+		if s.Options != nil && s.Options.Stash != nil {
+			c, err := stashStepContainer(s.Options.Stash, sourceDir, env, stageContainer, defaultImage)
+			if err != nil {
+				return nil, err
+			}
+			t.Spec.Steps = append(t.Spec.Steps, *c)
+		}
+
+		if posts := append(append([]Post{}, s.Post...), inheritedPost...); len(posts) > 0 {
+			t.Spec.Steps, err = addPostSteps(t.Spec.Steps, posts, sourceDir, env, stageContainer, defaultImage)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		ts := transformedStage{Stage: s, Task: t, Depth: depth, EnclosingStage: enclosingStage, PreviousSiblingStage: previousSiblingStage}
 		ts.computeWorkspace(parentWorkspace)
 		return &ts, nil
@@ -1248,7 +1345,7 @@ func stageToTask(s Stage, pipelineIdentifier string, buildIdentifier string, nam
 			if i > 0 {
 				nestedPreviousSibling = tasks[i-1]
 			}
-			nestedTask, err := stageToTask(nested, pipelineIdentifier, buildIdentifier, namespace, sourceDir, baseWorkingDir, env, agent, *ts.Stage.Options.Workspace, stageContainer, depth+1, &ts, nestedPreviousSibling, podTemplates, labels, defaultImage)
+			nestedTask, err := stageToTask(nested, pipelineIdentifier, buildIdentifier, namespace, sourceDir, baseWorkingDir, env, agent, *ts.Stage.Options.Workspace, stageContainer, depth+1, &ts, nestedPreviousSibling, podTemplates, labels, defaultImage, inheritedPost)
 			if err != nil {
 				return nil, err
 			}
@@ -1265,7 +1362,7 @@ func stageToTask(s Stage, pipelineIdentifier string, buildIdentifier string, nam
 		ts.computeWorkspace(parentWorkspace)
 
 		for _, nested := range s.Parallel {
-			nestedTask, err := stageToTask(nested, pipelineIdentifier, buildIdentifier, namespace, sourceDir, baseWorkingDir, env, agent, *ts.Stage.Options.Workspace, stageContainer, depth+1, &ts, nil, podTemplates, labels, defaultImage)
+			nestedTask, err := stageToTask(nested, pipelineIdentifier, buildIdentifier, namespace, sourceDir, baseWorkingDir, env, agent, *ts.Stage.Options.Workspace, stageContainer, depth+1, &ts, nil, podTemplates, labels, defaultImage, inheritedPost)
 			if err != nil {
 				return nil, err
 			}
@@ -1454,10 +1551,6 @@ func PipelineRunName(pipelineIdentifier string, buildIdentifier string) string {
 
 // GenerateCRDs translates the Pipeline structure into the corresponding Pipeline and Task CRDs
 func (j *ParsedPipeline) GenerateCRDs(pipelineIdentifier string, buildIdentifier string, namespace string, podTemplates map[string]*corev1.Pod, taskParams []tektonv1alpha1.TaskParam, sourceDir string, labels map[string]string, defaultImage string) (*tektonv1alpha1.Pipeline, []*tektonv1alpha1.Task, *v1.PipelineStructure, error) {
-	if len(j.Post) != 0 {
-		return nil, nil, nil, errors.New("Post at top level not yet supported")
-	}
-
 	var parentContainer *corev1.Container
 	baseWorkingDir := j.WorkingDir
 
@@ -1502,15 +1595,33 @@ func (j *ParsedPipeline) GenerateCRDs(pipelineIdentifier string, buildIdentifier
 	}
 
 	var previousStage *transformedStage
+	var transformedStages []*transformedStage
 
 	var tasks []*tektonv1alpha1.Task
 
 	baseEnv := j.GetEnv()
 
-	for i, s := range j.Stages {
-		isLastStage := i == len(j.Stages)-1
+	// The success and always post actions for the pipeline run in a final stage of their own, while the failure
+	// handling is added to the task for every other stage.
+	stages := j.Stages
+	postStage, err := j.postStage(defaultImage)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if postStage != nil {
+		stages = append(append([]Stage{}, j.Stages...), *postStage)
+	}
+	taskPosts := postsForTasks(j.Post)
+
+	for i, s := range stages {
+		isLastStage := i == len(stages)-1
 
-		stage, err := stageToTask(s, pipelineIdentifier, buildIdentifier, namespace, sourceDir, baseWorkingDir, baseEnv, j.Agent, "default", parentContainer, 0, nil, previousStage, podTemplates, labels, defaultImage)
+		inheritedPost := taskPosts
+		if postStage != nil && isLastStage {
+			inheritedPost = nil
+		}
+
+		stage, err := stageToTask(s, pipelineIdentifier, buildIdentifier, namespace, sourceDir, baseWorkingDir, baseEnv, j.Agent, "default", parentContainer, 0, nil, previousStage, podTemplates, labels, defaultImage, inheritedPost)
 		if err != nil {
 			return nil, nil, nil, err
 		}
@@ -1523,6 +1634,7 @@ func (j *ParsedPipeline) GenerateCRDs(pipelineIdentifier string, buildIdentifier
 			}
 		}
 		previousStage = stage
+		transformedStages = append(transformedStages, stage)
 
 		pipelineTasks := createPipelineTasks(stage, p.Spec.Resources[0].Name)
 
@@ -1543,6 +1655,8 @@ func (j *ParsedPipeline) GenerateCRDs(pipelineIdentifier string, buildIdentifier
 		structure.Stages = append(structure.Stages, stage.getAllAsPipelineStructureStages()...)
 	}
 
+	addUnstashDependencies(p, transformedStages)
+
 	return p, tasks, structure, nil
 }
 
@@ -1745,6 +1859,7 @@ func validateStageNames(j *ParsedPipeline) (err *apis.FieldError) {
 
 		for _, stage := range stages {
 			*stageNames = append(*stageNames, stage.Name)
+			*stageNames = append(*stageNames, stage.matrixStageNames()...)
 			if len(stage.Stages) > 0 {
 				validate(stage.Stages, stageNames)
 			}
@@ -1755,6 +1870,13 @@ func validateStageNames(j *ParsedPipeline) (err *apis.FieldError) {
 
 	validate(j.Stages, &names)
 
+	for _, p := range j.Post {
+		if p.Condition == PostConditionSuccess || p.Condition == PostConditionAlways {
+			names = append(names, PostStageName)
+			break
+		}
+	}
+
 	err = findDuplicates(names)
 
 	return
@@ -1762,17 +1884,9 @@ func validateStageNames(j *ParsedPipeline) (err *apis.FieldError) {
 
 // todo JR lets remove this when we switch tekton to using git merge type pipelineresources
 func getDefaultTaskSpec(envs []corev1.EnvVar, parentContainer *corev1.Container, defaultImage string) (tektonv1alpha1.TaskSpec, error) {
-	image := defaultImage
-	if image == "" {
-		image = os.Getenv("BUILDER_JX_IMAGE")
-		if image == "" {
-			image = GitMergeImage
-		}
-	}
-
 	childContainer := &corev1.Container{
 		Name:       "git-merge",
-		Image:      image,
+		Image:      jxImage(defaultImage),
 		Command:    []string{"jx"},
 		Args:       []string{"step", "git", "merge", "--verbose"},
 		WorkingDir: "/workspace/source",
@@ -1792,6 +1906,18 @@ func getDefaultTaskSpec(envs []corev1.EnvVar, parentContainer *corev1.Container,
 	}, nil
 }
 
+// jxImage returns the image to use for steps which run jx commands
+func jxImage(defaultImage string) string {
+	image := defaultImage
+	if image == "" {
+		image = os.Getenv("BUILDER_JX_IMAGE")
+		if image == "" {
+			image = GitMergeImage
+		}
+	}
+	return image
+}
+
 // AsStepsSlice returns a possibly empty slice of the step or steps in this override
 func (p *PipelineOverride) AsStepsSlice() []*Step {
 	if p.Step != nil {
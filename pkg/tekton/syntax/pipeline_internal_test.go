@@ -3,6 +3,10 @@ package syntax
 import (
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func TestFindDuplicates(t *testing.T) {
@@ -57,3 +61,36 @@ func TestFindDuplicates(t *testing.T) {
 		})
 	}
 }
+
+func TestAddPostStepsWithoutWrapping(t *testing.T) {
+	steps := []corev1.Container{
+		{Name: "build", Image: "maven", Command: []string{"/bin/sh", "-c"}, Args: []string{"mvn install"}},
+		{Name: "build-image", Image: "gcr.io/kaniko-project/executor", Command: []string{"/kaniko/executor"}},
+		{Name: "scan", Image: "scanner"},
+	}
+	notify := PostAction{Name: "notify"}
+
+	// success post actions don't need the steps to be wrapped as a failing step stops the task before them
+	answer, err := addPostSteps(steps, []Post{{Condition: PostConditionSuccess, Actions: []PostAction{notify}}}, "source", nil, nil, "")
+	require.NoError(t, err)
+	var names []string
+	for _, s := range answer {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"build", "build-image", "scan", "post-success-notify-1"}, names)
+	assert.Equal(t, steps[1], answer[1])
+
+	// the kaniko step and the step running the entrypoint of its image are left as they are, after the failure
+	// post actions of the steps before them
+	answer, err = addPostSteps(steps, []Post{{Condition: PostConditionFailure, Actions: []PostAction{notify}}}, "source", nil, nil, "")
+	require.NoError(t, err)
+	names = nil
+	for _, s := range answer {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"build", "post-failure-notify-1", "post-status", "build-image", "post-failure-notify-2",
+		"post-status-1", "scan", "post-failure-notify-3", "post-status-2"}, names)
+	assert.Equal(t, []string{"/bin/sh", "-c"}, answer[0].Command)
+	assert.Equal(t, steps[1], answer[3])
+	assert.Equal(t, steps[2], answer[6])
+}
@@ -25,6 +25,11 @@ var (
 	customWorkspace  = "custom"
 )
 
+const (
+	postWrapperScript = "if [ -f /workspace/.jx-post-failed-step ]; then echo \"skipping as an earlier step failed\"; exit 0; fi\n\"$@\" || echo \"$0\" > /workspace/.jx-post-failed-step"
+	postStatusScript  = "if [ -f /workspace/.jx-post-failed-step ]; then echo \"step $(cat /workspace/.jx-post-failed-step) failed\"; exit 1; fi"
+)
+
 // TODO: Try to write some helper functions to make Pipeline and Task expect building less bloody verbose.
 func TestParseJenkinsfileYaml(t *testing.T) {
 	ctx := context.Background()
@@ -576,24 +581,87 @@ func TestParseJenkinsfileYaml(t *testing.T) {
 				syntax_helpers_test.PipelineStage("A Working Stage",
 					syntax_helpers_test.StageStep(syntax_helpers_test.StepCmd("echo"), syntax_helpers_test.StepArg("hello"), syntax_helpers_test.StepArg("world")),
 					syntax_helpers_test.StagePost(syntax.PostConditionSuccess,
-						syntax_helpers_test.PostAction("mail", map[string]string{
-							"to":      "foo@bar.com",
-							"subject": "Yay, it passed",
+						syntax_helpers_test.PostAction("comment", map[string]string{
+							"comment": "Yay, it passed",
 						})),
 					syntax_helpers_test.StagePost(syntax.PostConditionFailure,
-						syntax_helpers_test.PostAction("slack", map[string]string{
-							"whatever": "the",
-							"slack":    "config",
-							"actually": "is. =)",
+						syntax_helpers_test.PostAction("comment", map[string]string{
+							"comment": "Oh no, it failed",
+						}),
+						syntax_helpers_test.PostAction("notify", map[string]string{
+							"channel": "#builds",
 						})),
 					syntax_helpers_test.StagePost(syntax.PostConditionAlways,
-						syntax_helpers_test.PostAction("junit", map[string]string{
-							"pattern": "target/surefire-reports/**/*.xml",
+						syntax_helpers_test.PostAction("collect", map[string]string{
+							"pattern":    "target/surefire-reports/**/*.xml",
+							"classifier": "tests",
 						}),
 					),
 				),
 			),
-			expectedErrorMsg: "post on stages not yet supported",
+			pipeline: tb.Pipeline("somepipeline-1", "jx", tb.PipelineSpec(
+				tb.PipelineTask("a-working-stage", "somepipeline-a-working-stage-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline"),
+				),
+				tb.PipelineDeclaredResource("somepipeline", tektonv1alpha1.PipelineResourceTypeGit))),
+			tasks: []*tektonv1alpha1.Task{
+				tb.Task("somepipeline-a-working-stage-1", "jx", syntax_helpers_test.TaskStageLabel("A Working Stage"),
+					tb.TaskSpec(
+						tb.TaskInputs(
+							tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+								tb.ResourceTargetPath("source"))),
+						tb.Step("git-merge", syntax.GitMergeImage, tb.Command("/bin/sh", "-c"), tb.Args(postWrapperScript, "git-merge", "jx", "step", "git", "merge", "--verbose"), workingDir("/workspace/source")),
+						tb.Step("step2", "some-image", tb.Command("/bin/sh", "-c"), tb.Args(postWrapperScript, "step2", "/bin/sh", "-c", "echo hello world"), workingDir("/workspace/source")),
+						tb.Step("post-success-comment-1", syntax.GitMergeImage, tb.Command("/bin/sh", "-c"),
+							tb.Args("if [ ! -f /workspace/.jx-post-failed-step ]; then jx step pr comment --comment 'Yay, it passed'; fi"), workingDir("/workspace/source")),
+						tb.Step("post-failure-comment-2", syntax.GitMergeImage, tb.Command("/bin/sh", "-c"),
+							tb.Args("if [ -f /workspace/.jx-post-failed-step ]; then jx step pr comment --comment 'Oh no, it failed'; fi"), workingDir("/workspace/source")),
+						tb.Step("post-failure-notify-3", syntax.GitMergeImage, tb.Command("/bin/sh", "-c"),
+							tb.Args("if [ -f /workspace/.jx-post-failed-step ]; then jx step notify --channel '#builds' --status \"$(if [ -f /workspace/.jx-post-failed-step ]; then echo Failed; else echo Succeeded; fi)\"; fi"), workingDir("/workspace/source")),
+						tb.Step("post-always-collect-4", syntax.GitMergeImage, tb.Command("/bin/sh", "-c"),
+							tb.Args("jx step stash --pattern 'target/surefire-reports/**/*.xml' --classifier tests"), workingDir("/workspace/source")),
+						tb.Step("post-status", syntax.GitMergeImage, tb.Command("/bin/sh", "-c"), tb.Args(postStatusScript), workingDir("/workspace/source")),
+					)),
+			},
+			structure: syntax_helpers_test.PipelineStructure("somepipeline-1",
+				syntax_helpers_test.StructureStage("A Working Stage", syntax_helpers_test.StructureStageTaskRef("somepipeline-a-working-stage-1")),
+			),
+		},
+		{
+			name: "pipeline_post",
+			pipeline: tb.Pipeline("somepipeline-1", "jx", tb.PipelineSpec(
+				tb.PipelineTask("a-working-stage", "somepipeline-a-working-stage-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline"),
+					tb.PipelineTaskOutputResource("workspace", "somepipeline")),
+				tb.PipelineTask("post", "somepipeline-post-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline",
+						tb.From("a-working-stage")),
+					tb.RunAfter("a-working-stage")),
+				tb.PipelineDeclaredResource("somepipeline", tektonv1alpha1.PipelineResourceTypeGit))),
+			tasks: []*tektonv1alpha1.Task{
+				tb.Task("somepipeline-a-working-stage-1", "jx", syntax_helpers_test.TaskStageLabel("A Working Stage"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.TaskOutputs(tb.OutputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit)),
+					tb.Step("git-merge", syntax.GitMergeImage, tb.Command("/bin/sh", "-c"), tb.Args(postWrapperScript, "git-merge", "jx", "step", "git", "merge", "--verbose"), workingDir("/workspace/source")),
+					tb.Step("step2", "some-image", tb.Command("/bin/sh", "-c"), tb.Args(postWrapperScript, "step2", "/bin/sh", "-c", "echo hello world"), workingDir("/workspace/source")),
+					tb.Step("post-failure-comment-1", syntax.GitMergeImage, tb.Command("/bin/sh", "-c"),
+						tb.Args("if [ -f /workspace/.jx-post-failed-step ]; then jx step pr comment --comment 'Pipeline failed'; fi"), workingDir("/workspace/source")),
+					tb.Step("post-status", syntax.GitMergeImage, tb.Command("/bin/sh", "-c"), tb.Args(postStatusScript), workingDir("/workspace/source")),
+				)),
+				tb.Task("somepipeline-post-1", "jx", syntax_helpers_test.TaskStageLabel("Post"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.Step("post-comment-1", syntax.GitMergeImage, tb.Command("/bin/sh", "-c"), tb.Args("jx step pr comment --comment 'Pipeline passed'"), workingDir("/workspace/source")),
+				)),
+			},
+			structure: syntax_helpers_test.PipelineStructure("somepipeline-1",
+				syntax_helpers_test.StructureStage("A Working Stage", syntax_helpers_test.StructureStageTaskRef("somepipeline-a-working-stage-1")),
+				syntax_helpers_test.StructureStage("Post", syntax_helpers_test.StructureStageTaskRef("somepipeline-post-1"),
+					syntax_helpers_test.StructureStagePrevious("A Working Stage")),
+			),
 		},
 		{
 			name: "top_level_and_stage_options",
@@ -613,7 +681,106 @@ func TestParseJenkinsfileYaml(t *testing.T) {
 					syntax_helpers_test.StageStep(syntax_helpers_test.StepCmd("echo"), syntax_helpers_test.StepArg("hello"), syntax_helpers_test.StepArg("world")),
 				),
 			),
-			expectedErrorMsg: "Retry at top level not yet supported",
+			expectedErrorMsg:   "Retry at top level not yet supported",
+			validationErrorMsg: "The stash 'Earlier Files' used by the stage 'A Working Stage' is not defined in an earlier stage",
+		},
+		{
+			name: "matrix",
+			pipeline: tb.Pipeline("somepipeline-1", "jx", tb.PipelineSpec(
+				tb.PipelineTask("build-1-11-linux", "somepipeline-build-1-11-linux-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline")),
+				tb.PipelineTask("build-1-12-linux", "somepipeline-build-1-12-linux-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline")),
+				tb.PipelineTask("build-1-12-windows", "somepipeline-build-1-12-windows-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline")),
+				tb.PipelineDeclaredResource("somepipeline", tektonv1alpha1.PipelineResourceTypeGit))),
+			tasks: []*tektonv1alpha1.Task{
+				tb.Task("somepipeline-build-1-11-linux-1", "jx", syntax_helpers_test.TaskStageLabel("Build 1.11 linux"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.Step("git-merge", syntax.GitMergeImage, tb.Command("jx"), tb.Args("step", "git", "merge", "--verbose"), workingDir("/workspace/source"),
+						tb.EnvVar("GOOS", "linux"), tb.EnvVar("GO_VERSION", "1.11")),
+					tb.Step("step2", "golang:1.11", tb.Command("/bin/sh", "-c"), tb.Args("go build ./..."), workingDir("/workspace/source"),
+						tb.EnvVar("GOOS", "linux"), tb.EnvVar("GO_VERSION", "1.11")),
+				)),
+				tb.Task("somepipeline-build-1-12-linux-1", "jx", syntax_helpers_test.TaskStageLabel("Build 1.12 linux"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.Step("git-merge", syntax.GitMergeImage, tb.Command("jx"), tb.Args("step", "git", "merge", "--verbose"), workingDir("/workspace/source"),
+						tb.EnvVar("GOOS", "linux"), tb.EnvVar("GO_VERSION", "1.12")),
+					tb.Step("step2", "golang:1.12", tb.Command("/bin/sh", "-c"), tb.Args("go build ./..."), workingDir("/workspace/source"),
+						tb.EnvVar("GOOS", "linux"), tb.EnvVar("GO_VERSION", "1.12")),
+				)),
+				tb.Task("somepipeline-build-1-12-windows-1", "jx", syntax_helpers_test.TaskStageLabel("Build 1.12 windows"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.Step("git-merge", syntax.GitMergeImage, tb.Command("jx"), tb.Args("step", "git", "merge", "--verbose"), workingDir("/workspace/source"),
+						tb.EnvVar("GOOS", "windows"), tb.EnvVar("GO_VERSION", "1.12")),
+					tb.Step("step2", "golang:1.12", tb.Command("/bin/sh", "-c"), tb.Args("go build ./..."), workingDir("/workspace/source"),
+						tb.EnvVar("GOOS", "windows"), tb.EnvVar("GO_VERSION", "1.12")),
+				)),
+			},
+			structure: syntax_helpers_test.PipelineStructure("somepipeline-1",
+				syntax_helpers_test.StructureStage("Build",
+					syntax_helpers_test.StructureStageParallel("Build 1.11 linux", "Build 1.12 linux", "Build 1.12 windows"),
+				),
+				syntax_helpers_test.StructureStage("Build 1.11 linux", syntax_helpers_test.StructureStageTaskRef("somepipeline-build-1-11-linux-1"),
+					syntax_helpers_test.StructureStageDepth(1),
+					syntax_helpers_test.StructureStageParent("Build"),
+				),
+				syntax_helpers_test.StructureStage("Build 1.12 linux", syntax_helpers_test.StructureStageTaskRef("somepipeline-build-1-12-linux-1"),
+					syntax_helpers_test.StructureStageDepth(1),
+					syntax_helpers_test.StructureStageParent("Build"),
+				),
+				syntax_helpers_test.StructureStage("Build 1.12 windows", syntax_helpers_test.StructureStageTaskRef("somepipeline-build-1-12-windows-1"),
+					syntax_helpers_test.StructureStageDepth(1),
+					syntax_helpers_test.StructureStageParent("Build"),
+				),
+			),
+		},
+		{
+			name: "stash_and_unstash",
+			pipeline: tb.Pipeline("somepipeline-1", "jx", tb.PipelineSpec(
+				tb.PipelineTask("build", "somepipeline-build-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline")),
+				tb.PipelineTask("publish", "somepipeline-publish-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline"),
+					tb.RunAfter("build")),
+				tb.PipelineDeclaredResource("somepipeline", tektonv1alpha1.PipelineResourceTypeGit))),
+			tasks: []*tektonv1alpha1.Task{
+				tb.Task("somepipeline-build-1", "jx", syntax_helpers_test.TaskStageLabel("Build"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.Step("git-merge", syntax.GitMergeImage, tb.Command("jx"), tb.Args("step", "git", "merge", "--verbose"), workingDir("/workspace/source")),
+					tb.Step("step2", "some-image", tb.Command("/bin/sh", "-c"), tb.Args("echo build"), workingDir("/workspace/source")),
+					tb.Step("stash-binaries", syntax.GitMergeImage, tb.Command("/bin/sh", "-c"), tb.Args("jx step stash --name binaries --pattern 'target/*'"), workingDir("/workspace/source")),
+				)),
+				tb.Task("somepipeline-publish-1", "jx", syntax_helpers_test.TaskStageLabel("Publish"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.Step("git-merge", syntax.GitMergeImage, tb.Command("jx"), tb.Args("step", "git", "merge", "--verbose"), workingDir("/workspace/source")),
+					tb.Step("unstash-binaries", syntax.GitMergeImage, tb.Command("/bin/sh", "-c"), tb.Args("jx step unstash --name binaries --output target"), workingDir("/workspace/source")),
+					tb.Step("step2", "some-image", tb.Command("/bin/sh", "-c"), tb.Args("echo publish"), workingDir("/workspace/source")),
+				)),
+			},
+			structure: syntax_helpers_test.PipelineStructure("somepipeline-1",
+				syntax_helpers_test.StructureStage("Parent Stage",
+					syntax_helpers_test.StructureStageParallel("Build", "Publish"),
+				),
+				syntax_helpers_test.StructureStage("Build", syntax_helpers_test.StructureStageTaskRef("somepipeline-build-1"),
+					syntax_helpers_test.StructureStageDepth(1),
+					syntax_helpers_test.StructureStageParent("Parent Stage"),
+				),
+				syntax_helpers_test.StructureStage("Publish", syntax_helpers_test.StructureStageTaskRef("somepipeline-publish-1"),
+					syntax_helpers_test.StructureStageDepth(1),
+					syntax_helpers_test.StructureStageParent("Parent Stage"),
+				),
+			),
 		},
 		{
 			name: "stage_and_step_agent",
@@ -1524,6 +1691,60 @@ func TestFailedValidation(t *testing.T) {
 				Paths:   []string{"container"},
 			}).ViaField("agent"),
 		},
+		{
+			name: "unstash_without_stash",
+			expectedError: &apis.FieldError{
+				Message: "Unstash must refer to a stash defined in an earlier stage",
+				Details: "The stash 'binaries' used by the stage 'A Working Stage' is not defined in an earlier stage",
+			},
+		},
+		{
+			name: "stash_name_duplicates",
+			expectedError: &apis.FieldError{
+				Message: "Stash names must be unique",
+				Details: "The stash name 'binaries' is used more than once",
+			},
+		},
+		{
+			name:          "when_without_conditions",
+			expectedError: apis.ErrMissingOneOf("branch", "changedPaths", "env", "kind").ViaField("when").ViaFieldIndex("stages", 0),
+		},
+		{
+			name:          "matrix_without_axes",
+			expectedError: apis.ErrMissingField("axes").ViaField("matrix").ViaFieldIndex("stages", 0),
+		},
+		{
+			name: "matrix_exclusion_unknown_variable",
+			expectedError: (&apis.FieldError{
+				Message: "The matrix exclusion refers to GOOS which is not a matrix variable",
+				Paths:   []string{"values"},
+			}).ViaFieldIndex("exclude", 0).ViaField("matrix").ViaFieldIndex("stages", 0),
+		},
+		{
+			name: "matrix_with_stash",
+			expectedError: (&apis.FieldError{
+				Message: "A stage with a matrix cannot stash files as the stash name would be used by every cell",
+				Paths:   []string{"stash"},
+			}).ViaField("options").ViaFieldIndex("stages", 0),
+		},
+		{
+			name: "post_with_invalid_condition",
+			expectedError: (&apis.FieldError{
+				Message: "sometimes is not a valid post condition. Valid post conditions are success, failure, always",
+				Paths:   []string{"condition"},
+			}).ViaFieldIndex("post", 0).ViaFieldIndex("stages", 0),
+		},
+		{
+			name: "post_with_unknown_action",
+			expectedError: (&apis.FieldError{
+				Message: "mail is not a valid post action. Valid post actions are collect, comment, notify",
+				Paths:   []string{"name"},
+			}).ViaFieldIndex("actions", 0).ViaFieldIndex("post", 0),
+		},
+		{
+			name:          "post_action_without_required_option",
+			expectedError: apis.ErrMissingField("pattern").ViaField("options").ViaFieldIndex("actions", 0).ViaFieldIndex("post", 0).ViaFieldIndex("stages", 0),
+		},
 		{
 			name: "duplicate_step_names",
 			expectedError: (&apis.FieldError{
@@ -1570,6 +1791,92 @@ func TestFailedValidation(t *testing.T) {
 	}
 }
 
+func TestRemoveSkippedStages(t *testing.T) {
+	tests := []struct {
+		name      string
+		context   syntax.WhenContext
+		remaining []string
+		skipped   []syntax.SkippedStage
+	}{
+		{
+			name: "pull_request_changing_frontend",
+			context: syntax.WhenContext{
+				Branch:       "PR-1",
+				Kind:         "pullrequest",
+				ChangedPaths: []string{"frontend/src/app.js", "README.md"},
+			},
+			remaining: []string{"Build", "Services", "Frontend"},
+			skipped: []syntax.SkippedStage{
+				{Name: "Backend", Parents: []string{"Services"}},
+				{Name: "Release"},
+				{Name: "Nightly"},
+			},
+		},
+		{
+			name: "pull_request_changing_docs",
+			context: syntax.WhenContext{
+				Branch:       "PR-2",
+				Kind:         "pullrequest",
+				ChangedPaths: []string{"docs/index.md", "common/sub/util.go"},
+			},
+			remaining: []string{"Build"},
+			skipped: []syntax.SkippedStage{
+				{Name: "Frontend", Parents: []string{"Services"}},
+				{Name: "Backend", Parents: []string{"Services"}},
+				{Name: "Services"},
+				{Name: "Release"},
+				{Name: "Nightly"},
+			},
+		},
+		{
+			name: "nightly_release",
+			context: syntax.WhenContext{
+				Branch: "release-1.0",
+				Kind:   "release",
+				Env:    map[string]string{"NIGHTLY": "true"},
+			},
+			remaining: []string{"Build", "Services", "Frontend", "Backend", "Release", "Nightly"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectConfig, _, err := config.LoadProjectConfig(filepath.Join("test_data", "when"))
+			if err != nil {
+				t.Fatalf("Failed to parse YAML for %s: %q", tt.name, err)
+			}
+			parsed := projectConfig.PipelineConfig.Pipelines.Release.Pipeline
+
+			if validateErr := parsed.Validate(context.Background()); validateErr != nil {
+				t.Fatalf("Validation failed: %s", validateErr)
+			}
+
+			skipped, err := parsed.RemoveSkippedStages(&tt.context)
+			if err != nil {
+				t.Fatalf("Failed to remove skipped stages: %s", err)
+			}
+
+			var remaining []string
+			var names func(stages []syntax.Stage)
+			names = func(stages []syntax.Stage) {
+				for _, s := range stages {
+					remaining = append(remaining, s.Name)
+					names(s.Stages)
+					names(s.Parallel)
+				}
+			}
+			names(parsed.Stages)
+
+			if d := cmp.Diff(tt.remaining, remaining); d != "" {
+				t.Errorf("Remaining stages did not match expected: %s", d)
+			}
+			if d := cmp.Diff(tt.skipped, skipped); d != "" {
+				t.Errorf("Skipped stages did not match expected: %s", d)
+			}
+		})
+	}
+}
+
 func TestRfc1035LabelMangling(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,349 @@
+package syntax
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/knative/pkg/apis"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// PostStageName is the name of the stage generated to run the pipeline level post actions
+	PostStageName = "Post"
+
+	// PostFailureMarkerFile is the file written by a step wrapped for post actions when it fails. Its contents are the
+	// name of the step that failed. It lives outside of the source directory so that it is not passed on to later tasks.
+	PostFailureMarkerFile = "/workspace/.jx-post-failed-step"
+)
+
+// All possible post conditions, used for validation
+var allPostConditions = []PostCondition{PostConditionSuccess, PostConditionFailure, PostConditionAlways}
+
+// postActionDefinition describes a built-in post action which can be selected by PostAction.Name
+type postActionDefinition struct {
+	// The options which must be specified for the action
+	RequiredOptions []string
+	// Generates the shell command to run for the action from its options
+	Command func(options map[string]string) string
+}
+
+// builtinPostActions are the post actions which can be used in jenkins-x.yml, keyed by name
+var builtinPostActions = map[string]postActionDefinition{
+	"collect": {
+		RequiredOptions: []string{"pattern"},
+		Command: func(options map[string]string) string {
+			args := []string{"jx", "step", "stash", "--pattern", options["pattern"]}
+			classifier := options["classifier"]
+			if classifier == "" {
+				classifier = "reports"
+			}
+			args = append(args, "--classifier", classifier)
+			if options["basedir"] != "" {
+				args = append(args, "--basedir", options["basedir"])
+			}
+			return shellJoin(args)
+		},
+	},
+	"comment": {
+		RequiredOptions: []string{"comment"},
+		Command: func(options map[string]string) string {
+			return shellJoin([]string{"jx", "step", "pr", "comment", "--comment", options["comment"]})
+		},
+	},
+	"notify": {
+		Command: func(options map[string]string) string {
+			args := []string{"jx", "step", "notify"}
+			if options["channel"] != "" {
+				args = append(args, "--channel", options["channel"])
+			}
+			status := shellQuote(options["status"])
+			if options["status"] == "" {
+				// the activity is still running when the post actions run so use the outcome of the steps
+				status = fmt.Sprintf("\"$(if [ -f %s ]; then echo Failed; else echo Succeeded; fi)\"", PostFailureMarkerFile)
+			}
+			return shellJoin(args) + " --status " + status
+		},
+	},
+}
+
+func builtinPostActionNames() []string {
+	var names []string
+	for k := range builtinPostActions {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func allPostConditionsAsStrings() []string {
+	pc := make([]string, len(allPostConditions))
+
+	for i, c := range allPostConditions {
+		pc[i] = string(c)
+	}
+
+	return pc
+}
+
+func validatePosts(posts []Post) *apis.FieldError {
+	for i, p := range posts {
+		if err := validatePost(p).ViaFieldIndex("post", i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validatePost(p Post) *apis.FieldError {
+	isAllowed := false
+	for _, allowed := range allPostConditions {
+		if p.Condition == allowed {
+			isAllowed = true
+		}
+	}
+
+	if !isAllowed {
+		return &apis.FieldError{
+			Message: fmt.Sprintf("%s is not a valid post condition. Valid post conditions are %s", string(p.Condition),
+				strings.Join(allPostConditionsAsStrings(), ", ")),
+			Paths: []string{"condition"},
+		}
+	}
+
+	if len(p.Actions) == 0 {
+		return apis.ErrMissingField("actions")
+	}
+
+	for i, a := range p.Actions {
+		if err := validatePostAction(a).ViaFieldIndex("actions", i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validatePostAction(a PostAction) *apis.FieldError {
+	definition, ok := builtinPostActions[a.Name]
+	if !ok {
+		return &apis.FieldError{
+			Message: fmt.Sprintf("%s is not a valid post action. Valid post actions are %s", a.Name,
+				strings.Join(builtinPostActionNames(), ", ")),
+			Paths: []string{"name"},
+		}
+	}
+
+	for _, o := range definition.RequiredOptions {
+		if a.Options[o] == "" {
+			return apis.ErrMissingField(o).ViaField("options")
+		}
+	}
+
+	return nil
+}
+
+// Command returns the shell command which executes this post action.
+func (a *PostAction) Command() (string, error) {
+	definition, ok := builtinPostActions[a.Name]
+	if !ok {
+		return "", errors.Errorf("unknown post action %s", a.Name)
+	}
+	return definition.Command(a.Options), nil
+}
+
+// postsForTasks returns the pipeline level post actions which need to be run by every task in the pipeline. Since a
+// later task will not be run at all if an earlier one fails, the failure handling for "failure" and "always" needs to
+// happen in the task which failed.
+func postsForTasks(posts []Post) []Post {
+	var answer []Post
+	for _, p := range posts {
+		if p.Condition == PostConditionFailure || p.Condition == PostConditionAlways {
+			answer = append(answer, Post{Condition: PostConditionFailure, Actions: p.Actions})
+		}
+	}
+	return answer
+}
+
+// postStage returns the stage to run at the end of the pipeline for the "success" and "always" pipeline level post
+// actions, or nil if there are none. This stage is only run if every earlier stage succeeded.
+func (j *ParsedPipeline) postStage(defaultImage string) (*Stage, error) {
+	var steps []Step
+	for _, p := range j.Post {
+		if p.Condition != PostConditionSuccess && p.Condition != PostConditionAlways {
+			continue
+		}
+		for _, a := range p.Actions {
+			command, err := a.Command()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, Step{
+				Name:    fmt.Sprintf("post-%s-%d", a.Name, len(steps)+1),
+				Command: command,
+			})
+		}
+	}
+	if len(steps) == 0 {
+		return nil, nil
+	}
+	return &Stage{
+		Name:  PostStageName,
+		Agent: &Agent{Image: jxImage(defaultImage)},
+		Steps: steps,
+	}, nil
+}
+
+// addPostSteps appends a step for each post action guarded by its condition. If there are failure or always post
+// actions, the existing steps of the task are wrapped so that a failing step records its failure rather than failing
+// the task, and a final step is appended which fails the task if one of the original steps failed. Steps which can't
+// be wrapped, as their image may not have a shell, are left as they are: the failure post actions and the status check
+// are run before them instead, so they are skipped if an earlier step failed, but their own failure fails the task
+// without running the failure post actions.
+func addPostSteps(steps []corev1.Container, posts []Post, sourceDir string, env []corev1.EnvVar, parentContainer *corev1.Container, defaultImage string) ([]corev1.Container, error) {
+	var failurePosts []Post
+	for _, p := range posts {
+		if p.Condition == PostConditionFailure || p.Condition == PostConditionAlways {
+			failurePosts = append(failurePosts, Post{Condition: PostConditionFailure, Actions: p.Actions})
+		}
+	}
+
+	var answer []corev1.Container
+	postCounter := 0
+	statusCounter := 0
+	addPostActions := func(posts []Post) error {
+		for _, p := range posts {
+			for _, a := range p.Actions {
+				command, err := a.Command()
+				if err != nil {
+					return err
+				}
+				postCounter++
+				c, err := jxCommandContainer(fmt.Sprintf("post-%s-%s-%d", p.Condition, a.Name, postCounter), guardPostCommand(p.Condition, command), sourceDir, env, parentContainer, defaultImage)
+				if err != nil {
+					return err
+				}
+				answer = append(answer, *c)
+			}
+		}
+		return nil
+	}
+	addStatus := func() error {
+		name := "post-status"
+		if statusCounter > 0 {
+			name = fmt.Sprintf("post-status-%d", statusCounter)
+		}
+		statusCounter++
+		statusCommand := fmt.Sprintf("if [ -f %[1]s ]; then echo \"step $(cat %[1]s) failed\"; exit 1; fi", PostFailureMarkerFile)
+		c, err := jxCommandContainer(name, statusCommand, sourceDir, env, parentContainer, defaultImage)
+		if err != nil {
+			return err
+		}
+		answer = append(answer, *c)
+		return nil
+	}
+
+	if len(failurePosts) == 0 {
+		// a failing step fails the task before the post actions so there is no need to wrap the steps
+		answer = append(answer, steps...)
+		err := addPostActions(posts)
+		return answer, err
+	}
+
+	for _, s := range steps {
+		if !canWrapStepForPost(s) {
+			log.Logger().Warnf("The failure post actions are not run if step %s fails as it is not run by a shell", s.Name)
+			err := addPostActions(failurePosts)
+			if err != nil {
+				return nil, err
+			}
+			err = addStatus()
+			if err != nil {
+				return nil, err
+			}
+			answer = append(answer, s)
+			continue
+		}
+		answer = append(answer, wrapStepForPost(s))
+	}
+	err := addPostActions(posts)
+	if err != nil {
+		return nil, err
+	}
+	err = addStatus()
+	if err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// canWrapStepForPost returns false for the steps which wrapStepForPost can't wrap: the steps which run the entrypoint
+// of their image, and the steps which run kaniko whose image has no shell
+func canWrapStepForPost(step corev1.Container) bool {
+	return len(step.Command) > 0 && !strings.HasPrefix(step.Command[0], "/kaniko")
+}
+
+// wrapStepForPost changes the step so that it is skipped if an earlier step has failed, and records its failure in
+// PostFailureMarkerFile instead of exiting with an error.
+func wrapStepForPost(step corev1.Container) corev1.Container {
+	c := step.DeepCopy()
+	script := fmt.Sprintf("if [ -f %[1]s ]; then echo \"skipping as an earlier step failed\"; exit 0; fi\n\"$@\" || echo \"$0\" > %[1]s", PostFailureMarkerFile)
+	c.Args = append([]string{script, step.Name}, append(append([]string{}, step.Command...), step.Args...)...)
+	c.Command = []string{"/bin/sh", "-c"}
+	return *c
+}
+
+func guardPostCommand(condition PostCondition, command string) string {
+	switch condition {
+	case PostConditionSuccess:
+		return fmt.Sprintf("if [ ! -f %s ]; then %s; fi", PostFailureMarkerFile, command)
+	case PostConditionFailure:
+		return fmt.Sprintf("if [ -f %s ]; then %s; fi", PostFailureMarkerFile, command)
+	default:
+		return command
+	}
+}
+
+// jxCommandContainer returns a step which runs the given shell command in the jx image
+func jxCommandContainer(name string, command string, sourceDir string, env []corev1.EnvVar, parentContainer *corev1.Container, defaultImage string) (*corev1.Container, error) {
+	c := &corev1.Container{
+		Name:       MangleToRfc1035Label(name, ""),
+		Image:      jxImage(defaultImage),
+		Command:    []string{"/bin/sh", "-c"},
+		Args:       []string{command},
+		WorkingDir: filepath.Join(WorkingDirRoot, sourceDir),
+		Env:        env,
+	}
+
+	if parentContainer != nil {
+		merged, err := MergeContainers(parentContainer, c)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error merging jx step and parent container overrides: %s", err)
+		}
+		c = merged
+	}
+	return c, nil
+}
+
+// shellJoin joins the arguments into a single command, quoting any that need it.
+func shellJoin(args []string) string {
+	var quoted []string
+	for _, a := range args {
+		quoted = append(quoted, shellQuote(a))
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
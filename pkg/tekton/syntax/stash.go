@@ -0,0 +1,100 @@
+package syntax
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/knative/pkg/apis"
+	tektonv1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// stashStepContainer returns the step which stores the stashed files with `jx step stash` at the end of a stage
+func stashStepContainer(stash *Stash, sourceDir string, env []corev1.EnvVar, parentContainer *corev1.Container, defaultImage string) (*corev1.Container, error) {
+	command := shellJoin([]string{"jx", "step", "stash", "--name", stash.Name, "--pattern", stash.Files})
+	return jxCommandContainer("stash-"+stash.Name, command, sourceDir, env, parentContainer, defaultImage)
+}
+
+// unstashStepContainer returns the step which copies previously stashed files into the workspace with
+// `jx step unstash` at the start of a stage
+func unstashStepContainer(unstash *Unstash, sourceDir string, env []corev1.EnvVar, parentContainer *corev1.Container, defaultImage string) (*corev1.Container, error) {
+	dir := unstash.Dir
+	if dir == "" {
+		dir = "."
+	}
+	command := shellJoin([]string{"jx", "step", "unstash", "--name", unstash.Name, "--output", dir})
+	return jxCommandContainer("unstash-"+unstash.Name, command, sourceDir, env, parentContainer, defaultImage)
+}
+
+// validateStashNames makes sure that stash names are unique and that every unstash refers to a stash defined in a
+// stage before it in the pipeline.
+func validateStashNames(j *ParsedPipeline) *apis.FieldError {
+	stashes := make(map[string]bool)
+
+	var validate func(stages []Stage) *apis.FieldError
+	validate = func(stages []Stage) *apis.FieldError {
+		for _, stage := range stages {
+			if o := stage.Options; o != nil {
+				if o.Unstash != nil && !stashes[o.Unstash.Name] {
+					return &apis.FieldError{
+						Message: "Unstash must refer to a stash defined in an earlier stage",
+						Details: fmt.Sprintf("The stash '%s' used by the stage '%s' is not defined in an earlier stage", o.Unstash.Name, stage.Name),
+					}
+				}
+				if o.Stash != nil {
+					if stashes[o.Stash.Name] {
+						return &apis.FieldError{
+							Message: "Stash names must be unique",
+							Details: fmt.Sprintf("The stash name '%s' is used more than once", o.Stash.Name),
+						}
+					}
+					stashes[o.Stash.Name] = true
+				}
+			}
+			if err := validate(stage.Stages); err != nil {
+				return err
+			}
+			if err := validate(stage.Parallel); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return validate(j.Stages)
+}
+
+// addUnstashDependencies makes each task which unstashes files run after the task which stashed them. This is already
+// the case for sequential stages, but not if the stash comes from a parallel sibling.
+func addUnstashDependencies(p *tektonv1alpha1.Pipeline, stages []*transformedStage) {
+	stashTasks := make(map[string]string)
+	var unstashStages []*transformedStage
+
+	for _, stage := range stages {
+		for _, ls := range stage.getLinearStages() {
+			o := ls.Stage.Options
+			if o == nil || ls.PipelineTask == nil {
+				continue
+			}
+			if o.Stash != nil {
+				stashTasks[o.Stash.Name] = ls.PipelineTask.Name
+			}
+			if o.Unstash != nil {
+				unstashStages = append(unstashStages, ls)
+			}
+		}
+	}
+
+	for _, ls := range unstashStages {
+		stashTask, ok := stashTasks[ls.Stage.Options.Unstash.Name]
+		if !ok {
+			continue
+		}
+		for i := range p.Spec.Tasks {
+			pt := &p.Spec.Tasks[i]
+			if pt.Name == ls.PipelineTask.Name && util.StringArrayIndex(pt.RunAfter, stashTask) < 0 {
+				pt.RunAfter = append(pt.RunAfter, stashTask)
+			}
+		}
+	}
+}
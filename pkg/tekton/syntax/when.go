@@ -0,0 +1,157 @@
+package syntax
+
+import (
+	"fmt"
+
+	"github.com/gobwas/glob"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/knative/pkg/apis"
+	"github.com/pkg/errors"
+)
+
+// WhenContext is the information about the build which the when conditions on stages are evaluated against.
+// +k8s:deepcopy-gen=false
+type WhenContext struct {
+	// The branch being built
+	Branch string
+	// The kind of pipeline being generated, such as pullrequest or release
+	Kind string
+	// The files changed by the pull request. If nil the changed files are not known, and conditions on them are
+	// treated as met so that the stage is run.
+	ChangedPaths []string
+	// The environment variables for the build
+	Env map[string]string
+}
+
+// SkippedStage is a stage which was removed from the pipeline as its when conditions were not met.
+// +k8s:deepcopy-gen=false
+type SkippedStage struct {
+	Name string
+	// The names of the enclosing stages, outermost first
+	Parents []string
+}
+
+// RemoveSkippedStages removes the stages whose when conditions are not met from the pipeline, along with any parent
+// stage left with no stages in it, and returns the stages which were removed.
+func (j *ParsedPipeline) RemoveSkippedStages(ctx *WhenContext) ([]SkippedStage, error) {
+	var skipped []SkippedStage
+
+	var remove func(stages []Stage, parents []string) ([]Stage, error)
+	remove = func(stages []Stage, parents []string) ([]Stage, error) {
+		var answer []Stage
+		for _, s := range stages {
+			run, err := s.When.matches(ctx)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to evaluate the when conditions of stage %s", s.Name)
+			}
+			if run && (len(s.Stages) > 0 || len(s.Parallel) > 0) {
+				childParents := append(append([]string{}, parents...), s.Name)
+				if s.Stages, err = remove(s.Stages, childParents); err != nil {
+					return nil, err
+				}
+				if s.Parallel, err = remove(s.Parallel, childParents); err != nil {
+					return nil, err
+				}
+				run = len(s.Stages) > 0 || len(s.Parallel) > 0
+			}
+			if run {
+				answer = append(answer, s)
+			} else {
+				skipped = append(skipped, SkippedStage{Name: s.Name, Parents: parents})
+			}
+		}
+		return answer, nil
+	}
+
+	stages, err := remove(j.Stages, nil)
+	if err != nil {
+		return nil, err
+	}
+	j.Stages = stages
+
+	if err := validateStashNames(j); err != nil {
+		return nil, errors.Errorf("%s after removing skipped stages: %s", err.Message, err.Details)
+	}
+	return skipped, nil
+}
+
+// matches returns true if every condition is met, or if there are no conditions.
+func (w *StageWhen) matches(ctx *WhenContext) (bool, error) {
+	if w == nil {
+		return true, nil
+	}
+	if len(w.Kind) > 0 && util.StringArrayIndex(w.Kind, ctx.Kind) < 0 {
+		return false, nil
+	}
+	if len(w.Branch) > 0 {
+		matched, err := matchesAny(w.Branch, []string{ctx.Branch})
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	if len(w.ChangedPaths) > 0 && ctx.ChangedPaths != nil {
+		matched, err := matchesAny(w.ChangedPaths, ctx.ChangedPaths, '/')
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	for _, e := range w.Env {
+		if value, ok := ctx.Env[e.Name]; !ok || value != e.Value {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// matchesAny returns true if any of the values matches any of the glob patterns
+func matchesAny(patterns []string, values []string, separators ...rune) (bool, error) {
+	for _, p := range patterns {
+		g, err := glob.Compile(p, separators...)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid pattern %s", p)
+		}
+		for _, v := range values {
+			if g.Match(v) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func invalidPattern(field string, index int, pattern string) *apis.FieldError {
+	return &apis.FieldError{
+		Message: fmt.Sprintf("%s is not a valid pattern", pattern),
+		Paths:   []string{fmt.Sprintf("%s[%d]", field, index)},
+	}
+}
+
+func validateWhen(w *StageWhen) *apis.FieldError {
+	if w == nil {
+		return nil
+	}
+
+	if len(w.Branch) == 0 && len(w.ChangedPaths) == 0 && len(w.Env) == 0 && len(w.Kind) == 0 {
+		return apis.ErrMissingOneOf("branch", "changedPaths", "env", "kind")
+	}
+
+	for i, p := range w.Branch {
+		if _, err := glob.Compile(p); err != nil {
+			return invalidPattern("branch", i, p)
+		}
+	}
+
+	for i, p := range w.ChangedPaths {
+		if _, err := glob.Compile(p, '/'); err != nil {
+			return invalidPattern("changedPaths", i, p)
+		}
+	}
+
+	for i, e := range w.Env {
+		if e.Name == "" {
+			return apis.ErrMissingField("name").ViaFieldIndex("env", i)
+		}
+	}
+
+	return nil
+}
@@ -52,6 +52,80 @@ func (in *Loop) DeepCopy() *Loop {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Matrix) DeepCopyInto(out *Matrix) {
+	*out = *in
+	if in.Axes != nil {
+		in, out := &in.Axes, &out.Axes
+		*out = make([]MatrixAxis, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]MatrixExclusion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Matrix.
+func (in *Matrix) DeepCopy() *Matrix {
+	if in == nil {
+		return nil
+	}
+	out := new(Matrix)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatrixAxis) DeepCopyInto(out *MatrixAxis) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatrixAxis.
+func (in *MatrixAxis) DeepCopy() *MatrixAxis {
+	if in == nil {
+		return nil
+	}
+	out := new(MatrixAxis)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatrixExclusion) DeepCopyInto(out *MatrixExclusion) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatrixExclusion.
+func (in *MatrixExclusion) DeepCopy() *MatrixExclusion {
+	if in == nil {
+		return nil
+	}
+	out := new(MatrixExclusion)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ParsedPipeline) DeepCopyInto(out *ParsedPipeline) {
 	*out = *in
@@ -323,6 +397,24 @@ func (in *Stage) DeepCopyInto(out *Stage) {
 			**out = **in
 		}
 	}
+	if in.Matrix != nil {
+		in, out := &in.Matrix, &out.Matrix
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(Matrix)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.When != nil {
+		in, out := &in.When, &out.When
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(StageWhen)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	if in.Environment != nil {
 		in, out := &in.Environment, &out.Environment
 		*out = make([]v1.EnvVar, len(*in))
@@ -395,6 +487,44 @@ func (in *StageOptions) DeepCopy() *StageOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StageWhen) DeepCopyInto(out *StageWhen) {
+	*out = *in
+	if in.Branch != nil {
+		in, out := &in.Branch, &out.Branch
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ChangedPaths != nil {
+		in, out := &in.ChangedPaths, &out.ChangedPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Kind != nil {
+		in, out := &in.Kind, &out.Kind
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StageWhen.
+func (in *StageWhen) DeepCopy() *StageWhen {
+	if in == nil {
+		return nil
+	}
+	out := new(StageWhen)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Stash) DeepCopyInto(out *Stash) {
 	*out = *in
@@ -0,0 +1,252 @@
+package workflow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StepName returns the name of the workflow step which defaults to the environment of a promote step
+func StepName(step *v1.WorkflowStep) string {
+	if step.Name != "" {
+		return step.Name
+	}
+	if step.Promote != nil {
+		return step.Promote.Environment
+	}
+	return ""
+}
+
+// FindStep returns the step of the workflow with the given name or nil if there isn't one
+func FindStep(flow *v1.Workflow, name string) *v1.WorkflowStep {
+	for i := range flow.Spec.Steps {
+		step := &flow.Spec.Steps[i]
+		if StepName(step) == name {
+			return step
+		}
+	}
+	return nil
+}
+
+// StepDependencies returns the names of the steps which need to have succeeded before the step can be triggered. The
+// environment preconditions are the names of the steps which promote to those environments, or the names of the
+// environments themselves if the workflow does not promote to them
+func StepDependencies(flow *v1.Workflow, step *v1.WorkflowStep) []string {
+	answer := []string{}
+	for _, envName := range step.Preconditions.Environments {
+		name := envName
+		promote := FindPromoteStep(flow, envName)
+		if promote != nil {
+			name = StepName(promote)
+		}
+		if util.StringArrayIndex(answer, name) < 0 {
+			answer = append(answer, name)
+		}
+	}
+	for _, name := range step.Preconditions.Steps {
+		if util.StringArrayIndex(answer, name) < 0 {
+			answer = append(answer, name)
+		}
+	}
+	return answer
+}
+
+// ValidateWorkflow returns an error if the steps of the workflow do not have unique names, are not a promotion or an
+// approval, have invalid timeouts or have dependencies which are missing or form a cycle
+func ValidateWorkflow(flow *v1.Workflow) error {
+	names := map[string]bool{}
+	for i := range flow.Spec.Steps {
+		step := &flow.Spec.Steps[i]
+		name := StepName(step)
+		if name == "" {
+			return fmt.Errorf("step %d of workflow %s has no name", i+1, flow.Name)
+		}
+		if names[name] {
+			return fmt.Errorf("workflow %s has more than one step called %s", flow.Name, name)
+		}
+		names[name] = true
+		if (step.Promote == nil) == (step.Approval == nil) {
+			return fmt.Errorf("step %s of workflow %s should either promote or be an approval", name, flow.Name)
+		}
+		if step.Timeout != "" {
+			_, err := time.ParseDuration(step.Timeout)
+			if err != nil {
+				return errors.Wrapf(err, "invalid timeout for step %s of workflow %s", name, flow.Name)
+			}
+		}
+	}
+	for i := range flow.Spec.Steps {
+		step := &flow.Spec.Steps[i]
+		for _, dependency := range step.Preconditions.Steps {
+			if !names[dependency] {
+				return fmt.Errorf("step %s of workflow %s depends on the missing step %s", StepName(step), flow.Name, dependency)
+			}
+		}
+	}
+	_, err := WorkflowStages(flow)
+	return err
+}
+
+// WorkflowStages returns the steps of the workflow grouped into stages, where each step only depends on the steps of
+// earlier stages so that the steps of a stage can run in parallel. Returns an error if the dependencies form a cycle
+func WorkflowStages(flow *v1.Workflow) ([][]*v1.WorkflowStep, error) {
+	remaining := map[string]*v1.WorkflowStep{}
+	order := []string{}
+	for i := range flow.Spec.Steps {
+		step := &flow.Spec.Steps[i]
+		name := StepName(step)
+		remaining[name] = step
+		order = append(order, name)
+	}
+	answer := [][]*v1.WorkflowStep{}
+	for len(remaining) > 0 {
+		stage := []*v1.WorkflowStep{}
+		for _, name := range order {
+			step := remaining[name]
+			if step == nil {
+				continue
+			}
+			ready := true
+			for _, dependency := range StepDependencies(flow, step) {
+				// environments which the workflow does not promote to are not steps so are ignored
+				if remaining[dependency] != nil {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				stage = append(stage, step)
+			}
+		}
+		if len(stage) == 0 {
+			cycle := []string{}
+			for name := range remaining {
+				cycle = append(cycle, name)
+			}
+			sort.Strings(cycle)
+			return nil, fmt.Errorf("the dependencies of steps %s of workflow %s form a cycle", strings.Join(cycle, ", "), flow.Name)
+		}
+		for _, step := range stage {
+			name := StepName(step)
+			delete(remaining, name)
+		}
+		answer = append(answer, stage)
+	}
+	return answer, nil
+}
+
+// GetStepStatus returns the status of the workflow step on the PipelineActivity or nil if the step has not started
+func GetStepStatus(activity *v1.PipelineActivity, step *v1.WorkflowStep) *v1.CoreActivityStep {
+	name := StepName(step)
+	for i := range activity.Spec.Steps {
+		s := &activity.Spec.Steps[i]
+		if step.Promote != nil && s.Promote != nil && s.Promote.Environment == step.Promote.Environment {
+			return &s.Promote.CoreActivityStep
+		}
+		if step.Approval != nil && s.Approval != nil && s.Approval.Name == name {
+			return &s.Approval.CoreActivityStep
+		}
+	}
+	return nil
+}
+
+// DependenciesSucceeded returns an empty string if all the steps the workflow step depends on have succeeded,
+// otherwise the reason it cannot be triggered yet
+func DependenciesSucceeded(flow *v1.Workflow, activity *v1.PipelineActivity, step *v1.WorkflowStep) string {
+	for _, name := range StepDependencies(flow, step) {
+		dependency := FindStep(flow, name)
+		if dependency == nil {
+			// an environment the workflow does not promote to
+			promote := CreateWorkflowPromoteStep(name)
+			dependency = &promote
+		}
+		status := GetStepStatus(activity, dependency)
+		if status == nil {
+			return fmt.Sprintf("step %s has not started", name)
+		}
+		if status.Status != v1.ActivityStatusTypeSucceeded {
+			return fmt.Sprintf("step %s has status %s", name, string(status.Status))
+		}
+	}
+	return ""
+}
+
+// StepTimedOut returns true if the started step is not complete within the timeout of the workflow step
+func StepTimedOut(step *v1.WorkflowStep, status *v1.CoreActivityStep, now time.Time) (bool, error) {
+	if step.Timeout == "" || status == nil || status.StartedTimestamp == nil || status.Status.IsTerminated() {
+		return false, nil
+	}
+	timeout, err := time.ParseDuration(step.Timeout)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid timeout for step %s", StepName(step))
+	}
+	return now.After(status.StartedTimestamp.Add(timeout)), nil
+}
+
+// FailStep marks the started step as failed on the PipelineActivity with the reason as its description
+func FailStep(status *v1.CoreActivityStep, reason string) {
+	status.Status = v1.ActivityStatusTypeFailed
+	status.Description = reason
+	if status.CompletedTimestamp == nil {
+		status.CompletedTimestamp = &metav1.Time{Time: time.Now()}
+	}
+}
+
+// StartApproval adds the step waiting for the approval of the workflow step to the PipelineActivity. Returns true if
+// the activity changed
+func StartApproval(activity *v1.PipelineActivity, step *v1.WorkflowStep) bool {
+	if step.Approval == nil || GetStepStatus(activity, step) != nil {
+		return false
+	}
+	activity.Spec.Steps = append(activity.Spec.Steps, v1.PipelineActivityStep{
+		Kind: v1.ActivityStepKindTypeApproval,
+		Approval: &v1.ApprovalActivityStep{
+			CoreActivityStep: v1.CoreActivityStep{
+				Name:             StepName(step),
+				Description:      step.Approval.Message,
+				Status:           v1.ActivityStatusTypeWaitingForApproval,
+				StartedTimestamp: &metav1.Time{Time: time.Now()},
+			},
+		},
+	})
+	return true
+}
+
+// ApproveStep records that the user approved or rejected the approval step of the workflow which the
+// PipelineActivity is waiting for
+func ApproveStep(jxClient versioned.Interface, ns string, activity *v1.PipelineActivity, flow *v1.Workflow, stepName string, user string, approve bool) error {
+	step := FindStep(flow, stepName)
+	if step == nil || step.Approval == nil {
+		return fmt.Errorf("workflow %s has no approval step called %s", flow.Name, stepName)
+	}
+	var approval *v1.ApprovalActivityStep
+	for i := range activity.Spec.Steps {
+		s := activity.Spec.Steps[i].Approval
+		if s != nil && s.Name == stepName {
+			approval = s
+		}
+	}
+	if approval == nil || approval.Status != v1.ActivityStatusTypeWaitingForApproval {
+		return fmt.Errorf("PipelineActivity %s is not waiting for the approval of step %s", activity.Name, stepName)
+	}
+	approval.Approver = user
+	approval.CompletedTimestamp = &metav1.Time{Time: time.Now()}
+	if approve {
+		approval.Status = v1.ActivityStatusTypeSucceeded
+	} else {
+		approval.Status = v1.ActivityStatusTypeFailed
+		approval.Description = fmt.Sprintf("rejected by %s", user)
+	}
+	_, err := jxClient.JenkinsV1().PipelineActivities(ns).PatchUpdate(activity)
+	if err != nil {
+		return errors.Wrapf(err, "failed to record the approval of step %s on PipelineActivity %s", stepName, activity.Name)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package workflow_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/pkg/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// createFanOutWorkflow promotes to eu-staging and us-staging in parallel, then to production once both succeed and
+// the release is approved
+func createFanOutWorkflow() *v1.Workflow {
+	euStaging := workflow.CreateWorkflowPromoteStep("eu-staging")
+	usStaging := workflow.CreateWorkflowPromoteStep("us-staging")
+	approval := workflow.CreateWorkflowApprovalStep("approve-production", euStaging, usStaging)
+	approval.Timeout = "24h"
+	production := workflow.CreateWorkflowPromoteStep("production", euStaging, usStaging)
+	production.Preconditions.Steps = []string{"approve-production"}
+	return workflow.CreateWorkflow(testNamespace, "fanout", euStaging, usStaging, approval, production)
+}
+
+func stageNames(stages [][]*v1.WorkflowStep) [][]string {
+	answer := [][]string{}
+	for _, stage := range stages {
+		names := []string{}
+		for _, step := range stage {
+			names = append(names, workflow.StepName(step))
+		}
+		answer = append(answer, names)
+	}
+	return answer
+}
+
+func TestWorkflowStages(t *testing.T) {
+	t.Parallel()
+
+	flow := createFanOutWorkflow()
+	require.NoError(t, workflow.ValidateWorkflow(flow))
+
+	assert.Equal(t, []string{"eu-staging", "us-staging", "approve-production"}, workflow.StepDependencies(flow, workflow.FindStep(flow, "production")))
+
+	stages, err := workflow.WorkflowStages(flow)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"eu-staging", "us-staging"}, {"approve-production"}, {"production"}}, stageNames(stages))
+}
+
+func TestValidateWorkflow(t *testing.T) {
+	t.Parallel()
+
+	cycle := createFanOutWorkflow()
+	cycle.Spec.Steps[0].Preconditions.Steps = []string{"production"}
+	err := workflow.ValidateWorkflow(cycle)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cycle")
+
+	missing := createFanOutWorkflow()
+	missing.Spec.Steps[3].Preconditions.Steps = []string{"approve-everything"}
+	assert.Error(t, workflow.ValidateWorkflow(missing))
+
+	duplicate := createFanOutWorkflow()
+	duplicate.Spec.Steps[1].Promote.Environment = "eu-staging"
+	assert.Error(t, workflow.ValidateWorkflow(duplicate))
+
+	timeout := createFanOutWorkflow()
+	timeout.Spec.Steps[2].Timeout = "a day"
+	assert.Error(t, workflow.ValidateWorkflow(timeout))
+}
+
+func TestApprovalStep(t *testing.T) {
+	t.Parallel()
+
+	flow := createFanOutWorkflow()
+	approval := workflow.FindStep(flow, "approve-production")
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myorg-myapp-master-1",
+			Namespace: testNamespace,
+		},
+		Spec: v1.PipelineActivitySpec{
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypePromote,
+					Promote: &v1.PromoteActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{Status: v1.ActivityStatusTypeSucceeded},
+						Environment:      "eu-staging",
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, "step us-staging has not started", workflow.DependenciesSucceeded(flow, activity, approval))
+
+	activity.Spec.Steps = append(activity.Spec.Steps, v1.PipelineActivityStep{
+		Kind: v1.ActivityStepKindTypePromote,
+		Promote: &v1.PromoteActivityStep{
+			CoreActivityStep: v1.CoreActivityStep{Status: v1.ActivityStatusTypeSucceeded},
+			Environment:      "us-staging",
+		},
+	})
+	assert.Equal(t, "", workflow.DependenciesSucceeded(flow, activity, approval))
+	assert.Equal(t, "step approve-production has not started", workflow.DependenciesSucceeded(flow, activity, workflow.FindStep(flow, "production")))
+
+	assert.True(t, workflow.StartApproval(activity, approval))
+	assert.False(t, workflow.StartApproval(activity, approval), "the approval has already started")
+	status := workflow.GetStepStatus(activity, approval)
+	require.NotNil(t, status)
+	assert.Equal(t, v1.ActivityStatusTypeWaitingForApproval, status.Status)
+
+	timedOut, err := workflow.StepTimedOut(approval, status, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, timedOut)
+	timedOut, err = workflow.StepTimedOut(approval, status, time.Now().Add(25*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, timedOut)
+
+	jxClient := fake.NewSimpleClientset(activity)
+	err = workflow.ApproveStep(jxClient, testNamespace, activity, flow, "approve-production", "alice", true)
+	require.NoError(t, err)
+
+	updated, err := jxClient.JenkinsV1().PipelineActivities(testNamespace).Get(activity.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	status = workflow.GetStepStatus(updated, approval)
+	require.NotNil(t, status)
+	assert.Equal(t, v1.ActivityStatusTypeSucceeded, status.Status)
+	assert.Equal(t, "", workflow.DependenciesSucceeded(flow, updated, workflow.FindStep(flow, "production")))
+
+	err = workflow.ApproveStep(jxClient, testNamespace, updated, flow, "approve-production", "alice", false)
+	assert.Error(t, err, "the step is no longer waiting for approval")
+}
@@ -0,0 +1,246 @@
+package workflow
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// LabelPipelineName the label on a Fact with the name of the PipelineActivity it is about
+	LabelPipelineName = "pipelineName"
+)
+
+var gatePattern = regexp.MustCompile(`^\s*([^\s=!<>]+)\s*(==|!=|>=|<=|>|<)\s*(\S+)\s*$`)
+
+// Gate is a condition on a Measurement or Statement of the Facts about a PipelineActivity, parsed from an expression
+// like 'jx.coverage/Covered >= 80'. The fact type is optional, so 'manual-approval == true' matches the Statement
+// named manual-approval in any Fact.
+type Gate struct {
+	Expression string
+	FactType   string
+	Name       string
+	Operator   string
+	// Statement is true if the gate compares a Statement with true or false, rather than a Measurement with a number
+	Statement bool
+	Value     int
+	BoolValue bool
+}
+
+// ParseGate parses a gate expression
+func ParseGate(expression string) (*Gate, error) {
+	matches := gatePattern.FindStringSubmatch(expression)
+	if matches == nil {
+		return nil, errors.Errorf("invalid gate '%s', it should be like 'factType/name >= 80' or 'name == true'", expression)
+	}
+	gate := &Gate{
+		Expression: strings.TrimSpace(expression),
+		Name:       matches[1],
+		Operator:   matches[2],
+	}
+	if i := strings.LastIndex(gate.Name, "/"); i >= 0 {
+		gate.FactType = gate.Name[:i]
+		gate.Name = gate.Name[i+1:]
+	}
+	if gate.Name == "" {
+		return nil, errors.Errorf("invalid gate '%s', it has no measurement or statement name", expression)
+	}
+	value := matches[3]
+	switch strings.ToLower(value) {
+	case "true", "false":
+		if gate.Operator != "==" && gate.Operator != "!=" {
+			return nil, errors.Errorf("invalid gate '%s', statements can only be compared with == or !=", expression)
+		}
+		gate.Statement = true
+		gate.BoolValue = strings.ToLower(value) == "true"
+	default:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, errors.Errorf("invalid gate '%s', the value %s should be a number, true or false", expression, value)
+		}
+		gate.Value = n
+	}
+	return gate, nil
+}
+
+// Evaluate returns an empty string if the gate passes for the facts, otherwise the reason it fails. Every matching
+// Measurement or Statement must pass, and the gate fails if there isn't one.
+func (g *Gate) Evaluate(facts []v1.Fact) string {
+	found := false
+	for _, fact := range facts {
+		if g.FactType != "" && g.FactType != fact.Spec.FactType {
+			continue
+		}
+		if g.Statement {
+			for _, s := range fact.Spec.Statements {
+				if s.Name != g.Name {
+					continue
+				}
+				found = true
+				if (s.MeasurementValue == g.BoolValue) != (g.Operator == "==") {
+					return fmt.Sprintf("gate '%s' failed as %s is %t in Fact %s", g.Expression, g.Name, s.MeasurementValue, fact.Name)
+				}
+			}
+			continue
+		}
+		for _, m := range fact.Spec.Measurements {
+			if m.Name != g.Name {
+				continue
+			}
+			found = true
+			if !g.compare(m.MeasurementValue) {
+				return fmt.Sprintf("gate '%s' failed as %s is %d in Fact %s", g.Expression, g.Name, m.MeasurementValue, fact.Name)
+			}
+		}
+	}
+	if !found {
+		kind := "measurement"
+		if g.Statement {
+			kind = "statement"
+		}
+		return fmt.Sprintf("gate '%s' failed as no Fact has the %s %s", g.Expression, kind, g.Name)
+	}
+	return ""
+}
+
+func (g *Gate) compare(value int) bool {
+	switch g.Operator {
+	case "==":
+		return value == g.Value
+	case "!=":
+		return value != g.Value
+	case ">=":
+		return value >= g.Value
+	case "<=":
+		return value <= g.Value
+	case ">":
+		return value > g.Value
+	case "<":
+		return value < g.Value
+	default:
+		return false
+	}
+}
+
+// EvaluateGates returns an empty string if all the gates pass for the facts, otherwise the reason the first failing
+// gate fails
+func EvaluateGates(gates []string, facts []v1.Fact) (string, error) {
+	for _, expression := range gates {
+		gate, err := ParseGate(expression)
+		if err != nil {
+			return "", err
+		}
+		reason := gate.Evaluate(facts)
+		if reason != "" {
+			return reason, nil
+		}
+	}
+	return "", nil
+}
+
+// FindActivityFacts returns the Facts about the PipelineActivity, which have its name as their pipelineName label
+func FindActivityFacts(jxClient versioned.Interface, ns string, activityName string) ([]v1.Fact, error) {
+	selector := LabelPipelineName + "=" + activityName
+	list, err := jxClient.JenkinsV1().Facts(ns).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the Facts in namespace %s with selector %s", ns, selector)
+	}
+	return list.Items, nil
+}
+
+// FindPromoteStep returns the step of the workflow which promotes to the environment, or nil if there isn't one
+func FindPromoteStep(workflow *v1.Workflow, envName string) *v1.WorkflowStep {
+	for i := range workflow.Spec.Steps {
+		step := &workflow.Spec.Steps[i]
+		if step.Promote != nil && step.Promote.Environment == envName {
+			return step
+		}
+	}
+	return nil
+}
+
+// CheckPromotion is like EvaluatePromotionGates but also blocks the promotion while the deployment windows of the
+// environment do not allow it, so that the promotion is queued until they do. The activity is not modified so the
+// caller records the reason with SetPromoteBlockedReason or RecordPromoteBlockedReason.
+func CheckPromotion(jxClient versioned.Interface, ns string, activity *v1.PipelineActivity, step *v1.WorkflowStep, envName string) (string, error) {
+	reason, err := EvaluatePromotionGates(jxClient, ns, activity, step)
+	if err != nil {
+		return "", err
+	}
+	if reason == "" {
+		env, err := jxClient.JenkinsV1().Environments(ns).Get(envName, metav1.GetOptions{})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get Environment %s", envName)
+		}
+		reason, err = kube.CheckDeploymentWindows(env, time.Now())
+		if err != nil {
+			return "", err
+		}
+	}
+	return reason, nil
+}
+
+// EvaluatePromotionGates returns an empty string if the gates of the workflow step pass for the Facts about the
+// PipelineActivity, otherwise the reason the first failing gate fails
+func EvaluatePromotionGates(jxClient versioned.Interface, ns string, activity *v1.PipelineActivity, step *v1.WorkflowStep) (string, error) {
+	if len(step.Preconditions.Gates) == 0 {
+		return "", nil
+	}
+	facts, err := FindActivityFacts(jxClient, ns, activity.Name)
+	if err != nil {
+		return "", err
+	}
+	return EvaluateGates(step.Preconditions.Gates, facts)
+}
+
+// RecordPromoteBlockedReason records the reason the promotion to the environment is blocked on the PipelineActivity,
+// clearing it if the reason is empty
+func RecordPromoteBlockedReason(jxClient versioned.Interface, ns string, activity *v1.PipelineActivity, envName string, reason string) error {
+	if !SetPromoteBlockedReason(activity, envName, reason) {
+		return nil
+	}
+	_, err := jxClient.JenkinsV1().PipelineActivities(ns).PatchUpdate(activity)
+	if err != nil {
+		return errors.Wrapf(err, "failed to record the blocked reason on PipelineActivity %s", activity.Name)
+	}
+	return nil
+}
+
+// SetPromoteBlockedReason sets the reason the promotion to the environment is blocked on its PromoteActivityStep,
+// adding a pending step if the promotion is blocked and there isn't one yet. Returns true if the activity changed.
+func SetPromoteBlockedReason(activity *v1.PipelineActivity, envName string, reason string) bool {
+	spec := &activity.Spec
+	for i := range spec.Steps {
+		promote := spec.Steps[i].Promote
+		if promote != nil && promote.Environment == envName {
+			if promote.BlockedReason == reason {
+				return false
+			}
+			promote.BlockedReason = reason
+			return true
+		}
+	}
+	if reason == "" {
+		return false
+	}
+	spec.Steps = append(spec.Steps, v1.PipelineActivityStep{
+		Kind: v1.ActivityStepKindTypePromote,
+		Promote: &v1.PromoteActivityStep{
+			CoreActivityStep: v1.CoreActivityStep{
+				Status:           v1.ActivityStatusTypePending,
+				StartedTimestamp: &metav1.Time{Time: time.Now()},
+			},
+			Environment:   envName,
+			BlockedReason: reason,
+		},
+	})
+	return true
+}
@@ -0,0 +1,218 @@
+package workflow_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/pkg/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testNamespace = "jx"
+
+func createFact(name string, factType string, activityName string) *v1.Fact {
+	return &v1.Fact{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				workflow.LabelPipelineName: activityName,
+			},
+		},
+		Spec: v1.FactSpec{
+			Name:     name,
+			FactType: factType,
+			SubjectReference: v1.ResourceReference{
+				Kind: "PipelineActivity",
+				Name: activityName,
+			},
+		},
+	}
+}
+
+func TestParseGate(t *testing.T) {
+	t.Parallel()
+
+	gate, err := workflow.ParseGate("jx.coverage/Covered >= 80")
+	require.NoError(t, err)
+	assert.Equal(t, "jx.coverage", gate.FactType)
+	assert.Equal(t, "Covered", gate.Name)
+	assert.Equal(t, ">=", gate.Operator)
+	assert.Equal(t, 80, gate.Value)
+	assert.False(t, gate.Statement)
+
+	gate, err = workflow.ParseGate("manual-approval==true")
+	require.NoError(t, err)
+	assert.Equal(t, "", gate.FactType)
+	assert.Equal(t, "manual-approval", gate.Name)
+	assert.True(t, gate.Statement)
+	assert.True(t, gate.BoolValue)
+
+	for _, invalid := range []string{"", "coverage", "coverage >= lots", "approved > true", "jx.coverage/ >= 1"} {
+		_, err = workflow.ParseGate(invalid)
+		assert.Error(t, err, "gate '%s' should be invalid", invalid)
+	}
+}
+
+func TestEvaluateGates(t *testing.T) {
+	t.Parallel()
+
+	coverage := createFact("coverage", v1.FactTypeCoverage, "myorg-myapp-master-1")
+	coverage.Spec.Measurements = []v1.Measurement{
+		{Name: v1.CodeCoverageMeasurementCoverage, MeasurementType: v1.MeasurementPercent, MeasurementValue: 75},
+	}
+	approval := createFact("approval", "approval", "myorg-myapp-master-1")
+	approval.Spec.Statements = []v1.Statement{
+		{Name: "manual-approval", MeasurementValue: true},
+	}
+	facts := []v1.Fact{*coverage, *approval}
+
+	testCases := []struct {
+		gates  []string
+		reason string
+	}{
+		{gates: nil},
+		{gates: []string{"jx.coverage/Covered >= 70", "manual-approval == true"}},
+		{gates: []string{"Covered < 80", "manual-approval != false"}},
+		{
+			gates:  []string{"manual-approval == true", "jx.coverage/Covered >= 80"},
+			reason: "gate 'jx.coverage/Covered >= 80' failed as Covered is 75 in Fact coverage",
+		},
+		{
+			gates:  []string{"manual-approval == false"},
+			reason: "gate 'manual-approval == false' failed as manual-approval is true in Fact approval",
+		},
+		{
+			gates:  []string{"jx.cve/Critical == 0"},
+			reason: "gate 'jx.cve/Critical == 0' failed as no Fact has the measurement Critical",
+		},
+	}
+	for _, tc := range testCases {
+		reason, err := workflow.EvaluateGates(tc.gates, facts)
+		require.NoError(t, err)
+		assert.Equal(t, tc.reason, reason, "gates %v", tc.gates)
+	}
+}
+
+func TestCheckPromotionRecordsBlockedReason(t *testing.T) {
+	t.Parallel()
+
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myorg-myapp-master-1",
+			Namespace: testNamespace,
+		},
+	}
+	coverage := createFact("coverage", v1.FactTypeCoverage, activity.Name)
+	coverage.Spec.Measurements = []v1.Measurement{{Name: v1.CodeCoverageMeasurementCoverage, MeasurementValue: 60}}
+	other := createFact("other-coverage", v1.FactTypeCoverage, "myorg-otherapp-master-1")
+	other.Spec.Measurements = []v1.Measurement{{Name: v1.CodeCoverageMeasurementCoverage, MeasurementValue: 100}}
+	production := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "production",
+			Namespace: testNamespace,
+		},
+	}
+	jxClient := fake.NewSimpleClientset(activity, coverage, other, production)
+
+	flow := &v1.Workflow{
+		Spec: v1.WorkflowSpec{
+			Steps: []v1.WorkflowStep{
+				{
+					Kind:    v1.WorkflowStepKindTypePromote,
+					Promote: &v1.PromoteWorkflowStep{Environment: "production"},
+					Preconditions: v1.WorkflowPreconditions{
+						Gates: []string{"jx.coverage/Covered >= 80"},
+					},
+				},
+			},
+		},
+	}
+	step := workflow.FindPromoteStep(flow, "production")
+	require.NotNil(t, step)
+	assert.Nil(t, workflow.FindPromoteStep(flow, "staging"))
+
+	reason, err := workflow.CheckPromotion(jxClient, testNamespace, activity, step, "production")
+	require.NoError(t, err)
+	assert.Equal(t, "gate 'jx.coverage/Covered >= 80' failed as Covered is 60 in Fact coverage", reason)
+	assert.Empty(t, activity.Spec.Steps, "checking the promotion does not modify the activity")
+
+	err = workflow.RecordPromoteBlockedReason(jxClient, testNamespace, activity, "production", reason)
+	require.NoError(t, err)
+	updated, err := jxClient.JenkinsV1().PipelineActivities(testNamespace).Get(activity.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, updated.Spec.Steps, 1)
+	promote := updated.Spec.Steps[0].Promote
+	require.NotNil(t, promote)
+	assert.Equal(t, "production", promote.Environment)
+	assert.Equal(t, reason, promote.BlockedReason)
+	assert.Equal(t, v1.ActivityStatusTypePending, promote.Status)
+
+	// once the coverage improves the blocked reason is cleared
+	coverage.Spec.Measurements[0].MeasurementValue = 85
+	_, err = jxClient.JenkinsV1().Facts(testNamespace).Update(coverage)
+	require.NoError(t, err)
+
+	reason, err = workflow.CheckPromotion(jxClient, testNamespace, updated, step, "production")
+	require.NoError(t, err)
+	assert.Equal(t, "", reason)
+	err = workflow.RecordPromoteBlockedReason(jxClient, testNamespace, updated, "production", reason)
+	require.NoError(t, err)
+	assert.Equal(t, "", updated.Spec.Steps[0].Promote.BlockedReason)
+}
+
+func TestCheckPromotionQueuesDuringFreeze(t *testing.T) {
+	t.Parallel()
+
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myorg-myapp-master-2",
+			Namespace: testNamespace,
+		},
+	}
+	now := time.Now()
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "production",
+			Namespace: testNamespace,
+		},
+		Spec: v1.EnvironmentSpec{
+			DeploymentWindows: &v1.DeploymentWindows{
+				Deny: []v1.DeploymentWindow{
+					{
+						Name:  "freeze",
+						Start: &metav1.Time{Time: now.Add(-time.Hour)},
+						End:   &metav1.Time{Time: now.Add(time.Hour)},
+					},
+				},
+			},
+		},
+	}
+	jxClient := fake.NewSimpleClientset(activity, env)
+	step := &v1.WorkflowStep{
+		Kind:    v1.WorkflowStepKindTypePromote,
+		Promote: &v1.PromoteWorkflowStep{Environment: "production"},
+	}
+
+	reason, err := workflow.CheckPromotion(jxClient, testNamespace, activity, step, "production")
+	require.NoError(t, err)
+	assert.Contains(t, reason, "environment production is frozen by deployment window freeze until ")
+	err = workflow.RecordPromoteBlockedReason(jxClient, testNamespace, activity, "production", reason)
+	require.NoError(t, err)
+
+	updated, err := jxClient.JenkinsV1().PipelineActivities(testNamespace).Get(activity.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, updated.Spec.Steps, 1)
+	assert.Equal(t, reason, updated.Spec.Steps[0].Promote.BlockedReason)
+	assert.Equal(t, v1.ActivityStatusTypePending, updated.Spec.Steps[0].Promote.Status)
+
+	// gates which fail are reported before the deployment windows
+	step.Preconditions.Gates = []string{"approved == true"}
+	reason, err = workflow.CheckPromotion(jxClient, testNamespace, updated, step, "production")
+	require.NoError(t, err)
+	assert.Equal(t, "gate 'approved == true' failed as no Fact has the statement approved", reason)
+}
@@ -85,3 +85,16 @@ func CreateWorkflowPromoteStep(envName string, preconditionSteps ...v1.WorkflowS
 	}
 	return answer
 }
+
+// CreateWorkflowApprovalStep creates a Workflow step which waits for a manual approval once the given steps succeed
+func CreateWorkflowApprovalStep(name string, preconditionSteps ...v1.WorkflowStep) v1.WorkflowStep {
+	answer := v1.WorkflowStep{
+		Kind:     v1.WorkflowStepKindTypeApproval,
+		Name:     name,
+		Approval: &v1.ApprovalWorkflowStep{},
+	}
+	for i := range preconditionSteps {
+		answer.Preconditions.Steps = append(answer.Preconditions.Steps, StepName(&preconditionSteps[i]))
+	}
+	return answer
+}